@@ -0,0 +1,153 @@
+package parser
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// abiWordSize is the size in bytes of an ABI-encoded word.
+const abiWordSize = 32
+
+// decodeHexData decodes 0x-prefixed hex data such as a log's data field.
+func decodeHexData(data string) ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(data, "0x"))
+}
+
+// abiWord returns the word at index in ABI-encoded data.
+func abiWord(data []byte, index int) ([]byte, error) {
+	start := index * abiWordSize
+	if index < 0 || start+abiWordSize > len(data) {
+		return nil, fmt.Errorf("ABI data too short for word %d", index)
+	}
+	return data[start : start+abiWordSize], nil
+}
+
+// abiAddress returns the address held in the low 20 bytes of a word.
+func abiAddress(word []byte) string {
+	return "0x" + hex.EncodeToString(word[abiWordSize-20:])
+}
+
+// abiUint returns the unsigned integer held in a word.
+func abiUint(word []byte) *big.Int {
+	return new(big.Int).SetBytes(word)
+}
+
+// abiDynamicBytes decodes the bytes value whose offset is stored in the word at index.
+func abiDynamicBytes(data []byte, index int) ([]byte, error) {
+	offsetWord, err := abiWord(data, index)
+	if err != nil {
+		return nil, err
+	}
+	offset := abiUint(offsetWord)
+	if !offset.IsInt64() || offset.Int64()+abiWordSize > int64(len(data)) {
+		return nil, fmt.Errorf("ABI offset %v out of range", offset)
+	}
+
+	start := int(offset.Int64())
+	length := abiUint(data[start : start+abiWordSize])
+	if !length.IsInt64() || int64(start+abiWordSize)+length.Int64() > int64(len(data)) {
+		return nil, fmt.Errorf("ABI length %v out of range", length)
+	}
+	return data[start+abiWordSize : start+abiWordSize+int(length.Int64())], nil
+}
+
+// topicAddress returns the address held in an indexed log topic.
+func topicAddress(topic string) (string, error) {
+	word, err := decodeHexData(topic)
+	if err != nil || len(word) != abiWordSize {
+		return "", fmt.Errorf("invalid address topic %q", topic)
+	}
+	return abiAddress(word), nil
+}
+
+// ABI decodes and encodes ABI values held in 0x-prefixed hex, such as the
+// words of log data and eth_call results. Its zero value is ready to use.
+type ABI struct{}
+
+// decodeWord decodes a 0x-prefixed 32-byte hex word.
+func (ABI) decodeWord(word string) ([]byte, error) {
+	data, err := decodeHexData(word)
+	if err != nil || len(data) != abiWordSize {
+		return nil, fmt.Errorf("invalid ABI word %q, expected 32 bytes of hex", word)
+	}
+	return data, nil
+}
+
+// DecodeAddress returns the checksummed address held in the low 20 bytes of a word.
+func (abi ABI) DecodeAddress(word string) (string, error) {
+	data, err := abi.decodeWord(word)
+	if err != nil {
+		return "", err
+	}
+	if new(big.Int).SetBytes(data[:abiWordSize-20]).Sign() != 0 {
+		return "", fmt.Errorf("invalid ABI address %q, expected the high 12 bytes to be zero", word)
+	}
+	return ToChecksumAddress(abiAddress(data)), nil
+}
+
+// DecodeUint256 returns the unsigned integer held in a word.
+func (abi ABI) DecodeUint256(word string) (*big.Int, error) {
+	data, err := abi.decodeWord(word)
+	if err != nil {
+		return nil, err
+	}
+	return abiUint(data), nil
+}
+
+// DecodeBytes32 returns the bytes of a word.
+func (abi ABI) DecodeBytes32(word string) ([32]byte, error) {
+	data, err := abi.decodeWord(word)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return [32]byte(data), nil
+}
+
+// DecodeString returns the string encoded in ABI data holding a single string,
+// such as the result of an ERC-20 name() call: its offset, then its length and
+// its padded bytes.
+func (ABI) DecodeString(hexData string) (string, error) {
+	data, err := decodeHexData(hexData)
+	if err != nil {
+		return "", fmt.Errorf("invalid ABI data: %w", err)
+	}
+	value, err := abiDynamicBytes(data, 0)
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+// EncodeAddress returns the word holding an address.
+func (ABI) EncodeAddress(address string) (string, error) {
+	data, err := decodeHexData(address)
+	if err != nil || len(data) != 20 || !strings.HasPrefix(address, "0x") {
+		return "", fmt.Errorf("invalid address %q", address)
+	}
+	return "0x" + hex.EncodeToString(make([]byte, abiWordSize-20)) + hex.EncodeToString(data), nil
+}
+
+// EncodeUint256 returns the word holding an unsigned integer.
+func (ABI) EncodeUint256(value *big.Int) (string, error) {
+	if value.Sign() < 0 || value.BitLen() > 8*abiWordSize {
+		return "", fmt.Errorf("value %v out of the uint256 range", value)
+	}
+	return "0x" + hex.EncodeToString(value.FillBytes(make([]byte, abiWordSize))), nil
+}
+
+// EncodeBytes32 returns the word holding 32 bytes.
+func (ABI) EncodeBytes32(value [32]byte) string {
+	return "0x" + hex.EncodeToString(value[:])
+}
+
+// EncodeString returns the ABI data holding a single string, as DecodeString reads it.
+func (ABI) EncodeString(value string) string {
+	padded := make([]byte, (len(value)+abiWordSize-1)/abiWordSize*abiWordSize)
+	copy(padded, value)
+
+	offset := new(big.Int).SetInt64(abiWordSize).FillBytes(make([]byte, abiWordSize))
+	length := new(big.Int).SetInt64(int64(len(value))).FillBytes(make([]byte, abiWordSize))
+	return "0x" + hex.EncodeToString(offset) + hex.EncodeToString(length) + hex.EncodeToString(padded)
+}
@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrMethodNotSupported is returned when the node does not serve a method,
+// e.g. the account methods on public read-only endpoints.
+var ErrMethodNotSupported = errors.New("method not supported by the node")
+
+// rpcMethodNotFound is the JSON-RPC error code of an unknown method.
+const rpcMethodNotFound = -32601
+
+// GetNodeAccounts returns the addresses of the accounts the node manages, such
+// as the funded accounts of a development node.
+func (parser *EthereumParser) GetNodeAccounts(ctx context.Context) ([]string, error) {
+	accounts, err := parser.rpcEthAccounts(ctx)
+	if isMethodNotSupported(err) {
+		return nil, fmt.Errorf("%w: %w", ErrMethodNotSupported, err)
+	}
+	return accounts, err
+}
+
+// IsNodeManagedAccount reports whether the node manages the address's account.
+func (parser *EthereumParser) IsNodeManagedAccount(ctx context.Context, address string) (bool, error) {
+	accounts, err := parser.GetNodeAccounts(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, account := range accounts {
+		if strings.EqualFold(account, address) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetCoinbase returns the node's default mining and fee recipient address.
+func (parser *EthereumParser) GetCoinbase(ctx context.Context) (string, error) {
+	coinbase, err := parser.rpcEthCoinbase(ctx)
+	if isMethodNotSupported(err) {
+		return "", fmt.Errorf("%w: %w", ErrMethodNotSupported, err)
+	}
+	return coinbase, err
+}
+
+// isMethodNotSupported reports whether the node rejected a method it does not
+// serve. Nodes answer with the method not found code, or a message naming the
+// method unsupported or unavailable.
+func isMethodNotSupported(err error) bool {
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	if rpcErr.Code == rpcMethodNotFound {
+		return true
+	}
+	message := strings.ToLower(rpcErr.Message)
+	for _, marker := range []string{"method not found", "not supported", "unsupported method", "does not exist/is not available"} {
+		if strings.Contains(message, marker) {
+			return true
+		}
+	}
+	return false
+}
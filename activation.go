@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"fmt"
+	"time"
+)
+
+// WithActivationDelay delays the activation of newly subscribed addresses, so
+// that Watch emits and indexes their transactions only once delay has passed.
+func WithActivationDelay(delay time.Duration) Option {
+	return func(parser *EthereumParser) {
+		parser.ActivationDelay = delay
+	}
+}
+
+// ScheduledSubscription subscribes to the address but keeps it inactive until
+// activateAt: Watch neither emits nor indexes its transactions before then.
+func (parser *EthereumParser) ScheduledSubscription(address string, activateAt time.Time) error {
+	if err := parser.ValidateSubscription(address); err != nil {
+		return err
+	}
+	key := NormalizeAddress(address)
+	if _, err := parser.store.SetSubscriber(key); err != nil {
+		return fmt.Errorf("failed to subscribe %v: %w", address, err)
+	}
+	parser.scheduleActivation(key, activateAt)
+	return nil
+}
+
+// scheduleActivation marks a subscribed address inactive until activateAt.
+func (parser *EthereumParser) scheduleActivation(address string, activateAt time.Time) {
+	if !activateAt.After(parser.clock.Now()) {
+		return
+	}
+	parser.mu.Lock()
+	defer parser.mu.Unlock()
+	parser.activations[address] = activateAt
+}
+
+// delayActivation applies ActivationDelay to a newly subscribed address.
+func (parser *EthereumParser) delayActivation(address string) {
+	if delay := parser.settings().ActivationDelay; delay > 0 {
+		parser.scheduleActivation(address, parser.clock.Now().Add(delay))
+	}
+}
+
+// activateDue activates the addresses whose activation time has come. They
+// are watched from the given block, which becomes their subscription watermark.
+func (parser *EthereumParser) activateDue(now time.Time, blockNumber uint64) {
+	parser.mu.Lock()
+	defer parser.mu.Unlock()
+
+	for address, activateAt := range parser.activations {
+		if !now.Before(activateAt) {
+			delete(parser.activations, address)
+			parser.watermarks[address] = max(parser.watermarks[address], blockNumber)
+		}
+	}
+}
+
+// emitsFor reports whether Watch emits the transactions of the address: whether
+// it is subscribed and active.
+func (parser *EthereumParser) emitsFor(address string) bool {
+	address = NormalizeAddress(address)
+	return parser.store.IsSubscriber(address) && !parser.inactive(address)
+}
+
+// inactive reports whether the address is waiting for its activation.
+func (parser *EthereumParser) inactive(address string) bool {
+	parser.mu.Lock()
+	defer parser.mu.Unlock()
+	_, scheduled := parser.activations[address]
+	return scheduled
+}
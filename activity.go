@@ -0,0 +1,177 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maxConcurrentActivityBlocks bounds the blocks fetched at once to score
+// the activity of addresses.
+const maxConcurrentActivityBlocks = 8
+
+// activityCache is the activity score of an address, computed over the
+// lookback blocks up to computedAtBlock.
+type activityCache struct {
+	score           float64
+	computedAtBlock uint64
+	lookbackBlocks  uint64
+}
+
+// fresh reports whether the score can be reused at the current block: it
+// was computed over the same lookback, less than half of it ago.
+func (cache activityCache) fresh(currentBlock, lookbackBlocks uint64) bool {
+	return cache.lookbackBlocks == lookbackBlocks && cache.computedAtBlock+lookbackBlocks/2 >= currentBlock
+}
+
+// ActivityScore returns the number of transactions from or to the address
+// per block, over the last lookbackBlocks blocks. Scores are cached until
+// the chain advanced by half the lookback.
+func (parser *EthereumParser) ActivityScore(ctx context.Context, address string, lookbackBlocks uint64) (float64, error) {
+	if !IsValidAddress(address) {
+		return 0, fmt.Errorf("invalid address: %v", address)
+	}
+	scores, err := parser.activityScores(ctx, []string{address}, lookbackBlocks)
+	if err != nil {
+		return 0, err
+	}
+	return scores[strings.ToLower(address)], nil
+}
+
+// TopNAddressesByActivity returns the n subscribers with the highest
+// activity scores over the last lookbackBlocks blocks, highest first. Ties
+// are ordered by address.
+func (parser *EthereumParser) TopNAddressesByActivity(ctx context.Context, n int, lookbackBlocks uint64) ([]string, error) {
+	if n <= 0 {
+		return nil, errors.New("n must be positive")
+	}
+	addresses, err := parser.Subscribers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscribers: %w", err)
+	}
+	scores, err := parser.activityScores(ctx, addresses, lookbackBlocks)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, address := range addresses {
+		addresses[i] = strings.ToLower(address)
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		if scores[addresses[i]] != scores[addresses[j]] {
+			return scores[addresses[i]] > scores[addresses[j]]
+		}
+		return addresses[i] < addresses[j]
+	})
+	return addresses[:min(n, len(addresses))], nil
+}
+
+// activityScores returns the scores of the addresses, keyed by their lower
+// case form. The blocks are fetched once for every address whose cached
+// score is stale.
+func (parser *EthereumParser) activityScores(ctx context.Context, addresses []string, lookbackBlocks uint64) (map[string]float64, error) {
+	if lookbackBlocks == 0 {
+		return nil, errors.New("lookback must be at least one block")
+	}
+	head, err := parser.blockNumber(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current block: %w", err)
+	}
+
+	scores := make(map[string]float64, len(addresses))
+	stale := make(map[string]int) // Transaction counts of the addresses to score
+	parser.activityMu.Lock()
+	for _, address := range addresses {
+		address = strings.ToLower(address)
+		if cache, ok := parser.activity[address]; ok && cache.fresh(head, lookbackBlocks) {
+			scores[address] = cache.score
+		} else {
+			stale[address] = 0
+		}
+	}
+	parser.activityMu.Unlock()
+	if len(stale) == 0 {
+		return scores, nil
+	}
+
+	if err := parser.countActivity(ctx, head, lookbackBlocks, stale); err != nil {
+		return nil, err
+	}
+	blocks := min(lookbackBlocks, head+1) // Short chains have fewer blocks
+	parser.activityMu.Lock()
+	defer parser.activityMu.Unlock()
+	if parser.activity == nil {
+		parser.activity = make(map[string]activityCache)
+	}
+	for address, count := range stale {
+		scores[address] = float64(count) / float64(blocks)
+		parser.activity[address] = activityCache{score: scores[address], computedAtBlock: head, lookbackBlocks: lookbackBlocks}
+	}
+	return scores, nil
+}
+
+// countActivity fetches the lookback blocks up to head concurrently and adds
+// the transactions from or to each address of counts to its count.
+func (parser *EthereumParser) countActivity(ctx context.Context, head, lookbackBlocks uint64, counts map[string]int) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	semaphore := make(chan struct{}, maxConcurrentActivityBlocks)
+
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	from := head - min(lookbackBlocks-1, head)
+	for number := from; number <= head; number++ {
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(number uint64) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			block, err := parser.getBlockByNumber(ctx, number)
+			if err != nil {
+				fail(fmt.Errorf("failed to get block %d: %w", number, err))
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, transaction := range block.Transactions {
+				from, to := strings.ToLower(transaction.From), strings.ToLower(transaction.To)
+				if _, ok := counts[from]; ok {
+					counts[from]++
+				}
+				if _, ok := counts[to]; ok && to != from {
+					counts[to]++
+				}
+			}
+		}(number)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,46 @@
+package parser
+
+import (
+	"encoding/hex"
+	"strings"
+)
+
+// ToChecksumAddress returns the EIP-55 mixed-case checksum form of a valid
+// address, and any other string unchanged.
+func ToChecksumAddress(address string) string {
+	if !IsValidAddress(address) {
+		return address
+	}
+	lower := strings.ToLower(address[2:])
+	hash := hex.EncodeToString(Keccak256([]byte(lower)))
+
+	checksummed := []byte(lower)
+	for i, char := range checksummed {
+		// Letters are uppercased when the matching nibble of the hash is 8 or more
+		if char >= 'a' && hash[i] >= '8' {
+			checksummed[i] = char - 'a' + 'A'
+		}
+	}
+	return "0x" + string(checksummed)
+}
+
+// IsChecksumAddress reports whether a valid address satisfies EIP-55. All-lowercase
+// and all-uppercase addresses carry no checksum and are accepted; invalid
+// addresses are not.
+func IsChecksumAddress(address string) bool {
+	if !IsValidAddress(address) {
+		return false
+	}
+	digits := address[2:]
+	if digits == strings.ToLower(digits) || digits == strings.ToUpper(digits) {
+		return true
+	}
+	return address == ToChecksumAddress(address)
+}
+
+// NormalizeAddress returns the lowercase form of an address, under which
+// subscriptions, labels and the index are keyed. The checksummed and
+// uppercase spellings of an address normalize alike.
+func NormalizeAddress(address string) string {
+	return strings.ToLower(address)
+}
@@ -0,0 +1,70 @@
+package parser
+
+import "testing"
+
+func TestNormalizeAddress(t *testing.T) {
+	const lower = "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"
+	tests := []struct {
+		name    string
+		address string
+	}{
+		{"lowercase", lower},
+		{"checksummed", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"},
+		{"uppercase", "0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := NormalizeAddress(test.address); got != lower {
+				t.Errorf("NormalizeAddress(%q) = %q, want %q", test.address, got, lower)
+			}
+		})
+	}
+}
+
+func TestToChecksumAddress(t *testing.T) {
+	// Vectors of EIP-55
+	for _, want := range []string{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+		"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+	} {
+		if got := ToChecksumAddress(NormalizeAddress(want)); got != want {
+			t.Errorf("ToChecksumAddress(%q) = %q, want %q", NormalizeAddress(want), got, want)
+		}
+		if !IsChecksumAddress(want) {
+			t.Errorf("IsChecksumAddress(%q) = false, want true", want)
+		}
+	}
+	if IsChecksumAddress("0x5AAeb6053F3E94C9b9A09f33669435E7Ef1BeAed") {
+		t.Error("IsChecksumAddress accepted an address with a wrong checksum")
+	}
+	for _, invalid := range []string{"", "0", "0x", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA"} {
+		if got := ToChecksumAddress(invalid); got != invalid {
+			t.Errorf("ToChecksumAddress(%q) = %q, want it unchanged", invalid, got)
+		}
+		if IsChecksumAddress(invalid) {
+			t.Errorf("IsChecksumAddress(%q) = true, want false", invalid)
+		}
+	}
+}
+
+func TestIsValidAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		want    bool
+	}{
+		{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", true},
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", true},
+		{"5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", false},
+		{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1bea", false},
+		{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaedaa", false},
+		{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beazz", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if got := IsValidAddress(test.address); got != test.want {
+			t.Errorf("IsValidAddress(%q) = %v, want %v", test.address, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package parser
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// NewAdminHandler serves the pprof profiles under /debug/pprof/, the
+// runtime gauges and the parser's processing metrics on /metrics, the node's
+// info on /node-info and the health of the endpoints on /endpoints, and
+// serves and replaces the alert rules on /alert-rules. Callers add their own
+// routes and guard the handler, see RequireToken.
+func NewAdminHandler(parser Parser) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		writeRuntimeMetrics(w)
+		if reporter, ok := parser.(MetricsReporter); ok {
+			writeProcessingMetrics(w, reporter.Metrics())
+		}
+	})
+	mux.HandleFunc("GET /node-info", handleNodeInfo(parser))
+	mux.HandleFunc("GET /endpoints", handleEndpoints(parser))
+	mux.HandleFunc("GET /alert-rules", handleAlertRules(parser))
+	mux.HandleFunc("PUT /alert-rules", handleAlertRules(parser))
+	return mux
+}
+
+// RequireToken rejects the requests without the bearer token with 401. The
+// token is looked up on every request, so that reloads can rotate it.
+func RequireToken(token func() string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token())) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, "missing or invalid token")
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// writeRuntimeMetrics writes runtime gauges in the Prometheus text format.
+func writeRuntimeMetrics(w io.Writer) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	lastPause := time.Duration(stats.PauseNs[(stats.NumGC+255)%256])
+
+	for _, gauge := range []struct {
+		name, help string
+		value      float64
+	}{
+		{"go_goroutines", "Number of goroutines.", float64(runtime.NumGoroutine())},
+		{"go_memstats_heap_inuse_bytes", "Bytes in in-use heap spans.", float64(stats.HeapInuse)},
+		{"go_memstats_heap_alloc_bytes", "Bytes of allocated heap objects.", float64(stats.HeapAlloc)},
+		{"go_gc_completed_cycles", "Number of completed GC cycles.", float64(stats.NumGC)},
+		{"go_gc_pause_cumulative_seconds", "Total time spent in GC stop-the-world pauses.", time.Duration(stats.PauseTotalNs).Seconds()},
+		{"go_gc_pause_last_seconds", "Duration of the last GC stop-the-world pause.", lastPause.Seconds()},
+	} {
+		writeMetricHeader(w, gauge.name, "gauge", gauge.help)
+		fmt.Fprintf(w, "%v %v\n", gauge.name, gauge.value)
+	}
+}
+
+// writeProcessingMetrics writes a Metrics snapshot in the Prometheus text format.
+func writeProcessingMetrics(w io.Writer, metrics Metrics) {
+	for _, counter := range []struct {
+		name, help string
+		value      uint64
+	}{
+		{"parser_blocks_processed_total", "Blocks processed by Watch.", metrics.BlocksProcessed},
+		{"parser_blocks_retried_total", "Blocks that failed to be fetched and were retried.", metrics.BlocksRetried},
+		{"parser_transactions_scanned_total", "Transactions in the processed blocks.", metrics.TransactionsScanned},
+		{"parser_reorgs_handled_total", "Processed blocks replaced by the canonical block of a chain split.", metrics.ReorgsHandled},
+		{"parser_notifications_sent_total", "Transactions delivered to Watch receivers.", metrics.NotificationsSent},
+		{"parser_notifications_failed_total", "Transactions not delivered because the receiver went away.", metrics.NotificationsFailed},
+		{"parser_notifications_dropped_total", "Transactions not delivered because a listener's buffer was full.", metrics.NotificationsDropped},
+		{"parser_index_dropped_transactions_total", "Old transactions dropped from the index at capacity.", metrics.DroppedTransactions},
+	} {
+		writeMetricHeader(w, counter.name, "counter", counter.help)
+		fmt.Fprintf(w, "%v %v\n", counter.name, counter.value)
+	}
+
+	writeMetricHeader(w, "parser_matches_total", "counter", "Transactions of subscribed addresses by match type.")
+	for _, match := range []string{MatchSent, MatchReceived, MatchInternal, MatchContractCreation} {
+		fmt.Fprintf(w, "parser_matches_total{type=%q} %v\n", match, metrics.Matches[match])
+	}
+
+	methods := metrics.RPCMethods()
+	writeMetricHeader(w, "parser_rpc_calls_total", "counter", "JSON-RPC calls by method.")
+	for _, method := range methods {
+		fmt.Fprintf(w, "parser_rpc_calls_total{method=%q} %v\n", method, metrics.RPC[method].Calls)
+	}
+	writeMetricHeader(w, "parser_rpc_errors_total", "counter", "Failed JSON-RPC calls by method.")
+	for _, method := range methods {
+		fmt.Fprintf(w, "parser_rpc_errors_total{method=%q} %v\n", method, metrics.RPC[method].Errors)
+	}
+	writeMetricHeader(w, "parser_rpc_duration_seconds", "histogram", "Latency of the JSON-RPC calls by method.")
+	for _, method := range methods {
+		histogram := metrics.rpcLatencyHistograms[method]
+		var cumulative uint64
+		for i, calls := range histogram.buckets {
+			cumulative += calls
+			bound := "+Inf"
+			if i < len(latencyBuckets) {
+				bound = fmt.Sprint(latencyBuckets[i].Seconds())
+			}
+			fmt.Fprintf(w, "parser_rpc_duration_seconds_bucket{method=%q,le=%q} %v\n", method, bound, cumulative)
+		}
+		fmt.Fprintf(w, "parser_rpc_duration_seconds_sum{method=%q} %v\n", method, histogram.total.Seconds())
+		fmt.Fprintf(w, "parser_rpc_duration_seconds_count{method=%q} %v\n", method, cumulative)
+	}
+
+	if bloom := metrics.BloomFilter; bloom != nil {
+		for _, counter := range []struct {
+			name, help string
+			value      uint64
+		}{
+			{"parser_bloom_checks_total", "Subscriber lookups checked against the bloom filter.", bloom.Checks},
+			{"parser_bloom_possible_hits_total", "Bloom filter checks passed on to the storage.", bloom.PossibleHits},
+			{"parser_bloom_false_positives_total", "Possible hits the storage did not hold.", bloom.FalsePositives},
+		} {
+			writeMetricHeader(w, counter.name, "counter", counter.help)
+			fmt.Fprintf(w, "%v %v\n", counter.name, counter.value)
+		}
+	}
+}
+
+// writeMetricHeader writes the HELP and TYPE lines of a metric.
+func writeMetricHeader(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %v %v\n# TYPE %v %v\n", name, help, name, kind)
+}
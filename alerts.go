@@ -0,0 +1,363 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Directions of the transfers an AlertRule matches, relative to its addresses.
+const (
+	alertDirectionIn  = "in"
+	alertDirectionOut = "out"
+	alertDirectionAny = "any"
+)
+
+var alertDirections = map[string]bool{"": true, alertDirectionIn: true, alertDirectionOut: true, alertDirectionAny: true}
+
+var (
+	erc20TransferFromMethod = Keccak256Hex("transferFrom(address,address,uint256)")[:10]
+	erc20DecimalsMethod     = Keccak256Hex("decimals()")[:10]
+)
+
+// AlertRule raises an AlertEvent for every transfer of at least MinValue to
+// or from its addresses, whether or not they are subscribed.
+type AlertRule struct {
+	Label     string   `json:"label"`
+	MinValue  string   `json:"minValue"`            // Decimal amount of ether, or of Token's units
+	Direction string   `json:"direction,omitempty"` // in, out or any, the default
+	Addresses []string `json:"addresses,omitempty"` // Every subscribed address when empty
+	Token     string   `json:"token,omitempty"`     // ERC-20 contract whose transfers are matched, ether when empty
+	Target    string   `json:"target,omitempty"`    // Notifier the alerts are sent to, see WithAlertNotifier
+}
+
+// AlertEvent is a transfer that matched an AlertRule.
+type AlertEvent struct {
+	Label       string      `json:"label"`
+	Target      string      `json:"target,omitempty"`
+	Address     string      `json:"address"`   // Address of the rule the transfer touched
+	Direction   string      `json:"direction"` // in or out, relative to Address
+	Token       string      `json:"token,omitempty"`
+	Value       string      `json:"value"` // Decimal amount of ether or of the token's units
+	Transaction Transaction `json:"transaction"`
+}
+
+// alertRule is an AlertRule ready to be evaluated.
+type alertRule struct {
+	AlertRule
+	minValue  *big.Rat
+	addresses map[string]bool // Lower case, every subscribed address when nil
+}
+
+// AlertRuler is implemented by parsers whose alert rules can be replaced at runtime.
+type AlertRuler interface {
+	AlertRules() []AlertRule
+	SetAlertRules(rules []AlertRule) error
+}
+
+// WithAlertRules sets the alert rules evaluated by Watch. Rules that are not
+// valid, see SetAlertRules, are left out.
+func WithAlertRules(rules []AlertRule) Option {
+	return func(parser *EthereumParser) {
+		var compiled []alertRule
+		for _, rule := range rules {
+			if rule, err := compileAlertRule(rule); err == nil {
+				compiled = append(compiled, rule)
+			}
+		}
+		parser.alertRules.Store(&compiled)
+	}
+}
+
+// WithAlertNotifier sends the alerts of the rules whose Target is target to
+// notifier. Sends block, like those of Watch, so that no alert is missed.
+// Alerts are logged whether or not their target has a notifier.
+func WithAlertNotifier(target string, notifier chan<- AlertEvent) Option {
+	return func(parser *EthereumParser) {
+		if parser.alertNotifiers == nil {
+			parser.alertNotifiers = make(map[string]chan<- AlertEvent)
+		}
+		parser.alertNotifiers[target] = notifier
+	}
+}
+
+// AlertRules returns the alert rules in effect.
+func (parser *EthereumParser) AlertRules() []AlertRule {
+	rules := []AlertRule{}
+	if compiled := parser.alertRules.Load(); compiled != nil {
+		for _, rule := range *compiled {
+			rules = append(rules, rule.AlertRule)
+		}
+	}
+	return rules
+}
+
+// SetAlertRules replaces the alert rules evaluated by Watch from the next
+// block on. The rules are rejected as a whole when one is not valid.
+func (parser *EthereumParser) SetAlertRules(rules []AlertRule) error {
+	compiled := make([]alertRule, len(rules))
+	for i, rule := range rules {
+		var err error
+		if compiled[i], err = compileAlertRule(rule); err != nil {
+			return fmt.Errorf("alert rule %q: %w", rule.Label, err)
+		}
+	}
+	parser.alertRules.Store(&compiled)
+	return nil
+}
+
+// SetAlertRules replaces the alert rules of every chain.
+func (multi *MultiChainParser) SetAlertRules(rules []AlertRule) error {
+	for _, chain := range multi.chains {
+		if err := chain.SetAlertRules(rules); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compileAlertRule validates a rule and prepares it for evaluation.
+func compileAlertRule(rule AlertRule) (alertRule, error) {
+	compiled := alertRule{AlertRule: rule}
+	if rule.Label == "" {
+		return alertRule{}, errors.New("label is empty")
+	}
+	minValue, ok := new(big.Rat).SetString(rule.MinValue)
+	if !ok || minValue.Sign() < 0 {
+		return alertRule{}, fmt.Errorf("invalid minimum value: %q", rule.MinValue)
+	}
+	compiled.minValue = minValue
+	if !alertDirections[rule.Direction] {
+		return alertRule{}, fmt.Errorf("unsupported direction: %q", rule.Direction)
+	}
+	if rule.Token != "" && !IsValidAddress(rule.Token) {
+		return alertRule{}, fmt.Errorf("invalid token address: %v", rule.Token)
+	}
+	if len(rule.Addresses) > 0 {
+		compiled.addresses = make(map[string]bool, len(rule.Addresses))
+		for _, address := range rule.Addresses {
+			if !IsValidAddress(address) {
+				return alertRule{}, fmt.Errorf("invalid address: %v", address)
+			}
+			compiled.addresses[strings.ToLower(address)] = true
+		}
+	}
+	return compiled, nil
+}
+
+// ParseAlertRules parses alert rules written as
+// label:min=100;direction=in;token=0x…;target=compliance;addresses=0x…|0x…,
+// in which only min is required.
+func ParseAlertRules(items []string) ([]AlertRule, error) {
+	var rules []AlertRule
+	for _, item := range items {
+		label, settings, ok := strings.Cut(item, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid alert rule %q: expected label:min=amount;…", item)
+		}
+		rule := AlertRule{Label: strings.TrimSpace(label)}
+		for _, setting := range strings.Split(settings, ";") {
+			key, value, _ := strings.Cut(setting, "=")
+			value = strings.TrimSpace(value)
+			switch strings.TrimSpace(key) {
+			case "min":
+				rule.MinValue = value
+			case "direction":
+				rule.Direction = value
+			case "token":
+				rule.Token = value
+			case "target":
+				rule.Target = value
+			case "addresses":
+				rule.Addresses = strings.Split(value, "|")
+			default:
+				return nil, fmt.Errorf("invalid alert rule %q: unknown setting %q", item, key)
+			}
+		}
+		if _, err := compileAlertRule(rule); err != nil {
+			return nil, fmt.Errorf("invalid alert rule %q: %w", item, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// raiseAlerts evaluates the alert rules against the transactions of a block,
+// after they were dispatched, and logs and sends the alerts they raise.
+func (parser *EthereumParser) raiseAlerts(ctx context.Context, block *Block, emits func(address string) bool) error {
+	rules := parser.alertRules.Load()
+	if rules == nil || len(*rules) == 0 {
+		return nil
+	}
+	for _, transaction := range block.Transactions {
+		if parser.chain.IsSystemTransaction(transaction) {
+			continue
+		}
+		transaction.Chain = parser.chain.Name
+		parser.labelTransaction(&transaction)
+		for _, rule := range *rules {
+			event, ok := parser.matchAlertRule(ctx, rule, transaction, emits)
+			if !ok {
+				continue
+			}
+			parser.logger.WarnContext(ctx, "alert", "label", event.Label, "target", event.Target, "address", event.Address, "direction", event.Direction, "value", event.Value, "token", event.Token, "hash", transaction.Hash)
+			notifier, ok := parser.alertNotifiers[event.Target]
+			if !ok {
+				continue
+			}
+			select {
+			case notifier <- event:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+	return nil
+}
+
+// matchAlertRule returns the alert a transaction raises under a rule, if any.
+// Token rules match the direct transfer and transferFrom calls of the token,
+// once its decimals are known.
+func (parser *EthereumParser) matchAlertRule(ctx context.Context, rule alertRule, transaction Transaction, emits func(address string) bool) (AlertEvent, bool) {
+	from, to, amount := transaction.From, transaction.To, (*big.Int)(nil)
+	if rule.Token == "" {
+		value, err := parseHexBig(transaction.Value)
+		if err != nil {
+			return AlertEvent{}, false
+		}
+		amount = value
+	} else {
+		if !strings.EqualFold(transaction.To, rule.Token) {
+			return AlertEvent{}, false
+		}
+		var ok bool
+		if from, to, amount, ok = decodeERC20Transfer(transaction); !ok {
+			return AlertEvent{}, false
+		}
+	}
+
+	watched := func(address string) bool {
+		if rule.addresses != nil {
+			return rule.addresses[strings.ToLower(address)]
+		}
+		return address != "" && emits(address)
+	}
+	event := AlertEvent{Label: rule.Label, Target: rule.Target, Token: rule.Token, Transaction: transaction}
+	switch {
+	case rule.Direction != alertDirectionOut && watched(to):
+		event.Address, event.Direction = to, alertDirectionIn
+	case rule.Direction != alertDirectionIn && watched(from):
+		event.Address, event.Direction = from, alertDirectionOut
+	default:
+		return AlertEvent{}, false
+	}
+
+	decimals := 18
+	if rule.Token != "" {
+		var err error
+		if decimals, err = parser.tokenDecimals(ctx, rule.Token); err != nil {
+			parser.logger.WarnContext(ctx, "alert rule skipped, the token's decimals are unknown", "label", rule.Label, "token", rule.Token, "error", err)
+			return AlertEvent{}, false
+		}
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	value := new(big.Rat).SetFrac(amount, scale)
+	if value.Cmp(rule.minValue) < 0 {
+		return AlertEvent{}, false
+	}
+	event.Value = value.FloatString(decimals)
+	if strings.Contains(event.Value, ".") {
+		event.Value = strings.TrimSuffix(strings.TrimRight(event.Value, "0"), ".")
+	}
+	return event, true
+}
+
+// decodeERC20Transfer returns the sender, recipient and amount of a call of
+// transfer or transferFrom.
+func decodeERC20Transfer(transaction Transaction) (from, to string, amount *big.Int, ok bool) {
+	input := strings.ToLower(transaction.Input)
+	var args []byte
+	var err error
+	switch {
+	case strings.HasPrefix(input, erc20TransferMethod):
+		args, err = decodeHexData(input[len(erc20TransferMethod):])
+		if err != nil || len(args) < 2*abiWordSize {
+			return "", "", nil, false
+		}
+		return transaction.From, abiAddress(args[:abiWordSize]), abiUint(args[abiWordSize : 2*abiWordSize]), true
+	case strings.HasPrefix(input, erc20TransferFromMethod):
+		args, err = decodeHexData(input[len(erc20TransferFromMethod):])
+		if err != nil || len(args) < 3*abiWordSize {
+			return "", "", nil, false
+		}
+		return abiAddress(args[:abiWordSize]), abiAddress(args[abiWordSize : 2*abiWordSize]), abiUint(args[2*abiWordSize : 3*abiWordSize]), true
+	}
+	return "", "", nil, false
+}
+
+// tokenDecimals returns the decimals of an ERC-20 token, from the well-known
+// contracts or cached once known.
+func (parser *EthereumParser) tokenDecimals(ctx context.Context, token string) (int, error) {
+	if contract, ok := parser.knownContract(token); ok && contract.Symbol != "" {
+		return contract.Decimals, nil
+	}
+	token = strings.ToLower(token)
+	parser.decimalsMu.Lock()
+	decimals, ok := parser.decimals[token]
+	parser.decimalsMu.Unlock()
+	if ok {
+		return decimals, nil
+	}
+
+	result, err := parser.rpcEthCall(ctx, map[string]interface{}{"to": token, "data": erc20DecimalsMethod}, "latest")
+	if err != nil {
+		return 0, err
+	}
+	data, err := decodeHexData(result)
+	if err != nil || len(data) < abiWordSize {
+		return 0, fmt.Errorf("invalid decimals: %q", result)
+	}
+	value := abiUint(data[:abiWordSize])
+	if !value.IsInt64() || value.Int64() > 77 {
+		return 0, fmt.Errorf("invalid decimals: %v", value)
+	}
+
+	parser.decimalsMu.Lock()
+	defer parser.decimalsMu.Unlock()
+	if parser.decimals == nil {
+		parser.decimals = make(map[string]int)
+	}
+	parser.decimals[token] = int(value.Int64())
+	return int(value.Int64()), nil
+}
+
+// handleAlertRules serves the alert rules in effect on GET and replaces them
+// with a JSON array of rules on PUT, until the next reload that changes
+// alert_rules.
+func handleAlertRules(parser Parser) http.HandlerFunc {
+	var mu sync.Mutex
+	return func(w http.ResponseWriter, r *http.Request) {
+		ruler, ok := parser.(AlertRuler)
+		if !ok {
+			writeError(w, http.StatusNotImplemented, "parser does not raise alerts")
+			return
+		}
+		if r.Method == http.MethodPut {
+			var rules []AlertRule
+			if !readJSON(w, r, &rules) {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err := ruler.SetAlertRules(rules); err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+		writeJSON(w, http.StatusOK, ruler.AlertRules())
+	}
+}
@@ -0,0 +1,187 @@
+package parser
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"strings"
+)
+
+// approvalHistorySize is the number of recent approvals kept per owner.
+const approvalHistorySize = 100
+
+// Kinds of ERC-20 approvals.
+const (
+	ApprovalLimited   = "limited"
+	ApprovalUnlimited = "unlimited" // The maximum uint256 allowance
+	ApprovalRevoked   = "revoked"   // A zero allowance
+)
+
+// approvalTopic is the topic of ERC-20 Approval events. ERC-721 approvals
+// share it, with their token ID as a fourth topic.
+var approvalTopic = Keccak256Hex("Approval(address,address,uint256)")
+
+// maxUint256 is the allowance wallets grant for unlimited approvals.
+var maxUint256 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+// ApprovalEvent is an allowance a subscribed address granted to a spender of
+// its ERC-20 tokens.
+type ApprovalEvent struct {
+	Owner           string `json:"owner"`
+	Spender         string `json:"spender"`
+	Token           string `json:"token"`
+	TokenSymbol     string `json:"tokenSymbol,omitempty"` // Symbol of a well-known token
+	Amount          string `json:"amount"`                // Allowance in base units of the token, in decimal
+	Kind            string `json:"kind"`                  // limited, unlimited or revoked
+	BlockNumber     uint64 `json:"blockNumber"`
+	TransactionHash string `json:"transactionHash"`
+	Chain           string `json:"chain,omitempty"`
+}
+
+// ApprovalLister is implemented by parsers that keep the approvals of
+// subscribed addresses.
+type ApprovalLister interface {
+	Approvals(owner string) []ApprovalEvent
+}
+
+// WithApprovalMonitoring makes Watch match the ERC-20 Approval events of the
+// blocks it processes whose owner is a subscribed address. The approvals are
+// kept, see Approvals, logged and, unless notifier is nil, sent to notifier.
+// Sends block, like those of Watch, so that no approval is missed.
+func WithApprovalMonitoring(notifier chan<- ApprovalEvent) Option {
+	return func(parser *EthereumParser) {
+		parser.approvalsEnabled = true
+		parser.approvalNotifier = notifier
+	}
+}
+
+// Approvals returns the recent approvals granted by an address, oldest first.
+func (parser *EthereumParser) Approvals(owner string) []ApprovalEvent {
+	parser.approvalsMu.Lock()
+	defer parser.approvalsMu.Unlock()
+	if history, ok := parser.approvals[strings.ToLower(owner)]; ok {
+		return history.Items()
+	}
+	return []ApprovalEvent{}
+}
+
+// Approvals returns the recent approvals granted by an address on every chain.
+func (multi *MultiChainParser) Approvals(owner string) []ApprovalEvent {
+	approvals := []ApprovalEvent{}
+	for _, chain := range multi.chains {
+		approvals = append(approvals, chain.Approvals(owner)...)
+	}
+	return approvals
+}
+
+// dispatchApprovals matches the Approval events of a block against the
+// subscribed addresses. A block whose logs cannot be read is skipped with a
+// warning rather than stopping Watch.
+func (parser *EthereumParser) dispatchApprovals(ctx context.Context, block *Block, emits func(address string) bool) error {
+	if !parser.approvalsEnabled {
+		return nil
+	}
+	logs, err := parser.GetLogs(ctx, LogFilter{BlockHash: block.Hash, Topics: [][]string{{approvalTopic}}})
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		parser.logger.WarnContext(ctx, "failed to get the approvals of block", "block", block.Number, "error", err)
+		return nil
+	}
+
+	for _, log := range logs {
+		event, ok := decodeApproval(log)
+		if !ok || !emits(event.Owner) {
+			continue
+		}
+		if contract, ok := parser.knownContract(event.Token); ok {
+			event.TokenSymbol = contract.Symbol
+		}
+		event.Chain = parser.chain.Name
+		parser.recordApproval(event)
+		parser.logger.InfoContext(ctx, "approval", "owner", event.Owner, "spender", event.Spender, "token", event.Token, "kind", event.Kind, "amount", event.Amount, "hash", event.TransactionHash)
+		if parser.approvalNotifier == nil {
+			continue
+		}
+		select {
+		case parser.approvalNotifier <- event:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// decodeApproval decodes Approval(address indexed owner, address indexed
+// spender, uint256 value), reporting false for the logs of other events,
+// including ERC-721 approvals, and for removed logs.
+func decodeApproval(log Log) (ApprovalEvent, bool) {
+	if log.Removed || len(log.Topics) != 3 || !strings.EqualFold(log.Topics[0], approvalTopic) {
+		return ApprovalEvent{}, false
+	}
+	owner, err := topicAddress(log.Topics[1])
+	if err != nil {
+		return ApprovalEvent{}, false
+	}
+	spender, err := topicAddress(log.Topics[2])
+	if err != nil {
+		return ApprovalEvent{}, false
+	}
+	data, err := decodeHexData(log.Data)
+	if err != nil {
+		return ApprovalEvent{}, false
+	}
+	word, err := abiWord(data, 0)
+	if err != nil {
+		return ApprovalEvent{}, false
+	}
+	amount := abiUint(word)
+	kind := ApprovalLimited
+	switch {
+	case amount.Sign() == 0:
+		kind = ApprovalRevoked
+	case amount.Cmp(maxUint256) == 0:
+		kind = ApprovalUnlimited
+	}
+	blockNumber, _ := ParseHexUint64(log.BlockNumber)
+	return ApprovalEvent{
+		Owner:           owner,
+		Spender:         spender,
+		Token:           strings.ToLower(log.Address),
+		Amount:          amount.String(),
+		Kind:            kind,
+		BlockNumber:     blockNumber,
+		TransactionHash: log.TransactionHash,
+	}, true
+}
+
+// recordApproval keeps an approval in the history of its owner.
+func (parser *EthereumParser) recordApproval(event ApprovalEvent) {
+	parser.approvalsMu.Lock()
+	defer parser.approvalsMu.Unlock()
+	owner := strings.ToLower(event.Owner)
+	history, ok := parser.approvals[owner]
+	if !ok {
+		if parser.approvals == nil {
+			parser.approvals = make(map[string]*RingBuffer[ApprovalEvent])
+		}
+		history = NewRingBuffer[ApprovalEvent](approvalHistorySize)
+		parser.approvals[owner] = history
+	}
+	history.Push(event)
+}
+
+func (server *Server) handleApprovals(w http.ResponseWriter, r *http.Request) {
+	address := r.URL.Query().Get("address")
+	if address == "" {
+		writeError(w, http.StatusBadRequest, "you need to define an address")
+		return
+	}
+	lister, ok := server.parser.(ApprovalLister)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, "parser does not monitor approvals")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string][]ApprovalEvent{"approvals": lister.Approvals(address)})
+}
@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// approvalsOwner is the subscribed owner of the approvals of approvals.json.
+const approvalsOwner = "0xb794f5ea0ba39494ce839613fffba74279579268"
+
+// loadApprovalBlocks adds the blocks of approvals.json, one per line with
+// their logs, to a fakeNode.
+func loadApprovalBlocks(t *testing.T) *fakeNode {
+	t.Helper()
+	node := newFakeNode(t)
+	scanner := bufio.NewScanner(bytes.NewReader(LoadFixture(t, "approvals.json")))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var entry struct {
+			Block
+			Logs []Log `json:"logs"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatal(err)
+		}
+		node.AddBlock(&entry.Block, entry.Logs...)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return node
+}
+
+// TestApprovalMonitoring watches the blocks of approvals.json: the limited,
+// unlimited and revoked ERC-20 approvals of the subscribed owner are kept and
+// sent, while the approval of an unsubscribed owner and the ERC-721 approval
+// are not.
+func TestApprovalMonitoring(t *testing.T) {
+	node := loadApprovalBlocks(t)
+	approvals := make(chan ApprovalEvent, 8)
+	parser := node.NewParser(WithChain(Chain{Name: "mainnet", PollInterval: testWatchInterval}), WithApprovalMonitoring(approvals))
+	if _, err := parser.SubscribeAddress(approvalsOwner); err != nil {
+		t.Fatal(err)
+	}
+	startWatch(t, parser, 0x100)
+
+	const spender = "0x3fc91a3afd70395cd496c647d5a6cc9d4b2b7fad"
+	want := []ApprovalEvent{
+		{Token: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", Amount: "1000000000", Kind: ApprovalLimited, BlockNumber: 0x100},
+		{Token: "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2", Amount: maxUint256.String(), Kind: ApprovalUnlimited, BlockNumber: 0x101},
+		{Token: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", Amount: "0", Kind: ApprovalRevoked, BlockNumber: 0x102},
+	}
+	for _, want := range want {
+		select {
+		case event := <-approvals:
+			if event.Owner != approvalsOwner || event.Spender != spender || event.Token != want.Token || event.Amount != want.Amount || event.Kind != want.Kind || event.BlockNumber != want.BlockNumber || event.Chain != "mainnet" {
+				t.Errorf("approval = %+v, want %v %v of %v in block %d", event, want.Kind, want.Amount, want.Token, want.BlockNumber)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no %v approval", want.Kind)
+		}
+	}
+	select {
+	case event := <-approvals:
+		t.Errorf("approval %+v, want none of the unsubscribed owner or ERC-721", event)
+	case <-time.After(5 * testWatchInterval):
+	}
+
+	if history := parser.Approvals("0xB794F5EA0BA39494CE839613FFFBA74279579268"); len(history) != 3 || history[2].Kind != ApprovalRevoked {
+		t.Errorf("Approvals = %+v, want the 3 approvals, the revocation last", history)
+	}
+	if history := parser.Approvals("0x2222222222222222222222222222222222222222"); len(history) != 0 {
+		t.Errorf("Approvals of an unsubscribed owner = %+v, want none", history)
+	}
+}
+
+func TestDecodeApproval(t *testing.T) {
+	owner := "0x000000000000000000000000" + approvalsOwner[2:]
+	spender := "0x0000000000000000000000003fc91a3afd70395cd496c647d5a6cc9d4b2b7fad"
+	amount := "0x000000000000000000000000000000000000000000000000000000000000002a"
+	tests := []struct {
+		name string
+		log  Log
+		want bool
+	}{
+		{"ERC-20", Log{Topics: []string{approvalTopic, owner, spender}, Data: amount}, true},
+		{"ERC-721", Log{Topics: []string{approvalTopic, owner, spender, amount}, Data: "0x"}, false},
+		{"removed", Log{Topics: []string{approvalTopic, owner, spender}, Data: amount, Removed: true}, false},
+		{"other event", Log{Topics: []string{Keccak256Hex("Transfer(address,address,uint256)"), owner, spender}, Data: amount}, false},
+		{"short data", Log{Topics: []string{approvalTopic, owner, spender}, Data: "0x2a"}, false},
+	}
+	for _, test := range tests {
+		if event, ok := decodeApproval(test.log); ok != test.want || ok && (event.Amount != "42" || event.Kind != ApprovalLimited) {
+			t.Errorf("decodeApproval of the %v log = %+v, %v, want %v", test.name, event, ok, test.want)
+		}
+	}
+}
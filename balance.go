@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// weiPerEther is the number of wei in one ether.
+var weiPerEther = new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+
+// BalanceGetter is implemented by parsers that can read account balances.
+type BalanceGetter interface {
+	GetBalance(ctx context.Context, address string, blockTag string) (*big.Int, error)
+}
+
+// GetBalance returns the balance in wei of an address at a block tag or 0x-hex block number.
+func (parser *EthereumParser) GetBalance(ctx context.Context, address string, blockTag string) (*big.Int, error) {
+	if !IsValidAddress(address) {
+		return nil, fmt.Errorf("invalid address: %v", address)
+	}
+
+	balanceHex, err := parser.rpcEthGetBalance(ctx, address, blockTag)
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := ParseHexBig(balanceHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid balance: %w", err)
+	}
+	return balance, nil
+}
+
+// GetCode returns the 0x-hex contract code deployed at an address, "0x" for accounts without code.
+func (parser *EthereumParser) GetCode(ctx context.Context, address string, blockTag string) (string, error) {
+	if !IsValidAddress(address) {
+		return "", fmt.Errorf("invalid address: %v", address)
+	}
+
+	code, err := parser.rpcEthGetCode(ctx, address, blockTag)
+	if err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// GetTransactionCount returns the number of transactions sent from an address
+// as of blockTag, which is the nonce of its next transaction.
+func (parser *EthereumParser) GetTransactionCount(ctx context.Context, address string, blockTag string) (uint64, error) {
+	if !IsValidAddress(address) {
+		return 0, fmt.Errorf("invalid address: %v", address)
+	}
+
+	countHex, err := parser.rpcEthGetTransactionCount(ctx, address, blockTag)
+	if err != nil {
+		return 0, err
+	}
+	count, err := ParseHexUint64(countHex)
+	if err != nil {
+		return 0, fmt.Errorf("invalid transaction count: %w", err)
+	}
+	return count, nil
+}
+
+// FormatEther formats an amount in wei as a decimal amount of ether.
+func FormatEther(wei *big.Int) string {
+	whole, fraction := new(big.Int).QuoRem(new(big.Int).Abs(wei), weiPerEther, new(big.Int))
+
+	text := whole.String()
+	if fraction.Sign() != 0 {
+		digits := fmt.Sprintf("%018s", fraction.String())
+		text += "." + strings.TrimRight(digits, "0")
+	}
+	if wei.Sign() < 0 {
+		text = "-" + text
+	}
+	return text
+}
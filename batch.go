@@ -0,0 +1,109 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+// maxRPCBatchSize is the number of calls sent in one JSON-RPC batch request.
+const maxRPCBatchSize = 100
+
+// errRPCBatchUnsupported is returned by callRPCBatch when the node does not
+// answer batch requests with a batch of responses.
+var errRPCBatchUnsupported = errors.New("node does not support batch requests")
+
+// callRPCBatch calls method once for every item of params, in batch requests
+// of up to maxRPCBatchSize calls, and decodes the result of each call into
+// the item of results at the same index. Batches are not retried.
+func (parser *EthereumParser) callRPCBatch(ctx context.Context, method string, params [][]interface{}, results []interface{}) error {
+	for start := 0; start < len(params); start += maxRPCBatchSize {
+		end := min(start+maxRPCBatchSize, len(params))
+		if err := parser.sendRPCBatch(ctx, method, params[start:end], results[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendRPCBatch sends one batch request, bounded by the method's RPC timeout.
+func (parser *EthereumParser) sendRPCBatch(ctx context.Context, method string, params [][]interface{}, results []interface{}) (err error) {
+	start := parser.clock.Now()
+	rawEndpoint := parser.endpoints().Next()
+	endpoint := RedactURL(rawEndpoint)
+	ctx, span := parser.tracer.Start(ctx, "rpc batch "+method, slog.String("rpc.method", method), slog.Int("rpc.batch_size", len(params)))
+	defer func() {
+		duration := parser.clock.Now().Sub(start)
+		attrs := []any{"method", method, "calls", len(params), "duration", duration, "endpoint", endpoint}
+		if err != nil {
+			attrs = append(attrs, "error", err)
+		}
+		parser.logger.DebugContext(ctx, "RPC batch", attrs...)
+		parser.metrics.recordRPC(method, duration, err)
+		span.RecordError(err)
+		span.End()
+		if err != nil {
+			err = &RPCCallError{Method: method, Endpoint: endpoint, Attempts: 1, Err: err}
+		}
+	}()
+
+	if timeout := parser.rpcTimeoutOf(method); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	requests := make([]RPCRequest, len(params))
+	for i, callParams := range params {
+		if callParams == nil {
+			callParams = []interface{}{}
+		}
+		requests[i] = RPCRequest{JSONRPC: "2.0", Method: method, Params: callParams, ID: i + 1}
+	}
+	body, err := json.Marshal(requests)
+	if err != nil {
+		return fmt.Errorf("failed to encode params: %w", err)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, rawEndpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	resp, err := parser.client.Do(request)
+	if err != nil {
+		return redactURLError(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var responses []RPCResponse
+	if err := json.Unmarshal(data, &responses); err != nil {
+		// Nodes without batches answer with a single error, or fail to decode the request
+		return errRPCBatchUnsupported
+	}
+	if len(responses) != len(params) {
+		return fmt.Errorf("%d responses to %d calls", len(responses), len(params))
+	}
+	for _, response := range responses {
+		// Responses may come in any order
+		if response.ID < 1 || response.ID > len(params) {
+			return fmt.Errorf("response to unknown call %d", response.ID)
+		}
+		if response.Error != nil {
+			return fmt.Errorf("call %d: %w", response.ID, response.Error)
+		}
+		if err := json.Unmarshal(response.Result, results[response.ID-1]); err != nil {
+			return fmt.Errorf("failed to decode result of call %d: %w", response.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+// countingTransport counts the bytes of the responses of the node.
+type countingTransport struct {
+	bytes atomic.Int64
+}
+
+func (transport *countingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	response, err := http.DefaultTransport.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+	response.Body = countingBody{response.Body, &transport.bytes}
+	return response, nil
+}
+
+type countingBody struct {
+	io.ReadCloser
+	bytes *atomic.Int64
+}
+
+func (body countingBody) Read(p []byte) (int, error) {
+	n, err := body.ReadCloser.Read(p)
+	body.bytes.Add(int64(n))
+	return n, err
+}
+
+// newBenchmarkParser returns a parser of a fixture node, and the counter of
+// the bytes the node sent it.
+func newBenchmarkParser(b *testing.B) (*EthereumParser, *countingTransport) {
+	b.Helper()
+	node := NewFixtureServer(b, nodeFixtures(b))
+	transport := &countingTransport{}
+	parser := NewEthereumParser(node.URL, NewMemoryStorage(), WithHTTPClient(&http.Client{Transport: transport}), WithLogger(slog.New(slog.DiscardHandler)))
+	return parser, transport
+}
+
+// reportBytes reports the bytes the node sent per operation.
+func reportBytes(b *testing.B, transport *countingTransport) {
+	b.ReportMetric(float64(transport.bytes.Load())/float64(b.N), "bytes/op")
+}
+
+func BenchmarkCallRPCMethod(b *testing.B) {
+	parser, transport := newBenchmarkParser(b)
+	ctx := context.Background()
+	for b.Loop() {
+		var result string
+		if err := parser.callRPCMethod(ctx, "eth_blockNumber", nil, &result); err != nil {
+			b.Fatal(err)
+		}
+	}
+	reportBytes(b, transport)
+}
+
+func BenchmarkCallRPCMethodParallel(b *testing.B) {
+	parser, transport := newBenchmarkParser(b)
+	ctx := context.Background()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			var result string
+			if err := parser.callRPCMethod(ctx, "eth_blockNumber", nil, &result); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+	reportBytes(b, transport)
+}
+
+func BenchmarkGetCurrentBlock(b *testing.B) {
+	parser, transport := newBenchmarkParser(b)
+	for b.Loop() {
+		if parser.GetCurrentBlock() != fixtureBlock {
+			b.Fatal("failed to get current block")
+		}
+	}
+	reportBytes(b, transport)
+}
+
+func BenchmarkGetTransactionsInRange100Blocks(b *testing.B) {
+	parser, transport := newBenchmarkParser(b)
+	ctx := context.Background()
+	transactions := 0
+	for b.Loop() {
+		err := parser.StreamTransactionsInRange(ctx, checksummedAddress, fixtureBlock-99, fixtureBlock, func(Transaction) error {
+			transactions++
+			return nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(transactions)/b.Elapsed().Seconds(), "tx/sec")
+	reportBytes(b, transport)
+}
+
+func BenchmarkSubscribeAddress(b *testing.B) {
+	parser := NewEthereumParser(unreachableEndpoint, NewMemoryStorage())
+	i := 0
+	for b.Loop() {
+		if _, err := parser.SubscribeAddress(fmt.Sprintf("0x%040x", i)); err != nil {
+			b.Fatal(err)
+		}
+		i++
+	}
+}
+
+func BenchmarkWatch1000Blocks(b *testing.B) {
+	const blocks = 1000
+	chain := make([]*Block, blocks)
+	for i := range chain {
+		chain[i] = testBlock(uint64(i+1), Transaction{Hash: Keccak256Hex(fmt.Sprint(i)), From: checksummedAddress, To: otherAddress, Value: "0x1", Nonce: fmt.Sprintf("0x%x", i)})
+	}
+	node := newFakeNode(b, chain...)
+	transactions := 0
+	for b.Loop() {
+		parser := node.NewParser(WithChain(Chain{PollInterval: testWatchInterval}))
+		if _, err := parser.SubscribeAddress(checksummedAddress); err != nil {
+			b.Fatal(err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		out := make(chan Transaction, blocks)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			parser.WatchFromBlock(ctx, 1, out)
+		}()
+		for range blocks {
+			<-out
+			transactions++
+		}
+		cancel()
+		<-done
+	}
+	b.ReportMetric(float64(transactions)/b.Elapsed().Seconds(), "tx/sec")
+}
+
+func BenchmarkMemoryStorage(b *testing.B) {
+	storage := NewMemoryStorage()
+	var next atomic.Int64
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			address := fmt.Sprintf("0x%040x", next.Add(1)%1024)
+			if _, err := storage.SetSubscriber(address); err != nil {
+				b.Error(err)
+				return
+			}
+			if !storage.IsSubscriber(address) {
+				b.Error("subscriber not stored")
+				return
+			}
+		}
+	})
+}
+
+// BenchmarkMatchBlock matches a 300-transaction block against 100k
+// subscribers, looking each address up in the store or in one snapshot.
+func BenchmarkMatchBlock(b *testing.B) {
+	const subscribers, transactions = 100_000, 300
+	parser := NewEthereumParser(unreachableEndpoint, NewMemoryStorage())
+	for i := range subscribers {
+		if _, err := parser.store.SetSubscriber(fmt.Sprintf("0x%040x", i)); err != nil {
+			b.Fatal(err)
+		}
+	}
+	block := make([]Transaction, transactions)
+	for i := range block {
+		// One in ten transactions is sent by a subscriber
+		from := fmt.Sprintf("0x%040x", subscribers+i)
+		if i%10 == 0 {
+			from = fmt.Sprintf("0x%040x", i*300)
+		}
+		block[i] = Transaction{From: from, To: fmt.Sprintf("0x%040x", 2*subscribers+i)}
+	}
+	match := func(b *testing.B, emits func(string) bool) {
+		matched := 0
+		for _, transaction := range block {
+			if emits(transaction.From) || emits(transaction.To) {
+				matched++
+			}
+		}
+		if matched != transactions/10 {
+			b.Fatalf("matched %d transactions, want %d", matched, transactions/10)
+		}
+	}
+
+	// Blocks are matched in parallel, as the parsers of several chains over one store do
+	b.Run("store", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				match(b, parser.emitsFor)
+			}
+		})
+	})
+	b.Run("snapshot", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				match(b, parser.emitters())
+			}
+		})
+	})
+}
@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"time"
+)
+
+// ErrBlockNotFound is returned when the node does not know a block.
+var ErrBlockNotFound = errors.New("block not found")
+
+// BlockSummary is a block header with the hashes of the block's transactions.
+type BlockSummary struct {
+	BlockHeader
+	TransactionHashes []string `json:"transactions"`
+}
+
+// BlockStats summarizes a block processed by WatchWithSummaries.
+type BlockStats struct {
+	Number           uint64    `json:"number"`
+	Hash             string    `json:"hash"`
+	Timestamp        time.Time `json:"timestamp"`
+	Miner            string    `json:"miner"`
+	GasUsed          uint64    `json:"gasUsed"`
+	GasLimit         uint64    `json:"gasLimit"`
+	BaseFeePerGas    *big.Int  `json:"baseFeePerGas,omitempty"` // Nil for blocks before the London fork
+	TransactionCount int       `json:"transactionCount"`
+
+	// Settings of the chain, to tell late blocks and the wait for finality
+	ExpectedBlockTime time.Duration `json:"expectedBlockTime,omitempty"`
+	FinalityDepth     uint64        `json:"finalityDepth,omitempty"`
+}
+
+// newBlockStats summarizes a block, leaving fields the node did not return as zero.
+func newBlockStats(block *Block) BlockStats {
+	stats := BlockStats{
+		Hash:             block.Hash,
+		Miner:            block.Miner,
+		TransactionCount: len(block.Transactions),
+	}
+	stats.Number, _ = parseHexQuantity(block.Number)
+	stats.GasUsed, _ = parseHexQuantity(block.GasUsed)
+	stats.GasLimit, _ = parseHexQuantity(block.GasLimit)
+	if timestamp, err := parseHexQuantity(block.Timestamp); err == nil {
+		stats.Timestamp = time.Unix(int64(timestamp), 0).UTC()
+	}
+	if block.BaseFeePerGas != "" {
+		stats.BaseFeePerGas, _ = parseHexBig(block.BaseFeePerGas)
+	}
+	return stats
+}
+
+// parseHexQuantity parses an optional 0x-hex quantity into a uint64.
+func parseHexQuantity(hexStr string) (uint64, error) {
+	if hexStr == "" {
+		return 0, nil
+	}
+	return ParseHexUint64(hexStr)
+}
+
+// BlockGetter is implemented by parsers that can look up arbitrary blocks.
+type BlockGetter interface {
+	GetBlock(ctx context.Context, block string) (*Block, error)
+	GetBlockSummary(ctx context.Context, block string) (*BlockSummary, error)
+}
+
+// GetBlock returns the block identified by a block tag, 0x-hex number or hash,
+// together with its full transactions.
+func (parser *EthereumParser) GetBlock(ctx context.Context, block string) (*Block, error) {
+	var result Block
+	if err := parser.getBlock(ctx, block, true, &result); err != nil {
+		return nil, err
+	}
+	if result.Hash == "" {
+		return nil, ErrBlockNotFound
+	}
+
+	return &result, nil
+}
+
+// GetBlockSummary returns the header and transaction hashes of the block
+// identified by a block tag, 0x-hex number or hash.
+func (parser *EthereumParser) GetBlockSummary(ctx context.Context, block string) (*BlockSummary, error) {
+	var result BlockSummary
+	if err := parser.getBlock(ctx, block, false, &result); err != nil {
+		return nil, err
+	}
+	if result.Hash == "" {
+		return nil, ErrBlockNotFound
+	}
+
+	return &result, nil
+}
+
+// getBlock fetches a block by hash or by number, depending on the form of block.
+func (parser *EthereumParser) getBlock(ctx context.Context, block string, full bool, result interface{}) error {
+	if IsValidHash(block) {
+		return parser.rpcEthGetBlockByHash(ctx, block, full, result)
+	}
+	return parser.rpcEthGetBlockByNumber(ctx, block, full, result)
+}
@@ -0,0 +1,172 @@
+package parser
+
+import (
+	"hash/maphash"
+	"math/bits"
+	"sync/atomic"
+)
+
+// defaultBloomHashes is the number of hash functions of a bloom filter when
+// none is configured, which suits about 10 slots per subscriber.
+const defaultBloomHashes = 7
+
+// MaxBloomHashes bounds the hash functions of a bloom filter, past which
+// lookups cost more than they save.
+const MaxBloomHashes = 32
+
+// bloomCounterMax is the value at which a counter saturates: it then stays
+// set, since the addresses that set it can no longer be counted.
+const bloomCounterMax = 0xff
+
+// BloomFilterStats reports the size and accuracy of the subscriber bloom filter.
+type BloomFilterStats struct {
+	Size              uint64  `json:"size"` // Number of counters
+	Hashes            int     `json:"hashes"`
+	Checks            uint64  `json:"checks"`
+	PossibleHits      uint64  `json:"possibleHits"`      // Checks passed on to the storage
+	FalsePositives    uint64  `json:"falsePositives"`    // Possible hits the storage did not hold
+	FalsePositiveRate float64 `json:"falsePositiveRate"` // Share of the unsubscribed addresses checked that were possible hits
+}
+
+// bloomReporter is implemented by stores with a subscriber bloom filter.
+type bloomReporter interface {
+	BloomFilterStats() *BloomFilterStats
+}
+
+// bloomFilter is a counting bloom filter over the subscribers of a storage,
+// which tells that an address is not subscribed without taking the storage's
+// lock. Changes are serialized by the storage's lock, lookups are lock-free.
+type bloomFilter struct {
+	size     uint64
+	hashes   int
+	seed     maphash.Seed
+	counters []atomic.Uint32 // Four 8-bit counters per word
+
+	checks         atomic.Uint64
+	possibleHits   atomic.Uint64
+	falsePositives atomic.Uint64
+}
+
+// newBloomFilter initializes an empty bloom filter of size counters, using
+// defaultBloomHashes hash functions when hashes is zero.
+func newBloomFilter(size uint64, hashes int) *bloomFilter {
+	if hashes == 0 {
+		hashes = defaultBloomHashes
+	}
+	return &bloomFilter{
+		size:     size,
+		hashes:   hashes,
+		seed:     maphash.MakeSeed(),
+		counters: make([]atomic.Uint32, (size+3)/4),
+	}
+}
+
+// slot returns the counter of the key for the i-th hash function, by double
+// hashing.
+func (filter *bloomFilter) slot(hash uint64, i int) (word *atomic.Uint32, shift uint) {
+	step := bits.RotateLeft64(hash, 32) | 1
+	index := (hash + uint64(i)*step) % filter.size
+	return &filter.counters[index/4], uint(index%4) * 8
+}
+
+func (filter *bloomFilter) add(key string) {
+	hash := maphash.String(filter.seed, key)
+	for i := 0; i < filter.hashes; i++ {
+		word, shift := filter.slot(hash, i)
+		value := word.Load()
+		if (value>>shift)&bloomCounterMax < bloomCounterMax {
+			word.Store(value + 1<<shift)
+		}
+	}
+}
+
+func (filter *bloomFilter) remove(key string) {
+	hash := maphash.String(filter.seed, key)
+	for i := 0; i < filter.hashes; i++ {
+		word, shift := filter.slot(hash, i)
+		value := word.Load()
+		if counter := (value >> shift) & bloomCounterMax; counter > 0 && counter < bloomCounterMax {
+			word.Store(value - 1<<shift)
+		}
+	}
+}
+
+// mayContain reports false when the key was certainly not added.
+func (filter *bloomFilter) mayContain(key string) bool {
+	filter.checks.Add(1)
+	hash := maphash.String(filter.seed, key)
+	for i := 0; i < filter.hashes; i++ {
+		word, shift := filter.slot(hash, i)
+		if (word.Load()>>shift)&bloomCounterMax == 0 {
+			return false
+		}
+	}
+	filter.possibleHits.Add(1)
+	return true
+}
+
+// falsePositive counts a possible hit the storage did not hold.
+func (filter *bloomFilter) falsePositive() {
+	filter.falsePositives.Add(1)
+}
+
+// update applies the change of the subscribers from old to new.
+func (filter *bloomFilter) update(old, new map[string]bool) {
+	for address := range new {
+		if _, ok := old[address]; !ok {
+			filter.add(address)
+		}
+	}
+	for address := range old {
+		if _, ok := new[address]; !ok {
+			filter.remove(address)
+		}
+	}
+}
+
+func (filter *bloomFilter) stats() *BloomFilterStats {
+	// Loaded in the reverse order of their updates, so that none exceeds the previous one
+	stats := &BloomFilterStats{Size: filter.size, Hashes: filter.hashes}
+	stats.FalsePositives = filter.falsePositives.Load()
+	stats.PossibleHits = filter.possibleHits.Load()
+	stats.Checks = filter.checks.Load()
+	if negatives := stats.Checks - (stats.PossibleHits - stats.FalsePositives); negatives > 0 {
+		stats.FalsePositiveRate = float64(stats.FalsePositives) / float64(negatives)
+	}
+	return stats
+}
+
+// EnableBloomFilter puts a counting bloom filter of size counters in front of
+// the subscriber lookups, which then only take the lock for addresses that
+// may be subscribed. It is updated as subscriptions change. Zero hashes uses
+// defaultBloomHashes, and a zero size disables the filter.
+func (memory *MemoryStorage) EnableBloomFilter(size uint64, hashes int) {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	if size == 0 {
+		memory.bloom.Store(nil)
+		return
+	}
+	filter := newBloomFilter(size, hashes)
+	for address := range memory.subscribers {
+		filter.add(address)
+	}
+	memory.bloom.Store(filter)
+}
+
+// BloomFilterStats returns the statistics of the bloom filter, or nil when it
+// is disabled.
+func (memory *MemoryStorage) BloomFilterStats() *BloomFilterStats {
+	if filter := memory.bloom.Load(); filter != nil {
+		return filter.stats()
+	}
+	return nil
+}
+
+func (store chainStore) BloomFilterStats() *BloomFilterStats {
+	if reporter, ok := store.store.(bloomReporter); ok {
+		return reporter.BloomFilterStats()
+	}
+	return nil
+}
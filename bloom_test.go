@@ -0,0 +1,88 @@
+package parser
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestBloomFilterCorrectness runs the same subscriptions without a filter,
+// with one that always hits, and with one large enough to only hit members:
+// the filter must not change what is subscribed.
+func TestBloomFilterCorrectness(t *testing.T) {
+	filters := []struct {
+		name   string
+		size   uint64
+		hashes int
+	}{
+		{"disabled", 0, 0},
+		{"always hit", 1, 1},
+		{"members only", 1 << 20, 0},
+	}
+	for _, filter := range filters {
+		t.Run(filter.name, func(t *testing.T) {
+			storage := NewMemoryStorage()
+			// Enabled before and after subscribing, which builds it from the subscribers
+			storage.EnableBloomFilter(filter.size, filter.hashes)
+			addresses := make([]string, 200)
+			for i := range addresses {
+				addresses[i] = fmt.Sprintf("0x%040x", i)
+				if i%2 == 0 {
+					storage.SetSubscriber(addresses[i])
+				}
+			}
+			storage.EnableBloomFilter(filter.size, filter.hashes)
+			for i := 0; i < len(addresses); i += 4 {
+				storage.RemoveSubscriber(addresses[i])
+			}
+
+			snapshot, err := takeSubscriberSnapshot(storage)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, address := range addresses {
+				want := i%2 == 0 && i%4 != 0
+				if got := storage.IsSubscriber(address); got != want {
+					t.Errorf("IsSubscriber(%v) = %v, want %v", address, got, want)
+				}
+				if got := snapshot.contains(address); got != want {
+					t.Errorf("snapshot contains %v = %v, want %v", address, got, want)
+				}
+			}
+
+			stats := storage.BloomFilterStats()
+			switch {
+			case filter.size == 0:
+				if stats != nil {
+					t.Errorf("BloomFilterStats of a disabled filter = %+v, want nil", stats)
+				}
+			case stats.Size != filter.size || stats.Checks != 2*uint64(len(addresses)):
+				t.Errorf("BloomFilterStats = %+v, want %d counters and %d checks", stats, filter.size, 2*len(addresses))
+			case filter.size == 1 && (stats.PossibleHits != stats.Checks || stats.FalsePositiveRate != 1):
+				t.Errorf("BloomFilterStats of an always hitting filter = %+v, want every check a possible hit", stats)
+			}
+		})
+	}
+}
+
+// TestBloomFilterCounters removes keys from a counting filter, and keeps a
+// saturated counter set.
+func TestBloomFilterCounters(t *testing.T) {
+	filter := newBloomFilter(1<<16, 0)
+	filter.add("a")
+	filter.add("b")
+	filter.remove("a")
+	if filter.mayContain("a") || !filter.mayContain("b") {
+		t.Errorf("after removing a: mayContain(a) = %v, mayContain(b) = %v, want false, true", filter.mayContain("a"), filter.mayContain("b"))
+	}
+
+	saturated := newBloomFilter(1, 1)
+	for range bloomCounterMax + 10 {
+		saturated.add("a")
+	}
+	for range bloomCounterMax + 10 {
+		saturated.remove("a")
+	}
+	if !saturated.mayContain("a") {
+		t.Error("a saturated counter was cleared")
+	}
+}
@@ -0,0 +1,167 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultCurrency is the native currency symbol of chains that do not name theirs.
+const DefaultCurrency = "ETH"
+
+// ChainCheckTimeout bounds the chain ID check made on startup.
+const ChainCheckTimeout = 10 * time.Second
+
+// CustomChain is the chain name of configurations that do not use a preset.
+const CustomChain = "custom"
+
+// ErrChainMismatch is returned by CheckChainID when the node serves another chain.
+var ErrChainMismatch = errors.New("endpoint serves a different chain")
+
+// Chain describes the network a parser is connected to.
+type Chain struct {
+	Name          string
+	ChainID       uint64        // Expected eth_chainId, not checked when zero
+	PollInterval  time.Duration // Default interval between polls, close to the block time
+	Confirmations uint64        // Default number of blocks to wait before a block is processed
+	ExplorerURL   string        // Base URL of the block explorer, without a trailing slash
+	Currency      string        // Symbol of the native currency
+
+	ExpectedBlockTime time.Duration // Average time between blocks, unknown when zero
+	FinalityDepth     uint64        // Blocks on top of a block after which it is considered final
+	Stack             string        // Rollup stack of L2 chains, arbitrum or op, empty for L1 chains
+}
+
+// ChainPresets holds the settings of the chains selectable by name. Add an
+// entry to support another chain. The finality depth of rollups covers the
+// time until their batches are final on mainnet, about 13 minutes. Their
+// stack tells their system transactions, see IsSystemTransaction.
+var ChainPresets = map[string]Chain{
+	"mainnet":  {Name: "mainnet", ChainID: 1, PollInterval: 12 * time.Second, Confirmations: 12, ExplorerURL: "https://etherscan.io", Currency: "ETH", ExpectedBlockTime: 12 * time.Second, FinalityDepth: 12},
+	"sepolia":  {Name: "sepolia", ChainID: 11155111, PollInterval: 12 * time.Second, Confirmations: 3, ExplorerURL: "https://sepolia.etherscan.io", Currency: "ETH", ExpectedBlockTime: 12 * time.Second, FinalityDepth: 12},
+	"polygon":  {Name: "polygon", ChainID: 137, PollInterval: 2 * time.Second, Confirmations: 64, ExplorerURL: "https://polygonscan.com", Currency: "POL", ExpectedBlockTime: 2 * time.Second, FinalityDepth: 256},
+	"bsc":      {Name: "bsc", ChainID: 56, PollInterval: 3 * time.Second, Confirmations: 15, ExplorerURL: "https://bscscan.com", Currency: "BNB", ExpectedBlockTime: 3 * time.Second, FinalityDepth: 256},
+	"arbitrum": {Name: "arbitrum", ChainID: 42161, PollInterval: time.Second, Confirmations: 1, ExplorerURL: "https://arbiscan.io", Currency: "ETH", ExpectedBlockTime: 250 * time.Millisecond, FinalityDepth: 3120, Stack: stackArbitrum},
+	"optimism": {Name: "optimism", ChainID: optimismChainID, PollInterval: 2 * time.Second, Confirmations: 1, ExplorerURL: "https://optimistic.etherscan.io", Currency: "ETH", ExpectedBlockTime: 2 * time.Second, FinalityDepth: 390, Stack: stackOP},
+	"base":     {Name: "base", ChainID: 8453, PollInterval: 2 * time.Second, Confirmations: 1, ExplorerURL: "https://basescan.org", Currency: "ETH", ExpectedBlockTime: 2 * time.Second, FinalityDepth: 390, Stack: stackOP},
+}
+
+// ChainPresetNames returns the names of the chain presets in alphabetical order.
+func ChainPresetNames() []string {
+	names := make([]string, 0, len(ChainPresets))
+	for name := range ChainPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithChain sets the chain the parser is connected to, along with its polling
+// interval and confirmation depth. AdaptiveWatch may poll as often as the
+// chain produces blocks.
+func WithChain(chain Chain) Option {
+	return func(parser *EthereumParser) {
+		parser.chain = chain
+		if chain.PollInterval > 0 {
+			parser.WatchInterval = chain.PollInterval
+		}
+		if chain.ExpectedBlockTime > 0 && chain.ExpectedBlockTime < parser.MinInterval {
+			parser.MinInterval = chain.ExpectedBlockTime
+		}
+		parser.Confirmations = chain.Confirmations
+	}
+}
+
+// Chain returns the chain the parser is connected to.
+func (parser *EthereumParser) Chain() Chain {
+	return parser.chain
+}
+
+// ChainID returns the chain ID reported by the node.
+func (parser *EthereumParser) ChainID(ctx context.Context) (uint64, error) {
+	chainIDHex, err := parser.rpcEthChainId(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return ParseHexUint64(chainIDHex)
+}
+
+// CheckChainID verifies that the node serves the expected chain, returning
+// ErrChainMismatch when it does not. It succeeds when no chain ID is expected.
+func (parser *EthereumParser) CheckChainID(ctx context.Context) error {
+	if parser.chain.ChainID == 0 {
+		return nil
+	}
+	chainID, err := parser.ChainID(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get chain ID: %w", err)
+	}
+	if chainID != parser.chain.ChainID {
+		return fmt.Errorf("%w: chain ID is %d, expected %d for %v", ErrChainMismatch, chainID, parser.chain.ChainID, parser.chain.Name)
+	}
+	return nil
+}
+
+// IsFinal reports whether a block is buried under the chain's finality
+// depth, counting from the node's latest block.
+func (parser *EthereumParser) IsFinal(ctx context.Context, blockNumber uint64) (bool, error) {
+	head, err := parser.blockNumber(ctx)
+	if err != nil {
+		return false, err
+	}
+	return head >= blockNumber && head-blockNumber >= parser.chain.FinalityDepth, nil
+}
+
+// FinalizationETA estimates the time until a block is final from the chain's
+// expected block time. It is zero for final blocks.
+func (parser *EthereumParser) FinalizationETA(ctx context.Context, blockNumber uint64) (time.Duration, error) {
+	head, err := parser.blockNumber(ctx)
+	if err != nil {
+		return 0, err
+	}
+	final := blockNumber + parser.chain.FinalityDepth
+	if head >= final {
+		return 0, nil
+	}
+	if parser.chain.ExpectedBlockTime <= 0 {
+		return 0, fmt.Errorf("expected block time of chain %v is unknown", parser.chain.Name)
+	}
+	return time.Duration(final-head) * parser.chain.ExpectedBlockTime, nil
+}
+
+// ChainChecker is implemented by parsers that can verify the chain their node serves.
+type ChainChecker interface {
+	CheckChainID(ctx context.Context) error
+}
+
+// ChainOf returns the chain of parsers that describe it, and otherwise an
+// unnamed chain with the default currency.
+func ChainOf(parser Parser) Chain {
+	chain := Chain{Currency: DefaultCurrency}
+	if describer, ok := parser.(interface{ Chain() Chain }); ok {
+		chain = describer.Chain()
+	}
+	if chain.Currency == "" {
+		chain.Currency = DefaultCurrency
+	}
+	return chain
+}
+
+// TransactionURL returns the explorer page of a transaction, or an empty string without an explorer.
+func (chain Chain) TransactionURL(hash string) string {
+	if chain.ExplorerURL == "" {
+		return ""
+	}
+	return strings.TrimSuffix(chain.ExplorerURL, "/") + "/tx/" + hash
+}
+
+// AddressURL returns the explorer page of an address, or an empty string without an explorer.
+func (chain Chain) AddressURL(address string) string {
+	if chain.ExplorerURL == "" {
+		return ""
+	}
+	return strings.TrimSuffix(chain.ExplorerURL, "/") + "/address/" + address
+}
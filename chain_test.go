@@ -0,0 +1,71 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestFinality checks IsFinal and FinalizationETA of blocks around the
+// finality depth of a chain at block 20.
+func TestFinality(t *testing.T) {
+	var blocks []*Block
+	for number := uint64(1); number <= 20; number++ {
+		blocks = append(blocks, testBlock(number))
+	}
+	parser := newTestParser(t, blocks, WithChain(Chain{Name: "mainnet", FinalityDepth: 12, ExpectedBlockTime: 12 * time.Second}))
+
+	tests := []struct {
+		block uint64
+		final bool
+		eta   time.Duration
+	}{
+		{block: 1, final: true},
+		{block: 8, final: true},
+		{block: 9, eta: 12 * time.Second},
+		{block: 10, eta: 24 * time.Second},
+		{block: 20, eta: 144 * time.Second},
+		{block: 25, eta: 204 * time.Second},
+	}
+	for _, tt := range tests {
+		final, err := parser.IsFinal(context.Background(), tt.block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if final != tt.final {
+			t.Errorf("IsFinal(%d) = %v, want %v", tt.block, final, tt.final)
+		}
+		eta, err := parser.FinalizationETA(context.Background(), tt.block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if eta != tt.eta {
+			t.Errorf("FinalizationETA(%d) = %v, want %v", tt.block, eta, tt.eta)
+		}
+	}
+}
+
+// TestFinalityUnknownBlockTime estimates the finalization of blocks of a
+// chain whose block time is unknown, and with a failing node.
+func TestFinalityUnknownBlockTime(t *testing.T) {
+	node := newFakeNode(t, testBlock(1), testBlock(2), testBlock(3))
+	parser := node.NewParser(WithChain(Chain{Name: "devnet", FinalityDepth: 2}))
+
+	if eta, err := parser.FinalizationETA(context.Background(), 1); err != nil || eta != 0 {
+		t.Errorf("FinalizationETA of a final block = %v, %v, want 0 without the block time", eta, err)
+	}
+	if _, err := parser.FinalizationETA(context.Background(), 2); err == nil {
+		t.Error("FinalizationETA without the expected block time succeeded")
+	}
+
+	var rpcErr *RPCError
+	node.Fail("eth_blockNumber", &RPCError{Code: -32603, Message: "internal error"})
+	if _, err := parser.IsFinal(context.Background(), 1); !errors.As(err, &rpcErr) || rpcErr.Code != -32603 {
+		t.Errorf("IsFinal with a failing node = %v, want the internal error", err)
+	}
+	node.Fail("eth_blockNumber", &RPCError{Code: -32603, Message: "internal error"})
+	if _, err := parser.FinalizationETA(context.Background(), 1); !errors.As(err, &rpcErr) || rpcErr.Code != -32603 {
+		t.Errorf("FinalizationETA with a failing node = %v, want the internal error", err)
+	}
+}
@@ -0,0 +1,154 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// TxClass is the kind of activity a transaction performs.
+type TxClass string
+
+const (
+	TxClassETHTransfer      TxClass = "eth-transfer"
+	TxClassContractCreation TxClass = "contract-creation"
+	TxClassContractCall     TxClass = "contract-call"
+	TxClassERC20Transfer    TxClass = "erc20-transfer"
+	TxClassNFTTransfer      TxClass = "nft-transfer"
+)
+
+// maxConcurrentReceipts limits the receipts ClassifyBatch fetches at once.
+const maxConcurrentReceipts = 8
+
+var (
+	transferTopic       = Keccak256Hex("Transfer(address,address,uint256)")
+	transferSingleTopic = Keccak256Hex("TransferSingle(address,address,address,uint256,uint256)")
+	transferBatchTopic  = Keccak256Hex("TransferBatch(address,address,address,uint256[],uint256[])")
+	erc20TransferMethod = Keccak256Hex("transfer(address,uint256)")[:10]
+)
+
+// Classifier tags a transaction with its class. The receipt may be nil when it
+// is not available, in which case only the transaction itself is inspected.
+type Classifier interface {
+	Classify(tx Transaction, receipt *TransactionReceipt) (TxClass, error)
+}
+
+// DefaultClassifier classifies transactions by their recipient, input and the
+// token transfer events in their receipt.
+type DefaultClassifier struct{}
+
+// Classify implements the Classifier interface.
+func (DefaultClassifier) Classify(tx Transaction, receipt *TransactionReceipt) (TxClass, error) {
+	if IsContractCreation(tx) {
+		return TxClassContractCreation, nil
+	}
+
+	if receipt != nil {
+		for _, log := range receipt.Logs {
+			switch {
+			case isNFTTransferLog(log):
+				return TxClassNFTTransfer, nil
+			case isERC20TransferLog(log):
+				return TxClassERC20Transfer, nil
+			}
+		}
+	} else if strings.HasPrefix(strings.ToLower(tx.Input), erc20TransferMethod) {
+		return TxClassERC20Transfer, nil
+	}
+
+	if tx.Input == "" || tx.Input == "0x" {
+		return TxClassETHTransfer, nil
+	}
+	return TxClassContractCall, nil
+}
+
+// IsContractCreation reports whether the transaction deploys a contract.
+func IsContractCreation(tx Transaction) bool {
+	return tx.To == ""
+}
+
+// isERC20TransferLog reports whether the log is an ERC-20 Transfer event, whose
+// amount is carried in the data rather than in a topic.
+func isERC20TransferLog(log Log) bool {
+	return len(log.Topics) == 3 && log.Topics[0] == transferTopic
+}
+
+// isNFTTransferLog reports whether the log is an ERC-721 Transfer event, whose
+// token ID is indexed, or an ERC-1155 transfer event.
+func isNFTTransferLog(log Log) bool {
+	if len(log.Topics) == 0 {
+		return false
+	}
+	switch log.Topics[0] {
+	case transferTopic:
+		return len(log.Topics) == 4
+	case transferSingleTopic, transferBatchTopic:
+		return true
+	}
+	return false
+}
+
+// ClassifyBatch classifies transactions, fetching their receipts concurrently.
+// The result maps each transaction hash to its class.
+func (parser *EthereumParser) ClassifyBatch(ctx context.Context, txs []Transaction, classifier Classifier) (map[string]TxClass, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	classes := make(map[string]TxClass, len(txs))
+	semaphore := make(chan struct{}, maxConcurrentReceipts)
+
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	for _, tx := range txs {
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(tx Transaction) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			receipt, err := parser.GetTransactionReceipt(ctx, tx.Hash)
+			if err != nil {
+				fail(fmt.Errorf("failed to get receipt for %v: %w", tx.Hash, err))
+				return
+			}
+			class, err := classifier.Classify(tx, receipt)
+			if err != nil {
+				fail(fmt.Errorf("failed to classify %v: %w", tx.Hash, err))
+				return
+			}
+
+			mu.Lock()
+			classes[tx.Hash] = class
+			mu.Unlock()
+		}(tx)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return classes, nil
+}
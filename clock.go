@@ -0,0 +1,184 @@
+package parser
+
+import (
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock is the source of time used by the parser, so that time-dependent
+// behavior such as polling, throttling, RPC latencies and heartbeats can be
+// driven by a MockClock.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+	After(d time.Duration) <-chan time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers the time every interval on C until it is stopped. Like
+// time.Ticker, it drops ticks for slow receivers.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// RealClock is the Clock of the time package.
+type RealClock struct{}
+
+func (RealClock) Now() time.Time                         { return time.Now() }
+func (RealClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (RealClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (RealClock) NewTicker(d time.Duration) Ticker       { return realTicker{time.NewTicker(d)} }
+
+// realTicker is the Ticker of RealClock.
+type realTicker struct {
+	ticker *time.Ticker
+}
+
+func (ticker realTicker) C() <-chan time.Time { return ticker.ticker.C }
+func (ticker realTicker) Stop()               { ticker.ticker.Stop() }
+
+// MockClock is a Clock whose time only moves when Advance is called. It is
+// safe for concurrent use.
+type MockClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []mockWaiter  // Pending After channels, in no particular order
+	tickers []*mockTicker // Running tickers
+}
+
+// mockWaiter is a channel returned by MockClock.After that has not fired yet.
+type mockWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// NewMockClock initializes a MockClock set to start.
+func NewMockClock(start time.Time) *MockClock {
+	return &MockClock{now: start}
+}
+
+// Now returns the clock's current time.
+func (clock *MockClock) Now() time.Time {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	return clock.now
+}
+
+// After returns a channel that receives the clock's time once it has been
+// advanced by d. It fires immediately when d is not positive.
+func (clock *MockClock) After(d time.Duration) <-chan time.Time {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- clock.now
+		return ch
+	}
+	clock.waiters = append(clock.waiters, mockWaiter{deadline: clock.now.Add(d), ch: ch})
+	return ch
+}
+
+// NewTicker returns a Ticker that ticks each time the clock has been advanced
+// by another d. It panics when d is not positive, like time.NewTicker.
+func (clock *MockClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for MockClock.NewTicker")
+	}
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+
+	ticker := &mockTicker{clock: clock, interval: d, next: clock.now.Add(d), ch: make(chan time.Time, 1)}
+	clock.tickers = append(clock.tickers, ticker)
+	return ticker
+}
+
+// Sleep blocks until the clock has been advanced by d.
+func (clock *MockClock) Sleep(d time.Duration) {
+	<-clock.After(d)
+}
+
+// Advance moves the clock forward by d and fires the After channels whose
+// deadline has passed, earliest first.
+func (clock *MockClock) Advance(d time.Duration) {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+
+	clock.now = clock.now.Add(d)
+	sort.Slice(clock.waiters, func(i, j int) bool {
+		return clock.waiters[i].deadline.Before(clock.waiters[j].deadline)
+	})
+	fired := 0
+	for _, waiter := range clock.waiters {
+		if waiter.deadline.After(clock.now) {
+			break
+		}
+		waiter.ch <- clock.now
+		fired++
+	}
+	clock.waiters = clock.waiters[fired:]
+
+	for _, ticker := range clock.tickers {
+		if ticker.next.After(clock.now) {
+			continue
+		}
+		select {
+		case ticker.ch <- clock.now:
+		default:
+		}
+		for !ticker.next.After(clock.now) {
+			ticker.next = ticker.next.Add(ticker.interval)
+		}
+	}
+}
+
+// mockTicker is the Ticker of MockClock.
+type mockTicker struct {
+	clock    *MockClock
+	interval time.Duration
+	next     time.Time // Time of the next tick
+	ch       chan time.Time
+}
+
+func (ticker *mockTicker) C() <-chan time.Time {
+	return ticker.ch
+}
+
+func (ticker *mockTicker) Stop() {
+	ticker.clock.mu.Lock()
+	defer ticker.clock.mu.Unlock()
+	ticker.clock.tickers = slices.DeleteFunc(ticker.clock.tickers, func(other *mockTicker) bool {
+		return other == ticker
+	})
+}
+
+// Pending returns the number of After channels that have not fired yet, which
+// lets callers wait for a goroutine to start waiting before advancing the clock.
+func (clock *MockClock) Pending() int {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	return len(clock.waiters)
+}
+
+// WithClock sets the clock used for polling, throttling, uptime and RPC latencies.
+func WithClock(clock Clock) Option {
+	return func(parser *EthereumParser) {
+		parser.clock = clock
+	}
+}
+
+// Clock returns the parser's clock.
+func (parser *EthereumParser) Clock() Clock {
+	return parser.clock
+}
+
+// ClockOf returns the clock of parsers that have one, and otherwise the real clock.
+func ClockOf(parser Parser) Clock {
+	if clocked, ok := parser.(interface{ Clock() Clock }); ok && clocked.Clock() != nil {
+		return clocked.Clock()
+	}
+	return RealClock{}
+}
@@ -0,0 +1,128 @@
+package parser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMockClockAfter(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	clock := NewMockClock(start)
+	select {
+	case now := <-clock.After(0):
+		if !now.Equal(start) {
+			t.Errorf("After(0) fired at %v, want %v", now, start)
+		}
+	default:
+		t.Error("After(0) did not fire immediately")
+	}
+
+	late, early := clock.After(200*time.Millisecond), clock.After(100*time.Millisecond)
+	if pending := clock.Pending(); pending != 2 {
+		t.Fatalf("Pending = %d, want 2", pending)
+	}
+	clock.Advance(50 * time.Millisecond)
+	select {
+	case <-early:
+		t.Fatal("After(100ms) fired 50ms in")
+	default:
+	}
+	clock.Advance(50 * time.Millisecond)
+	if now := <-early; !now.Equal(start.Add(100 * time.Millisecond)) {
+		t.Errorf("After(100ms) fired at %v, want %v", now, start.Add(100*time.Millisecond))
+	}
+	if pending := clock.Pending(); pending != 1 {
+		t.Errorf("Pending = %d, want 1", pending)
+	}
+	clock.Advance(time.Hour)
+	<-late
+	if now := clock.Now(); !now.Equal(start.Add(time.Hour + 100*time.Millisecond)) {
+		t.Errorf("Now = %v, want %v", now, start.Add(time.Hour+100*time.Millisecond))
+	}
+}
+
+func TestMockClockSleep(t *testing.T) {
+	clock := NewMockClock(time.Unix(1_700_000_000, 0))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		clock.Sleep(time.Second)
+	}()
+	waitForPending(t, clock)
+	clock.Advance(time.Second)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Sleep did not return once the clock was advanced")
+	}
+}
+
+func TestMockClockTicker(t *testing.T) {
+	clock := NewMockClock(time.Unix(1_700_000_000, 0))
+	ticker := clock.NewTicker(10 * time.Second)
+	clock.Advance(5 * time.Second)
+	select {
+	case <-ticker.C():
+		t.Fatal("ticked before the interval")
+	default:
+	}
+
+	// Like time.Ticker, ticks are dropped for a slow receiver
+	clock.Advance(25 * time.Second)
+	<-ticker.C()
+	select {
+	case <-ticker.C():
+		t.Fatal("ticked more than once for a slow receiver")
+	default:
+	}
+	clock.Advance(10 * time.Second)
+	<-ticker.C()
+
+	ticker.Stop()
+	clock.Advance(time.Minute)
+	select {
+	case <-ticker.C():
+		t.Error("ticked after Stop")
+	default:
+	}
+}
+
+// TestWatchMockClock polls for the next block only when the clock reaches the
+// watch interval.
+func TestWatchMockClock(t *testing.T) {
+	const interval = 15 * time.Second
+	clock := NewMockClock(time.Unix(1_700_000_000, 0))
+	transfer := func(hash string) Transaction {
+		return Transaction{Hash: hash, From: checksummedAddress, To: otherAddress, Value: "0x1", Nonce: "0x0"}
+	}
+	node := newFakeNode(t, testBlock(1, transfer("0x1")))
+	parser := node.NewParser(WithClock(clock), WithChain(Chain{PollInterval: interval}))
+	if _, err := parser.SubscribeAddress(checksummedAddress); err != nil {
+		t.Fatal(err)
+	}
+	out := startWatch(t, parser, 1)
+	if transaction := receive(t, out); transaction.Hash != "0x1" {
+		t.Fatalf("received %v, want 0x1", transaction.Hash)
+	}
+
+	node.AddBlock(testBlock(2, transfer("0x2")))
+	waitForPending(t, clock)
+	clock.Advance(interval - time.Second)
+	receiveNone(t, out, 5*testWatchInterval)
+	clock.Advance(time.Second)
+	if transaction := receive(t, out); transaction.Hash != "0x2" {
+		t.Errorf("received %v, want 0x2", transaction.Hash)
+	}
+}
+
+// waitForPending waits until a goroutine waits on the clock.
+func waitForPending(t *testing.T, clock *MockClock) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for clock.Pending() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("nothing waits on the clock")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
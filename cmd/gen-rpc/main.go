@@ -0,0 +1,188 @@
+// Command gen-rpc generates typed wrappers around callRPCMethod from a list of
+// JSON-RPC methods, so that method names and parameters are checked at compile
+// time. It is run by go generate in the repository root.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// rpcMethod describes a JSON-RPC method and the Go types of its params and result.
+type rpcMethod struct {
+	Name       string
+	Params     []rpcParam
+	Result     string // Go type of the result, or "any" when the caller supplies the value to decode into
+	Idempotent bool   // Whether the method can be retried when a call may or may not have reached the node
+}
+
+// rpcParam is a positional parameter of a JSON-RPC method.
+type rpcParam struct {
+	Name string
+	Type string
+}
+
+func main() {
+	in := flag.String("in", "rpc_methods.yaml", "list of JSON-RPC methods")
+	out := flag.String("out", "rpc_generated.go", "generated Go file")
+	flag.Parse()
+
+	methods, err := readMethods(*in)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	source, err := generate(*in, methods)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*out, source, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// readMethods reads the methods file. Only the subset of YAML the file uses is
+// supported: a methods list whose items have name, result and idempotent keys
+// and a params list of single-key name: type mappings.
+func readMethods(path string) ([]rpcMethod, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var methods []rpcMethod
+	var method *rpcMethod
+	inParams := false
+	scanner := bufio.NewScanner(file)
+	for number := 1; scanner.Scan(); number++ {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 && !strings.Contains(line[:i], `"`) {
+			line = line[:i]
+		}
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed == "methods:" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(strings.TrimPrefix(trimmed, "- "), ":")
+		if !ok {
+			return nil, fmt.Errorf("%v:%d: expected key: value", path, number)
+		}
+		key, value = strings.TrimSpace(key), unquote(strings.TrimSpace(value))
+
+		switch {
+		case strings.HasPrefix(trimmed, "- ") && key == "name" && !inParamsIndent(line):
+			methods = append(methods, rpcMethod{Name: value})
+			method = &methods[len(methods)-1]
+			inParams = false
+		case method == nil:
+			return nil, fmt.Errorf("%v:%d: expected a method name", path, number)
+		case key == "params":
+			inParams = true
+		case key == "result":
+			method.Result = value
+			inParams = false
+		case key == "idempotent":
+			if value != "true" && value != "false" {
+				return nil, fmt.Errorf("%v:%d: expected idempotent: true or false", path, number)
+			}
+			method.Idempotent = value == "true"
+			inParams = false
+		case inParams && strings.HasPrefix(trimmed, "- "):
+			method.Params = append(method.Params, rpcParam{Name: key, Type: value})
+		default:
+			return nil, fmt.Errorf("%v:%d: unexpected key %q", path, number, key)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, method := range methods {
+		if method.Result == "" {
+			return nil, fmt.Errorf("%v: method %v has no result type", path, method.Name)
+		}
+	}
+	return methods, nil
+}
+
+// inParamsIndent reports whether a list item is nested deeper than a method, as params are.
+func inParamsIndent(line string) bool {
+	return len(line)-len(strings.TrimLeft(line, " ")) > 2
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// generate returns the formatted source of the wrappers.
+func generate(source string, methods []rpcMethod) ([]byte, error) {
+	var buffer bytes.Buffer
+	fmt.Fprintf(&buffer, "// Code generated by gen-rpc from %v. DO NOT EDIT.\n\n", source)
+	fmt.Fprintf(&buffer, "package parser\n\nimport \"context\"\n")
+
+	fmt.Fprintf(&buffer, "\n// idempotentRPCMethods are the methods callRPCMethod retries.\n")
+	fmt.Fprintf(&buffer, "var idempotentRPCMethods = map[string]bool{\n")
+	for _, method := range methods {
+		if method.Idempotent {
+			fmt.Fprintf(&buffer, "%q: true,\n", method.Name)
+		}
+	}
+	fmt.Fprintf(&buffer, "}\n")
+
+	for _, method := range methods {
+		name := wrapperName(method.Name)
+		params := []string{"ctx context.Context"}
+		var args []string
+		for _, param := range method.Params {
+			params = append(params, param.Name+" "+param.Type)
+			args = append(args, param.Name)
+		}
+		callParams := "nil"
+		if len(args) > 0 {
+			callParams = "[]interface{}{" + strings.Join(args, ", ") + "}"
+		}
+
+		if method.Result == "any" {
+			fmt.Fprintf(&buffer, "\n// %v calls %v, decoding its result into result.\n", name, method.Name)
+			fmt.Fprintf(&buffer, "func (parser *EthereumParser) %v(%v, result interface{}) error {\n", name, strings.Join(params, ", "))
+			fmt.Fprintf(&buffer, "return parser.callRPCMethod(ctx, %q, %v, result)\n}\n", method.Name, callParams)
+			continue
+		}
+		fmt.Fprintf(&buffer, "\n// %v calls %v.\n", name, method.Name)
+		fmt.Fprintf(&buffer, "func (parser *EthereumParser) %v(%v) (%v, error) {\n", name, strings.Join(params, ", "), method.Result)
+		fmt.Fprintf(&buffer, "var result %v\n", method.Result)
+		fmt.Fprintf(&buffer, "err := parser.callRPCMethod(ctx, %q, %v, &result)\n", method.Name, callParams)
+		fmt.Fprintf(&buffer, "return result, err\n}\n")
+	}
+
+	return format.Source(buffer.Bytes())
+}
+
+// wrapperName returns the Go name of the wrapper of a method, e.g.
+// rpcEthBlockNumber for eth_blockNumber.
+func wrapperName(method string) string {
+	var name strings.Builder
+	name.WriteString("rpc")
+	for _, part := range strings.Split(method, "_") {
+		if part == "" {
+			continue
+		}
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		name.WriteString(string(runes))
+	}
+	return name.String()
+}
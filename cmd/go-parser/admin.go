@@ -0,0 +1,16 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/GeorgeIwu/go-parser"
+)
+
+// newAdminHandler serves the parser's admin routes, see parser.NewAdminHandler,
+// and reloads the configuration on POST /reload. Every request must carry the
+// admin token of the running configuration as a bearer token.
+func newAdminHandler(reloader *configReloader, p parser.Parser) http.Handler {
+	mux := parser.NewAdminHandler(p)
+	mux.HandleFunc("POST /reload", reloader.handleReload)
+	return parser.RequireToken(reloader.adminToken, mux)
+}
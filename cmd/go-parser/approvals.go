@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/GeorgeIwu/go-parser"
+)
+
+// approvalList is the result of the getApprovals command.
+type approvalList struct {
+	Approvals []parser.ApprovalEvent `json:"approvals"`
+	full      bool                   // Whether hashes and addresses are printed in full
+}
+
+func (list *approvalList) printText(w io.Writer) {
+	if len(list.Approvals) == 0 {
+		fmt.Fprintln(w, "No approvals")
+		return
+	}
+	table := newTable(list.full, "BLOCK", "TOKEN", "SPENDER", "ALLOWANCE", "HASH")
+	table.alignRight(0)
+	for _, approval := range list.Approvals {
+		token := approval.Token
+		if approval.TokenSymbol != "" {
+			token = approval.TokenSymbol
+		}
+		allowance := approval.Amount
+		if approval.Kind != parser.ApprovalLimited {
+			allowance = approval.Kind
+		}
+		table.addRow(fmt.Sprint(approval.BlockNumber), token, approval.Spender, allowance, approval.TransactionHash)
+	}
+	table.render(w)
+}
+
+// getApprovals returns the recent approvals granted by an address.
+func getApprovals(p parser.Parser, address string, full bool) (*approvalList, error) {
+	lister, ok := p.(parser.ApprovalLister)
+	if !ok {
+		return nil, errors.New("parser does not monitor approvals")
+	}
+	return &approvalList{Approvals: lister.Approvals(address), full: full}, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/GeorgeIwu/go-parser"
+)
+
+// blockTags are the named blocks accepted by the JSON-RPC API.
+var blockTags = map[string]bool{
+	"latest":    true,
+	"earliest":  true,
+	"pending":   true,
+	"safe":      true,
+	"finalized": true,
+}
+
+// balanceResult is the result of the getBalance command.
+type balanceResult struct {
+	Address  string `json:"address"`
+	Wei      string `json:"wei"`
+	Ether    string `json:"ether"`
+	BlockTag string `json:"blockTag"`
+	currency string // Symbol of the native currency, used in text output
+}
+
+// normalizeBlockTag accepts a named block tag or a decimal or 0x-hex block number
+// and returns the form used by the JSON-RPC API.
+func normalizeBlockTag(block string) (string, error) {
+	if blockTags[block] {
+		return block, nil
+	}
+	if strings.HasPrefix(block, "0x") {
+		if _, err := parser.ParseHexUint64(block); err != nil {
+			return "", fmt.Errorf("invalid block: %v", block)
+		}
+		return block, nil
+	}
+	number, err := strconv.ParseUint(block, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid block: %v", block)
+	}
+	return fmt.Sprintf("0x%x", number), nil
+}
+
+func (result *balanceResult) printText(w io.Writer) {
+	fmt.Fprintf(w, "%v wei (%v %v) at %v\n", result.Wei, result.Ether, result.currency, result.BlockTag)
+}
+
+// getBalance reads the balance of an address in wei and ether.
+func getBalance(ctx context.Context, p parser.Parser, address string, block string) (*balanceResult, error) {
+	getter, ok := p.(parser.BalanceGetter)
+	if !ok {
+		return nil, fmt.Errorf("parser does not support reading balances")
+	}
+	if !parser.IsValidAddress(address) {
+		return nil, newUsageError("invalid address: %v", address)
+	}
+	blockTag, err := normalizeBlockTag(block)
+	if err != nil {
+		return nil, newUsageError("%v", err)
+	}
+
+	balance, err := getter.GetBalance(ctx, address, blockTag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance: %w", err)
+	}
+
+	return &balanceResult{
+		Address:  address,
+		Wei:      balance.String(),
+		Ether:    parser.FormatEther(balance),
+		BlockTag: blockTag,
+		currency: parser.ChainOf(p).Currency,
+	}, nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/GeorgeIwu/go-parser"
+)
+
+// normalizeBlockID accepts a block hash in addition to the forms accepted by normalizeBlockTag.
+func normalizeBlockID(block string) (string, error) {
+	if parser.IsValidHash(block) {
+		return block, nil
+	}
+	return normalizeBlockTag(block)
+}
+
+// blockSummaryResult is the result of the getBlock command. It is encoded as the block summary.
+type blockSummaryResult struct {
+	*parser.BlockSummary
+}
+
+func (summary blockSummaryResult) printText(w io.Writer) {
+	printBlockHeader(w, summary.BlockHeader)
+	printField(w, "transactions", fmt.Sprint(len(summary.TransactionHashes)))
+}
+
+// blockResult is the result of the getBlock command with --full. It is encoded as the block.
+type blockResult struct {
+	*parser.Block
+	currency string // Symbol of the native currency, used in text output
+}
+
+func (result blockResult) printText(w io.Writer) {
+	printBlockHeader(w, result.BlockHeader)
+	printField(w, "transactions", fmt.Sprint(len(result.Transactions)))
+	if len(result.Transactions) > 0 {
+		fmt.Fprintln(w)
+		transactionList{transactions: result.Transactions, currency: result.currency}.printText(w)
+	}
+}
+
+// getBlock looks up a block's header and transaction hashes or, with --full, its transactions.
+func getBlock(ctx context.Context, p parser.Parser, args []string) (textPrinter, error) {
+	getter, ok := p.(parser.BlockGetter)
+	if !ok {
+		return nil, errors.New("parser does not support looking up blocks")
+	}
+
+	id := ""
+	full := false
+	for _, arg := range args {
+		switch {
+		case arg == "--full" || arg == "-full":
+			full = true
+		case strings.HasPrefix(arg, "-"):
+			return nil, newUsageError("unknown flag: %v", arg)
+		case id == "":
+			id = arg
+		default:
+			return nil, newUsageError("unexpected argument: %v", arg)
+		}
+	}
+	if id == "" {
+		return nil, newUsageError("you need to define a block (number, hash, latest or finalized)")
+	}
+	block, err := normalizeBlockID(id)
+	if err != nil {
+		return nil, newUsageError("%v", err)
+	}
+
+	var result textPrinter
+	if full {
+		var fullBlock *parser.Block
+		fullBlock, err = getter.GetBlock(ctx, block)
+		result = blockResult{Block: fullBlock, currency: parser.ChainOf(p).Currency}
+	} else {
+		var summary *parser.BlockSummary
+		summary, err = getter.GetBlockSummary(ctx, block)
+		result = blockSummaryResult{summary}
+	}
+	if errors.Is(err, parser.ErrBlockNotFound) {
+		return nil, fmt.Errorf("block %v not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block: %w", err)
+	}
+	return result, nil
+}
+
+// printBlockHeader prints the decoded fields of a block header.
+func printBlockHeader(w io.Writer, header parser.BlockHeader) {
+	printField(w, "number", formatQuantity(header.Number))
+	printField(w, "hash", header.Hash)
+	printField(w, "parent hash", header.ParentHash)
+	if timestamp, err := parseHexBig(header.Timestamp); err == nil && timestamp.IsInt64() {
+		printField(w, "timestamp", time.Unix(timestamp.Int64(), 0).Local().Format("2006-01-02 15:04:05 MST"))
+	}
+	printField(w, "miner", header.Miner)
+
+	gasUsed, usedErr := parseHexBig(header.GasUsed)
+	gasLimit, limitErr := parseHexBig(header.GasLimit)
+	if usedErr == nil && limitErr == nil && gasLimit.Sign() > 0 {
+		percent, _ := new(big.Rat).SetFrac(new(big.Int).Mul(gasUsed, big.NewInt(100)), gasLimit).Float64()
+		printField(w, "gas used", fmt.Sprintf("%v / %v (%.1f%%)", gasUsed, gasLimit, percent))
+	}
+	if header.BaseFeePerGas != "" {
+		printField(w, "base fee", formatQuantity(header.BaseFeePerGas)+" wei")
+	}
+}
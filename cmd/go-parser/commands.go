@@ -0,0 +1,500 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/GeorgeIwu/go-parser"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// clearScreen is the ANSI sequence that clears the terminal and moves the cursor home.
+	clearScreen = "\033[H\033[2J"
+	// watchHeartbeatInterval is how often the watch command reports that it is still running.
+	watchHeartbeatInterval = 30 * time.Second
+	// interactiveAnnotation marks the commands only available at the prompt,
+	// which are hidden from the command line and its completion.
+	interactiveAnnotation = "interactive"
+)
+
+func init() {
+	// Names and aliases are matched case-insensitively, and help lists the
+	// commands in the order they are registered
+	cobra.EnableCaseInsensitive = true
+	cobra.EnableCommandSorting = false
+}
+
+// commandRun runs a command against a session and returns its result.
+type commandRun func(session *session, args []string) (interface{}, error)
+
+// commandCall carries the session of an executed command and its result
+// through the context of the command.
+type commandCall struct {
+	session *session
+	result  interface{}
+}
+
+type commandCallKey struct{}
+
+// sessionRun adapts run to a cobra command, which leaves its flags to run.
+func sessionRun(run commandRun) func(*cobra.Command, []string) error {
+	return func(command *cobra.Command, args []string) (err error) {
+		call := command.Context().Value(commandCallKey{}).(*commandCall)
+		call.result, err = run(call.session, args)
+		return err
+	}
+}
+
+// interactiveOnly annotates the commands only available at the prompt.
+var interactiveOnly = map[string]string{interactiveAnnotation: "true"}
+
+// newRootCommand returns the CLI commands as the subcommands of the program,
+// in the order help lists them. Help, usage, suggestions for mistyped commands
+// and the completion scripts are generated from the tree, so a command only
+// needs to be added here to become available.
+func newRootCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:                        "go-parser",
+		Short:                      "Query an Ethereum node and watch the transactions of subscribed addresses",
+		SilenceErrors:              true,
+		SilenceUsage:               true,
+		SuggestionsMinimumDistance: 2,
+		CompletionOptions:          cobra.CompletionOptions{DisableDefaultCmd: true},
+	}
+	for _, command := range []*cobra.Command{
+		{Use: "getCurrentBlock", Short: "print the number of the latest block", RunE: sessionRun(runGetCurrentBlock)},
+		{Use: "getTransaction <address> [--full]", Aliases: []string{"getTransactions"}, Short: "list the transactions of a subscribed address since it was watched, or in the latest block", RunE: sessionRun(runGetTransaction)},
+		{Use: "getTransactionByHash <hash> [--receipt]", Short: "print a decoded transaction and optionally its receipt", RunE: sessionRun(runGetTransactionByHash)},
+		{Use: "getBlock <number|hash|latest|finalized> [--full]", Short: "print a block header and optionally its transactions", RunE: sessionRun(runGetBlock)},
+		{Use: "getBalance <address> [block]", Short: "print the balance of an address", RunE: sessionRun(runGetBalance)},
+		{Use: "feeEstimate", Aliases: []string{"fee-estimate", "fees"}, Short: "suggest slow, standard and fast fees from the recent blocks", RunE: sessionRun(runFeeEstimate)},
+		{Use: "subscribeAddress <address>", Aliases: []string{"subscribe", "sub"}, Short: "subscribe to an address", RunE: sessionRun(runSubscribeAddress)},
+		{Use: "subscribeFile <path>", Short: "subscribe to every address listed in a file", RunE: sessionRun(runSubscribeFile)},
+		{Use: "listSubscribers [filter] [--full]", Short: "list the subscribed addresses", RunE: sessionRun(runListSubscribers)},
+		{Use: "setAddressLabel <address> [name]", Aliases: []string{"label"}, Short: "name an address in the output, or remove its name", RunE: sessionRun(runSetAddressLabel)},
+		{Use: "importAddressLabels <path.json|path.csv>", Aliases: []string{"import-labels"}, Short: "name the addresses listed in a JSON or CSV file", RunE: sessionRun(runImportAddressLabels)},
+		{Use: "listAddressLabels [--full]", Aliases: []string{"labels"}, Short: "list the named addresses", RunE: sessionRun(runListAddressLabels)},
+		{Use: "getApprovals <address> [--full]", Aliases: []string{"approvals"}, Short: "list the recent ERC-20 approvals granted by a subscribed address", RunE: sessionRun(runGetApprovals)},
+		{Use: "recordBlocks <from> <to> <file>", Aliases: []string{"record-blocks"}, Short: "capture a range of blocks into a fixture for --simulate", RunE: sessionRun(runRecordBlocks)},
+		{Use: "watch <address>", Short: "print new confirmed transactions of an address until interrupted", RunE: sessionRun(runWatch)},
+		{Use: "status [--metrics]", Short: "print the health of the parser and optionally its processing metrics", RunE: sessionRun(runStatus)},
+		{Use: "doctor", Short: "probe the node for the methods and transports it supports, and warn about the features that will not work", RunE: sessionRun(runDoctor)},
+		{Use: "debug", Short: "print a JSON dump of the parser's internal state to stderr", RunE: sessionRun(runDebug)},
+		{Use: "help [command]", Short: "list the available commands, or describe one", RunE: sessionRun(runHelp)},
+		{Use: "completion bash|zsh", Short: "print a shell completion script for the command names", RunE: sessionRun(runCompletion)},
+		{Use: "set format text|json|yaml | loglevel debug|info|warn|error", Short: "change the output format or log level", Annotations: interactiveOnly, RunE: sessionRun(runSet)},
+		{Use: "clear", Short: "clear the screen", Annotations: interactiveOnly, RunE: sessionRun(runClear)},
+		{Use: "quit", Aliases: []string{"exit"}, Short: "exit the program", Annotations: interactiveOnly, RunE: sessionRun(runQuit)},
+	} {
+		// The commands take their flags from their arguments, like at the prompt
+		command.DisableFlagParsing = true
+		command.Hidden = command.Annotations[interactiveAnnotation] != ""
+		root.AddCommand(command)
+		if command.Name() == "help" {
+			root.SetHelpCommand(command)
+		}
+	}
+	return root
+}
+
+// availableCommand reports whether the command can be run in the session:
+// the commands only available at the prompt are hidden from the command line,
+// as are cobra's own.
+func availableCommand(command *cobra.Command, interactive bool) bool {
+	if command.Annotations[interactiveAnnotation] != "" {
+		return interactive
+	}
+	return command.Runnable() && !command.Hidden
+}
+
+// lookupCommand returns the subcommand of root with the given name or alias, or nil.
+func lookupCommand(root *cobra.Command, name string) *cobra.Command {
+	command, _, err := root.Find([]string{name})
+	if err != nil || command == root {
+		return nil
+	}
+	return command
+}
+
+// executeCommand executes a single command and returns its result.
+func executeCommand(session *session, args []string) (interface{}, error) {
+	if len(args) < 1 {
+		return nil, newUsageError("you need to define a command, run help to list them")
+	}
+
+	root := newRootCommand()
+	// The completion scripts ask the program itself for the completions
+	if args[0] != cobra.ShellCompRequestCmd && args[0] != cobra.ShellCompNoDescRequestCmd {
+		command := lookupCommand(root, args[0])
+		if command == nil || !availableCommand(command, session.interactive) {
+			if suggestions := root.SuggestionsFor(args[0]); len(suggestions) > 0 {
+				return nil, newUsageError("unknown command: %v, did you mean %v?", args[0], strings.Join(suggestions, " or "))
+			}
+			var names []string
+			for _, help := range commandHelps(session.interactive) {
+				names = append(names, help.Name)
+			}
+			return nil, newUsageError("unknown command: %v, commands are: %v", args[0], strings.Join(names, ", "))
+		}
+		if _, wantsHelp := takeFlag(args[1:], "help"); wantsHelp {
+			return helpResult{describeCommand(command)}, nil
+		}
+	}
+
+	call := &commandCall{session: session}
+	root.SetArgs(args)
+	root.SetOut(session.stdout)
+	root.SetErr(session.stderr)
+	if _, err := root.ExecuteContextC(context.WithValue(session.ctx, commandCallKey{}, call)); err != nil {
+		return nil, err
+	}
+	return call.result, nil
+}
+
+// takeFlag removes every --name or -name flag from args and reports whether one was present.
+func takeFlag(args []string, name string) ([]string, bool) {
+	var remaining []string
+	found := false
+	for _, arg := range args {
+		if arg == "--"+name || arg == "-"+name {
+			found = true
+			continue
+		}
+		remaining = append(remaining, arg)
+	}
+	return remaining, found
+}
+
+// takeFlagValue removes a flag and its value, given as --name value or
+// --name=value, from the arguments.
+func takeFlagValue(args []string, name string) ([]string, string, error) {
+	var remaining []string
+	value := ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--"+name || arg == "-"+name:
+			if i+1 == len(args) {
+				return nil, "", newUsageError("flag needs a value: --%v", name)
+			}
+			i++
+			value = args[i]
+		case strings.HasPrefix(arg, "--"+name+"="):
+			value = strings.TrimPrefix(arg, "--"+name+"=")
+		default:
+			remaining = append(remaining, arg)
+		}
+	}
+	return remaining, value, nil
+}
+
+// commandHelp describes a command in the help output.
+type commandHelp struct {
+	Name        string   `json:"name"`
+	Aliases     []string `json:"aliases,omitempty"`
+	Args        string   `json:"args,omitempty"`
+	Description string   `json:"description"`
+}
+
+// helpResult is the result of the help command.
+type helpResult []commandHelp
+
+func (help helpResult) printText(w io.Writer) {
+	table := newTable(true, "COMMAND", "DESCRIPTION")
+	for _, command := range help {
+		description := command.Description
+		if len(command.Aliases) > 0 {
+			description += " (also " + strings.Join(command.Aliases, ", ") + ")"
+		}
+		table.addRow(strings.TrimSpace(command.Name+" "+command.Args), description)
+	}
+	table.render(w)
+}
+
+// commandHelps describes the commands available in the session.
+func commandHelps(interactive bool) helpResult {
+	var help helpResult
+	for _, command := range newRootCommand().Commands() {
+		if availableCommand(command, interactive) {
+			help = append(help, describeCommand(command))
+		}
+	}
+	return help
+}
+
+// describeCommand describes the command in the help output.
+func describeCommand(command *cobra.Command) commandHelp {
+	args := strings.TrimSpace(strings.TrimPrefix(command.Use, command.Name()))
+	return commandHelp{Name: command.Name(), Aliases: command.Aliases, Args: args, Description: command.Short}
+}
+
+func runHelp(session *session, args []string) (interface{}, error) {
+	if len(args) == 0 {
+		return commandHelps(session.interactive), nil
+	}
+	command := lookupCommand(newRootCommand(), args[0])
+	if command == nil || !availableCommand(command, session.interactive) {
+		return nil, newUsageError("unknown command: %v", args[0])
+	}
+	return helpResult{describeCommand(command)}, nil
+}
+
+// completionScript is the result of the completion command. It is encoded as a JSON string.
+type completionScript string
+
+func (script completionScript) printText(w io.Writer) {
+	fmt.Fprint(w, script)
+}
+
+// runCompletion prints the completion script cobra generates for the command
+// names. The zsh script asks the program for the completions, which
+// executeCommand hands to cobra.
+func runCompletion(session *session, args []string) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, newUsageError("usage: completion bash|zsh")
+	}
+	var script bytes.Buffer
+	root := newRootCommand()
+	var err error
+	switch args[0] {
+	case "bash":
+		fmt.Fprintln(&script, "# bash completion for go-parser, load with: source <(go-parser completion bash)")
+		err = root.GenBashCompletion(&script)
+	case "zsh":
+		err = root.GenZshCompletion(&script)
+	default:
+		return nil, newUsageError("usage: completion bash|zsh")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate the completion script: %w", err)
+	}
+	return completionScript(script.String()), nil
+}
+
+func runGetCurrentBlock(session *session, args []string) (interface{}, error) {
+	blockNumber := parser.CurrentBlockOf(session.ctx, session.parser)
+	if blockNumber == 0 {
+		return nil, errors.New("failed to get current block")
+	}
+	return currentBlockResult{BlockNumber: blockNumber}, nil
+}
+
+func runGetTransaction(session *session, args []string) (interface{}, error) {
+	args, full := takeFlag(args, "full")
+	if len(args) == 0 {
+		return nil, newUsageError("you need to define an address")
+	}
+	transactions, err := parser.FetchTransactionsOf(session.ctx, session.parser, args[0])
+	if err != nil {
+		return nil, err
+	}
+	return transactionList{transactions: transactions, full: full, currency: parser.ChainOf(session.parser).Currency}, nil
+}
+
+func runGetTransactionByHash(session *session, args []string) (interface{}, error) {
+	return getTransactionByHash(session.ctx, session.parser, args)
+}
+
+func runGetBlock(session *session, args []string) (interface{}, error) {
+	return getBlock(session.ctx, session.parser, args)
+}
+
+func runGetBalance(session *session, args []string) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, newUsageError("you need to define an address")
+	}
+	block := "latest"
+	if len(args) > 1 {
+		block = args[1]
+	}
+	return getBalance(session.ctx, session.parser, args[0], block)
+}
+
+func runFeeEstimate(session *session, args []string) (interface{}, error) {
+	return estimateFees(session.ctx, session.parser)
+}
+
+func runSubscribeAddress(session *session, args []string) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, newUsageError("you need to define an address")
+	}
+	address := args[0]
+	if !parser.IsValidAddress(address) {
+		return nil, newUsageError("invalid address: %v", address)
+	}
+	alreadySubscribed, err := session.parser.SubscribeAddress(address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe address: %v (%v)", address, parser.SubscribeFailureReason(err))
+	}
+	return subscribeResult{Subscribed: address, AlreadySubscribed: alreadySubscribed}, nil
+}
+
+func runSubscribeFile(session *session, args []string) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, newUsageError("you need to define a file path")
+	}
+	return subscribeFile(session.parser, args[0])
+}
+
+func runListSubscribers(session *session, args []string) (interface{}, error) {
+	args, full := takeFlag(args, "full")
+	filter := ""
+	if len(args) > 0 {
+		filter = args[0]
+	}
+	return listSubscribers(session.parser, filter, full)
+}
+
+func runSetAddressLabel(session *session, args []string) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, newUsageError("you need to define an address")
+	}
+	book, ok := session.parser.(parser.AddressBook)
+	if !ok {
+		return nil, errors.New("parser does not keep address labels")
+	}
+	if !parser.IsValidAddress(args[0]) {
+		return nil, newUsageError("invalid address: %v", args[0])
+	}
+	name := strings.Join(args[1:], " ")
+	if err := book.SetAddressLabel(args[0], name); err != nil {
+		return nil, err
+	}
+	return &addressLabelList{AddressLabelList: &parser.AddressLabelList{Labels: []parser.AddressLabel{{Address: args[0], Name: strings.TrimSpace(name)}}}, full: true}, nil
+}
+
+func runImportAddressLabels(session *session, args []string) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, newUsageError("you need to define a file path")
+	}
+	return importAddressLabels(session.parser, args[0])
+}
+
+func runListAddressLabels(session *session, args []string) (interface{}, error) {
+	_, full := takeFlag(args, "full")
+	return listAddressLabels(session.parser, full)
+}
+
+func runGetApprovals(session *session, args []string) (interface{}, error) {
+	args, full := takeFlag(args, "full")
+	if len(args) == 0 {
+		return nil, newUsageError("you need to define an address")
+	}
+	return getApprovals(session.parser, args[0], full)
+}
+
+// addressWatcher is implemented by parsers that can stream the transactions of an address.
+type addressWatcher interface {
+	Watch(ctx context.Context, out chan<- parser.Transaction) error
+	WatchAddress(ctx context.Context, address string, out chan<- parser.Transaction) (func(), error)
+}
+
+func runWatch(session *session, args []string) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, newUsageError("you need to define an address")
+	}
+	address := args[0]
+	if !parser.IsValidAddress(address) {
+		return nil, newUsageError("invalid address: %v", address)
+	}
+	watcher, ok := session.parser.(addressWatcher)
+	if !ok {
+		return nil, errors.New("parser does not support watching addresses")
+	}
+
+	// Subscribe the address unless it already is
+	if _, err := session.parser.SubscribeAddress(address); err != nil {
+		return nil, fmt.Errorf("failed to subscribe address: %v (%v)", address, parser.SubscribeFailureReason(err))
+	}
+
+	// The poller outlives the command, so that interrupting it keeps the progress
+	// made. It polls every chain, whichever one the command selected
+	session.watchOnce.Do(func() {
+		poller, ok := session.chains.(addressWatcher)
+		if !ok {
+			poller = watcher
+		}
+		session.pollers.Add(1)
+		go func() {
+			defer session.pollers.Done()
+			poller.Watch(session.pollCtx, nil)
+		}()
+	})
+
+	ctx, cancel := session.interruptibleContext()
+	defer cancel()
+	matches := make(chan parser.Transaction)
+	stop, err := watcher.WatchAddress(ctx, address, matches)
+	if err != nil {
+		return nil, err
+	}
+	defer stop()
+
+	format := session.commandFormat
+	if format == formatText {
+		fmt.Fprintf(session.stderr, "Watching %v, press Ctrl-C to stop\n", address)
+	}
+	clock := parser.ClockOf(session.parser)
+	heartbeat := clock.NewTicker(watchHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	count := 0
+	for {
+		select {
+		case transaction := <-matches:
+			count++
+			session.printEvent(transaction, format)
+		case <-heartbeat.C():
+			if format == formatText {
+				fmt.Fprintf(session.stderr, "%v still watching %v, %d transactions so far\n", clock.Now().Format(time.TimeOnly), address, count)
+			}
+		case <-ctx.Done():
+			return nil, nil
+		}
+	}
+}
+
+func runStatus(session *session, args []string) (interface{}, error) {
+	_, withMetrics := takeFlag(args, "metrics")
+	status, err := getStatus(session.ctx, session.parser)
+	if err != nil || !withMetrics {
+		return status, err
+	}
+	reporter, ok := session.parser.(parser.MetricsReporter)
+	if !ok {
+		return nil, errors.New("parser does not support reporting metrics")
+	}
+	return statusWithMetrics{Status: status.Status, Metrics: reporter.Metrics()}, nil
+}
+
+func runSet(session *session, args []string) (interface{}, error) {
+	if len(args) == 2 && args[0] == "format" && outputFormats[args[1]] {
+		session.format = args[1]
+		return nil, nil
+	}
+	if len(args) == 2 && args[0] == "loglevel" && session.logLevel != nil {
+		if level, ok := parser.LogLevels[args[1]]; ok {
+			session.logLevel.Set(level)
+			return nil, nil
+		}
+	}
+	return nil, newUsageError("usage: set format text|json|yaml | set loglevel debug|info|warn|error")
+}
+
+func runClear(session *session, args []string) (interface{}, error) {
+	if session.format == formatText {
+		io.WriteString(session.stdout, clearScreen)
+	}
+	return nil, nil
+}
+
+func runQuit(session *session, args []string) (interface{}, error) {
+	session.close()
+	return nil, nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/GeorgeIwu/go-parser"
+	"github.com/GeorgeIwu/go-parser/parsertest"
+)
+
+const testAddress = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+
+func TestRunGetTransactionErrors(t *testing.T) {
+	p := parser.NewEthereumParser("http://127.0.0.1:1", parser.NewMemoryStorage())
+	session := newSession(p, formatText, io.Discard, io.Discard)
+
+	if _, err := runGetTransaction(session, []string{testAddress}); !errors.Is(err, parser.ErrNotSubscribed) {
+		t.Errorf("getTransactions of an unsubscribed address error = %v, want ErrNotSubscribed", err)
+	}
+	if _, err := p.SubscribeAddress(testAddress); err != nil {
+		t.Fatal(err)
+	}
+	_, err := runGetTransaction(session, []string{testAddress})
+	var callErr *parser.RPCCallError
+	if !errors.As(err, &callErr) || !strings.Contains(err.Error(), "failed to get current block") {
+		t.Errorf("getTransactions with the node down error = %v, want the RPC error", err)
+	}
+}
+
+func TestLookupCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		want string // Name of the command, "" for none
+	}{
+		{"getCurrentBlock", "getCurrentBlock"},
+		{"getcurrentblock", "getCurrentBlock"},
+		{"GETCURRENTBLOCK", "getCurrentBlock"},
+		{"getTransaction", "getTransaction"},
+		{"getTransactions", "getTransaction"},
+		{"gettransactions", "getTransaction"},
+		{"subscribeAddress", "subscribeAddress"},
+		{"SubscribeAddress", "subscribeAddress"},
+		{"subscribe", "subscribeAddress"},
+		{"sub", "subscribeAddress"},
+		{"SUB", "subscribeAddress"},
+		{"exit", "quit"},
+		{"getTransactionz", ""},
+		{"", ""},
+	}
+	root := newRootCommand()
+	for _, test := range tests {
+		got := ""
+		if command := lookupCommand(root, test.name); command != nil {
+			got = command.Name()
+		}
+		if got != test.want {
+			t.Errorf("lookupCommand(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+// TestRunBatchAliases runs commands spelled with aliases, other cases and
+// extra spaces.
+func TestRunBatchAliases(t *testing.T) {
+	p := parsertest.NewMockParser()
+	p.CurrentBlock = 42
+	var stdout, stderr strings.Builder
+	session := newSession(p, formatText, &stdout, &stderr)
+
+	input := "  GETCURRENTBLOCK  \nsub " + testAddress + "\nSubscribe " + testAddress + " \ngettransactions\t" + testAddress + "\n"
+	if code := runBatch(session, strings.NewReader(input), true); code != 0 {
+		t.Fatalf("runBatch = %d, stderr: %v", code, stderr.String())
+	}
+	want := "42\nSubscribed " + testAddress + "\nAlready subscribed " + testAddress + "\nNo transactions\n"
+	if stdout.String() != want {
+		t.Errorf("output = %q, want %q", stdout.String(), want)
+	}
+}
+
+// TestUnknownCommand lists exactly the commands available outside the prompt.
+func TestUnknownCommand(t *testing.T) {
+	session := newSession(parsertest.NewMockParser(), formatText, io.Discard, io.Discard)
+	var names []string
+	for _, command := range newRootCommand().Commands() {
+		if command.Annotations[interactiveAnnotation] == "" {
+			names = append(names, command.Name())
+		}
+	}
+	_, err := executeCommand(session, []string{"frobnicate"})
+	if want := "unknown command: frobnicate, commands are: " + strings.Join(names, ", "); err == nil || err.Error() != want {
+		t.Errorf("executeCommand(frobnicate) error = %v, want %v", err, want)
+	}
+
+	if _, err := executeCommand(session, []string{"subscribeAdress"}); err == nil || !strings.HasSuffix(err.Error(), "did you mean subscribeAddress?") {
+		t.Errorf("executeCommand(subscribeAdress) error = %v, want a suggestion of subscribeAddress", err)
+	}
+	if _, err := executeCommand(session, []string{"QUIT"}); err == nil || !strings.HasPrefix(err.Error(), "unknown command: QUIT") {
+		t.Errorf("executeCommand(QUIT) outside the prompt error = %v, want unknown command", err)
+	}
+}
+
+// TestCompletion generates the completion scripts from the commands, and
+// answers the completion requests of the zsh script with the command names
+// available outside the prompt.
+func TestCompletion(t *testing.T) {
+	session := newSession(parsertest.NewMockParser(), formatText, io.Discard, io.Discard)
+	for shell, want := range map[string]string{"bash": "commands+=(\"getCurrentBlock\")", "zsh": "#compdef go-parser"} {
+		script, err := executeCommand(session, []string{"completion", shell})
+		if err != nil || !strings.Contains(string(script.(completionScript)), want) {
+			t.Errorf("completion %v = %.80q..., %v, want a script holding %q", shell, script, err, want)
+		}
+	}
+	if _, err := executeCommand(session, []string{"completion", "fish"}); err == nil || err.Error() != "usage: completion bash|zsh" {
+		t.Errorf("completion fish error = %v, want the usage", err)
+	}
+
+	var stdout strings.Builder
+	session = newSession(parsertest.NewMockParser(), formatText, &stdout, io.Discard)
+	if _, err := executeCommand(session, []string{"__complete", "get"}); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if name, _, _ := strings.Cut(line, "\t"); name != "" && !strings.HasPrefix(name, ":") {
+			names = append(names, name)
+		}
+	}
+	if want := []string{"getCurrentBlock", "getTransaction", "getTransactionByHash", "getBlock", "getBalance", "getApprovals"}; strings.Join(names, " ") != strings.Join(want, " ") {
+		t.Errorf("completions of get = %v, want %v", names, want)
+	}
+}
@@ -0,0 +1,531 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GeorgeIwu/go-parser"
+)
+
+// Config holds the settings used to construct the parser. The toml tags name
+// the keys of the configuration file, the env tags the environment variables
+// that override them, and fields tagged secret are redacted when printed.
+type Config struct {
+	Endpoint            string        `toml:"endpoint" env:"PARSER_ENDPOINT" secret:"url"`              // Ethereum node JSON-RPC endpoint
+	Endpoints           []string      `toml:"endpoints" env:"PARSER_ENDPOINTS" secret:"url"`            // Endpoints of the same chain called instead of Endpoint, the healthiest first
+	PollInterval        time.Duration `toml:"poll_interval" env:"PARSER_POLL_INTERVAL"`                 // Interval between polls for new blocks
+	Confirmations       uint64        `toml:"confirmations" env:"PARSER_CONFIRMATIONS"`                 // Number of blocks to wait before a block is processed
+	ActivationDelay     time.Duration `toml:"activation_delay" env:"PARSER_ACTIVATION_DELAY"`           // Time after subscribing before an address's transactions are watched
+	IndexCapacity       int           `toml:"index_capacity" env:"PARSER_INDEX_CAPACITY"`               // Number of recent transactions indexed per address
+	UserAgent           string        `toml:"user_agent" env:"PARSER_USER_AGENT"`                       // User-Agent header sent to the node
+	Format              string        `toml:"format" env:"PARSER_FORMAT"`                               // Output format of command results: text, json or yaml
+	LogLevel            string        `toml:"log_level" env:"PARSER_LOG_LEVEL"`                         // Level of the diagnostics logged to stderr: debug, info, warn or error
+	LogFormat           string        `toml:"log_format" env:"PARSER_LOG_FORMAT"`                       // Format of the diagnostics logged to stderr: text or json
+	FailFast            bool          `toml:"fail_fast" env:"PARSER_FAIL_FAST"`                         // Whether piped commands stop at the first failure
+	RecordDir           string        `toml:"record_dir" env:"PARSER_RECORD_DIR"`                       // Directory JSON-RPC responses are recorded to as fixtures, disabled when empty
+	Daemon              bool          `toml:"daemon" env:"PARSER_DAEMON"`                               // Whether to run the poller and the listeners without a prompt
+	PIDFile             string        `toml:"pid_file" env:"PARSER_PID_FILE"`                           // File the process ID is written to in daemon mode, none when empty
+	Simulate            string        `toml:"simulate" env:"PARSER_SIMULATE"`                           // Fixture of blocks replayed instead of querying the endpoint, disabled when empty
+	SimulateInterval    time.Duration `toml:"simulate_interval" env:"PARSER_SIMULATE_INTERVAL"`         // Time between the blocks of a simulation, all at once when 0
+	RPCTimeout          time.Duration `toml:"rpc_timeout" env:"PARSER_RPC_TIMEOUT"`                     // Time a JSON-RPC call and its retries may take, unbounded when 0
+	RPCTimeouts         []string      `toml:"rpc_timeouts" env:"PARSER_RPC_TIMEOUTS"`                   // Timeouts of specific methods, as method=duration
+	SyncCheckInterval   time.Duration `toml:"sync_check_interval" env:"PARSER_SYNC_CHECK_INTERVAL"`     // Interval the poller checks whether the node syncs at, never when 0
+	HealthCheckInterval time.Duration `toml:"health_check_interval" env:"PARSER_HEALTH_CHECK_INTERVAL"` // Interval the poller checks the health of the endpoint at, never when 0
+	PendingScanInterval time.Duration `toml:"pending_scan_interval" env:"PARSER_PENDING_SCAN_INTERVAL"` // Interval the pending block is scanned for stuck transactions at, never when 0
+	AlertRules          []string      `toml:"alert_rules" env:"PARSER_ALERT_RULES"`                     // Alerts on large transfers, as label:min=amount;direction=in;token=…;target=…;addresses=…|…
+	StuckAfter          time.Duration `toml:"stuck_after" env:"PARSER_STUCK_AFTER"`                     // Time a transaction of a subscribed address may stay pending before it is reported
+	WatchApprovals      bool          `toml:"watch_approvals" env:"PARSER_WATCH_APPROVALS"`             // Whether the ERC-20 approvals granted by subscribed addresses are monitored
+	TokenList           string        `toml:"token_list" env:"PARSER_TOKEN_LIST"`                       // Token list JSON file of well-known contracts, merged over the embedded ones
+	Chains              []string      `toml:"chains" env:"PARSER_CHAINS"`                               // Chains watched together, as name=endpoint
+	Chain               ChainConfig   `toml:"chain"`
+	Storage             StorageConfig `toml:"storage"`
+	Server              ServerConfig  `toml:"server"`
+	Admin               AdminConfig   `toml:"admin"`
+}
+
+// ChainConfig selects the chain the node serves. A preset provides the
+// defaults of the chain settings, including the poll interval and confirmations.
+type ChainConfig struct {
+	Name        string `toml:"name" env:"PARSER_CHAIN"`                // Chain preset, or custom
+	ID          uint64 `toml:"id" env:"PARSER_CHAIN_ID"`               // Expected chain ID of the node, not checked when 0
+	ExplorerURL string `toml:"explorer_url" env:"PARSER_EXPLORER_URL"` // Base URL of the block explorer
+	Currency    string `toml:"currency" env:"PARSER_CURRENCY"`         // Symbol of the native currency
+
+	ExpectedBlockTime time.Duration `toml:"expected_block_time" env:"PARSER_EXPECTED_BLOCK_TIME"` // Average time between blocks, unknown when 0
+	FinalityDepth     uint64        `toml:"finality_depth" env:"PARSER_FINALITY_DEPTH"`           // Blocks after which a block is considered final
+	Stack             string        `toml:"stack" env:"PARSER_CHAIN_STACK"`                       // Rollup stack of L2 chains: arbitrum or op
+}
+
+// StorageConfig selects the storage backend.
+type StorageConfig struct {
+	Backend           string `toml:"backend" env:"PARSER_STORAGE"`                         // Storage backend name
+	DSN               string `toml:"dsn" env:"PARSER_STORAGE_DSN" secret:"true"`           // Backend-specific connection string
+	BloomFilterSize   uint64 `toml:"bloom_filter_size" env:"PARSER_BLOOM_FILTER_SIZE"`     // Counters of the subscriber bloom filter, disabled when 0
+	BloomFilterHashes int    `toml:"bloom_filter_hashes" env:"PARSER_BLOOM_FILTER_HASHES"` // Hash functions of the subscriber bloom filter, a default when 0
+}
+
+// ServerConfig configures the HTTP API.
+type ServerConfig struct {
+	Addr string `toml:"addr" env:"PARSER_HTTP_ADDR"` // Listen address of the HTTP API, disabled when empty
+}
+
+// AdminConfig configures the admin listener serving the pprof profiles and
+// runtime metrics.
+type AdminConfig struct {
+	Addr  string `toml:"addr" env:"PARSER_ADMIN_ADDR"`                 // Listen address of the admin endpoints, disabled when empty
+	Token string `toml:"token" env:"PARSER_ADMIN_TOKEN" secret:"true"` // Bearer token required by the admin endpoints
+}
+
+// storageBackends lists the storage backends available in this build.
+var storageBackends = map[string]bool{
+	"memory": true,
+}
+
+// defaultConfig returns the configuration used when nothing is overridden.
+func defaultConfig() Config {
+	return Config{
+		Endpoint:            defaultEndpoint,
+		PollInterval:        parser.DefaultWatchInterval,
+		IndexCapacity:       parser.DefaultIndexCapacity,
+		UserAgent:           parser.DefaultUserAgent,
+		RPCTimeout:          parser.DefaultRPCTimeout,
+		SyncCheckInterval:   parser.DefaultSyncCheckInterval,
+		HealthCheckInterval: parser.DefaultHealthCheckInterval,
+		StuckAfter:          parser.DefaultStuckAfter,
+		SimulateInterval:    parser.DefaultSimulateInterval,
+		Format:              formatText,
+		LogLevel:            parser.DefaultLogLevel,
+		LogFormat:           parser.LogFormatText,
+		Storage:             StorageConfig{Backend: "memory"},
+	}
+}
+
+// defineFlags registers the command-line flags, storing their values in config.
+func defineFlags(flags *flag.FlagSet, config *Config, configPath *string) {
+	flags.StringVar(configPath, "config", *configPath, "path to a TOML configuration file (PARSER_CONFIG)")
+	flags.StringVar(&config.Endpoint, "endpoint", config.Endpoint, "Ethereum node JSON-RPC endpoint (PARSER_ENDPOINT)")
+	flags.Func("endpoints", "comma-separated JSON-RPC endpoints of the same chain called instead of --endpoint, each call going to the healthy one with the lowest latency (PARSER_ENDPOINTS)", func(text string) error {
+		return setConfigFieldFromString(reflect.ValueOf(&config.Endpoints).Elem(), text)
+	})
+	flags.DurationVar(&config.PollInterval, "poll-interval", config.PollInterval, "interval between polls for new blocks (PARSER_POLL_INTERVAL)")
+	flags.Uint64Var(&config.Confirmations, "confirmations", config.Confirmations, "blocks to wait before processing a block (PARSER_CONFIRMATIONS)")
+	flags.DurationVar(&config.ActivationDelay, "activation-delay", config.ActivationDelay, "time after subscribing before an address's transactions are watched (PARSER_ACTIVATION_DELAY)")
+	flags.IntVar(&config.IndexCapacity, "index-capacity", config.IndexCapacity, "recent transactions indexed per address (PARSER_INDEX_CAPACITY)")
+	flags.StringVar(&config.UserAgent, "user-agent", config.UserAgent, "User-Agent header sent to the node (PARSER_USER_AGENT)")
+	flags.DurationVar(&config.RPCTimeout, "rpc-timeout", config.RPCTimeout, "time a JSON-RPC call and its retries may take, 0 for no limit (PARSER_RPC_TIMEOUT)")
+	flags.Func("rpc-timeouts", "timeouts of specific JSON-RPC methods, as comma-separated method=duration pairs, e.g. eth_blockNumber=2s,eth_getLogs=1m (PARSER_RPC_TIMEOUTS)", func(text string) error {
+		return setConfigFieldFromString(reflect.ValueOf(&config.RPCTimeouts).Elem(), text)
+	})
+	flags.DurationVar(&config.SyncCheckInterval, "sync-check-interval", config.SyncCheckInterval, "interval the poller checks whether the node syncs at, pausing while it does, 0 to never check (PARSER_SYNC_CHECK_INTERVAL)")
+	flags.DurationVar(&config.HealthCheckInterval, "health-check-interval", config.HealthCheckInterval, "interval the poller checks the latency and health of the endpoint at, see GET /endpoints on the admin listener, 0 to never check (PARSER_HEALTH_CHECK_INTERVAL)")
+	flags.DurationVar(&config.PendingScanInterval, "pending-scan-interval", config.PendingScanInterval, "interval the pending block is scanned for stuck transactions of subscribed addresses at, 0 to never scan (PARSER_PENDING_SCAN_INTERVAL)")
+	flags.DurationVar(&config.StuckAfter, "stuck-after", config.StuckAfter, "time a transaction may stay pending before it is reported as stuck (PARSER_STUCK_AFTER)")
+	flags.Func("alert-rules", "alerts on large transfers, as comma-separated label:min=amount;direction=in|out|any;token=address;target=name;addresses=address|address rules (PARSER_ALERT_RULES)", func(text string) error {
+		return setConfigFieldFromString(reflect.ValueOf(&config.AlertRules).Elem(), text)
+	})
+	flags.BoolVar(&config.WatchApprovals, "watch-approvals", config.WatchApprovals, "monitor the ERC-20 approvals granted by subscribed addresses, see getApprovals (PARSER_WATCH_APPROVALS)")
+	flags.StringVar(&config.TokenList, "token-list", config.TokenList, "token list JSON file of well-known contracts named in transactions, merged over the embedded ones and re-read on SIGHUP (PARSER_TOKEN_LIST)")
+	flags.StringVar(&config.Format, "format", config.Format, "output format of command results: text, json or yaml (PARSER_FORMAT)")
+	flags.BoolFunc("json", "print command results as JSON, same as --format json", func(string) error {
+		config.Format = formatJSON
+		return nil
+	})
+	flags.StringVar(&config.LogLevel, "log-level", config.LogLevel, "level of the diagnostics logged to stderr: debug, info, warn or error (PARSER_LOG_LEVEL)")
+	for name, level := range map[string]string{"quiet": "error", "verbose": "info", "debug": "debug"} {
+		flags.BoolFunc(name, "log at level "+level+", same as --log-level "+level, func(string) error {
+			config.LogLevel = level
+			return nil
+		})
+	}
+	flags.StringVar(&config.Chain.Name, "chain", config.Chain.Name, "chain preset: "+strings.Join(parser.ChainPresetNames(), ", ")+" or custom (PARSER_CHAIN)")
+	flags.Func("chains", "watch several chains, as comma-separated name=endpoint pairs, e.g. mainnet=https://...,polygon=https://... (PARSER_CHAINS)", func(text string) error {
+		return setConfigFieldFromString(reflect.ValueOf(&config.Chains).Elem(), text)
+	})
+	flags.Uint64Var(&config.Chain.ID, "chain-id", config.Chain.ID, "expected chain ID of the node, 0 to skip the check (PARSER_CHAIN_ID)")
+	flags.StringVar(&config.LogFormat, "log-format", config.LogFormat, "format of the diagnostics logged to stderr: text or json (PARSER_LOG_FORMAT)")
+	flags.BoolVar(&config.FailFast, "fail-fast", config.FailFast, "stop piped commands at the first failure (PARSER_FAIL_FAST)")
+	flags.BoolVar(&config.Daemon, "daemon", config.Daemon, "run the poller and the listeners without a prompt until SIGTERM (PARSER_DAEMON)")
+	flags.StringVar(&config.PIDFile, "pid-file", config.PIDFile, "file the process ID is written to in daemon mode (PARSER_PID_FILE)")
+	flags.StringVar(&config.Simulate, "simulate", config.Simulate, "replay the blocks of a JSON or NDJSON fixture instead of querying the endpoint (PARSER_SIMULATE)")
+	flags.DurationVar(&config.SimulateInterval, "simulate-interval", config.SimulateInterval, "time between the blocks of a simulation, 0 for all at once (PARSER_SIMULATE_INTERVAL)")
+	flags.StringVar(&config.Storage.Backend, "storage", config.Storage.Backend, "storage backend: memory (PARSER_STORAGE)")
+	flags.StringVar(&config.Storage.DSN, "storage-dsn", config.Storage.DSN, "storage backend connection string (PARSER_STORAGE_DSN)")
+	flags.Uint64Var(&config.Storage.BloomFilterSize, "bloom-filter-size", config.Storage.BloomFilterSize, "counters of the subscriber bloom filter, about 10 per subscriber, 0 to disable it (PARSER_BLOOM_FILTER_SIZE)")
+	flags.IntVar(&config.Storage.BloomFilterHashes, "bloom-filter-hashes", config.Storage.BloomFilterHashes, "hash functions of the subscriber bloom filter, 0 for the default (PARSER_BLOOM_FILTER_HASHES)")
+	flags.StringVar(&config.Server.Addr, "http-addr", config.Server.Addr, "listen address of the HTTP API, e.g. :8080 (PARSER_HTTP_ADDR)")
+	flags.StringVar(&config.Admin.Addr, "admin-addr", config.Admin.Addr, "listen address of the pprof and runtime metrics endpoints, e.g. localhost:6060 (PARSER_ADMIN_ADDR)")
+}
+
+// parseConfig builds the configuration from, in increasing order of precedence,
+// the defaults, the configuration file, PARSER_* environment variables and
+// command-line flags. It returns the arguments left after the flags. Usage is
+// printed for invalid configuration.
+func parseConfig(args []string) (Config, []string, error) {
+	return loadConfig(args, os.Stderr)
+}
+
+// loadConfig behaves like parseConfig, but prints errors and usage to output,
+// or nowhere when it is nil.
+func loadConfig(args []string, output io.Writer) (Config, []string, error) {
+	if output == nil {
+		output = io.Discard
+	}
+	// The first pass only finds the configuration file, the second applies the
+	// flags on top of the file and the environment.
+	configPath := os.Getenv("PARSER_CONFIG")
+	var probed Config
+	probe := flag.NewFlagSet("go-parser", flag.ContinueOnError)
+	probe.SetOutput(io.Discard)
+	defineFlags(probe, &probed, &configPath)
+	probe.Parse(args)
+
+	config := defaultConfig()
+	config.applyChainPreset(selectedChain(probed.Chain.Name, configPath))
+	flags := flag.NewFlagSet("go-parser", flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: go-parser [flags] [command [address]]\n       go-parser [flags] config validate|print\n\nflags:\n")
+		flags.PrintDefaults()
+	}
+	defineFlags(flags, &config, &configPath)
+
+	fail := func(err error) (Config, []string, error) {
+		fmt.Fprintf(flags.Output(), "error: %v\n", err)
+		flags.Usage()
+		return config, nil, err
+	}
+
+	if configPath != "" {
+		if err := loadConfigFile(configPath, &config); err != nil {
+			return fail(err)
+		}
+	}
+	if err := applyEnv(&config); err != nil {
+		return fail(err)
+	}
+	if err := flags.Parse(args); err != nil {
+		return config, nil, err
+	}
+	if err := config.validate(); err != nil {
+		return fail(err)
+	}
+
+	return config, flags.Args(), nil
+}
+
+// selectedChain returns the chain named by the flags, the environment or the
+// configuration file, in that order. Errors in the file are reported when it
+// is loaded.
+func selectedChain(flagChain, configPath string) string {
+	if flagChain != "" {
+		return flagChain
+	}
+	if name, ok := os.LookupEnv("PARSER_CHAIN"); ok {
+		return name
+	}
+	var config Config
+	if configPath != "" && loadConfigFile(configPath, &config) == nil {
+		return config.Chain.Name
+	}
+	return ""
+}
+
+// applyChainPreset replaces the chain settings with those of a preset, leaving
+// them unchanged for custom chains.
+func (config *Config) applyChainPreset(name string) {
+	preset, ok := parser.ChainPresets[name]
+	if !ok {
+		return
+	}
+	config.PollInterval = preset.PollInterval
+	config.Confirmations = preset.Confirmations
+	config.Chain = ChainConfig{
+		Name:              name,
+		ID:                preset.ChainID,
+		ExplorerURL:       preset.ExplorerURL,
+		Currency:          preset.Currency,
+		ExpectedBlockTime: preset.ExpectedBlockTime,
+		FinalityDepth:     preset.FinalityDepth,
+		Stack:             preset.Stack,
+	}
+}
+
+// applyEnv overrides the configuration with the environment variables named
+// by the env struct tags that are set.
+func applyEnv(config *Config) error {
+	return applyEnvFields(reflect.ValueOf(config).Elem())
+}
+
+func applyEnvFields(value reflect.Value) error {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		if field.Kind() == reflect.Struct && field.Type() != reflect.TypeOf(time.Duration(0)) {
+			if err := applyEnvFields(field); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := value.Type().Field(i).Tag.Get("env")
+		if name == "" {
+			continue
+		}
+		text, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setConfigFieldFromString(field, text); err != nil {
+			return fmt.Errorf("invalid %v=%q: %w", name, text, err)
+		}
+	}
+	return nil
+}
+
+// setConfigFieldFromString converts an environment variable value to the field's type.
+// Lists are comma-separated.
+func setConfigFieldFromString(field reflect.Value, text string) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		return setConfigField(field, text)
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		return setConfigField(field, text)
+	case reflect.Bool:
+		flag, err := strconv.ParseBool(text)
+		if err != nil {
+			return fmt.Errorf("expected a boolean such as true or false")
+		}
+		return setConfigField(field, flag)
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		number, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return fmt.Errorf("expected an integer")
+		}
+		return setConfigField(field, number)
+	case reflect.Float64:
+		number, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return fmt.Errorf("expected a number")
+		}
+		return setConfigField(field, number)
+	case reflect.Slice:
+		items := []string{}
+		for _, item := range strings.Split(text, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return setConfigField(field, items)
+	default:
+		return fmt.Errorf("unsupported field type %v", field.Type())
+	}
+}
+
+// validate checks that the configuration can be used to construct a parser.
+func (config Config) validate() error {
+	for _, text := range append([]string{config.Endpoint}, config.Endpoints...) {
+		endpoint, err := url.Parse(text)
+		if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			return fmt.Errorf("invalid endpoint: %q", text)
+		}
+	}
+	if len(config.Endpoints) > 0 && len(config.Chains) > 0 {
+		return errors.New("endpoints cannot be combined with chains, which have an endpoint each")
+	}
+	if config.PollInterval <= 0 {
+		return errors.New("poll interval must be positive")
+	}
+	if config.ActivationDelay < 0 {
+		return errors.New("activation delay must not be negative")
+	}
+	if config.IndexCapacity <= 0 {
+		return errors.New("index capacity must be positive")
+	}
+	if !outputFormats[config.Format] {
+		return fmt.Errorf("unsupported output format: %q", config.Format)
+	}
+	if _, ok := parser.LogLevels[config.LogLevel]; !ok {
+		return fmt.Errorf("unsupported log level: %q", config.LogLevel)
+	}
+	if !parser.LogFormats[config.LogFormat] {
+		return fmt.Errorf("unsupported log format: %q", config.LogFormat)
+	}
+	if config.RPCTimeout < 0 {
+		return errors.New("RPC timeout must not be negative")
+	}
+	if _, err := parser.ParseRPCTimeouts(config.RPCTimeouts); err != nil {
+		return err
+	}
+	if config.SyncCheckInterval < 0 {
+		return errors.New("sync check interval must not be negative")
+	}
+	if config.HealthCheckInterval < 0 {
+		return errors.New("health check interval must not be negative")
+	}
+	if _, err := parser.ParseAlertRules(config.AlertRules); err != nil {
+		return err
+	}
+	if _, err := parser.LoadKnownContracts(config.TokenList); err != nil {
+		return err
+	}
+	if config.PendingScanInterval < 0 {
+		return errors.New("pending scan interval must not be negative")
+	}
+	if config.StuckAfter <= 0 {
+		return errors.New("stuck after must be positive")
+	}
+	if config.Chain.ExpectedBlockTime < 0 {
+		return errors.New("expected block time must not be negative")
+	}
+	if !parser.RollupStacks[config.Chain.Stack] {
+		return fmt.Errorf("unsupported rollup stack: %q", config.Chain.Stack)
+	}
+	if _, ok := parser.ChainPresets[config.Chain.Name]; !ok && config.Chain.Name != "" && config.Chain.Name != parser.CustomChain {
+		return fmt.Errorf("unsupported chain: %q", config.Chain.Name)
+	}
+	seen := make(map[string]bool)
+	for _, item := range config.Chains {
+		name, endpoint, ok := strings.Cut(item, "=")
+		if !ok || name == "" {
+			return fmt.Errorf("invalid chain %q, expected name=endpoint", item)
+		}
+		if seen[name] {
+			return fmt.Errorf("duplicate chain: %q", name)
+		}
+		seen[name] = true
+		if parsed, err := url.Parse(endpoint); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("invalid endpoint of chain %v: %q", name, endpoint)
+		}
+	}
+	if !storageBackends[config.Storage.Backend] {
+		return fmt.Errorf("unsupported storage backend: %q", config.Storage.Backend)
+	}
+	if config.Storage.BloomFilterHashes < 0 || config.Storage.BloomFilterHashes > parser.MaxBloomHashes {
+		return fmt.Errorf("bloom filter hashes must be between 0 and %d", parser.MaxBloomHashes)
+	}
+	if config.SimulateInterval < 0 {
+		return errors.New("simulate interval must not be negative")
+	}
+	if config.Simulate != "" && len(config.Chains) > 0 {
+		return errors.New("a simulation replays a single chain")
+	}
+	if config.PIDFile != "" && !config.Daemon {
+		return errors.New("a PID file is only written in daemon mode")
+	}
+	if config.Admin.Addr != "" && config.Admin.Token == "" {
+		return errors.New("the admin endpoints need a token, set PARSER_ADMIN_TOKEN")
+	}
+	return nil
+}
+
+// runConfigCommand handles the config subcommands, which inspect the
+// configuration without starting the parser.
+func runConfigCommand(config Config, args []string) int {
+	switch {
+	case len(args) == 1 && args[0] == "validate":
+		// parseConfig has already rejected invalid configuration
+		fmt.Println("configuration is valid")
+		return 0
+	case len(args) == 1 && args[0] == "print":
+		if err := writeConfigTOML(os.Stdout, config); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return exitRuntimeError
+		}
+		return 0
+	}
+	fmt.Fprintln(os.Stderr, "usage: go-parser [flags] config validate|print")
+	return exitUsageError
+}
+
+// newStore creates the storage backend selected by the configuration.
+func newStore(config Config) (parser.Store, error) {
+	switch config.Storage.Backend {
+	case "memory":
+		memory := parser.NewMemoryStorage()
+		memory.EnableBloomFilter(config.Storage.BloomFilterSize, config.Storage.BloomFilterHashes)
+		return memory, nil
+	default:
+		return nil, fmt.Errorf("unsupported storage backend: %q", config.Storage.Backend)
+	}
+}
+
+// newParser constructs the parser described by the configuration: a
+// MultiChainParser when several chains are configured.
+func newParser(config Config, logger *slog.Logger) (parser.Parser, error) {
+	store, err := newStore(config)
+	if err != nil {
+		return nil, err
+	}
+
+	timeouts, err := parser.ParseRPCTimeouts(config.RPCTimeouts)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := parser.ParseAlertRules(config.AlertRules)
+	if err != nil {
+		return nil, err
+	}
+	contracts, err := parser.LoadKnownContracts(config.TokenList)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []parser.Option{parser.WithUserAgent(config.UserAgent), parser.WithLogger(logger), parser.WithActivationDelay(config.ActivationDelay), parser.WithIndexCapacity(config.IndexCapacity), parser.WithRPCTimeouts(config.RPCTimeout, timeouts), parser.WithSyncCheckInterval(config.SyncCheckInterval), parser.WithHealthCheckInterval(config.HealthCheckInterval), parser.WithPendingScan(config.PendingScanInterval, config.StuckAfter), parser.WithAlertRules(rules), parser.WithKnownContracts(contracts)}
+	if config.WatchApprovals {
+		opts = append(opts, parser.WithApprovalMonitoring(nil))
+	}
+	if config.RecordDir != "" {
+		opts = append(opts, parser.WithRecording(config.RecordDir))
+	}
+	if config.Simulate != "" {
+		client, err := parser.NewSimulationClient(config.Simulate, config.SimulateInterval, config.Chain.ID, logger)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, parser.WithHTTPClient(client))
+	}
+
+	if len(config.Chains) > 0 {
+		var chains []parser.Chain
+		var endpoints []string
+		for _, item := range config.Chains {
+			name, endpoint, _ := strings.Cut(item, "=")
+			chain, ok := parser.ChainPresets[name]
+			if !ok {
+				chain = parser.Chain{Name: name, PollInterval: config.PollInterval, Confirmations: config.Confirmations}
+			}
+			chains = append(chains, chain)
+			endpoints = append(endpoints, endpoint)
+		}
+		return parser.NewMultiChainParser(chains, endpoints, store, opts...)
+	}
+
+	chain := parser.Chain{
+		Name:          config.Chain.Name,
+		ChainID:       config.Chain.ID,
+		PollInterval:  config.PollInterval,
+		Confirmations: config.Confirmations,
+		ExplorerURL:   config.Chain.ExplorerURL,
+		Currency:      config.Chain.Currency,
+
+		ExpectedBlockTime: config.Chain.ExpectedBlockTime,
+		FinalityDepth:     config.Chain.FinalityDepth,
+		Stack:             config.Chain.Stack,
+	}
+	endpoint := config.Endpoint
+	if len(config.Endpoints) > 0 {
+		endpoint = config.Endpoints[0]
+		opts = append(opts, parser.WithEndpointPool(parser.NewEndpointPool(config.Endpoints...)))
+	}
+	return parser.NewEthereumParser(endpoint, store, append(opts, parser.WithChain(chain))...), nil
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GeorgeIwu/go-parser"
+)
+
+// envReference matches ${ENV_VAR} references in configuration values.
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// configValue is a value read from a configuration file with its position.
+type configValue struct {
+	value interface{} // string, int64, float64, bool or []string
+	line  int
+}
+
+// loadConfigFile applies the settings of a TOML configuration file to config.
+// Unknown keys are rejected so that typos do not go unnoticed.
+func loadConfigFile(path string, config *Config) error {
+	if ext := filepath.Ext(path); ext != ".toml" {
+		return fmt.Errorf("unsupported configuration format %q: use a .toml file", ext)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	values, err := parseTOML(file)
+	if err != nil {
+		return fmt.Errorf("%v: %w", path, err)
+	}
+
+	for key, value := range values {
+		field, err := lookupConfigField(reflect.ValueOf(config).Elem(), key)
+		if err != nil {
+			return fmt.Errorf("%v: line %d: %w", path, value.line, err)
+		}
+		if err := setConfigField(field, value.value); err != nil {
+			return fmt.Errorf("%v: line %d: invalid value for %q: %w", path, value.line, key, err)
+		}
+	}
+	return nil
+}
+
+// lookupConfigField finds the struct field for a dotted key using the toml struct tags.
+func lookupConfigField(value reflect.Value, key string) (reflect.Value, error) {
+	for _, name := range strings.Split(key, ".") {
+		if value.Kind() != reflect.Struct {
+			return reflect.Value{}, fmt.Errorf("unknown key %q", key)
+		}
+		found := false
+		for i := 0; i < value.NumField(); i++ {
+			if value.Type().Field(i).Tag.Get("toml") == name {
+				value = value.Field(i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return reflect.Value{}, fmt.Errorf("unknown key %q", key)
+		}
+	}
+	if value.Kind() == reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("%q is a section, not a key", key)
+	}
+	return value, nil
+}
+
+// setConfigField stores a parsed configuration value into a struct field.
+func setConfigField(field reflect.Value, value interface{}) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		text, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("expected a duration string such as \"12s\"")
+		}
+		duration, err := time.ParseDuration(text)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(duration))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		text, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("expected a string")
+		}
+		field.SetString(text)
+	case reflect.Bool:
+		flag, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("expected a boolean")
+		}
+		field.SetBool(flag)
+	case reflect.Int, reflect.Int64:
+		number, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("expected an integer")
+		}
+		field.SetInt(number)
+	case reflect.Uint, reflect.Uint64:
+		number, ok := value.(int64)
+		if !ok || number < 0 {
+			return fmt.Errorf("expected a non-negative integer")
+		}
+		field.SetUint(uint64(number))
+	case reflect.Float64:
+		switch number := value.(type) {
+		case float64:
+			field.SetFloat(number)
+		case int64:
+			field.SetFloat(float64(number))
+		default:
+			return fmt.Errorf("expected a number")
+		}
+	case reflect.Slice:
+		items, ok := value.([]string)
+		if !ok || field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("expected an array of strings")
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported field type %v", field.Type())
+	}
+	return nil
+}
+
+// parseTOML parses the subset of TOML used by configuration files: [section]
+// headers and key = value pairs holding strings, integers, floats, booleans
+// and arrays of strings. Keys are returned in dotted form, e.g. "server.addr".
+func parseTOML(r io.Reader) (map[string]configValue, error) {
+	values := make(map[string]configValue)
+	section := ""
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(stripComment(scanner.Text()))
+		if text == "" {
+			continue
+		}
+
+		if strings.HasPrefix(text, "[") {
+			if !strings.HasSuffix(text, "]") {
+				return nil, fmt.Errorf("line %d: malformed section header", line)
+			}
+			section = strings.TrimSpace(text[1 : len(text)-1])
+			if section == "" {
+				return nil, fmt.Errorf("line %d: empty section name", line)
+			}
+			continue
+		}
+
+		key, rawValue, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", line)
+		}
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return nil, fmt.Errorf("line %d: missing key", line)
+		}
+		if section != "" {
+			key = section + "." + key
+		}
+		if _, exists := values[key]; exists {
+			return nil, fmt.Errorf("line %d: duplicate key %q", line, key)
+		}
+
+		value, err := parseTOMLValue(strings.TrimSpace(rawValue))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		values[key] = configValue{value: value, line: line}
+	}
+	return values, scanner.Err()
+}
+
+// stripComment removes a trailing # comment that is not inside a string.
+func stripComment(line string) string {
+	var quote rune
+	for i, char := range line {
+		switch {
+		case quote != 0 && char == quote && (quote == '\'' || i == 0 || line[i-1] != '\\'):
+			quote = 0
+		case quote == 0 && (char == '"' || char == '\''):
+			quote = char
+		case quote == 0 && char == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func parseTOMLValue(text string) (interface{}, error) {
+	switch {
+	case text == "":
+		return nil, fmt.Errorf("missing value")
+	case text == "true" || text == "false":
+		return text == "true", nil
+	case strings.HasPrefix(text, "\"") || strings.HasPrefix(text, "'"):
+		return parseTOMLString(text)
+	case strings.HasPrefix(text, "["):
+		if !strings.HasSuffix(text, "]") {
+			return nil, fmt.Errorf("unterminated array")
+		}
+		items := []string{}
+		inner := strings.TrimSpace(text[1 : len(text)-1])
+		for _, item := range splitTOMLArray(inner) {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			value, err := parseTOMLString(item)
+			if err != nil {
+				return nil, fmt.Errorf("array items must be strings: %w", err)
+			}
+			items = append(items, value)
+		}
+		return items, nil
+	}
+
+	number := strings.ReplaceAll(text, "_", "")
+	if integer, err := strconv.ParseInt(number, 10, 64); err == nil {
+		return integer, nil
+	}
+	if float, err := strconv.ParseFloat(number, 64); err == nil {
+		return float, nil
+	}
+	return nil, fmt.Errorf("invalid value %q", text)
+}
+
+// parseTOMLString parses a quoted string and expands ${ENV_VAR} references.
+func parseTOMLString(text string) (string, error) {
+	var value string
+	switch {
+	case len(text) >= 2 && text[0] == '\'' && text[len(text)-1] == '\'':
+		value = text[1 : len(text)-1]
+	case len(text) >= 2 && text[0] == '"' && text[len(text)-1] == '"':
+		unquoted, err := strconv.Unquote(text)
+		if err != nil {
+			return "", fmt.Errorf("invalid string %v", text)
+		}
+		value = unquoted
+	default:
+		return "", fmt.Errorf("unterminated string %v", text)
+	}
+	return expandEnv(value)
+}
+
+// splitTOMLArray splits array items on commas outside of strings.
+func splitTOMLArray(text string) []string {
+	var items []string
+	var quote rune
+	start := 0
+	for i, char := range text {
+		switch {
+		case quote != 0 && char == quote && (quote == '\'' || text[i-1] != '\\'):
+			quote = 0
+		case quote == 0 && (char == '"' || char == '\''):
+			quote = char
+		case quote == 0 && char == ',':
+			items = append(items, text[start:i])
+			start = i + 1
+		}
+	}
+	return append(items, text[start:])
+}
+
+// expandEnv replaces ${ENV_VAR} references with the variable's value.
+func expandEnv(value string) (string, error) {
+	var missing []string
+	expanded := envReference.ReplaceAllStringFunc(value, func(reference string) string {
+		name := envReference.FindStringSubmatch(reference)[1]
+		variable, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return variable
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("undefined environment variable %v", strings.Join(missing, ", "))
+	}
+	return expanded, nil
+}
+
+// writeConfigTOML writes the configuration in the configuration file format.
+// Fields tagged secret are redacted.
+func writeConfigTOML(w io.Writer, config Config) error {
+	return writeConfigSection(w, reflect.ValueOf(config), "")
+}
+
+func writeConfigSection(w io.Writer, value reflect.Value, section string) error {
+	if section != "" {
+		if _, err := fmt.Fprintf(w, "\n[%v]\n", section); err != nil {
+			return err
+		}
+	}
+
+	// Keys must precede nested sections, which would otherwise capture them
+	var sections []int
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if value.Field(i).Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Duration(0)) {
+			sections = append(sections, i)
+			continue
+		}
+		text := formatConfigValue(value.Field(i), field.Tag.Get("secret"))
+		if _, err := fmt.Fprintf(w, "%v = %v\n", field.Tag.Get("toml"), text); err != nil {
+			return err
+		}
+	}
+
+	for _, i := range sections {
+		name := value.Type().Field(i).Tag.Get("toml")
+		if section != "" {
+			name = section + "." + name
+		}
+		if err := writeConfigSection(w, value.Field(i), name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatConfigValue formats a field as a TOML value, applying the secret tag:
+// "true" hides the whole value and "url" keeps only the scheme and host.
+func formatConfigValue(field reflect.Value, secret string) string {
+	if duration, ok := field.Interface().(time.Duration); ok {
+		return strconv.Quote(duration.String())
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		return strconv.Quote(redactConfigValue(field.String(), secret))
+	case reflect.Slice:
+		items := make([]string, field.Len())
+		for i := range items {
+			items[i] = strconv.Quote(redactConfigValue(field.Index(i).String(), secret))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	default:
+		return fmt.Sprint(field.Interface())
+	}
+}
+
+func redactConfigValue(value, secret string) string {
+	if value == "" {
+		return value
+	}
+	switch secret {
+	case "true":
+		return "[redacted]"
+	case "url":
+		return parser.RedactURL(value)
+	}
+	return value
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/GeorgeIwu/go-parser"
+)
+
+// TestConfigRPCTimeouts reads the default and per-method RPC timeouts from a
+// configuration file, on top of which the flags apply.
+func TestConfigRPCTimeouts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "parser.toml")
+	file := "rpc_timeout = \"2s\"\nrpc_timeouts = [\"eth_getLogs=2m\", \"debug_traceBlockByNumber=90s\"]\n"
+	if err := os.WriteFile(path, []byte(file), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, _, err := loadConfig([]string{"--config", path}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"eth_getLogs=2m", "debug_traceBlockByNumber=90s"}; config.RPCTimeout != 2*time.Second || !slices.Equal(config.RPCTimeouts, want) {
+		t.Errorf("RPC timeouts = %v, %v, want 2s, %v", config.RPCTimeout, config.RPCTimeouts, want)
+	}
+
+	config, _, err = loadConfig([]string{"--config", path, "--rpc-timeouts", "eth_blockNumber=1s"}, nil)
+	if err != nil || !slices.Equal(config.RPCTimeouts, []string{"eth_blockNumber=1s"}) {
+		t.Errorf("RPC timeouts with --rpc-timeouts = %v, %v, want the flag's", config.RPCTimeouts, err)
+	}
+	if _, _, err := loadConfig([]string{"--config", path, "--rpc-timeouts", "eth_getLogs=soon"}, nil); err == nil {
+		t.Error("loadConfig of an invalid method timeout succeeded")
+	}
+}
+
+func TestConfigRollupPresets(t *testing.T) {
+	for name, want := range map[string]ChainConfig{
+		"optimism": {Name: "optimism", ID: 10, Stack: "op", ExpectedBlockTime: 2 * time.Second},
+		"arbitrum": {Name: "arbitrum", ID: 42161, Stack: "arbitrum", ExpectedBlockTime: 250 * time.Millisecond},
+	} {
+		config, _, err := loadConfig([]string{"--chain", name}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := config.Chain
+		if got.Name != want.Name || got.ID != want.ID || got.Stack != want.Stack || got.ExpectedBlockTime != want.ExpectedBlockTime {
+			t.Errorf("--chain %v = %+v, want %+v", name, got, want)
+		}
+		if config.PollInterval != parser.ChainPresets[name].PollInterval || config.Confirmations != 1 {
+			t.Errorf("--chain %v polls every %v with %d confirmations, want the preset's", name, config.PollInterval, config.Confirmations)
+		}
+	}
+}
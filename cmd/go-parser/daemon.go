@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/GeorgeIwu/go-parser"
+)
+
+// daemon runs the poller and the HTTP listeners without a prompt, for process
+// supervisors.
+type daemon struct {
+	parser      parser.Parser
+	servers     []*http.Server // Listeners already serving
+	serveErrors <-chan error   // Failures of the listeners
+	pidFile     string         // Path the process ID was written to, none when empty
+	logger      *slog.Logger
+}
+
+// run polls until a signal is received, a listener fails or the poller
+// stops, then shuts down: the listeners finish their requests first, so that
+// none sees a stopped poller, then the poller stops and the PID file, written
+// by the caller, is removed last. It returns the exit code.
+func (daemon *daemon) run(signals <-chan os.Signal) int {
+	ctx, stopPolling := context.WithCancel(context.Background())
+	var poller sync.WaitGroup
+	pollErrors := make(chan error, 1)
+	if watcher, ok := daemon.parser.(addressWatcher); ok {
+		poller.Add(1)
+		go func() {
+			defer poller.Done()
+			if err := watcher.Watch(ctx, nil); err != nil && ctx.Err() == nil {
+				pollErrors <- err
+			}
+		}()
+	}
+	stop := func() {
+		stopPolling()
+		poller.Wait()
+	}
+
+	daemon.logger.Info("daemon started", "pid", os.Getpid())
+	code := 0
+	select {
+	case sig := <-signals:
+		daemon.logger.Info("shutting down", "signal", sig)
+	case err := <-daemon.serveErrors:
+		daemon.logger.Error("listener failed, shutting down", "error", err)
+		code = exitRuntimeError
+	case err := <-pollErrors:
+		daemon.logger.Error("poller failed, shutting down", "error", err)
+		code = exitRuntimeError
+	}
+	daemon.shutdown(stop)
+	return code
+}
+
+// shutdown stops the listeners, then the poller with stop, then removes the
+// PID file.
+func (daemon *daemon) shutdown(stop func()) {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	for _, server := range daemon.servers {
+		if err := server.Shutdown(ctx); err != nil {
+			daemon.logger.Error("failed to stop listener", "addr", server.Addr, "error", err)
+		}
+	}
+	stop()
+	if daemon.pidFile != "" {
+		if err := os.Remove(daemon.pidFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+			daemon.logger.Error("failed to remove PID file", "path", daemon.pidFile, "error", err)
+		}
+	}
+	daemon.logger.Info("daemon stopped")
+}
+
+// writePIDFile writes the process ID to path, replacing it atomically so that
+// a supervisor never reads a partial file.
+func writePIDFile(path string) error {
+	temporary := path + ".tmp"
+	if err := os.WriteFile(temporary, []byte(strconv.Itoa(os.Getpid())+"\n"), 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(temporary, path); err != nil {
+		os.Remove(temporary)
+		return fmt.Errorf("failed to replace %v: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/GeorgeIwu/go-parser"
+	"github.com/GeorgeIwu/go-parser/parsertest"
+)
+
+// watchingParser is a MockParser whose poller runs until its context is
+// cancelled, or fails with err.
+type watchingParser struct {
+	*parsertest.MockParser
+	err    error
+	events *eventLog
+}
+
+func (p *watchingParser) Watch(ctx context.Context, out chan<- parser.Transaction) error {
+	if p.err != nil {
+		return p.err
+	}
+	<-ctx.Done()
+	p.events.add("poller stopped")
+	return ctx.Err()
+}
+
+func (p *watchingParser) WatchAddress(ctx context.Context, address string, out chan<- parser.Transaction) (func(), error) {
+	return func() {}, nil
+}
+
+// eventLog records the order of the shutdown steps.
+type eventLog struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (log *eventLog) add(event string) {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	log.events = append(log.events, event)
+}
+
+func (log *eventLog) list() []string {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	return slices.Clone(log.events)
+}
+
+// newTestDaemon returns a daemon polling with p and serving one listener
+// whose requests signal started then block until release is closed, with its
+// PID file written.
+func newTestDaemon(t *testing.T, p parser.Parser, events *eventLog, started chan<- struct{}, release <-chan struct{}) (*daemon, chan error, string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		events.add("request finished")
+	})}
+	serveErrors := make(chan error, 1)
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+
+	pidFile := filepath.Join(t.TempDir(), "go-parser.pid")
+	if err := writePIDFile(pidFile); err != nil {
+		t.Fatal(err)
+	}
+	return &daemon{
+		parser:      p,
+		servers:     []*http.Server{server},
+		serveErrors: serveErrors,
+		pidFile:     pidFile,
+		logger:      slog.New(slog.DiscardHandler),
+	}, serveErrors, "http://" + listener.Addr().String()
+}
+
+// TestDaemonSignal stops the daemon with a signal while a request is in
+// flight: the request finishes before the poller stops, the PID file is
+// removed and the exit code is 0.
+func TestDaemonSignal(t *testing.T) {
+	events := &eventLog{}
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	d, _, url := newTestDaemon(t, &watchingParser{MockParser: parsertest.NewMockParser(), events: events}, events, started, release)
+
+	requested := make(chan error, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err == nil {
+			resp.Body.Close()
+		}
+		requested <- err
+	}()
+	<-started
+
+	signals := make(chan os.Signal, 1)
+	signals <- syscall.SIGTERM
+	exited := make(chan int, 1)
+	go func() { exited <- d.run(signals) }()
+
+	time.Sleep(50 * time.Millisecond)
+	if got := events.list(); len(got) != 0 {
+		t.Fatalf("events before the request finished = %v, want none", got)
+	}
+	close(release)
+
+	select {
+	case code := <-exited:
+		if code != 0 {
+			t.Errorf("exit code = %d, want 0", code)
+		}
+	case <-time.After(shutdownTimeout):
+		t.Fatal("daemon did not stop after the signal")
+	}
+	if err := <-requested; err != nil {
+		t.Errorf("in-flight request failed: %v", err)
+	}
+	if got, want := events.list(), []string{"request finished", "poller stopped"}; !slices.Equal(got, want) {
+		t.Errorf("shutdown order = %v, want %v", got, want)
+	}
+	if _, err := os.Stat(d.pidFile); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("PID file after shutdown: %v, want it removed", err)
+	}
+}
+
+// TestDaemonFailures stops the daemon with a runtime error exit code when a
+// listener or the poller fails.
+func TestDaemonFailures(t *testing.T) {
+	release := make(chan struct{})
+	close(release)
+
+	t.Run("listener", func(t *testing.T) {
+		events := &eventLog{}
+		d, serveErrors, _ := newTestDaemon(t, &watchingParser{MockParser: parsertest.NewMockParser(), events: events}, events, nil, release)
+		serveErrors <- errors.New("address already in use")
+		if code := d.run(make(chan os.Signal)); code != exitRuntimeError {
+			t.Errorf("exit code = %d, want %d", code, exitRuntimeError)
+		}
+		if got := events.list(); !slices.Equal(got, []string{"poller stopped"}) {
+			t.Errorf("events = %v, want the poller stopped", got)
+		}
+		if _, err := os.Stat(d.pidFile); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("PID file after shutdown: %v, want it removed", err)
+		}
+	})
+
+	t.Run("poller", func(t *testing.T) {
+		events := &eventLog{}
+		d, _, _ := newTestDaemon(t, &watchingParser{MockParser: parsertest.NewMockParser(), err: errors.New("node unreachable"), events: events}, events, nil, release)
+		if code := d.run(make(chan os.Signal)); code != exitRuntimeError {
+			t.Errorf("exit code = %d, want %d", code, exitRuntimeError)
+		}
+		if _, err := os.Stat(d.pidFile); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("PID file after shutdown: %v, want it removed", err)
+		}
+	})
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/GeorgeIwu/go-parser"
+)
+
+// runDebug writes the debug dump to stderr as indented JSON, whatever the
+// output format, so that it does not end up in piped output.
+func runDebug(session *session, args []string) (interface{}, error) {
+	dumper, ok := session.parser.(parser.DebugDumper)
+	if !ok {
+		return nil, errors.New("parser does not support debug dumps")
+	}
+	data, err := json.MarshalIndent(dumper.DebugDump(session.ctx), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	_, err = session.stderr.Write(append(data, '\n'))
+	return nil, err
+}
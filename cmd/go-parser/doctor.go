@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/GeorgeIwu/go-parser"
+)
+
+// doctorResult is the result of the doctor command. It is encoded as the report.
+type doctorResult struct {
+	parser.DoctorReport
+}
+
+func (report doctorResult) printText(w io.Writer) {
+	printStatusField(w, "endpoint", report.Endpoint, false)
+	fmt.Fprintln(w)
+
+	table := newTable(true, "CAPABILITY", "METHOD", "SUPPORTED", "DETAIL")
+	for _, probe := range report.Probes {
+		supported := "no"
+		if probe.Supported {
+			supported = "yes"
+		}
+		table.addRow(probe.Name, probe.Method, supported, probe.Detail)
+	}
+	table.render(w)
+
+	if len(report.Warnings) > 0 {
+		fmt.Fprintln(w)
+	}
+	for _, warning := range report.Warnings {
+		printStatusField(w, "warning", warning, true)
+	}
+}
+
+// runDoctor probes the node of the selected chain.
+func runDoctor(session *session, args []string) (interface{}, error) {
+	prober, ok := session.parser.(parser.CapabilityProber)
+	if !ok {
+		return nil, errors.New("parser does not support probing the node")
+	}
+	ctx, cancel := session.interruptibleContext()
+	defer cancel()
+	return doctorResult{prober.Doctor(ctx)}, nil
+}
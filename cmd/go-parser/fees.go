@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/GeorgeIwu/go-parser"
+)
+
+// feeEstimateResult is the result of the estimateFees command. It is encoded as the estimate.
+type feeEstimateResult struct {
+	parser.FeeEstimate
+}
+
+func (estimate feeEstimateResult) printText(w io.Writer) {
+	if estimate.Legacy {
+		printField(w, "pricing", "legacy gas price")
+	} else {
+		printField(w, "base fee", estimate.BaseFee.String()+" wei")
+		printField(w, "priority fee", estimate.SuggestedPriorityFee.String()+" wei")
+	}
+	printField(w, "slow", estimate.Slow.String()+" wei")
+	printField(w, "standard", estimate.Standard.String()+" wei")
+	printField(w, "fast", estimate.Fast.String()+" wei")
+}
+
+// estimateFees estimates the fees with the parser, for the feeEstimate command.
+func estimateFees(ctx context.Context, p parser.Parser) (feeEstimateResult, error) {
+	estimator, ok := p.(parser.FeeEstimator)
+	if !ok {
+		return feeEstimateResult{}, errors.New("parser does not support estimating fees")
+	}
+	estimate, err := estimator.EstimateFees(ctx)
+	return feeEstimateResult{estimate}, err
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"github.com/GeorgeIwu/go-parser"
+)
+
+// applyKnownContracts reloads the token list of the configuration, keeping
+// the contracts in effect when it cannot be read.
+func applyKnownContracts(p parser.Parser, config Config) error {
+	namer, ok := p.(parser.KnownContractNamer)
+	if !ok {
+		return nil
+	}
+	contracts, err := parser.LoadKnownContracts(config.TokenList)
+	if err != nil {
+		return err
+	}
+	return namer.SetKnownContracts(contracts)
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/GeorgeIwu/go-parser"
+)
+
+// readAddressLabelFile reads the labels of a .json file, holding an object
+// from address to name or an array of {address, name}, or of a .csv file of
+// address,name rows with an optional header.
+func readAddressLabelFile(path string) ([]parser.AddressLabel, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		return readAddressLabelsJSON(file)
+	case ".csv":
+		return readAddressLabelsCSV(file)
+	default:
+		return nil, fmt.Errorf("unsupported label file format %q: use a .json or .csv file", ext)
+	}
+}
+
+func readAddressLabelsJSON(r io.Reader) ([]parser.AddressLabel, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+	var labels []parser.AddressLabel
+	if err := json.Unmarshal(raw, &labels); err == nil {
+		return labels, nil
+	}
+	var byAddress map[string]string
+	if err := json.Unmarshal(raw, &byAddress); err != nil {
+		return nil, errors.New("expected an object from address to name or an array of {address, name}")
+	}
+	for address, name := range byAddress {
+		labels = append(labels, parser.AddressLabel{Address: address, Name: name})
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Address < labels[j].Address })
+	return labels, nil
+}
+
+func readAddressLabelsCSV(r io.Reader) ([]parser.AddressLabel, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+	var labels []parser.AddressLabel
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return labels, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(labels) == 0 && strings.EqualFold(record[0], "address") {
+			continue
+		}
+		labels = append(labels, parser.AddressLabel{Address: record[0], Name: record[1]})
+	}
+}
+
+// importAddressLabels sets the labels listed in a file. Every label is
+// checked before any is set, so that a file with a mistake changes nothing.
+func importAddressLabels(p parser.Parser, path string) (*labelImportResult, error) {
+	book, ok := p.(parser.AddressBook)
+	if !ok {
+		return nil, errors.New("parser does not keep address labels")
+	}
+	labels, err := readAddressLabelFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read label file: %w", err)
+	}
+	for _, label := range labels {
+		if !parser.IsValidAddress(label.Address) {
+			return nil, fmt.Errorf("invalid address: %v", label.Address)
+		}
+		if utf8.RuneCountInString(strings.TrimSpace(label.Name)) > parser.MaxAddressLabelLength {
+			return nil, fmt.Errorf("label of %v longer than %d characters", label.Address, parser.MaxAddressLabelLength)
+		}
+	}
+	for _, label := range labels {
+		if err := book.SetAddressLabel(label.Address, label.Name); err != nil {
+			return nil, err
+		}
+	}
+	return &labelImportResult{Imported: len(labels)}, nil
+}
+
+// labelImportResult is the result of the importAddressLabels command.
+type labelImportResult struct {
+	Imported int `json:"imported"`
+}
+
+func (result *labelImportResult) printText(w io.Writer) {
+	fmt.Fprintf(w, "Imported %d label(s)\n", result.Imported)
+}
+
+// addressLabelList is the result of the listAddressLabels command.
+type addressLabelList struct {
+	*parser.AddressLabelList
+	full bool // Whether addresses are printed in full
+}
+
+// listAddressLabels returns the address book, ordered by address.
+func listAddressLabels(p parser.Parser, full bool) (*addressLabelList, error) {
+	list, err := parser.ListAddressLabels(p)
+	if err != nil {
+		return nil, err
+	}
+	return &addressLabelList{AddressLabelList: list, full: full}, nil
+}
+
+func (list *addressLabelList) printText(w io.Writer) {
+	if len(list.Labels) == 0 {
+		fmt.Fprintln(w, "No labels")
+		return
+	}
+	table := newTable(list.full, "ADDRESS", "NAME")
+	for _, label := range list.Labels {
+		table.addRow(label.Address, label.Name)
+	}
+	table.render(w)
+}
+
+// labeledAddress is the text form of an address in tables: its label when it
+// has one, followed by the address when full.
+func labeledAddress(address, label string, full bool) string {
+	switch {
+	case label == "":
+		return address
+	case full:
+		return label + " (" + address + ")"
+	}
+	return label
+}
@@ -0,0 +1,481 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/GeorgeIwu/go-parser"
+)
+
+const (
+	// historyFileName is the file in the home directory that keeps the prompt history between sessions.
+	historyFileName = ".go-parser_history"
+	// maxHistory is the number of entered lines kept in the history.
+	maxHistory = 1000
+)
+
+// Keys read by the line editor.
+const (
+	keyCtrlA     = 0x01
+	keyCtrlB     = 0x02
+	keyCtrlD     = 0x04
+	keyCtrlE     = 0x05
+	keyCtrlF     = 0x06
+	keyCtrlG     = 0x07
+	keyCtrlH     = 0x08
+	keyTab       = 0x09
+	keyCtrlK     = 0x0b
+	keyCtrlL     = 0x0c
+	keyCtrlR     = 0x12
+	keyCtrlU     = 0x15
+	keyCtrlW     = 0x17
+	keyEscape    = 0x1b
+	keyBackspace = 0x7f
+)
+
+// Keys sent as escape sequences, returned by readEscape.
+const (
+	keyUnknown = iota
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyHome
+	keyEnd
+	keyDelete
+)
+
+// lineReader reads the lines entered at the interactive prompt.
+type lineReader interface {
+	readLine(prompt string) (string, error)
+	close()
+}
+
+// newLineReader returns a line editor when in is a terminal, and otherwise, or
+// when the terminal cannot be configured, a reader of plain lines. subscribers
+// supplies addresses for tab completion.
+func newLineReader(in *os.File, out io.Writer, subscribers func() []string) lineReader {
+	if !isTerminal(in) {
+		return &scannerReader{scanner: bufio.NewScanner(in), out: out}
+	}
+
+	// Disable line buffering and echo so that keys are read as they are typed,
+	// while keeping signals so that Ctrl-C still interrupts
+	saved, err := stty(in, "-g")
+	if err != nil {
+		return &scannerReader{scanner: bufio.NewScanner(in), out: out}
+	}
+	if _, err := stty(in, "-icanon", "-echo", "min", "1", "time", "0"); err != nil {
+		stty(in, saved)
+		return &scannerReader{scanner: bufio.NewScanner(in), out: out}
+	}
+
+	editor := &lineEditor{
+		terminal:    in,
+		in:          bufio.NewReader(in),
+		out:         out,
+		saved:       saved,
+		subscribers: subscribers,
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		editor.historyFile = filepath.Join(home, historyFileName)
+		editor.loadHistory()
+	}
+	return editor
+}
+
+// isTerminal reports whether the file is a terminal rather than a pipe or a regular file.
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// stty runs stty on the terminal and returns its output.
+func stty(terminal *os.File, args ...string) (string, error) {
+	command := exec.Command("stty", args...)
+	command.Stdin = terminal
+	output, err := command.Output()
+	return strings.TrimSpace(string(output)), err
+}
+
+// scannerReader reads plain lines from input that is not a terminal, such as a pipe.
+type scannerReader struct {
+	scanner *bufio.Scanner
+	out     io.Writer
+}
+
+func (reader *scannerReader) readLine(prompt string) (string, error) {
+	fmt.Fprint(reader.out, prompt)
+	if !reader.scanner.Scan() {
+		if err := reader.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return reader.scanner.Text(), nil
+}
+
+func (reader *scannerReader) close() {}
+
+// lineEditor reads lines from a terminal with cursor movement, history,
+// reverse search and tab completion.
+type lineEditor struct {
+	terminal    *os.File
+	in          *bufio.Reader
+	out         io.Writer
+	saved       string // Terminal settings restored by close
+	history     []string
+	historyFile string
+	subscribers func() []string
+}
+
+// close restores the terminal settings.
+func (editor *lineEditor) close() {
+	stty(editor.terminal, editor.saved)
+}
+
+func (editor *lineEditor) readLine(prompt string) (string, error) {
+	var line []rune
+	cursor := 0
+	historyIndex := len(editor.history)
+	var pending []rune // Line being edited before browsing the history
+
+	editor.redraw(prompt, line, cursor)
+	for {
+		key, _, err := editor.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch key {
+		case '\r', '\n':
+			fmt.Fprint(editor.out, "\n")
+			text := string(line)
+			editor.addHistory(text)
+			return text, nil
+		case keyCtrlD:
+			if len(line) == 0 {
+				fmt.Fprint(editor.out, "\n")
+				return "", io.EOF
+			}
+			if cursor < len(line) {
+				line = append(line[:cursor], line[cursor+1:]...)
+			}
+		case keyCtrlA:
+			cursor = 0
+		case keyCtrlE:
+			cursor = len(line)
+		case keyCtrlB:
+			cursor = max(cursor-1, 0)
+		case keyCtrlF:
+			cursor = min(cursor+1, len(line))
+		case keyCtrlK:
+			line = line[:cursor]
+		case keyCtrlU:
+			line = append([]rune{}, line[cursor:]...)
+			cursor = 0
+		case keyCtrlW:
+			start := cursor
+			for start > 0 && line[start-1] == ' ' {
+				start--
+			}
+			for start > 0 && line[start-1] != ' ' {
+				start--
+			}
+			line = append(line[:start], line[cursor:]...)
+			cursor = start
+		case keyCtrlL:
+			fmt.Fprint(editor.out, clearScreen)
+		case keyCtrlR:
+			found, accepted, err := editor.search(line)
+			if err != nil {
+				return "", err
+			}
+			line, cursor = found, len(found)
+			if accepted {
+				editor.redraw(prompt, line, cursor)
+				fmt.Fprint(editor.out, "\n")
+				text := string(line)
+				editor.addHistory(text)
+				return text, nil
+			}
+		case keyTab:
+			line, cursor = editor.completeWord(line, cursor)
+		case keyBackspace, keyCtrlH:
+			if cursor > 0 {
+				line = append(line[:cursor-1], line[cursor:]...)
+				cursor--
+			}
+		case keyEscape:
+			switch editor.readEscape() {
+			case keyUp:
+				if historyIndex > 0 {
+					if historyIndex == len(editor.history) {
+						pending = line
+					}
+					historyIndex--
+					line = []rune(editor.history[historyIndex])
+					cursor = len(line)
+				}
+			case keyDown:
+				if historyIndex < len(editor.history) {
+					historyIndex++
+					if historyIndex == len(editor.history) {
+						line = pending
+					} else {
+						line = []rune(editor.history[historyIndex])
+					}
+					cursor = len(line)
+				}
+			case keyLeft:
+				cursor = max(cursor-1, 0)
+			case keyRight:
+				cursor = min(cursor+1, len(line))
+			case keyHome:
+				cursor = 0
+			case keyEnd:
+				cursor = len(line)
+			case keyDelete:
+				if cursor < len(line) {
+					line = append(line[:cursor], line[cursor+1:]...)
+				}
+			}
+		default:
+			if key >= ' ' {
+				line = append(line[:cursor], append([]rune{key}, line[cursor:]...)...)
+				cursor++
+			}
+		}
+		editor.redraw(prompt, line, cursor)
+	}
+}
+
+// redraw rewrites the current terminal line and places the cursor.
+func (editor *lineEditor) redraw(prompt string, line []rune, cursor int) {
+	fmt.Fprintf(editor.out, "\r%v%v\033[K", prompt, string(line))
+	if back := len(line) - cursor; back > 0 {
+		fmt.Fprintf(editor.out, "\033[%dD", back)
+	}
+}
+
+// readEscape reads the rest of an escape sequence such as an arrow key.
+func (editor *lineEditor) readEscape() int {
+	introducer, err := editor.in.ReadByte()
+	if err != nil || (introducer != '[' && introducer != 'O') {
+		return keyUnknown
+	}
+
+	var parameter []byte
+	for {
+		b, err := editor.in.ReadByte()
+		if err != nil {
+			return keyUnknown
+		}
+		if b >= '0' && b <= '9' || b == ';' {
+			parameter = append(parameter, b)
+			continue
+		}
+
+		switch {
+		case b == 'A':
+			return keyUp
+		case b == 'B':
+			return keyDown
+		case b == 'C':
+			return keyRight
+		case b == 'D':
+			return keyLeft
+		case b == 'H':
+			return keyHome
+		case b == 'F':
+			return keyEnd
+		case b == '~' && (string(parameter) == "1" || string(parameter) == "7"):
+			return keyHome
+		case b == '~' && (string(parameter) == "4" || string(parameter) == "8"):
+			return keyEnd
+		case b == '~' && string(parameter) == "3":
+			return keyDelete
+		}
+		return keyUnknown
+	}
+}
+
+// search runs a reverse incremental search of the history, returning the line
+// found and whether Enter accepted it. Any other key ends the search so that
+// the line can be edited, and Ctrl-G cancels it.
+func (editor *lineEditor) search(original []rune) ([]rune, bool, error) {
+	var query []rune
+	index := len(editor.history)
+	match := ""
+
+	find := func(from int) {
+		for i := min(from, len(editor.history)-1); i >= 0; i-- {
+			if strings.Contains(editor.history[i], string(query)) {
+				index, match = i, editor.history[i]
+				return
+			}
+		}
+	}
+
+	for {
+		fmt.Fprintf(editor.out, "\r(reverse-i-search)`%v': %v\033[K", string(query), match)
+		key, _, err := editor.in.ReadRune()
+		if err != nil {
+			return nil, false, err
+		}
+
+		switch {
+		case key == keyCtrlR:
+			find(index - 1)
+		case key == keyBackspace || key == keyCtrlH:
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+				find(len(editor.history) - 1)
+			}
+		case key == '\r' || key == '\n':
+			return []rune(match), true, nil
+		case key == keyCtrlG:
+			return original, false, nil
+		case key == keyEscape:
+			editor.readEscape()
+			return []rune(match), false, nil
+		case key >= ' ':
+			query = append(query, key)
+			find(index)
+		default:
+			return []rune(match), false, nil
+		}
+	}
+}
+
+// completeWord completes the word before the cursor with a command name when
+// it is the first word, and with a known address otherwise. Ambiguous
+// completions are extended to their common prefix and listed.
+func (editor *lineEditor) completeWord(line []rune, cursor int) ([]rune, int) {
+	start := cursor
+	for start > 0 && line[start-1] != ' ' {
+		start--
+	}
+	word := string(line[start:cursor])
+
+	var candidates []string
+	if strings.TrimSpace(string(line[:start])) == "" {
+		for _, command := range commandHelps(true) {
+			candidates = append(candidates, command.Name)
+		}
+	} else {
+		candidates = editor.knownAddresses()
+	}
+
+	var matches []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(strings.ToLower(candidate), strings.ToLower(word)) {
+			matches = append(matches, candidate)
+		}
+	}
+	if len(matches) == 0 {
+		fmt.Fprint(editor.out, "\a")
+		return line, cursor
+	}
+
+	completion := commonPrefix(matches)
+	if len(matches) == 1 {
+		completion += " "
+	} else if len(completion) <= len(word) {
+		fmt.Fprintf(editor.out, "\n%v\n", strings.Join(matches, "  "))
+		return line, cursor
+	}
+
+	completed := append(append(append([]rune{}, line[:start]...), []rune(completion)...), line[cursor:]...)
+	return completed, start + len([]rune(completion))
+}
+
+// knownAddresses returns the subscribed addresses and the addresses entered
+// before, sorted and without duplicates.
+func (editor *lineEditor) knownAddresses() []string {
+	seen := make(map[string]bool)
+	var addresses []string
+	add := func(address string) {
+		if parser.IsValidAddress(address) && !seen[strings.ToLower(address)] {
+			seen[strings.ToLower(address)] = true
+			addresses = append(addresses, address)
+		}
+	}
+
+	if editor.subscribers != nil {
+		for _, address := range editor.subscribers() {
+			add(address)
+		}
+	}
+	for _, line := range editor.history {
+		for _, word := range strings.Fields(line) {
+			add(word)
+		}
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+// commonPrefix returns the longest prefix shared by all values, compared case-insensitively.
+func commonPrefix(values []string) string {
+	prefix := values[0]
+	for _, value := range values[1:] {
+		n := 0
+		for n < len(prefix) && n < len(value) && strings.EqualFold(prefix[n:n+1], value[n:n+1]) {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	return prefix
+}
+
+// addHistory appends a line to the history and the history file, skipping
+// blank lines and repeats of the previous line.
+func (editor *lineEditor) addHistory(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	if len(editor.history) > 0 && editor.history[len(editor.history)-1] == line {
+		return
+	}
+
+	editor.history = append(editor.history, line)
+	if len(editor.history) > maxHistory {
+		editor.history = editor.history[len(editor.history)-maxHistory:]
+	}
+
+	if editor.historyFile == "" {
+		return
+	}
+	file, err := os.OpenFile(editor.historyFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	fmt.Fprintln(file, line)
+}
+
+// loadHistory reads the most recent lines of the history file.
+func (editor *lineEditor) loadHistory() {
+	file, err := os.Open(editor.historyFile)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.TrimSpace(line) != "" {
+			editor.history = append(editor.history, line)
+		}
+	}
+	if len(editor.history) > maxHistory {
+		editor.history = editor.history[len(editor.history)-maxHistory:]
+	}
+}
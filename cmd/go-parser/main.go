@@ -0,0 +1,384 @@
+// Command go-parser subscribes addresses and queries the chain from a prompt,
+// single commands or a daemon serving the HTTP API.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/GeorgeIwu/go-parser"
+)
+
+// Exit codes returned in single-command mode and on invalid configuration.
+const (
+	exitRuntimeError = 1
+	exitUsageError   = 2
+	exitInterrupted  = 130 // Ctrl-C, following the shell convention of 128 + SIGINT
+)
+
+// defaultEndpoint is the Ethereum node JSON-RPC endpoint.
+const defaultEndpoint = "https://cloudflare-eth.com"
+
+// shutdownTimeout bounds how long the HTTP servers may take to finish their requests on exit.
+const shutdownTimeout = 5 * time.Second
+
+// usageError reports a command that was used incorrectly rather than an operation that failed.
+type usageError struct {
+	message string
+}
+
+func (err *usageError) Error() string {
+	return err.message
+}
+
+func newUsageError(format string, args ...interface{}) error {
+	return &usageError{message: fmt.Sprintf(format, args...)}
+}
+
+// commandFormat removes a --json flag from a command's arguments, returning the
+// remaining arguments and the output format to use for the command.
+func commandFormat(args []string, format string) ([]string, string) {
+	args, asJSON := takeFlag(args, "json")
+	if asJSON {
+		format = formatJSON
+	}
+	return args, format
+}
+
+// runCommand executes a single command and prints its result, or its error, in the session's format.
+func runCommand(session *session, args []string) error {
+	args, format := commandFormat(args, session.format)
+	session.commandFormat = format
+	session.ctx = parser.ContextWithTraceID(context.Background(), parser.NewTraceID())
+	args, chainName, err := takeFlagValue(args, "chain")
+	if err == nil && chainName != "" {
+		err = session.selectChain(chainName)
+		defer session.selectChain("")
+	}
+	var result interface{}
+	if err == nil {
+		result, err = executeCommand(session, args)
+	}
+	if err == nil && result != nil {
+		err = session.print(result, format)
+	}
+	if err != nil {
+		session.printError(err, format)
+	}
+	return err
+}
+
+// exitCode returns the exit code reporting a command error.
+func exitCode(err error) int {
+	var usageErr *usageError
+	if errors.As(err, &usageErr) {
+		return exitUsageError
+	}
+	return exitRuntimeError
+}
+
+// runSingleCommand executes the command given on the command line and returns the exit code.
+func runSingleCommand(session *session, args []string) int {
+	err := runCommand(session, args)
+	if err == nil {
+		return 0
+	}
+	if exitCode(err) != exitUsageError {
+		return exitRuntimeError
+	}
+	if _, format := commandFormat(args, session.format); format == formatText {
+		fmt.Fprintf(session.stderr, "usage: %v [flags] <command> [arguments] [--json]\n\n", os.Args[0])
+		commandHelps(false).printText(session.stderr)
+	}
+	return exitUsageError
+}
+
+// runBatch executes the commands read from r, one per line, without prompting,
+// skipping blank lines and lines starting with #. It returns the exit code of
+// the first failed command, or 0 when every command succeeded, and stops at
+// that command when failFast is set.
+func runBatch(session *session, r io.Reader, failFast bool) int {
+	code := 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if err := runCommand(session, strings.Fields(line)); err != nil {
+			if code == 0 {
+				code = exitCode(err)
+			}
+			if failFast {
+				return code
+			}
+		}
+		if session.closed() {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(session.stderr, "error: failed to read standard input: %v\n", err)
+		return exitRuntimeError
+	}
+	return code
+}
+
+// prompt is printed when the interactive loop is ready for the next command.
+const prompt = "Enter command (e.g: getCurrentBlock): "
+
+// processCommands runs each command from cmdCh, signalling ready when the next
+// command can be read.
+func processCommands(session *session, cmdCh <-chan string, ready chan<- struct{}) {
+	for cmd := range cmdCh {
+		runCommand(session, strings.Fields(cmd))
+		if session.closed() {
+			return
+		}
+		ready <- struct{}{}
+	}
+}
+
+// readLines sends each line read from reader to lines, prompting for the next
+// one once ready is signalled, and closes lines at the end of the input.
+func readLines(reader lineReader, lines chan<- string, ready <-chan struct{}) {
+	defer close(lines)
+
+	for {
+		line, err := reader.readLine(prompt)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				fmt.Fprintf(os.Stderr, "Error reading standard input: %v\n", err)
+			}
+			return
+		}
+		lines <- line
+		<-ready
+	}
+}
+
+// subscribedAddresses returns the parser's subscribers for tab completion, or
+// nil when they cannot be listed.
+func subscribedAddresses(p parser.Parser) func() []string {
+	return func() []string {
+		lister, ok := p.(parser.SubscriberLister)
+		if !ok {
+			return nil
+		}
+		addresses, err := lister.Subscribers()
+		if err != nil {
+			return nil
+		}
+		return addresses
+	}
+}
+
+func main() {
+	config, args, err := parseConfig(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		os.Exit(exitUsageError)
+	}
+
+	if len(args) > 0 && args[0] == "config" {
+		os.Exit(runConfigCommand(config, args[1:]))
+	}
+	if config.Daemon && len(args) > 0 {
+		fmt.Fprintln(os.Stderr, "error: daemon mode does not run commands")
+		os.Exit(exitUsageError)
+	}
+
+	// Log to stderr so that stdout only holds command results
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(parser.LogLevels[config.LogLevel])
+	logger := parser.NewLogger(os.Stderr, logLevel, config.LogFormat)
+
+	// Create EthereumParser instance
+	p, err := newParser(config, logger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(exitRuntimeError)
+	}
+
+	// Refuse to run against a node of another chain, but do not fail on an
+	// unreachable node, which the commands report themselves
+	ctx, cancel := context.WithTimeout(context.Background(), parser.ChainCheckTimeout)
+	err = p.(parser.ChainChecker).CheckChainID(ctx)
+	cancel()
+	if errors.Is(err, parser.ErrChainMismatch) {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(exitRuntimeError)
+	} else if err != nil {
+		logger.Warn("Could not check the chain ID", "error", err)
+	}
+
+	// Under a supervisor, the PID file is written before the listeners start,
+	// and a write failure is fatal
+	if config.Daemon && config.PIDFile != "" {
+		if err := writePIDFile(config.PIDFile); err != nil {
+			fmt.Fprintf(os.Stderr, "error: failed to write PID file: %v\n", err)
+			os.Exit(exitRuntimeError)
+		}
+	}
+
+	// Listener failures are logged, and fatal in daemon mode
+	serveErrors := make(chan error, 2)
+
+	// Serve the HTTP API alongside the prompt when an address is configured
+	var server *http.Server
+	if config.Server.Addr != "" {
+		server = &http.Server{Addr: config.Server.Addr, Handler: parser.NewServer(p)}
+		go func() {
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Error("HTTP server stopped", "error", err)
+				serveErrors <- err
+			}
+		}()
+	}
+
+	// Reload the configuration on SIGHUP, applying the changes that are safe
+	// at runtime
+	reloader := newConfigReloader(config, os.Args[1:], p, logLevel, logger)
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	go func() {
+		for range hangups {
+			reloader.reload()
+		}
+	}()
+
+	// Serve the profiles and runtime metrics on their own listener, which is
+	// kept off the public API
+	var admin *http.Server
+	if config.Admin.Addr != "" {
+		admin = &http.Server{Addr: config.Admin.Addr, Handler: newAdminHandler(reloader, p)}
+		go func() {
+			if err := admin.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Error("admin server stopped", "error", err)
+				serveErrors <- err
+			}
+		}()
+	}
+
+	// Without a prompt, run the poller and the listeners until SIGTERM or
+	// SIGINT. Writes to a closed stdout or stderr must not kill the process
+	if config.Daemon {
+		signal.Ignore(syscall.SIGPIPE)
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+		daemon := &daemon{parser: p, serveErrors: serveErrors, pidFile: config.PIDFile, logger: logger}
+		for _, listener := range []*http.Server{server, admin} {
+			if listener != nil {
+				daemon.servers = append(daemon.servers, listener)
+			}
+		}
+		os.Exit(daemon.run(signals))
+	}
+
+	session := newSession(p, config.Format, os.Stdout, os.Stderr)
+	session.logLevel = logLevel
+
+	// Ctrl-C stops a command that handles interrupts, such as watch, and
+	// otherwise ends the program
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	exitOnInterrupt := func() {
+		for range interrupts {
+			if !session.interrupt() {
+				os.Exit(exitInterrupted)
+			}
+		}
+	}
+
+	// Run a single command non-interactively when one is given as arguments
+	if len(args) > 0 {
+		go exitOnInterrupt()
+		code := runSingleCommand(session, args)
+		session.stopPollers()
+		os.Exit(code)
+	}
+	session.interactive = true
+
+	// Run piped commands as a batch, without prompts and with an exit status
+	if !isTerminal(os.Stdin) {
+		session.compact = true
+		go exitOnInterrupt()
+		code := runBatch(session, os.Stdin, config.FailFast)
+		session.stopPollers()
+		os.Exit(code)
+	}
+
+	// Shut down gracefully on quit, at the end of the input or on Ctrl-C
+
+	// Create a channel to receive commands
+	cmdCh := make(chan string)
+
+	// Start a goroutine to continuously process commands
+	finished := make(chan struct{})
+	ready := make(chan struct{}, 1)
+	go func() {
+		processCommands(session, cmdCh, ready)
+		close(finished)
+	}()
+
+	// Read user input in the background so that quit and interrupts are noticed at
+	// any time, with line editing when stdin is a terminal
+	reader := newLineReader(os.Stdin, os.Stdout, subscribedAddresses(p))
+	defer reader.close()
+	lines := make(chan string)
+	go readLines(reader, lines, ready)
+
+	// Main loop to send user input to the command channel
+	for running := true; running; {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				// Let the last command finish at the end of the input
+				close(cmdCh)
+				<-finished
+				running = false
+				break
+			}
+			select {
+			case cmdCh <- line: // Send the command to the channel
+			case <-session.done:
+				running = false
+			case <-interrupts:
+				running = session.interrupt()
+			}
+		case <-session.done:
+			running = false
+		case <-interrupts:
+			running = session.interrupt()
+		}
+	}
+
+	fmt.Println("Shutting down")
+	session.stopPollers()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if server != nil {
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logger.Error("failed to stop HTTP server", "error", err)
+		}
+	}
+	if admin != nil {
+		if err := admin.Shutdown(shutdownCtx); err != nil {
+			logger.Error("failed to stop admin server", "error", err)
+		}
+	}
+}
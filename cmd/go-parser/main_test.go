@@ -0,0 +1,133 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GeorgeIwu/go-parser/parsertest"
+)
+
+// TestProcessCommands drives the command loop through its channel, reading
+// the output of each command once the loop is ready for the next one.
+func TestProcessCommands(t *testing.T) {
+	p := parsertest.NewMockParser()
+	p.CurrentBlock = 42
+	var stdout, stderr strings.Builder
+	session := newSession(p, formatText, &stdout, &stderr)
+	session.interactive = true
+
+	cmdCh := make(chan string)
+	ready := make(chan struct{}, 1)
+	finished := make(chan struct{})
+	go func() {
+		processCommands(session, cmdCh, ready)
+		close(finished)
+	}()
+	run := func(cmd string) {
+		t.Helper()
+		cmdCh <- cmd
+		select {
+		case <-ready:
+		case <-time.After(time.Second):
+			t.Fatalf("no ready signal after %q", cmd)
+		}
+	}
+
+	run("getCurrentBlock")
+	run("sub " + testAddress)
+	run("  getTransactions   " + testAddress + "  ")
+	run("frobnicate")
+	if want := "42\nSubscribed " + testAddress + "\nNo transactions\n"; stdout.String() != want {
+		t.Errorf("output = %q, want %q", stdout.String(), want)
+	}
+	if !strings.Contains(stderr.String(), "unknown command: frobnicate") {
+		t.Errorf("errors = %q, want the unknown command", stderr.String())
+	}
+	if calls := p.Calls("SubscribeAddress"); len(calls) != 1 {
+		t.Errorf("SubscribeAddress called %d times, want once", len(calls))
+	}
+
+	cmdCh <- "quit"
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("processCommands did not return on quit")
+	}
+	select {
+	case <-ready:
+		t.Error("ready signalled after quit")
+	default:
+	}
+}
+
+// TestProcessCommandsEndOfInput returns once the channel of commands is
+// closed.
+func TestProcessCommandsEndOfInput(t *testing.T) {
+	var stdout strings.Builder
+	p := parsertest.NewMockParser()
+	p.CurrentBlock = 7
+	session := newSession(p, formatJSON, &stdout, io.Discard)
+	cmdCh := make(chan string, 1)
+	ready := make(chan struct{}, 1)
+	cmdCh <- "getCurrentBlock"
+	close(cmdCh)
+
+	finished := make(chan struct{})
+	go func() {
+		processCommands(session, cmdCh, ready)
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("processCommands did not return at the end of the commands")
+	}
+	if stdout.String() != "{\n  \"blockNumber\": 7\n}\n" {
+		t.Errorf("output = %q, want the JSON block number", stdout.String())
+	}
+	if len(ready) != 1 {
+		t.Error("no ready signal after the last command")
+	}
+}
+
+// scriptedReader is a lineReader returning lines, then io.EOF.
+type scriptedReader struct {
+	lines   []string
+	prompts int
+}
+
+func (reader *scriptedReader) readLine(prompt string) (string, error) {
+	reader.prompts++
+	if len(reader.lines) == 0 {
+		return "", io.EOF
+	}
+	line := reader.lines[0]
+	reader.lines = reader.lines[1:]
+	return line, nil
+}
+
+func (reader *scriptedReader) close() {}
+
+// TestReadLines prompts for the next line only once the previous one was
+// processed, and closes the lines at the end of the input.
+func TestReadLines(t *testing.T) {
+	reader := &scriptedReader{lines: []string{"getCurrentBlock", "quit"}}
+	lines := make(chan string)
+	ready := make(chan struct{})
+	go readLines(reader, lines, ready)
+
+	for _, want := range []string{"getCurrentBlock", "quit"} {
+		if line := <-lines; line != want {
+			t.Errorf("line = %q, want %q", line, want)
+		}
+		ready <- struct{}{}
+	}
+	if _, ok := <-lines; ok {
+		t.Error("lines not closed at the end of the input")
+	}
+	if reader.prompts != 3 {
+		t.Errorf("prompted %d times, want 3", reader.prompts)
+	}
+}
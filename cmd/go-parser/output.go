@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"sync"
+
+	"github.com/GeorgeIwu/go-parser"
+)
+
+// Output formats of command results.
+const (
+	formatText = "text"
+	formatJSON = "json"
+	formatYAML = "yaml"
+)
+
+// outputFormats lists the accepted output formats.
+var outputFormats = map[string]bool{
+	formatText: true,
+	formatJSON: true,
+	formatYAML: true,
+}
+
+// textPrinter is implemented by command results with a human-readable form.
+type textPrinter interface {
+	printText(w io.Writer)
+}
+
+// session holds the state shared by the commands run against a parser.
+type session struct {
+	parser      parser.Parser
+	format      string
+	interactive bool           // Whether commands are read from the prompt
+	compact     bool           // Whether JSON results are printed on a single line
+	logLevel    *slog.LevelVar // Level of the logger, changed by set loglevel, or nil
+	stdout      io.Writer
+	stderr      io.Writer
+
+	commandFormat string          // Output format of the running command
+	ctx           context.Context // Context of the running command, carrying its trace ID
+
+	done      chan struct{} // Closed when the session is asked to end
+	closeOnce sync.Once
+	watchOnce sync.Once // Starts the background poller shared by watch commands
+
+	chains      parser.Parser      // Parser of every chain, of which session.parser may select one
+	pollers     sync.WaitGroup     // Background pollers started by watch commands
+	pollCtx     context.Context    // Context of the background pollers
+	stopPolling context.CancelFunc // Cancels pollCtx
+
+	mu            sync.Mutex
+	cancelCommand context.CancelFunc // Cancels the running command while it handles interrupts
+}
+
+// newSession initializes a session printing to stdout and stderr.
+func newSession(p parser.Parser, format string, stdout, stderr io.Writer) *session {
+	pollCtx, stopPolling := context.WithCancel(context.Background())
+	return &session{
+		parser:      p,
+		chains:      p,
+		format:      format,
+		stdout:      stdout,
+		stderr:      stderr,
+		ctx:         context.Background(),
+		done:        make(chan struct{}),
+		pollCtx:     pollCtx,
+		stopPolling: stopPolling,
+	}
+}
+
+// selectChain directs the commands to the parser of the named chain, or back
+// to the parser of every chain when name is empty.
+func (session *session) selectChain(name string) error {
+	if name == "" {
+		session.parser = session.chains
+		return nil
+	}
+	p, ok := parser.SelectChain(session.chains, name)
+	if !ok {
+		return newUsageError("unknown chain: %v", name)
+	}
+	session.parser = p
+	return nil
+}
+
+// stopPollers stops the background pollers and waits for them to return.
+func (session *session) stopPollers() {
+	session.stopPolling()
+	session.pollers.Wait()
+}
+
+// close asks the session to end.
+func (session *session) close() {
+	session.closeOnce.Do(func() {
+		close(session.done)
+	})
+}
+
+// closed reports whether the session was asked to end.
+func (session *session) closed() bool {
+	select {
+	case <-session.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// interruptibleContext returns a context for a long-running command that is
+// cancelled when the user interrupts it or the session ends. Interrupts only
+// end the command, rather than the program, until the returned function is called.
+func (session *session) interruptibleContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(session.ctx)
+	go func() {
+		select {
+		case <-session.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	session.mu.Lock()
+	session.cancelCommand = cancel
+	session.mu.Unlock()
+	return ctx, func() {
+		session.mu.Lock()
+		session.cancelCommand = nil
+		session.mu.Unlock()
+		cancel()
+	}
+}
+
+// interrupt cancels the running command that handles interrupts and reports
+// whether there was one.
+func (session *session) interrupt() bool {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	if session.cancelCommand == nil {
+		return false
+	}
+	session.cancelCommand()
+	return true
+}
+
+// print writes a command result to stdout in the session's format.
+func (session *session) print(result interface{}, format string) error {
+	if format == formatJSON {
+		encoder := json.NewEncoder(session.stdout)
+		if !session.compact {
+			encoder.SetIndent("", "  ")
+		}
+		return encoder.Encode(result)
+	}
+	if format == formatYAML {
+		// Batches print a stream of documents
+		if session.compact {
+			io.WriteString(session.stdout, "---\n")
+		}
+		return parser.WriteYAML(session.stdout, result)
+	}
+	if printer, ok := result.(textPrinter); ok {
+		printer.printText(session.stdout)
+		return nil
+	}
+	_, err := fmt.Fprintln(session.stdout, result)
+	return err
+}
+
+// printError writes a command error to stderr in the session's format.
+func (session *session) printError(err error, format string) {
+	response := parser.ErrorResponse{Error: err.Error(), TraceID: parser.TraceID(session.ctx)}
+	if format == formatJSON {
+		json.NewEncoder(session.stderr).Encode(response)
+		return
+	}
+	if format == formatYAML {
+		parser.WriteYAML(session.stderr, response)
+		return
+	}
+	fmt.Fprintf(session.stderr, "error: %v\n", err)
+}
+
+// printEvent writes a streamed transaction to stdout on a single line, or as
+// an item of a YAML sequence, and flushes it, so that the output can be piped
+// to other tools as it arrives.
+func (session *session) printEvent(transaction parser.Transaction, format string) {
+	if format == formatJSON {
+		json.NewEncoder(session.stdout).Encode(transaction)
+	} else if format == formatYAML {
+		parser.WriteYAML(session.stdout, []parser.YAMLTransaction{parser.NewYAMLTransaction(transaction)})
+	} else {
+		to := labeledAddress(transaction.To, transaction.ToLabel, true)
+		if parser.IsContractCreation(transaction) {
+			to = "contract creation"
+		}
+		value, err := parseHexBig(transaction.Value)
+		if err != nil {
+			value = new(big.Int)
+		}
+		chain := parser.ChainOf(session.parser)
+		if p, ok := parser.SelectChain(session.chains, transaction.Chain); ok {
+			chain = parser.ChainOf(p)
+		}
+		// Name the chain when events of several chains are interleaved
+		if _, ok := session.chains.(parser.ChainSelector); ok {
+			fmt.Fprintf(session.stdout, "%v ", transaction.Chain)
+		}
+		fmt.Fprintf(session.stdout, "%v %v %v -> %v %v %v\n", formatQuantity(transaction.BlockNumber), transaction.Hash, labeledAddress(transaction.From, transaction.FromLabel, true), to, parser.FormatEther(value), chain.Currency)
+	}
+
+	if flusher, ok := session.stdout.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+}
+
+// currentBlockResult is the result of the getCurrentBlock command.
+type currentBlockResult struct {
+	BlockNumber uint64 `json:"blockNumber"`
+}
+
+func (result currentBlockResult) printText(w io.Writer) {
+	fmt.Fprintln(w, result.BlockNumber)
+}
+
+// transactionList is the result of the getTransaction command. It is encoded as a JSON array.
+type transactionList struct {
+	transactions []parser.Transaction
+	full         bool   // Whether hashes and addresses are printed in full
+	currency     string // Symbol of the native currency the values are printed in
+}
+
+func (list transactionList) MarshalJSON() ([]byte, error) {
+	if list.transactions == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(list.transactions)
+}
+
+func (list transactionList) YAMLValue() interface{} {
+	return parser.NewYAMLTransactions(list.transactions)
+}
+
+func (list transactionList) printText(w io.Writer) {
+	if len(list.transactions) == 0 {
+		fmt.Fprintln(w, "No transactions")
+		return
+	}
+
+	currency := list.currency
+	if currency == "" {
+		currency = parser.DefaultCurrency
+	}
+	table := newTable(list.full, "HASH", "BLOCK", "FROM", "TO", "VALUE ("+currency+")")
+	table.alignRight(1, 4)
+	for _, transaction := range list.transactions {
+		to := labeledAddress(transaction.To, transaction.ToLabel, list.full)
+		if parser.IsContractCreation(transaction) {
+			to = "contract creation"
+		}
+		value, err := parseHexBig(transaction.Value)
+		if err != nil {
+			value = new(big.Int)
+		}
+		table.addRow(transaction.Hash, formatQuantity(transaction.BlockNumber), labeledAddress(transaction.From, transaction.FromLabel, list.full), to, parser.FormatEther(value))
+	}
+	table.render(w)
+}
+
+// subscribeResult is the result of the subscribeAddress command.
+type subscribeResult struct {
+	Subscribed        string `json:"subscribed"`
+	AlreadySubscribed bool   `json:"alreadySubscribed,omitempty"`
+}
+
+func (result subscribeResult) printText(w io.Writer) {
+	if result.AlreadySubscribed {
+		fmt.Fprintf(w, "Already subscribed %v\n", result.Subscribed)
+		return
+	}
+	fmt.Fprintf(w, "Subscribed %v\n", result.Subscribed)
+}
+
+func printField(w io.Writer, name string, value string) {
+	fmt.Fprintf(w, "%-20s %v\n", name+":", value)
+}
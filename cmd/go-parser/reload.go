@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GeorgeIwu/go-parser"
+)
+
+// ConfigChange is a setting that differs between the running configuration and
+// the reloaded one. Secrets are redacted as in config print.
+type ConfigChange struct {
+	Key    string `json:"key"` // Key in the configuration file, e.g. storage.backend
+	Old    string `json:"old"`
+	New    string `json:"new"`
+	Reason string `json:"reason,omitempty"` // Why a rejected change needs a restart
+	index  []int  // Index of the field in Config
+}
+
+// ReloadResult lists the changes a reload applied and those it rejected.
+type ReloadResult struct {
+	Applied  []ConfigChange `json:"applied"`
+	Rejected []ConfigChange `json:"rejected"`
+}
+
+// runtimeConfigKeys are the settings a reload applies to the running parser.
+// The others are only read at startup.
+var runtimeConfigKeys = map[string]bool{
+	"endpoint":         true,
+	"poll_interval":    true,
+	"confirmations":    true,
+	"activation_delay": true,
+	"log_level":        true,
+	"chains":           true, // Endpoints only, see restartReason
+	"admin.token":      true,
+	"alert_rules":      true,
+	"token_list":       true,
+}
+
+// configReloader re-reads the configuration from the sources it was parsed
+// from, and applies the changes that are safe at runtime.
+type configReloader struct {
+	args     []string // Command-line arguments, which still override the file and the environment
+	parser   parser.Parser
+	logLevel *slog.LevelVar
+	logger   *slog.Logger
+
+	mu     sync.Mutex
+	config Config // Running configuration, including the changes applied by reloads
+}
+
+func newConfigReloader(config Config, args []string, p parser.Parser, logLevel *slog.LevelVar, logger *slog.Logger) *configReloader {
+	return &configReloader{args: args, parser: p, logLevel: logLevel, logger: logger, config: config}
+}
+
+// reload re-reads the configuration and applies the changed settings that are
+// safe at runtime. Changes that need a restart are logged and left pending,
+// so that the next reload reports them again. An invalid configuration is
+// rejected as a whole.
+func (reloader *configReloader) reload() (ReloadResult, error) {
+	config, _, err := loadConfig(reloader.args, nil)
+	if err != nil {
+		reloader.logger.Error("failed to reload the configuration", "error", err)
+		return ReloadResult{}, err
+	}
+
+	reloader.mu.Lock()
+	defer reloader.mu.Unlock()
+
+	result := ReloadResult{Applied: []ConfigChange{}, Rejected: []ConfigChange{}}
+	running := reflect.ValueOf(&reloader.config).Elem()
+	for _, change := range diffConfig(reloader.config, config) {
+		if change.Reason = restartReason(change.Key, reloader.config, config); change.Reason != "" {
+			reloader.logger.Warn("configuration change needs a restart", "key", change.Key, "old", change.Old, "new", change.New, "reason", change.Reason)
+			result.Rejected = append(result.Rejected, change)
+			continue
+		}
+		reloader.logger.Info("configuration changed", "key", change.Key, "old", change.Old, "new", change.New)
+		running.FieldByIndex(change.index).Set(reflect.ValueOf(config).FieldByIndex(change.index))
+		result.Applied = append(result.Applied, change)
+	}
+
+	if len(result.Applied) > 0 {
+		reloader.logLevel.Set(parser.LogLevels[reloader.config.LogLevel])
+		applyRuntimeConfig(reloader.parser, reloader.config)
+	}
+	// Only a change replaces the rules set through the admin API
+	if slices.ContainsFunc(result.Applied, func(change ConfigChange) bool { return change.Key == "alert_rules" }) {
+		applyAlertRules(reloader.parser, reloader.config)
+	}
+	// The token list is re-read whether or not its path changed
+	if err := applyKnownContracts(reloader.parser, reloader.config); err != nil {
+		reloader.logger.Error("failed to reload the token list", "error", err)
+	}
+	reloader.logger.Info("configuration reloaded", "applied", len(result.Applied), "rejected", len(result.Rejected))
+	return result, nil
+}
+
+// adminToken returns the admin token of the running configuration.
+func (reloader *configReloader) adminToken() string {
+	reloader.mu.Lock()
+	defer reloader.mu.Unlock()
+	return reloader.config.Admin.Token
+}
+
+// restartReason explains why a changed setting cannot be applied at runtime,
+// or returns "" when it can.
+func restartReason(key string, old, new Config) string {
+	switch {
+	case key == "chains":
+		if !slices.Equal(chainNamesOf(old.Chains), chainNamesOf(new.Chains)) {
+			return "the chains are set up at startup, only their endpoints can change at runtime"
+		}
+		return ""
+	case runtimeConfigKeys[key]:
+		return ""
+	case strings.HasPrefix(key, "storage."):
+		return "the storage backend is opened at startup and holds the subscriptions"
+	case strings.HasPrefix(key, "chain."):
+		return "the node is checked against the chain at startup"
+	case key == "server.addr" || key == "admin.addr":
+		return "the listeners are bound at startup"
+	}
+	return "the setting is only read at startup"
+}
+
+// chainNamesOf returns the names of the chains configured as name=endpoint.
+func chainNamesOf(chains []string) []string {
+	names := make([]string, len(chains))
+	for i, item := range chains {
+		names[i], _, _ = strings.Cut(item, "=")
+	}
+	return names
+}
+
+// diffConfig returns the settings that differ between two configurations, in
+// the order of the configuration file.
+func diffConfig(old, new Config) []ConfigChange {
+	var changes []ConfigChange
+	diffConfigSection(reflect.ValueOf(old), reflect.ValueOf(new), "", nil, &changes)
+	return changes
+}
+
+func diffConfigSection(old, new reflect.Value, section string, index []int, changes *[]ConfigChange) {
+	for i := 0; i < old.NumField(); i++ {
+		field := old.Type().Field(i)
+		key := field.Tag.Get("toml")
+		if section != "" {
+			key = section + "." + key
+		}
+		fieldIndex := append(slices.Clone(index), i)
+		if old.Field(i).Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Duration(0)) {
+			diffConfigSection(old.Field(i), new.Field(i), key, fieldIndex, changes)
+			continue
+		}
+		if reflect.DeepEqual(old.Field(i).Interface(), new.Field(i).Interface()) {
+			continue
+		}
+		secret := field.Tag.Get("secret")
+		*changes = append(*changes, ConfigChange{
+			Key:   key,
+			Old:   formatConfigValue(old.Field(i), secret),
+			New:   formatConfigValue(new.Field(i), secret),
+			index: fieldIndex,
+		})
+	}
+}
+
+// applyRuntimeConfig applies the runtime settings of the configuration to the
+// parser's chains, as newParser set them up.
+func applyRuntimeConfig(p parser.Parser, config Config) {
+	switch p := p.(type) {
+	case *parser.MultiChainParser:
+		for i, item := range config.Chains {
+			name, endpoint, _ := strings.Cut(item, "=")
+			settings := parser.RuntimeSettings{Endpoint: endpoint, WatchInterval: config.PollInterval, Confirmations: config.Confirmations, ActivationDelay: config.ActivationDelay}
+			if chain, ok := parser.ChainPresets[name]; ok {
+				settings.WatchInterval, settings.Confirmations = chain.PollInterval, chain.Confirmations
+			}
+			p.Chains()[i].Reconfigure(settings)
+		}
+	case *parser.EthereumParser:
+		p.Reconfigure(parser.RuntimeSettings{Endpoint: config.Endpoint, WatchInterval: config.PollInterval, Confirmations: config.Confirmations, ActivationDelay: config.ActivationDelay})
+	}
+}
+
+// applyAlertRules replaces the alert rules of the parser with those of the
+// configuration, which was validated.
+func applyAlertRules(p parser.Parser, config Config) {
+	ruler, ok := p.(parser.AlertRuler)
+	if !ok {
+		return
+	}
+	if rules, err := parser.ParseAlertRules(config.AlertRules); err == nil {
+		ruler.SetAlertRules(rules)
+	}
+}
+
+// handleReload reloads the configuration and reports the applied and
+// rejected changes.
+func (reloader *configReloader) handleReload(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	result, err := reloader.reload()
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(parser.ErrorResponse{Error: err.Error()})
+		return
+	}
+	json.NewEncoder(w).Encode(result)
+}
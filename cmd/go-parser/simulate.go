@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/GeorgeIwu/go-parser"
+)
+
+// recordedBlocks is the result of the recordBlocks command.
+type recordedBlocks struct {
+	File   string `json:"file"`
+	Blocks int    `json:"blocks"`
+}
+
+func (result recordedBlocks) printText(w io.Writer) {
+	fmt.Fprintf(w, "recorded %d block(s) to %v\n", result.Blocks, result.File)
+}
+
+// runRecordBlocks captures a range of blocks into a simulation fixture.
+func runRecordBlocks(session *session, args []string) (interface{}, error) {
+	if len(args) != 3 {
+		return nil, newUsageError("you need to define the first and last blocks and a file")
+	}
+	from, fromErr := strconv.ParseUint(args[0], 10, 64)
+	to, toErr := strconv.ParseUint(args[1], 10, 64)
+	if fromErr != nil || toErr != nil || from > to {
+		return nil, newUsageError("invalid block range: %v to %v", args[0], args[1])
+	}
+	recorder, ok := session.parser.(parser.BlockRecorder)
+	if !ok {
+		return nil, errors.New("parser does not support recording blocks")
+	}
+
+	file, err := os.Create(args[2])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fixture: %w", err)
+	}
+	defer file.Close()
+	ctx, cancel := session.interruptibleContext()
+	defer cancel()
+	blocks, err := recorder.RecordBlocks(ctx, from, to, file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record blocks, %d recorded: %w", blocks, err)
+	}
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write fixture: %w", err)
+	}
+	return recordedBlocks{File: args[2], Blocks: blocks}, nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/GeorgeIwu/go-parser"
+	"github.com/GeorgeIwu/go-parser/parsertest"
+)
+
+// writeSimulation writes a fixture of blocks from to to, the last of which
+// holds the transaction, and returns its path.
+func writeSimulation(t *testing.T, from, to uint64, transaction parser.Transaction) string {
+	t.Helper()
+	var fixture strings.Builder
+	encoder := json.NewEncoder(&fixture)
+	for number := from; number <= to; number++ {
+		block := parsertest.NewBlock(number)
+		if number == to {
+			block.Transactions(transaction)
+		}
+		if err := encoder.Encode(block.Build()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(t.TempDir(), "blocks.ndjson")
+	if err := os.WriteFile(path, []byte(fixture.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestSimulateRecordBlocks records blocks replayed by --simulate with the
+// record-blocks command, and replays the recording.
+func TestSimulateRecordBlocks(t *testing.T) {
+	transaction := parsertest.NewTransaction().From(testAddress).Build()
+	config := defaultConfig()
+	config.Simulate = writeSimulation(t, 10, 12, transaction)
+	config.SimulateInterval = 0
+	p, err := newParser(config, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := newSession(p, formatText, io.Discard, io.Discard)
+
+	recording := filepath.Join(t.TempDir(), "recording.ndjson")
+	result, err := executeCommand(session, []string{"record-blocks", "10", "12", recording})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (recordedBlocks{File: recording, Blocks: 3}); result != want {
+		t.Errorf("record-blocks = %+v, want %+v", result, want)
+	}
+
+	data, err := os.ReadFile(recording)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), transaction.Hash) {
+		t.Errorf("recording misses the transaction %v of block 12", transaction.Hash)
+	}
+
+	config.Simulate = recording
+	replay, err := newParser(config, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block := replay.GetCurrentBlock(); block != 12 {
+		t.Errorf("GetCurrentBlock of the recording = %d, want 12", block)
+	}
+}
+
+func TestRunRecordBlocksErrors(t *testing.T) {
+	session := newSession(parsertest.NewMockParser(), formatText, io.Discard, io.Discard)
+	file := filepath.Join(t.TempDir(), "blocks.ndjson")
+	for _, args := range [][]string{
+		{"1", "2"},
+		{"one", "2", file},
+		{"1", "-2", file},
+		{"3", "2", file},
+	} {
+		if _, err := runRecordBlocks(session, args); !errors.As(err, new(*usageError)) {
+			t.Errorf("recordBlocks %q error = %v, want a usage error", args, err)
+		}
+	}
+	if _, err := runRecordBlocks(session, []string{"1", "2", file}); err == nil || !strings.Contains(err.Error(), "does not support recording") {
+		t.Errorf("recordBlocks with a MockParser error = %v, want unsupported", err)
+	}
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Errorf("recordBlocks with a MockParser created the fixture: %v", err)
+	}
+}
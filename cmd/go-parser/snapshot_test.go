@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/GeorgeIwu/go-parser/parsertest"
+)
+
+var updateSnapshots = flag.Bool("update-snapshots", false, "write the output of the snapshot tests to testdata/snapshots")
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	os.Exit(m.Run())
+}
+
+// AssertOutputSnapshot compares got to testdata/snapshots/<name>.txt, which
+// is written when missing or with --update-snapshots.
+func AssertOutputSnapshot(t *testing.T, name string, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", "snapshots", name+".txt")
+	want, err := os.ReadFile(path)
+	if os.IsNotExist(err) || *updateSnapshots {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("output differs from %v (rerun with -update-snapshots if intended):\n--- got\n%v--- want\n%v", path, got, want)
+	}
+}
+
+// TestOutputSnapshots prints the results of the commands in every format.
+func TestOutputSnapshots(t *testing.T) {
+	const recipient = "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359"
+	commands := []struct {
+		name string
+		args []string
+	}{
+		{"getCurrentBlock", []string{"getCurrentBlock"}},
+		{"subscribeAddress", []string{"subscribeAddress", testAddress}},
+		{"subscribeAddress-again", []string{"subscribeAddress", testAddress}},
+		{"listSubscribers", []string{"listSubscribers"}},
+		{"getTransaction", []string{"getTransaction", testAddress}},
+		{"getTransaction-full", []string{"getTransaction", testAddress, "--full"}},
+		{"getTransaction-none", []string{"getTransaction", recipient}},
+	}
+	for _, format := range []string{formatText, formatJSON, formatYAML} {
+		t.Run(format, func(t *testing.T) {
+			p := parsertest.NewMockParser()
+			p.CurrentBlock = 19531250
+			p.AddTransactions(
+				parsertest.NewTransaction().Hash("0x88df016429689c079f3b2f6ad39fa052532c56795b733da78a91ebe6a713944b").From(testAddress).To(recipient).Value(big.NewInt(1_500_000_000_000_000_000)).Block(19531249).Nonce(7).Build(),
+				parsertest.NewTransaction().Hash("0x3f4a2b1c0d9e8f7a6b5c4d3e2f1a0b9c8d7e6f5a4b3c2d1e0f9a8b7c6d5e4f3a").From(recipient).To(testAddress).Value(big.NewInt(42)).Block(19531250).Nonce(3).Build(),
+			)
+			var stdout bytes.Buffer
+			session := newSession(p, format, &stdout, io.Discard)
+			for _, command := range commands {
+				stdout.Reset()
+				if err := runCommand(session, command.args); err != nil {
+					t.Fatalf("%v: %v", strings.Join(command.args, " "), err)
+				}
+				AssertOutputSnapshot(t, command.name+"."+format, stdout.String())
+			}
+		})
+	}
+}
+
+// TestEventSnapshots prints a streamed transaction in every format.
+func TestEventSnapshots(t *testing.T) {
+	transaction := parsertest.NewTransaction().Hash("0x88df016429689c079f3b2f6ad39fa052532c56795b733da78a91ebe6a713944b").From(testAddress).Value(big.NewInt(1_000_000_000_000_000_000)).Block(19531250).Build()
+	for _, format := range []string{formatText, formatJSON, formatYAML} {
+		var stdout bytes.Buffer
+		newSession(parsertest.NewMockParser(), format, &stdout, io.Discard).printEvent(transaction, format)
+		AssertOutputSnapshot(t, "event."+format, stdout.String())
+	}
+}
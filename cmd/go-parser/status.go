@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/GeorgeIwu/go-parser"
+)
+
+// statusResult is the result of the status command. It is encoded as the status.
+type statusResult struct {
+	parser.Status
+}
+
+func (status statusResult) printText(w io.Writer) {
+	if status.ChainHeadError != "" {
+		printStatusField(w, "chain head", "unavailable ("+status.ChainHeadError+")", true)
+	} else {
+		printStatusField(w, "chain head", fmt.Sprint(status.ChainHead), false)
+	}
+	if status.Watching {
+		printStatusField(w, "last processed", fmt.Sprint(status.LastProcessedBlock), false)
+		if status.ChainHeadError == "" {
+			printStatusField(w, "lag", fmt.Sprintf("%d blocks", status.Lag), status.LaggingBehind())
+		}
+	} else {
+		printStatusField(w, "last processed", "not watching", false)
+	}
+	if status.Paused {
+		printStatusField(w, "watch", "paused, node is syncing", true)
+	}
+	printStatusField(w, "endpoint", status.Endpoint, false)
+	printStatusField(w, "subscribers", fmt.Sprint(status.Subscribers), false)
+	printStatusField(w, "queue depth", fmt.Sprint(status.QueueDepth), false)
+	printStatusField(w, "uptime", status.Uptime.String(), false)
+	switch {
+	case status.Fees == nil:
+	case status.Fees.Legacy:
+		printStatusField(w, "gas price", status.Fees.Standard.String()+" wei", false)
+	default:
+		printStatusField(w, "base fee", status.Fees.BaseFee.String()+" wei", false)
+		printStatusField(w, "standard max fee", status.Fees.Standard.String()+" wei", false)
+	}
+}
+
+// statusWithMetrics is the result of status --metrics.
+type statusWithMetrics struct {
+	parser.Status
+	Metrics parser.Metrics `json:"metrics"`
+}
+
+func (result statusWithMetrics) printText(w io.Writer) {
+	statusResult{result.Status}.printText(w)
+	fmt.Fprintln(w)
+	metricsResult{result.Metrics}.printText(w)
+}
+
+// getStatus reports the parser's health. In text form fields that indicate a problem are marked with "!".
+func getStatus(ctx context.Context, p parser.Parser) (statusResult, error) {
+	reporter, ok := p.(parser.StatusReporter)
+	if !ok {
+		return statusResult{}, errors.New("parser does not support reporting status")
+	}
+	return statusResult{reporter.Status(ctx)}, nil
+}
+
+func printStatusField(w io.Writer, name string, value string, problem bool) {
+	marker := " "
+	if problem {
+		marker = "!"
+	}
+	fmt.Fprintf(w, "%v %-20s %v\n", marker, name+":", value)
+}
+
+// metricsResult is the text form of the processing metrics.
+type metricsResult struct {
+	parser.Metrics
+}
+
+func (metrics metricsResult) printText(w io.Writer) {
+	printStatusField(w, "blocks", fmt.Sprintf("%d processed, %d retried", metrics.BlocksProcessed, metrics.BlocksRetried), false)
+	printStatusField(w, "transactions", fmt.Sprintf("%d scanned", metrics.TransactionsScanned), false)
+	var matches []string
+	for _, match := range []string{parser.MatchSent, parser.MatchReceived, parser.MatchInternal, parser.MatchContractCreation} {
+		matches = append(matches, fmt.Sprintf("%d %v", metrics.Matches[match], match))
+	}
+	printStatusField(w, "matches", strings.Join(matches, ", "), false)
+	printStatusField(w, "reorgs handled", fmt.Sprint(metrics.ReorgsHandled), false)
+	printStatusField(w, "index dropped", fmt.Sprintf("%d old transactions", metrics.DroppedTransactions), false)
+	printStatusField(w, "notifications", fmt.Sprintf("%d sent, %d failed, %d dropped", metrics.NotificationsSent, metrics.NotificationsFailed, metrics.NotificationsDropped), false)
+	if bloom := metrics.BloomFilter; bloom != nil {
+		printStatusField(w, "bloom filter", fmt.Sprintf("%d checks, %d possible hits, %.2f%% false positives", bloom.Checks, bloom.PossibleHits, 100*bloom.FalsePositiveRate), false)
+	}
+	if len(metrics.RPC) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	table := newTable(true, "METHOD", "CALLS", "ERRORS", "P50", "P90", "P99")
+	table.alignRight(1, 2, 3, 4, 5)
+	for _, method := range metrics.RPCMethods() {
+		rpc := metrics.RPC[method]
+		table.addRow(method, fmt.Sprint(rpc.Calls), fmt.Sprint(rpc.Errors), rpc.P50.String(), rpc.P90.String(), rpc.P99.String())
+	}
+	table.render(w)
+}
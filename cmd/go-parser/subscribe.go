@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/GeorgeIwu/go-parser"
+)
+
+// bulkSubscribeResult is the result of the subscribeFile command.
+type bulkSubscribeResult struct {
+	*parser.BulkSubscribeResult
+}
+
+func (result *bulkSubscribeResult) printText(w io.Writer) {
+	total := len(result.Subscribed) + len(result.AlreadySubscribed) + len(result.Failed)
+	var notes []string
+	if len(result.AlreadySubscribed) > 0 {
+		notes = append(notes, fmt.Sprintf("%d already subscribed", len(result.AlreadySubscribed)))
+	}
+	if len(result.Failed) > 0 {
+		reasons := make([]string, 0, len(result.Failed))
+		for _, failure := range result.Failed {
+			reasons = append(reasons, fmt.Sprintf("%v (%v)", failure.Address, failure.Reason))
+		}
+		notes = append(notes, fmt.Sprintf("%d failed: %v", len(result.Failed), strings.Join(reasons, ", ")))
+	}
+	if len(notes) == 0 {
+		fmt.Fprintf(w, "Subscribed %d/%d addresses\n", len(result.Subscribed), total)
+		return
+	}
+	fmt.Fprintf(w, "Subscribed %d/%d addresses (%v)\n", len(result.Subscribed), total, strings.Join(notes, "; "))
+}
+
+// readAddressFile reads one address per line, skipping blank lines and #-prefixed comments.
+func readAddressFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return readAddresses(file)
+}
+
+func readAddresses(r io.Reader) ([]string, error) {
+	var addresses []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		addresses = append(addresses, line)
+	}
+	return addresses, scanner.Err()
+}
+
+// subscribeFile subscribes every address listed in the file.
+func subscribeFile(p parser.Parser, path string) (*bulkSubscribeResult, error) {
+	addresses, err := readAddressFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read address file: %w", err)
+	}
+
+	return &bulkSubscribeResult{parser.SubscribeAll(p, addresses)}, nil
+}
+
+// maxListedSubscribers is the number of subscribers listSubscribers prints before truncating.
+const maxListedSubscribers = 100
+
+// subscriberList is the result of the listSubscribers command.
+type subscriberList struct {
+	*parser.SubscriberList
+	full bool // Whether addresses are printed in full
+}
+
+// listSubscribers returns the subscribed addresses containing filter, in ascending order.
+func listSubscribers(p parser.Parser, filter string, full bool) (*subscriberList, error) {
+	list, err := parser.ListSubscribers(p, filter)
+	if err != nil {
+		return nil, err
+	}
+	return &subscriberList{SubscriberList: list, full: full}, nil
+}
+
+func (list *subscriberList) printText(w io.Writer) {
+	table := newTable(list.full, "#", "ADDRESS")
+	table.alignRight(0)
+	for i, address := range list.Subscribers {
+		if i == maxListedSubscribers {
+			break
+		}
+		table.addRow(fmt.Sprint(i+1), address)
+	}
+	if len(list.Subscribers) > 0 {
+		table.render(w)
+	}
+	if len(list.Subscribers) > maxListedSubscribers {
+		fmt.Fprintf(w, "... %d more not shown\n", len(list.Subscribers)-maxListedSubscribers)
+	}
+	fmt.Fprintf(w, "%d subscriber(s)\n", list.Total)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/GeorgeIwu/go-parser"
+	"github.com/GeorgeIwu/go-parser/parsertest"
+)
+
+// TestSubscribeFile subscribes the 100 addresses of a file, among blank lines,
+// comments and an invalid address, one of them being already subscribed.
+func TestSubscribeFile(t *testing.T) {
+	var addresses []string
+	var file strings.Builder
+	file.WriteString("# Addresses to watch\n\n")
+	for i := range 100 {
+		address := parser.ToChecksumAddress(fmt.Sprintf("0x%040x", 0xabcdef*(i+1)))
+		addresses = append(addresses, address)
+		fmt.Fprintf(&file, "  %v  \n", address)
+		if i%10 == 0 {
+			file.WriteString("\n# Next batch\n")
+		}
+	}
+	file.WriteString("0x1234\n\n")
+	path := filepath.Join(t.TempDir(), "addresses.txt")
+	if err := os.WriteFile(path, []byte(file.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := parsertest.NewMockParser()
+	p.Store.SetSubscriber(parser.NormalizeAddress(addresses[0]))
+	result, err := subscribeFile(p, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Subscribed) != 99 || len(result.AlreadySubscribed) != 1 || len(result.Failed) != 1 {
+		t.Fatalf("subscribed %d, already %d and failed %d, want 99, 1 and 1", len(result.Subscribed), len(result.AlreadySubscribed), len(result.Failed))
+	}
+	if !slices.Equal(result.Subscribed, addresses[1:]) || result.AlreadySubscribed[0] != addresses[0] {
+		t.Errorf("Subscribed = %v, want the addresses of the file after the first", result.Subscribed)
+	}
+	if failure := result.Failed[0]; failure.Address != "0x1234" || failure.Reason != "invalid address" {
+		t.Errorf("Failed = %+v, want 0x1234 as an invalid address", failure)
+	}
+
+	var called []string
+	for _, call := range p.Calls("SubscribeAddress") {
+		called = append(called, call.Args[0].(string))
+	}
+	if !slices.Equal(called, addresses) {
+		t.Errorf("SubscribeAddress called with %d addresses, want the 100 valid ones in order", len(called))
+	}
+	for _, address := range addresses {
+		if !p.Store.IsSubscriber(parser.NormalizeAddress(address)) {
+			t.Errorf("%v is not subscribed", address)
+		}
+	}
+
+	var out strings.Builder
+	result.printText(&out)
+	if want := "Subscribed 99/101 addresses (1 already subscribed; 1 failed: 0x1234 (invalid address))\n"; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+
+	if _, err := subscribeFile(p, filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("subscribeFile of a missing file succeeded")
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// pipedTableWidth is the table width used when output is not a terminal.
+	pipedTableWidth = 160
+	// defaultTerminalWidth is the table width used when a terminal's width cannot be detected.
+	defaultTerminalWidth = 80
+	// minColumnWidth is the narrowest a column is shrunk to when the table does not fit.
+	minColumnWidth = 8
+	// columnGap is the number of spaces between columns.
+	columnGap = 2
+	// abbreviatedHexLength is the length above which hashes and addresses are abbreviated.
+	abbreviatedHexLength = 14
+)
+
+// table renders rows of text as aligned columns.
+type table struct {
+	headers    []string
+	rows       [][]string
+	rightAlign map[int]bool
+	full       bool // Whether hashes and addresses are printed in full rather than abbreviated
+}
+
+// newTable initializes a table with the given column headers.
+func newTable(full bool, headers ...string) *table {
+	return &table{
+		headers:    headers,
+		rightAlign: make(map[int]bool),
+		full:       full,
+	}
+}
+
+// alignRight right-aligns the given columns, e.g. for numbers.
+func (table *table) alignRight(columns ...int) {
+	for _, column := range columns {
+		table.rightAlign[column] = true
+	}
+}
+
+// addRow appends a row, abbreviating its hashes and addresses unless the table is full.
+func (table *table) addRow(cells ...string) {
+	row := make([]string, len(table.headers))
+	for i := range row {
+		if i < len(cells) {
+			row[i] = cells[i]
+		}
+		if !table.full && isHexValue(row[i]) && len(row[i]) > abbreviatedHexLength {
+			row[i] = row[i][:6] + "…" + row[i][len(row[i])-4:]
+		}
+	}
+	table.rows = append(table.rows, row)
+}
+
+// render writes the table to w, shrinking the widest columns to fit the width of w.
+func (table *table) render(w io.Writer) {
+	widths := make([]int, len(table.headers))
+	for _, row := range append([][]string{table.headers}, table.rows...) {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+
+	total := columnGap * (len(widths) - 1)
+	for _, width := range widths {
+		total += width
+	}
+	for limit := outputWidth(w); total > limit; total-- {
+		widest := 0
+		for i, width := range widths {
+			if width > widths[widest] {
+				widest = i
+			}
+		}
+		if widths[widest] <= minColumnWidth {
+			break
+		}
+		widths[widest]--
+	}
+
+	for _, row := range append([][]string{table.headers}, table.rows...) {
+		var line strings.Builder
+		for i, cell := range row {
+			cell = truncateCell(cell, widths[i])
+			padding := strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell))
+			if i > 0 {
+				line.WriteString(strings.Repeat(" ", columnGap))
+			}
+			if table.rightAlign[i] {
+				line.WriteString(padding + cell)
+			} else {
+				line.WriteString(cell + padding)
+			}
+		}
+		io.WriteString(w, strings.TrimRight(line.String(), " ")+"\n")
+	}
+}
+
+// truncateCell shortens a cell to width, keeping both ends of hex values.
+func truncateCell(cell string, width int) string {
+	runes := []rune(cell)
+	if len(runes) <= width {
+		return cell
+	}
+	if isHexValue(cell) {
+		head := (width - 1) / 2
+		return string(runes[:head]) + "…" + string(runes[len(runes)-(width-1-head):])
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+// isHexValue reports whether a cell holds a 0x-prefixed value such as a hash or an address.
+func isHexValue(cell string) bool {
+	return strings.HasPrefix(cell, "0x")
+}
+
+// outputWidth returns the width of the terminal w writes to, taken from COLUMNS when
+// set, or pipedTableWidth when w is not a terminal.
+func outputWidth(w io.Writer) int {
+	if file, ok := w.(*os.File); !ok || !isTerminal(file) {
+		return pipedTableWidth
+	}
+
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	return defaultTerminalWidth
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/GeorgeIwu/go-parser"
+)
+
+// parseHexBig parses an optional 0x-prefixed hex quantity, treating an empty
+// string or "0x" as zero.
+func parseHexBig(hexStr string) (*big.Int, error) {
+	if hexStr == "" || hexStr == "0x" {
+		return new(big.Int), nil
+	}
+	return parser.ParseHexBig(hexStr)
+}
+
+// formatQuantity formats a hex quantity as a decimal string, leaving invalid values as they are.
+func formatQuantity(hexStr string) string {
+	value, err := parseHexBig(hexStr)
+	if err != nil {
+		return hexStr
+	}
+	return value.String()
+}
+
+// transactionResult is the result of the getTransactionByHash command.
+type transactionResult struct {
+	Transaction *parser.TransactionDetails `json:"transaction"`
+	Receipt     *parser.TransactionReceipt `json:"receipt,omitempty"`
+	Fee         *big.Int                   `json:"fee,omitempty"` // In wei, once mined
+	Pending     bool                       `json:"pending"`       // Whether the transaction has not been mined yet
+	chain       parser.Chain               // Chain of the transaction, used in text output
+}
+
+func (result *transactionResult) printText(w io.Writer) {
+	printTransactionDetails(w, result.Transaction, result.chain)
+	if result.Receipt != nil {
+		printReceipt(w, result.Receipt)
+		if result.Fee != nil {
+			printField(w, "fee", parser.FormatEther(result.Fee)+" "+result.chain.Currency)
+		}
+	} else if result.Pending {
+		printField(w, "status", "pending")
+	}
+}
+
+// getTransactionByHash looks up a transaction and, if requested, its receipt.
+func getTransactionByHash(ctx context.Context, p parser.Parser, args []string) (*transactionResult, error) {
+	getter, ok := p.(parser.TransactionGetter)
+	if !ok {
+		return nil, errors.New("parser does not support looking up transactions")
+	}
+
+	hash := ""
+	withReceipt := false
+	for _, arg := range args {
+		switch {
+		case arg == "--receipt" || arg == "-receipt":
+			withReceipt = true
+		case strings.HasPrefix(arg, "-"):
+			return nil, newUsageError("unknown flag: %v", arg)
+		case hash == "":
+			hash = arg
+		default:
+			return nil, newUsageError("unexpected argument: %v", arg)
+		}
+	}
+	if hash == "" {
+		return nil, newUsageError("you need to define a transaction hash")
+	}
+	if !parser.IsValidHash(hash) {
+		return nil, newUsageError("invalid transaction hash: %v", hash)
+	}
+
+	transaction, err := getter.GetTransactionByHash(ctx, hash)
+	if errors.Is(err, parser.ErrTransactionNotFound) {
+		return nil, fmt.Errorf("transaction %v not found", hash)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+
+	result := &transactionResult{
+		Transaction: transaction,
+		Pending:     transaction.BlockNumber == "",
+		chain:       parser.ChainOf(p),
+	}
+	if !withReceipt || result.Pending {
+		return result, nil
+	}
+
+	receipt, err := getter.GetTransactionReceipt(ctx, hash)
+	if errors.Is(err, parser.ErrTransactionNotFound) {
+		result.Pending = true
+		return result, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get receipt: %w", err)
+	}
+	result.Receipt = receipt
+	if fee, err := parser.TransactionFee(transaction, receipt); err == nil {
+		result.Fee = fee
+	}
+	return result, nil
+}
+
+// printTransactionDetails prints the decoded fields of a transaction, with
+// values in the chain's currency.
+func printTransactionDetails(w io.Writer, transaction *parser.TransactionDetails, chain parser.Chain) {
+	printField(w, "hash", transaction.Hash)
+
+	txType, err := parseHexBig(transaction.Type)
+	if err == nil && txType.IsUint64() {
+		printField(w, "type", fmt.Sprintf("%v (%v)", txType, parser.TransactionTypeName(txType.Uint64())))
+	}
+
+	if transaction.BlockNumber == "" {
+		printField(w, "block", "pending")
+	} else {
+		printField(w, "block", fmt.Sprintf("%v (%v)", formatQuantity(transaction.BlockNumber), transaction.BlockHash))
+	}
+	printField(w, "from", transaction.From)
+	if chain.IsSystemTransaction(transaction.Transaction) {
+		printField(w, "system", "yes")
+	}
+	if transaction.To == "" {
+		printField(w, "to", "contract creation")
+	} else {
+		printField(w, "to", transaction.To)
+	}
+	printField(w, "nonce", formatQuantity(transaction.Nonce))
+
+	if value, err := parseHexBig(transaction.Value); err == nil {
+		printField(w, "value", parser.FormatEther(value)+" "+chain.Currency)
+	}
+	printField(w, "gas limit", formatQuantity(transaction.Gas))
+	if transaction.MaxFeePerGas != "" {
+		printField(w, "max fee per gas", formatQuantity(transaction.MaxFeePerGas)+" wei")
+		printField(w, "max priority fee", formatQuantity(transaction.MaxPriorityFeePerGas)+" wei")
+	} else {
+		printField(w, "gas price", formatQuantity(transaction.GasPrice)+" wei")
+	}
+
+	if method := parser.DecodeMethodCall(transaction.Input); method != "" {
+		printField(w, "method", method)
+	}
+	if link := chain.TransactionURL(transaction.Hash); link != "" {
+		printField(w, "explorer", link)
+	}
+}
+
+// printReceipt prints the outcome of a mined transaction.
+func printReceipt(w io.Writer, receipt *parser.TransactionReceipt) {
+	switch receipt.Status {
+	case "0x1":
+		printField(w, "status", "success")
+	case "0x0":
+		printField(w, "status", "failed")
+	default:
+		printField(w, "status", receipt.Status)
+	}
+	printField(w, "gas used", formatQuantity(receipt.GasUsed))
+	if receipt.GasUsedForL1 != "" {
+		printField(w, "gas used for L1", formatQuantity(receipt.GasUsedForL1))
+	}
+	if receipt.EffectiveGasPrice != "" {
+		printField(w, "effective gas price", formatQuantity(receipt.EffectiveGasPrice)+" wei")
+	}
+	if receipt.L1Fee != "" {
+		printField(w, "L1 fee", formatQuantity(receipt.L1Fee)+" wei")
+	}
+	if receipt.ContractAddress != "" {
+		printField(w, "contract address", receipt.ContractAddress)
+	}
+	printField(w, "logs", fmt.Sprint(len(receipt.Logs)))
+}
@@ -0,0 +1,229 @@
+package parser
+
+import (
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// TransactionCodec encodes transactions for publishing to message queues.
+type TransactionCodec interface {
+	Marshal(tx Transaction) ([]byte, error)
+	Unmarshal(data []byte, tx *Transaction) error
+}
+
+// JSONCodec encodes transactions as JSON objects, in the shape returned by the HTTP API.
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(tx Transaction) ([]byte, error) {
+	return json.Marshal(tx)
+}
+
+func (JSONCodec) Unmarshal(data []byte, tx *Transaction) error {
+	return json.Unmarshal(data, tx)
+}
+
+// CBOR major types used by CBORCodec (RFC 8949).
+const (
+	cborTextString = 3
+	cborMap        = 5
+)
+
+// CBORCodec encodes transactions as CBOR maps keyed by the JSON field names,
+// which is more compact than JSON for the same data.
+type CBORCodec struct{}
+
+// cborFields lists the fields of Transaction, all strings, in encoding order:
+// the key is the JSON field name, and fields that JSON omits when empty are
+// omitted as well.
+var cborFields = func() []cborField {
+	var fields []cborField
+	txType := reflect.TypeFor[Transaction]()
+	for i := range txType.NumField() {
+		field := txType.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if field.Type.Kind() != reflect.String || name == "-" {
+			panic("CBORCodec cannot encode Transaction field " + field.Name)
+		}
+		fields = append(fields, cborField{key: cmp.Or(name, field.Name), index: i, omitEmpty: options == "omitempty"})
+	}
+	return fields
+}()
+
+// cborField is a field of Transaction in the CBOR encoding.
+type cborField struct {
+	key       string
+	index     int
+	omitEmpty bool
+}
+
+func (CBORCodec) Marshal(tx Transaction) ([]byte, error) {
+	value := reflect.ValueOf(tx)
+	var pairs uint64
+	var body bytes.Buffer
+	for _, field := range cborFields {
+		text := value.Field(field.index).String()
+		if field.omitEmpty && text == "" {
+			continue
+		}
+		writeCBORHead(&body, cborTextString, uint64(len(field.key)))
+		body.WriteString(field.key)
+		writeCBORHead(&body, cborTextString, uint64(len(text)))
+		body.WriteString(text)
+		pairs++
+	}
+	var buffer bytes.Buffer
+	writeCBORHead(&buffer, cborMap, pairs)
+	buffer.Write(body.Bytes())
+	return buffer.Bytes(), nil
+}
+
+func (CBORCodec) Unmarshal(data []byte, tx *Transaction) error {
+	reader := bytes.NewReader(data)
+	pairs, err := readCBORHead(reader, cborMap)
+	if err != nil {
+		return err
+	}
+
+	*tx = Transaction{}
+	value := reflect.ValueOf(tx).Elem()
+	for i := uint64(0); i < pairs; i++ {
+		key, err := readCBORText(reader)
+		if err != nil {
+			return err
+		}
+		text, err := readCBORText(reader)
+		if err != nil {
+			return err
+		}
+		// Unknown keys are skipped so that fields can be added to the encoding
+		for _, field := range cborFields {
+			if field.key == key {
+				value.Field(field.index).SetString(text)
+			}
+		}
+	}
+	if reader.Len() != 0 {
+		return errors.New("trailing data after CBOR transaction")
+	}
+	return nil
+}
+
+// writeCBORHead writes the initial bytes of a data item of the given major type and length.
+func writeCBORHead(buffer *bytes.Buffer, major byte, length uint64) {
+	switch {
+	case length < 24:
+		buffer.WriteByte(major<<5 | byte(length))
+	case length <= 0xff:
+		buffer.WriteByte(major<<5 | 24)
+		buffer.WriteByte(byte(length))
+	case length <= 0xffff:
+		buffer.WriteByte(major<<5 | 25)
+		buffer.Write(binary.BigEndian.AppendUint16(nil, uint16(length)))
+	case length <= 0xffffffff:
+		buffer.WriteByte(major<<5 | 26)
+		buffer.Write(binary.BigEndian.AppendUint32(nil, uint32(length)))
+	default:
+		buffer.WriteByte(major<<5 | 27)
+		buffer.Write(binary.BigEndian.AppendUint64(nil, length))
+	}
+}
+
+// readCBORHead reads the initial bytes of a data item of the expected major type and returns its length.
+func readCBORHead(reader *bytes.Reader, major byte) (uint64, error) {
+	initial, err := reader.ReadByte()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read CBOR item: %w", err)
+	}
+	if initial>>5 != major {
+		return 0, fmt.Errorf("unexpected CBOR major type %d, expected %d", initial>>5, major)
+	}
+
+	info := initial & 0x1f
+	if info < 24 {
+		return uint64(info), nil
+	}
+	size := map[byte]int{24: 1, 25: 2, 26: 4, 27: 8}[info]
+	if size == 0 {
+		return 0, fmt.Errorf("unsupported CBOR length encoding %d", info)
+	}
+	lengthBytes := make([]byte, size)
+	if _, err := io.ReadFull(reader, lengthBytes); err != nil {
+		return 0, fmt.Errorf("failed to read CBOR length: %w", err)
+	}
+	var length uint64
+	for _, b := range lengthBytes {
+		length = length<<8 | uint64(b)
+	}
+	return length, nil
+}
+
+// readCBORText reads a definite-length CBOR text string.
+func readCBORText(reader *bytes.Reader) (string, error) {
+	length, err := readCBORHead(reader, cborTextString)
+	if err != nil {
+		return "", err
+	}
+	if length > uint64(reader.Len()) {
+		return "", errors.New("CBOR text string exceeds data")
+	}
+	text := make([]byte, length)
+	io.ReadFull(reader, text)
+	return string(text), nil
+}
+
+// NewCompressedCodec wraps a codec so that its encoded bytes are compressed
+// with algo: "gzip", or "none" to return the codec unchanged.
+func NewCompressedCodec(codec TransactionCodec, algo string) (TransactionCodec, error) {
+	switch algo {
+	case "none", "":
+		return codec, nil
+	case "gzip":
+		return gzipCodec{codec: codec}, nil
+	default:
+		return nil, fmt.Errorf("unsupported compression: %q", algo)
+	}
+}
+
+// gzipCodec compresses the output of another codec with gzip.
+type gzipCodec struct {
+	codec TransactionCodec
+}
+
+func (codec gzipCodec) Marshal(tx Transaction) ([]byte, error) {
+	data, err := codec.codec.Marshal(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func (codec gzipCodec) Unmarshal(data []byte, tx *Transaction) error {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to decompress transaction: %w", err)
+	}
+	defer reader.Close()
+
+	decompressed, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("failed to decompress transaction: %w", err)
+	}
+	return codec.codec.Unmarshal(decompressed, tx)
+}
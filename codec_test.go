@@ -0,0 +1,56 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestCodecRoundTrip encodes a transaction with every field set through each
+// codec, compressed or not, and decodes it back unchanged.
+func TestCodecRoundTrip(t *testing.T) {
+	var transaction Transaction
+	value := reflect.ValueOf(&transaction).Elem()
+	for i := range value.NumField() {
+		value.Field(i).SetString("value of " + value.Type().Field(i).Name)
+	}
+
+	for name, codec := range map[string]TransactionCodec{"JSON": JSONCodec{}, "CBOR": CBORCodec{}} {
+		for _, algo := range []string{"none", "gzip"} {
+			compressed, err := NewCompressedCodec(codec, algo)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range []Transaction{transaction, {Hash: "0x1", From: checksummedAddress}} {
+				data, err := compressed.Marshal(want)
+				if err != nil {
+					t.Fatalf("%v %v: Marshal error = %v", name, algo, err)
+				}
+				var got Transaction
+				if err := compressed.Unmarshal(data, &got); err != nil {
+					t.Fatalf("%v %v: Unmarshal error = %v", name, algo, err)
+				}
+				if got != want {
+					t.Errorf("%v %v round trip = %+v, want %+v", name, algo, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestCBORCodecUnmarshalErrors(t *testing.T) {
+	data, err := CBORCodec{}.Marshal(Transaction{Hash: "0x1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string][]byte{
+		"empty":     nil,
+		"not a map": {0x61, 'a'},
+		"truncated": data[:len(data)-1],
+		"trailing":  append(append([]byte{}, data...), 0x00),
+	} {
+		var transaction Transaction
+		if err := (CBORCodec{}).Unmarshal(data, &transaction); err == nil {
+			t.Errorf("Unmarshal of %v CBOR succeeded", name)
+		}
+	}
+}
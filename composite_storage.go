@@ -0,0 +1,230 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// storageOp is a change to the subscribers that a replica of a
+// CompositeStorage failed to apply.
+type storageOp struct {
+	replica int    // Index of the replica in CompositeStorage.replicas
+	action  string // subscriberAdded or subscriberRemoved
+	address string
+	err     error
+}
+
+// compositeReplica applies the changes of a CompositeStorage to a replica in
+// the order they were made to the primary.
+type compositeReplica struct {
+	store    Store
+	mu       sync.Mutex
+	queue    []storageOp
+	draining bool // Whether a goroutine applies the queue
+}
+
+// CompositeStorage keeps the subscribers in a primary store, and copies every
+// change to replicas, such as a durable store behind a MemoryStorage. Reads
+// are served by the primary. Changes apply to the primary first and, once it
+// accepted them, to the replicas in the background. Changes a replica failed
+// to apply are kept for FlushFailedWrites.
+type CompositeStorage struct {
+	primary  Store
+	replicas []*compositeReplica
+	wg       sync.WaitGroup // Goroutines applying changes to the replicas
+
+	mu           sync.Mutex
+	failedWrites []storageOp
+}
+
+// NewCompositeStorage returns a store over primary copying its changes to replicas.
+func NewCompositeStorage(primary Store, replicas ...Store) *CompositeStorage {
+	composite := &CompositeStorage{primary: primary}
+	for _, replica := range replicas {
+		composite.replicas = append(composite.replicas, &compositeReplica{store: replica})
+	}
+	return composite
+}
+
+func (composite *CompositeStorage) GetSubscribers() (map[string]bool, error) {
+	return composite.primary.GetSubscribers()
+}
+
+func (composite *CompositeStorage) SetSubscriber(address string) (existed bool, err error) {
+	existed, err = composite.primary.SetSubscriber(address)
+	if err != nil {
+		return existed, err
+	}
+	composite.replicate(subscriberAdded, address)
+	return existed, nil
+}
+
+func (composite *CompositeStorage) RemoveSubscriber(address string) error {
+	if err := composite.primary.RemoveSubscriber(address); err != nil {
+		return err
+	}
+	composite.replicate(subscriberRemoved, address)
+	return nil
+}
+
+func (composite *CompositeStorage) IsSubscriber(address string) bool {
+	return composite.primary.IsSubscriber(address)
+}
+
+func (composite *CompositeStorage) AtCapacity() bool {
+	limiter, ok := composite.primary.(capacityLimiter)
+	return ok && limiter.AtCapacity()
+}
+
+// BeginTransaction starts a transaction on the primary, whose changes are
+// copied to the replicas once committed.
+func (composite *CompositeStorage) BeginTransaction() StorageTransaction {
+	return &compositeTransaction{tx: composite.primary.BeginTransaction(), composite: composite}
+}
+
+// replicate queues a change the primary accepted on every replica.
+func (composite *CompositeStorage) replicate(action, address string) {
+	for i, replica := range composite.replicas {
+		replica.mu.Lock()
+		replica.queue = append(replica.queue, storageOp{replica: i, action: action, address: address})
+		if !replica.draining {
+			replica.draining = true
+			composite.wg.Add(1)
+			go composite.drain(replica)
+		}
+		replica.mu.Unlock()
+	}
+}
+
+// drain applies the changes queued on a replica until the queue is empty.
+func (composite *CompositeStorage) drain(replica *compositeReplica) {
+	defer composite.wg.Done()
+	for {
+		replica.mu.Lock()
+		if len(replica.queue) == 0 {
+			replica.draining = false
+			replica.mu.Unlock()
+			return
+		}
+		op := replica.queue[0]
+		replica.queue = replica.queue[1:]
+		replica.mu.Unlock()
+
+		if op.err = applyStorageOp(replica.store, op.action, op.address); op.err != nil {
+			composite.mu.Lock()
+			composite.failedWrites = append(composite.failedWrites, op)
+			composite.mu.Unlock()
+		}
+	}
+}
+
+// applyStorageOp makes a change to the subscribers of store.
+func applyStorageOp(store Store, action, address string) error {
+	if action == subscriberRemoved {
+		return store.RemoveSubscriber(address)
+	}
+	_, err := store.SetSubscriber(address)
+	return err
+}
+
+// FlushFailedWrites waits for the changes being copied to the replicas, then
+// retries those that failed. A retry brings the address on the replica to its
+// current state on the primary, so that a change that failed is not applied
+// over a later one. It returns the errors of the changes that failed again,
+// which are kept for the next flush.
+func (composite *CompositeStorage) FlushFailedWrites(ctx context.Context) error {
+	copied := make(chan struct{})
+	go func() {
+		composite.wg.Wait()
+		close(copied)
+	}()
+	select {
+	case <-copied:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	composite.mu.Lock()
+	ops := composite.failedWrites
+	composite.failedWrites = nil
+	composite.mu.Unlock()
+
+	type replicaAddress struct {
+		replica int
+		address string
+	}
+	retried := make(map[replicaAddress]bool, len(ops))
+	var failed []storageOp
+	var errs []error
+	for i, op := range ops {
+		if err := ctx.Err(); err != nil {
+			failed = append(failed, ops[i:]...)
+			errs = append(errs, err)
+			break
+		}
+		// One retry brings the address up to date on the replica
+		if retried[replicaAddress{op.replica, op.address}] {
+			continue
+		}
+		retried[replicaAddress{op.replica, op.address}] = true
+		op.action = subscriberRemoved
+		if composite.primary.IsSubscriber(op.address) {
+			op.action = subscriberAdded
+		}
+		if op.err = applyStorageOp(composite.replicas[op.replica].store, op.action, op.address); op.err != nil {
+			failed = append(failed, op)
+			errs = append(errs, fmt.Errorf("replica %d: failed to %v %v: %w", op.replica, op.action, op.address, op.err))
+		}
+	}
+
+	composite.mu.Lock()
+	composite.failedWrites = append(failed, composite.failedWrites...)
+	composite.mu.Unlock()
+	return errors.Join(errs...)
+}
+
+// compositeTransaction is a transaction on a CompositeStorage, which records
+// the changes staged on the primary to copy them on Commit.
+type compositeTransaction struct {
+	tx        StorageTransaction
+	composite *CompositeStorage
+	changes   []storageOp
+}
+
+func (tx *compositeTransaction) SetSubscriber(address string) error {
+	if err := tx.tx.SetSubscriber(address); err != nil {
+		return err
+	}
+	tx.changes = append(tx.changes, storageOp{action: subscriberAdded, address: address})
+	return nil
+}
+
+func (tx *compositeTransaction) RemoveSubscriber(address string) error {
+	if err := tx.tx.RemoveSubscriber(address); err != nil {
+		return err
+	}
+	tx.changes = append(tx.changes, storageOp{action: subscriberRemoved, address: address})
+	return nil
+}
+
+func (tx *compositeTransaction) IsSubscriber(address string) bool {
+	return tx.tx.IsSubscriber(address)
+}
+
+func (tx *compositeTransaction) Commit() error {
+	if err := tx.tx.Commit(); err != nil {
+		return err
+	}
+	for _, change := range tx.changes {
+		tx.composite.replicate(change.action, change.address)
+	}
+	tx.changes = nil
+	return nil
+}
+
+func (tx *compositeTransaction) Rollback() error {
+	tx.changes = nil
+	return tx.tx.Rollback()
+}
@@ -0,0 +1,99 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// failingStore is a MemoryStorage whose changes fail with err while it is set.
+type failingStore struct {
+	*MemoryStorage
+	mu  sync.Mutex
+	err error
+}
+
+func (store *failingStore) fail(err error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.err = err
+}
+
+func (store *failingStore) failure() error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return store.err
+}
+
+func (store *failingStore) SetSubscriber(address string) (bool, error) {
+	if err := store.failure(); err != nil {
+		return false, err
+	}
+	return store.MemoryStorage.SetSubscriber(address)
+}
+
+func (store *failingStore) RemoveSubscriber(address string) error {
+	if err := store.failure(); err != nil {
+		return err
+	}
+	return store.MemoryStorage.RemoveSubscriber(address)
+}
+
+// TestCompositeStorageFailingReplica keeps the changes a replica failed to
+// apply, while the primary and the other replicas accept them, until
+// FlushFailedWrites retries them once the replica recovers.
+func TestCompositeStorageFailingReplica(t *testing.T) {
+	primary, healthy := NewMemoryStorage(), NewMemoryStorage()
+	failing := &failingStore{MemoryStorage: NewMemoryStorage()}
+	failing.fail(errors.New("disk full"))
+	composite := NewCompositeStorage(primary, failing, healthy)
+	ctx := context.Background()
+
+	if existed, err := composite.SetSubscriber(checksummedAddress); existed || err != nil {
+		t.Fatalf("SetSubscriber = %v, %v, want false, nil despite the failing replica", existed, err)
+	}
+	composite.SetSubscriber(otherAddress)
+	if err := composite.RemoveSubscriber(otherAddress); err != nil {
+		t.Fatalf("RemoveSubscriber: %v", err)
+	}
+	if !composite.IsSubscriber(checksummedAddress) || composite.IsSubscriber(otherAddress) {
+		t.Error("primary does not hold the changes")
+	}
+
+	// The failures are recorded once the changes are copied
+	if err := composite.FlushFailedWrites(ctx); err == nil {
+		t.Error("FlushFailedWrites with the replica still failing succeeded")
+	}
+	composite.mu.Lock()
+	failed := composite.failedWrites
+	composite.mu.Unlock()
+	if len(failed) != 2 || failed[0].replica != 0 || failed[0].address != checksummedAddress || failed[1].address != otherAddress {
+		t.Fatalf("failedWrites = %+v, want one retry of each address on replica 0", failed)
+	}
+	if !healthy.IsSubscriber(checksummedAddress) || healthy.IsSubscriber(otherAddress) {
+		t.Error("healthy replica does not hold the changes")
+	}
+
+	failing.fail(nil)
+	if err := composite.FlushFailedWrites(ctx); err != nil {
+		t.Fatalf("FlushFailedWrites after recovery: %v", err)
+	}
+	composite.mu.Lock()
+	failed = composite.failedWrites
+	composite.mu.Unlock()
+	if len(failed) != 0 {
+		t.Errorf("failedWrites after a successful flush = %+v, want none", failed)
+	}
+	if !failing.IsSubscriber(checksummedAddress) || failing.IsSubscriber(otherAddress) {
+		t.Error("recovered replica does not hold the current subscribers")
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	failing.fail(errors.New("disk full"))
+	composite.SetSubscriber(otherAddress)
+	if err := composite.FlushFailedWrites(canceled); !errors.Is(err, context.Canceled) {
+		t.Errorf("FlushFailedWrites with a cancelled context = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,290 @@
+package parser
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ABIArgument describes a parameter of a function or event in an ABI JSON file.
+type ABIArgument struct {
+	Name       string        `json:"name"`
+	Type       string        `json:"type"`
+	Indexed    bool          `json:"indexed"`              // Events only
+	Components []ABIArgument `json:"components,omitempty"` // Fields of a tuple
+}
+
+// canonicalType returns the type as written in signatures, with the fields of
+// tuples spelled out.
+func (argument ABIArgument) canonicalType() string {
+	suffix, found := strings.CutPrefix(argument.Type, "tuple")
+	if !found {
+		return argument.Type
+	}
+	types := make([]string, len(argument.Components))
+	for i, component := range argument.Components {
+		types[i] = component.canonicalType()
+	}
+	return "(" + strings.Join(types, ",") + ")" + suffix
+}
+
+// ABIFunction describes a contract function.
+type ABIFunction struct {
+	Name            string
+	Inputs          []ABIArgument
+	Outputs         []ABIArgument
+	StateMutability string
+}
+
+// Signature returns the function's canonical signature, e.g. transfer(address,uint256).
+func (function ABIFunction) Signature() string {
+	types := make([]string, len(function.Inputs))
+	for i, input := range function.Inputs {
+		types[i] = input.canonicalType()
+	}
+	return function.Name + "(" + strings.Join(types, ",") + ")"
+}
+
+// Selector returns the first 4 bytes of the hash of the function's signature,
+// which prefix the input of its calls, e.g. 0xa9059cbb.
+func (function ABIFunction) Selector() string {
+	return Keccak256Hex(function.Signature())[:10]
+}
+
+// ContractABI holds the functions and events of a contract's ABI.
+type ContractABI struct {
+	Functions map[string]ABIFunction // Keyed by selector
+	Events    map[string]EventSchema // Keyed by name, the first of overloaded events
+}
+
+// DecodedCall is the function called by a transaction's input and its
+// arguments, keyed by name. Values are decoded as by DecodeLog, and arrays
+// are []interface{}.
+type DecodedCall struct {
+	FunctionName string
+	Params       map[string]interface{}
+}
+
+// LoadABI parses a standard Ethereum ABI JSON file: an array of function,
+// event, constructor, fallback and error descriptors, of which the functions
+// and events are kept.
+func LoadABI(r io.Reader) (*ContractABI, error) {
+	var descriptors []struct {
+		Type            string        `json:"type"`
+		Name            string        `json:"name"`
+		Inputs          []ABIArgument `json:"inputs"`
+		Outputs         []ABIArgument `json:"outputs"`
+		StateMutability string        `json:"stateMutability"`
+		Anonymous       bool          `json:"anonymous"`
+	}
+	if err := json.NewDecoder(r).Decode(&descriptors); err != nil {
+		return nil, fmt.Errorf("failed to parse ABI: %w", err)
+	}
+
+	contract := &ContractABI{Functions: make(map[string]ABIFunction), Events: make(map[string]EventSchema)}
+	for _, descriptor := range descriptors {
+		switch descriptor.Type {
+		case "function", "": // The type defaults to function
+			function := ABIFunction{
+				Name:            descriptor.Name,
+				Inputs:          descriptor.Inputs,
+				Outputs:         descriptor.Outputs,
+				StateMutability: descriptor.StateMutability,
+			}
+			contract.Functions[function.Selector()] = function
+		case "event":
+			if _, ok := contract.Events[descriptor.Name]; ok || descriptor.Anonymous {
+				continue
+			}
+			schema := EventSchema{Name: descriptor.Name}
+			for _, input := range descriptor.Inputs {
+				schema.Params = append(schema.Params, EventParam{Name: input.Name, Type: input.canonicalType(), Indexed: input.Indexed})
+			}
+			contract.Events[descriptor.Name] = schema
+		}
+	}
+	return contract, nil
+}
+
+// DecodeInput decodes the input of a transaction calling one of the
+// contract's functions. Unnamed arguments are keyed by their position, e.g.
+// "0". Tuple arguments are not supported.
+func (contract *ContractABI) DecodeInput(inputHex string) (*DecodedCall, error) {
+	input, err := decodeHexData(inputHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid input data: %w", err)
+	}
+	if len(input) < 4 {
+		return nil, fmt.Errorf("input data too short for a function selector")
+	}
+	selector := "0x" + hex.EncodeToString(input[:4])
+	function, ok := contract.Functions[selector]
+	if !ok {
+		return nil, fmt.Errorf("unknown function selector %v", selector)
+	}
+
+	types := make([]string, len(function.Inputs))
+	for i, argument := range function.Inputs {
+		types[i] = argument.Type
+	}
+	values, err := decodeABIValues(types, input[4:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %v input: %w", function.Name, err)
+	}
+
+	call := &DecodedCall{FunctionName: function.Name, Params: make(map[string]interface{}, len(values))}
+	for i, argument := range function.Inputs {
+		name := argument.Name
+		if name == "" {
+			name = strconv.Itoa(i)
+		}
+		call.Params[name] = values[i]
+	}
+	return call, nil
+}
+
+// decodeABIValues decodes a sequence of values ABI-encoded together, such as
+// the arguments of a call: static values are inline, dynamic ones are
+// referenced by their offset in data.
+func decodeABIValues(types []string, data []byte) ([]interface{}, error) {
+	values := make([]interface{}, len(types))
+	position := 0
+	for i, abiType := range types {
+		dynamic, err := isDynamicABIType(abiType)
+		if err != nil {
+			return nil, err
+		}
+		if dynamic {
+			offset, err := abiOffset(data, position)
+			if err != nil {
+				return nil, err
+			}
+			if values[i], err = decodeABIValue(abiType, data[offset:]); err != nil {
+				return nil, err
+			}
+			position += abiWordSize
+			continue
+		}
+		if values[i], err = decodeABIValue(abiType, data[min(position, len(data)):]); err != nil {
+			return nil, err
+		}
+		position += staticABISize(abiType)
+	}
+	return values, nil
+}
+
+// decodeABIValue decodes the value of a type encoded at the start of data.
+func decodeABIValue(abiType string, data []byte) (interface{}, error) {
+	if element, length, ok := parseABIArray(abiType); ok {
+		if length < 0 {
+			word, err := abiWord(data, 0)
+			if err != nil {
+				return nil, err
+			}
+			count := abiUint(word)
+			if !count.IsInt64() || count.Int64() > int64(len(data)) {
+				return nil, fmt.Errorf("ABI array length %v out of range", count)
+			}
+			length, data = int(count.Int64()), data[abiWordSize:]
+		}
+		// Every element takes at least a word, which bounds the allocation
+		if length > len(data)/abiWordSize {
+			return nil, fmt.Errorf("ABI data too short for %d elements of %v", length, element)
+		}
+		types := make([]string, length)
+		for i := range types {
+			types[i] = element
+		}
+		values, err := decodeABIValues(types, data)
+		if err != nil {
+			return nil, err
+		}
+		return values, nil
+	}
+
+	switch abiType {
+	case "string", "bytes":
+		word, err := abiWord(data, 0)
+		if err != nil {
+			return nil, err
+		}
+		length := abiUint(word)
+		if !length.IsInt64() || length.Int64() > int64(len(data)-abiWordSize) {
+			return nil, fmt.Errorf("ABI length %v out of range", length)
+		}
+		value := data[abiWordSize : abiWordSize+int(length.Int64())]
+		if abiType == "string" {
+			return string(value), nil
+		}
+		return "0x" + hex.EncodeToString(value), nil
+	}
+
+	word, err := abiWord(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	return decodeStaticValue(abiType, word)
+}
+
+// abiOffset returns the offset held in the word at position, checked against
+// the size of data.
+func abiOffset(data []byte, position int) (int, error) {
+	if position+abiWordSize > len(data) {
+		return 0, fmt.Errorf("ABI data too short for the offset at %d", position)
+	}
+	offset := abiUint(data[position : position+abiWordSize])
+	if !offset.IsInt64() || offset.Int64() > int64(len(data)) {
+		return 0, fmt.Errorf("ABI offset %v out of range", offset)
+	}
+	return int(offset.Int64()), nil
+}
+
+// parseABIArray splits an array type into the type of its elements and its
+// length, which is -1 for dynamic arrays. ok is false for other types.
+func parseABIArray(abiType string) (element string, length int, ok bool) {
+	if !strings.HasSuffix(abiType, "]") {
+		return "", 0, false
+	}
+	open := strings.LastIndex(abiType, "[")
+	if open < 0 {
+		return "", 0, false
+	}
+	element, size := abiType[:open], abiType[open+1:len(abiType)-1]
+	if size == "" {
+		return element, -1, true
+	}
+	length, err := strconv.Atoi(size)
+	if err != nil || length < 0 {
+		return "", 0, false
+	}
+	return element, length, true
+}
+
+// isDynamicABIType reports whether values of the type are encoded out of
+// line, referenced by their offset.
+func isDynamicABIType(abiType string) (bool, error) {
+	if element, length, ok := parseABIArray(abiType); ok {
+		if length < 0 {
+			return true, nil
+		}
+		return isDynamicABIType(element)
+	}
+	switch {
+	case abiType == "string" || abiType == "bytes":
+		return true, nil
+	case strings.HasPrefix(abiType, "tuple"):
+		return false, fmt.Errorf("unsupported type %v", abiType)
+	}
+	return false, nil
+}
+
+// staticABISize returns the size in bytes of the inline encoding of a static type.
+func staticABISize(abiType string) int {
+	if element, length, ok := parseABIArray(abiType); ok {
+		return length * staticABISize(element)
+	}
+	return abiWordSize
+}
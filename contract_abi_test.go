@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// loadERC20ABI loads the ABI of OpenZeppelin's ERC20 contract.
+func loadERC20ABI(t *testing.T) *ContractABI {
+	t.Helper()
+	contract, err := LoadABI(bytes.NewReader(LoadFixture(t, "erc20_abi.json")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return contract
+}
+
+func TestLoadABI(t *testing.T) {
+	contract := loadERC20ABI(t)
+	if len(contract.Functions) != 11 {
+		t.Errorf("loaded %d functions, want the 11 of ERC20", len(contract.Functions))
+	}
+	selectors := map[string]string{
+		"0xa9059cbb": "transfer(address,uint256)",
+		"0x095ea7b3": "approve(address,uint256)",
+		"0x23b872dd": "transferFrom(address,address,uint256)",
+		"0x70a08231": "balanceOf(address)",
+		"0xdd62ed3e": "allowance(address,address)",
+		"0x18160ddd": "totalSupply()",
+	}
+	for selector, signature := range selectors {
+		if function, ok := contract.Functions[selector]; !ok || function.Signature() != signature {
+			t.Errorf("function of %v = %v, want %v", selector, function.Signature(), signature)
+		}
+	}
+	transfer := contract.Events["Transfer"]
+	if len(transfer.Params) != 3 || !transfer.Params[0].Indexed || transfer.Params[2].Indexed || transfer.Params[2].Type != "uint256" {
+		t.Errorf("Transfer event = %+v", transfer)
+	}
+}
+
+// TestDecodeInputERC20 decodes the input of the ERC-20 calls.
+func TestDecodeInputERC20(t *testing.T) {
+	contract := loadERC20ABI(t)
+	abi := ABI{}
+	word := func(address string) string {
+		encoded, err := abi.EncodeAddress(address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimPrefix(encoded, "0x")
+	}
+	amount := func(value int64) string {
+		encoded, _ := abi.EncodeUint256(big.NewInt(value))
+		return strings.TrimPrefix(encoded, "0x")
+	}
+
+	tests := []struct {
+		input    string
+		function string
+		params   map[string]interface{}
+	}{
+		{
+			// The USDC transfer of the transaction fixtures
+			"0xa9059cbb0000000000000000000000005aaeb6053f3e94c9b9a09f33669435e7ef1beaed00000000000000000000000000000000000000000000000000000000000f4240",
+			"transfer", map[string]interface{}{"to": checksummedAddress, "amount": big.NewInt(1_000_000)},
+		},
+		{
+			"0x23b872dd" + word(checksummedAddress) + word(otherAddress) + amount(42),
+			"transferFrom", map[string]interface{}{"from": checksummedAddress, "to": otherAddress, "amount": big.NewInt(42)},
+		},
+		{"0x18160ddd", "totalSupply", map[string]interface{}{}},
+	}
+	for _, test := range tests {
+		call, err := contract.DecodeInput(test.input)
+		if err != nil {
+			t.Errorf("DecodeInput(%v) error = %v", test.input[:10], err)
+			continue
+		}
+		if call.FunctionName != test.function || fmt.Sprint(call.Params) != fmt.Sprint(test.params) {
+			t.Errorf("DecodeInput(%v) = %v %v, want %v %v", test.input[:10], call.FunctionName, call.Params, test.function, test.params)
+		}
+	}
+
+	for _, input := range []string{"0x", "0xa9059c", "0x12345678", "0xa9059cbb" + word(checksummedAddress), "0xzz"} {
+		if call, err := contract.DecodeInput(input); err == nil {
+			t.Errorf("DecodeInput(%v) = %+v, want an error", input, call)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// contractStatusTTL is how long whether an address holds code is cached.
+	// Deployed code does not change, but code may yet be deployed at an
+	// address without it.
+	contractStatusTTL = 24 * time.Hour
+	// contractStatusPruneSize is the number of cached statuses above which
+	// the expired ones are dropped.
+	contractStatusPruneSize = 100000
+)
+
+// contractStatus records whether an address held code when it was checked.
+type contractStatus struct {
+	isContract bool
+	checked    time.Time
+}
+
+// WatchContractInteractions behaves like Watch, but sends to out only the
+// calls of contracts from or to one of addresses: transactions with input
+// data whose recipient holds code. Plain transfers between accounts and
+// contract creations are left out. Code lookups are cached for a day, see
+// PreloadContractStatus.
+func (parser *EthereumParser) WatchContractInteractions(ctx context.Context, addresses map[string]bool, out chan<- Transaction) error {
+	watched := make(map[string]bool, len(addresses))
+	for address, ok := range addresses {
+		if ok {
+			watched[strings.ToLower(address)] = true
+		}
+	}
+	if len(watched) == 0 {
+		return errors.New("no addresses to watch")
+	}
+
+	onBlock := func(block *Block) error {
+		var calls []Transaction
+		var recipients []string
+		for _, transaction := range block.Transactions {
+			if transaction.To == "" || transaction.Input == "" || transaction.Input == "0x" {
+				continue
+			}
+			if watched[strings.ToLower(transaction.From)] || watched[strings.ToLower(transaction.To)] {
+				calls = append(calls, transaction)
+				recipients = append(recipients, transaction.To)
+			}
+		}
+		if len(calls) == 0 {
+			return nil
+		}
+		if err := parser.PreloadContractStatus(ctx, recipients); err != nil {
+			return fmt.Errorf("failed to check the recipients of block %v: %w", block.Number, err)
+		}
+
+		for _, transaction := range calls {
+			isContract, err := parser.isContract(ctx, transaction.To)
+			if err != nil {
+				return fmt.Errorf("failed to check recipient %v: %w", transaction.To, err)
+			}
+			if !isContract {
+				continue
+			}
+			select {
+			case out <- transaction:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	}
+	return parser.watch(ctx, 0, newSplitDetector(), nil, onBlock, func() time.Duration {
+		return parser.settings().WatchInterval
+	})
+}
+
+// PreloadContractStatus fetches whether the addresses hold code, in batch
+// requests of eth_getCode, so that WatchContractInteractions starts with a
+// warm cache. Addresses checked within a day are skipped. Nodes without batch
+// requests are asked for one address at a time.
+func (parser *EthereumParser) PreloadContractStatus(ctx context.Context, addresses []string) error {
+	now := parser.clock.Now()
+	seen := make(map[string]bool, len(addresses))
+	var stale []string
+	for _, address := range addresses {
+		if !IsValidAddress(address) {
+			return fmt.Errorf("invalid address: %v", address)
+		}
+		address = strings.ToLower(address)
+		if seen[address] {
+			continue
+		}
+		seen[address] = true
+		if _, ok := parser.cachedContractStatus(address, now); !ok {
+			stale = append(stale, address)
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+
+	codes := make([]string, len(stale))
+	params := make([][]interface{}, len(stale))
+	results := make([]interface{}, len(stale))
+	for i, address := range stale {
+		params[i] = []interface{}{address, "latest"}
+		results[i] = &codes[i]
+	}
+	err := parser.callRPCBatch(ctx, "eth_getCode", params, results)
+	if errors.Is(err, errRPCBatchUnsupported) {
+		parser.logger.DebugContext(ctx, "fetching code one address at a time", "addresses", len(stale), "error", err)
+		for i, address := range stale {
+			if codes[i], err = parser.GetCode(ctx, address, "latest"); err != nil {
+				return err
+			}
+		}
+	} else if err != nil {
+		return err
+	}
+
+	parser.contractsMu.Lock()
+	defer parser.contractsMu.Unlock()
+	if parser.contracts == nil {
+		parser.contracts = make(map[string]contractStatus)
+	}
+	if len(parser.contracts) >= contractStatusPruneSize {
+		for address, status := range parser.contracts {
+			if now.Sub(status.checked) >= contractStatusTTL {
+				delete(parser.contracts, address)
+			}
+		}
+	}
+	for i, address := range stale {
+		parser.contracts[address] = contractStatus{isContract: hasCode(codes[i]), checked: now}
+	}
+	return nil
+}
+
+// isContract reports whether an address holds code, from the cache when it
+// was checked within a day.
+func (parser *EthereumParser) isContract(ctx context.Context, address string) (bool, error) {
+	if isContract, ok := parser.cachedContractStatus(strings.ToLower(address), parser.clock.Now()); ok {
+		return isContract, nil
+	}
+	if err := parser.PreloadContractStatus(ctx, []string{address}); err != nil {
+		return false, err
+	}
+	isContract, _ := parser.cachedContractStatus(strings.ToLower(address), time.Time{})
+	return isContract, nil
+}
+
+// cachedContractStatus returns the cached status of a lower case address,
+// unless it is older than contractStatusTTL at now. A zero now accepts any age.
+func (parser *EthereumParser) cachedContractStatus(address string, now time.Time) (isContract, ok bool) {
+	parser.contractsMu.Lock()
+	defer parser.contractsMu.Unlock()
+	status, ok := parser.contracts[address]
+	if !ok || !now.IsZero() && now.Sub(status.checked) >= contractStatusTTL {
+		return false, false
+	}
+	return status.isContract, true
+}
+
+// hasCode reports whether the result of eth_getCode is code rather than empty.
+func hasCode(code string) bool {
+	return code != "" && code != "0x"
+}
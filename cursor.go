@@ -0,0 +1,115 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// WatchCursor is the position of Watch: the last processed block, from which
+// WatchFromCursor resumes, and the subscription watermarks at that point.
+type WatchCursor struct {
+	BlockNumber       uint64            `json:"blockNumber"`
+	BlockHash         string            `json:"blockHash"` // Checked on resume to detect a reorg
+	AddressWatermarks map[string]uint64 `json:"addressWatermarks,omitempty"`
+	GeneratedAt       time.Time         `json:"generatedAt"`
+}
+
+// Cursor returns the current position of Watch. Its BlockNumber is zero until
+// Watch has processed a block.
+func (parser *EthereumParser) Cursor() WatchCursor {
+	parser.mu.Lock()
+	defer parser.mu.Unlock()
+
+	return WatchCursor{
+		BlockNumber:       parser.lastProcessed,
+		BlockHash:         parser.lastProcessedHash,
+		AddressWatermarks: maps.Clone(parser.watermarks),
+		GeneratedAt:       parser.clock.Now(),
+	}
+}
+
+// SaveCursor writes a cursor to a JSON file. The file is replaced atomically,
+// so a crash leaves either the previous cursor or the new one.
+func SaveCursor(cursor WatchCursor, path string) error {
+	data, err := json.MarshalIndent(cursor, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cursor: %w", err)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save cursor: %w", err)
+	}
+	defer os.Remove(file.Name()) // Fails harmlessly once renamed
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to save cursor: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to save cursor: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to save cursor: %w", err)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("failed to save cursor: %w", err)
+	}
+	return nil
+}
+
+// LoadCursor reads a cursor written by SaveCursor.
+func LoadCursor(path string) (*WatchCursor, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cursor: %w", err)
+	}
+	var cursor WatchCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, fmt.Errorf("failed to decode cursor %v: %w", path, err)
+	}
+	return &cursor, nil
+}
+
+// WatchFromCursor behaves like Watch, but resumes after the cursor's block
+// instead of starting at the chain head, and restores the watermarks of the
+// addresses that have none. When the cursor's block was orphaned since the
+// cursor was saved, Watch resumes at the canonical block of that height;
+// reorgs deeper than the cursor's block are not detected. A nil cursor, or
+// one without a block, starts at the chain head.
+func (parser *EthereumParser) WatchFromCursor(ctx context.Context, cursor *WatchCursor, out chan<- Transaction) error {
+	if cursor == nil || cursor.BlockNumber == 0 {
+		return parser.Watch(ctx, out)
+	}
+
+	parser.mu.Lock()
+	for address, watermark := range cursor.AddressWatermarks {
+		address = NormalizeAddress(address)
+		if _, ok := parser.watermarks[address]; !ok {
+			parser.watermarks[address] = watermark
+		}
+	}
+	parser.mu.Unlock()
+
+	block, err := parser.getBlockByNumber(ctx, cursor.BlockNumber)
+	if err != nil {
+		return fmt.Errorf("failed to get cursor block: %w", err)
+	}
+	next, splits := cursor.BlockNumber+1, newSplitDetector()
+	if cursor.BlockHash != "" && block.Hash != cursor.BlockHash {
+		parser.logger.WarnContext(ctx, "chain reorganized since the cursor was saved", "block", cursor.BlockNumber, "cursor", cursor.BlockHash, "canonical", block.Hash)
+		next = cursor.BlockNumber
+	} else {
+		// The next block's parent is checked against the cursor's block
+		splits.record(cursor.BlockNumber, block.Hash)
+	}
+
+	return parser.watch(ctx, next, splits, out, nil, func() time.Duration {
+		return parser.settings().WatchInterval
+	})
+}
@@ -0,0 +1,57 @@
+package parser
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestCursorRoundTrip saves a cursor over a previous one and loads it back.
+func TestCursorRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cursor.json")
+	if err := SaveCursor(WatchCursor{BlockNumber: 1}, path); err != nil {
+		t.Fatal(err)
+	}
+	cursor := WatchCursor{
+		BlockNumber:       19_000_000,
+		BlockHash:         testBlockHash(19_000_000, ""),
+		AddressWatermarks: map[string]uint64{NormalizeAddress(checksummedAddress): 18_999_990},
+		GeneratedAt:       time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	if err := SaveCursor(cursor, path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadCursor(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*loaded, cursor) {
+		t.Errorf("LoadCursor = %+v, want %+v", *loaded, cursor)
+	}
+	if temporary, _ := filepath.Glob(path + ".*.tmp"); len(temporary) != 0 {
+		t.Errorf("SaveCursor left %v", temporary)
+	}
+}
+
+func TestLoadCursorErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := LoadCursor(filepath.Join(dir, "missing.json")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("LoadCursor of a missing file = %v, want fs.ErrNotExist", err)
+	}
+
+	corrupt := filepath.Join(dir, "corrupt.json")
+	if err := os.WriteFile(corrupt, []byte(`{"blockNumber": 12`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if cursor, err := LoadCursor(corrupt); err == nil {
+		t.Errorf("LoadCursor of a truncated file = %+v, want an error", cursor)
+	}
+
+	if err := SaveCursor(WatchCursor{BlockNumber: 1}, filepath.Join(dir, "missing", "cursor.json")); err == nil {
+		t.Error("SaveCursor into a missing directory succeeded")
+	}
+}
@@ -0,0 +1,144 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+)
+
+const (
+	// debugRecentRPCErrors is the number of failed RPC calls kept for the debug dump.
+	debugRecentRPCErrors = 10
+	// debugSubscriberSample is the number of subscribers listed at each end of the debug dump's list.
+	debugSubscriberSample = 5
+)
+
+// DebugDump is a snapshot of the parser's internal state, for troubleshooting.
+type DebugDump struct {
+	GeneratedAt        time.Time                     `json:"generatedAt"`
+	Endpoint           string                        `json:"endpoint"` // Credentials redacted
+	CurrentBlock       uint64                        `json:"currentBlock"`
+	CurrentBlockError  string                        `json:"currentBlockError,omitempty"`
+	LastProcessedBlock uint64                        `json:"lastProcessedBlock"`
+	Subscribers        DebugSubscribers              `json:"subscribers"`
+	IndexCoverage      map[string]DebugIndexCoverage `json:"indexCoverage"` // Keyed by subscribed address
+	RecentRPCErrors    []RPCErrorRecord              `json:"recentRPCErrors"`
+	Capabilities       *Capabilities                 `json:"capabilities,omitempty"` // Nil until doctor has run
+	Goroutines         int                           `json:"goroutines"`
+}
+
+// DebugSubscribers counts the subscribed addresses and lists the first and
+// last ones in ascending order.
+type DebugSubscribers struct {
+	Count int      `json:"count"`
+	First []string `json:"first"`
+	Last  []string `json:"last"`
+}
+
+// DebugIndexCoverage is the range of blocks the Index covers for an address.
+type DebugIndexCoverage struct {
+	From uint64 `json:"from"`
+	To   uint64 `json:"to"`
+}
+
+// RPCErrorRecord is a failed RPC call.
+type RPCErrorRecord struct {
+	Time   time.Time `json:"time"`
+	Method string    `json:"method"`
+	Error  string    `json:"error"`
+}
+
+// DebugDumper is implemented by parsers that can dump their internal state.
+type DebugDumper interface {
+	DebugDump(ctx context.Context) DebugDump
+}
+
+// rpcErrorLog keeps the last failed RPC calls.
+type rpcErrorLog struct {
+	mu      sync.Mutex
+	records *RingBuffer[RPCErrorRecord]
+}
+
+func (log *rpcErrorLog) record(record RPCErrorRecord) {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	if log.records == nil {
+		log.records = NewRingBuffer[RPCErrorRecord](debugRecentRPCErrors)
+	}
+	log.records.Push(record)
+}
+
+// recent returns the last failed RPC calls, oldest first.
+func (log *rpcErrorLog) recent() []RPCErrorRecord {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	if log.records == nil {
+		return []RPCErrorRecord{}
+	}
+	return log.records.Items()
+}
+
+// DebugDump returns a snapshot of the parser's state. Credentials in the
+// endpoint are redacted, including from the recorded RPC errors.
+func (parser *EthereumParser) DebugDump(ctx context.Context) DebugDump {
+	dump := DebugDump{
+		GeneratedAt:   parser.clock.Now(),
+		Endpoint:      RedactURL(parser.settings().Endpoint),
+		Subscribers:   DebugSubscribers{First: []string{}, Last: []string{}},
+		IndexCoverage: make(map[string]DebugIndexCoverage),
+		Goroutines:    runtime.NumGoroutine(),
+	}
+
+	head, err := parser.blockNumber(ctx)
+	if err != nil {
+		dump.CurrentBlockError = err.Error()
+	}
+	dump.CurrentBlock = head
+
+	parser.mu.Lock()
+	dump.LastProcessedBlock = parser.lastProcessed
+	parser.mu.Unlock()
+
+	if subscribers, err := parser.Subscribers(); err == nil {
+		dump.Subscribers.Count = len(subscribers)
+		dump.Subscribers.First = subscribers[:min(debugSubscriberSample, len(subscribers))]
+		dump.Subscribers.Last = subscribers[max(len(subscribers)-debugSubscriberSample, 0):]
+		for _, address := range subscribers {
+			if from, to := parser.index.Coverage(address); to != 0 {
+				dump.IndexCoverage[address] = DebugIndexCoverage{From: from, To: to}
+			}
+		}
+	}
+
+	dump.RecentRPCErrors = parser.rpcErrors.recent()
+	dump.Capabilities = parser.Capabilities()
+	return dump
+}
+
+// getDebugDump dumps the parser's state.
+func getDebugDump(ctx context.Context, parser Parser) (DebugDump, error) {
+	dumper, ok := parser.(DebugDumper)
+	if !ok {
+		return DebugDump{}, errors.New("parser does not support debug dumps")
+	}
+	return dumper.DebugDump(ctx), nil
+}
+
+func (server *Server) handleDebugDump(w http.ResponseWriter, r *http.Request) {
+	dump, err := getDebugDump(r.Context(), server.parser)
+	if err != nil {
+		writeError(w, http.StatusNotImplemented, err.Error())
+		return
+	}
+	data, err := json.MarshalIndent(dump, "", "  ")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(data, '\n'))
+}
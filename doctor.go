@@ -0,0 +1,242 @@
+package parser
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// doctorProbeTimeout bounds each probe of the doctor command.
+	doctorProbeTimeout = 10 * time.Second
+	// doctorLogsRange is the number of blocks the eth_getLogs probe queries,
+	// which most providers accept.
+	doctorLogsRange = 100
+)
+
+// doctorProbeHash is the transaction hash the trace probes ask for. Nodes
+// serving the method answer that it does not exist.
+const doctorProbeHash = "0x0000000000000000000000000000000000000000000000000000000000000000"
+
+// doctorProbeAddress is the contract address of the log probes, which keeps
+// their results small.
+const doctorProbeAddress = "0x0000000000000000000000000000000000000000"
+
+// CapabilityProbe is the result of probing the node for a capability.
+type CapabilityProbe struct {
+	Name      string `json:"name"`
+	Method    string `json:"method,omitempty"`
+	Supported bool   `json:"supported"`
+	Detail    string `json:"detail,omitempty"` // What was found, or why the capability is missing
+}
+
+// DoctorReport lists the capabilities of the node, and warns about the
+// features that will not work against it.
+type DoctorReport struct {
+	Endpoint string            `json:"endpoint"` // Credentials redacted
+	Probes   []CapabilityProbe `json:"probes"`
+	Warnings []string          `json:"warnings"`
+}
+
+// Capabilities are the results of the last probe of the node, keyed by
+// capability name.
+type Capabilities struct {
+	ProbedAt  time.Time       `json:"probedAt"`
+	Supported map[string]bool `json:"supported"`
+}
+
+// CapabilityProber is implemented by parsers that can probe their node.
+type CapabilityProber interface {
+	Doctor(ctx context.Context) DoctorReport
+}
+
+// Doctor probes the node for the methods and transports the parser and
+// common tools rely on, and warns about the features they break. The
+// results are kept, see Capabilities.
+func (parser *EthereumParser) Doctor(ctx context.Context) DoctorReport {
+	endpoint := parser.settings().Endpoint
+	report := DoctorReport{Endpoint: RedactURL(endpoint), Warnings: []string{}}
+	probe := func(name, method, warning string, run func(ctx context.Context) (string, error)) bool {
+		ctx, cancel := context.WithTimeout(ctx, doctorProbeTimeout)
+		defer cancel()
+		detail, err := run(ctx)
+		if isMethodNotSupported(err) {
+			detail = "not supported by the node"
+		} else if err != nil {
+			detail = err.Error()
+		}
+		report.Probes = append(report.Probes, CapabilityProbe{Name: name, Method: method, Supported: err == nil, Detail: detail})
+		if err != nil && warning != "" {
+			report.Warnings = append(report.Warnings, warning)
+		}
+		return err == nil
+	}
+
+	reachable := probe("chain ID", "eth_chainId", "the node is unreachable, or not an Ethereum node", func(ctx context.Context) (string, error) {
+		chainID, err := parser.ChainID(ctx)
+		if err == nil && parser.chain.ChainID != 0 && chainID != parser.chain.ChainID {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("the node serves chain %d, not %v (%d)", chainID, parser.chain.Name, parser.chain.ChainID))
+		}
+		return fmt.Sprint(chainID), err
+	})
+	var head uint64
+	reachable = probe("latest block", "eth_blockNumber", "watch cannot follow the chain head", func(ctx context.Context) (string, error) {
+		var err error
+		head, err = parser.blockNumber(ctx)
+		return fmt.Sprint(head), err
+	}) || reachable
+	if !reachable {
+		report.Warnings = append(report.Warnings, "the other capabilities were not probed")
+		parser.storeCapabilities(report)
+		return report
+	}
+	latest := fmt.Sprintf("0x%x", head)
+	probe("protocol version", "eth_protocolVersion", "", func(ctx context.Context) (string, error) {
+		version, err := parser.GetProtocolVersion(ctx)
+		if err == nil && version < minProtocolVersion {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("the node speaks the deprecated wire protocol eth/%d", version))
+		}
+		return fmt.Sprintf("eth/%d", version), err
+	})
+	probe("full blocks", "eth_getBlockByNumber", "watch cannot read the transactions of blocks", func(ctx context.Context) (string, error) {
+		var block Block
+		if err := parser.rpcEthGetBlockByNumber(ctx, latest, true, &block); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d transactions in block %d", len(block.Transactions), head), nil
+	})
+	probe("batch requests", "", "", func(ctx context.Context) (string, error) {
+		return parser.probeBatch(ctx, endpoint)
+	})
+	probe("logs range", "eth_getLogs", fmt.Sprintf("log queries, such as contract watchers, fail on ranges of %d blocks", doctorLogsRange), func(ctx context.Context) (string, error) {
+		from := max(head, doctorLogsRange-1) - (doctorLogsRange - 1)
+		_, err := parser.rpcEthGetLogs(ctx, LogFilter{FromBlock: from, ToBlock: head, Address: doctorProbeAddress}.params())
+		return fmt.Sprintf("%d blocks", doctorLogsRange), err
+	})
+	probe("log filters", "eth_newFilter", "contract event subscriptions will fail", func(ctx context.Context) (string, error) {
+		filterID, err := parser.rpcEthNewFilter(ctx, LogFilter{FromBlock: head, ToBlock: head, Address: doctorProbeAddress}.params())
+		if err != nil {
+			return "", err
+		}
+		parser.UninstallLogFilter(ctx, filterID)
+		return "", nil
+	})
+	probe("fee history", "eth_feeHistory", "fee suggestions, such as GET /fees, will fail", func(ctx context.Context) (string, error) {
+		_, err := parser.rpcEthFeeHistory(ctx, "0x1", "latest", nil)
+		return "", err
+	})
+	probe("block receipts", "eth_getBlockReceipts", "", func(ctx context.Context) (string, error) {
+		receipts, err := parser.rpcEthGetBlockReceipts(ctx, latest)
+		return fmt.Sprintf("%d receipts in block %d", len(receipts), head), err
+	})
+	probe("debug traces", "debug_traceTransaction", "", func(ctx context.Context) (string, error) {
+		return served(parser.rpcDebugTraceTransaction(ctx, doctorProbeHash, new(json.RawMessage)))
+	})
+	probe("parity traces", "trace_transaction", "", func(ctx context.Context) (string, error) {
+		return served(parser.rpcTraceTransaction(ctx, doctorProbeHash, new(json.RawMessage)))
+	})
+	probe("websocket", "", "", func(ctx context.Context) (string, error) {
+		return parser.probeWebSocket(ctx, endpoint)
+	})
+
+	parser.storeCapabilities(report)
+	return report
+}
+
+// storeCapabilities keeps the results of the probes of report.
+func (parser *EthereumParser) storeCapabilities(report DoctorReport) {
+	capabilities := &Capabilities{ProbedAt: parser.clock.Now(), Supported: make(map[string]bool, len(report.Probes))}
+	for _, probe := range report.Probes {
+		capabilities.Supported[probe.Name] = probe.Supported
+	}
+	parser.capabilities.Store(capabilities)
+}
+
+// Capabilities returns the results of the last Doctor probe, or nil when the
+// node was not probed.
+func (parser *EthereumParser) Capabilities() *Capabilities {
+	return parser.capabilities.Load()
+}
+
+// served reports a method as served when the node answered with any error
+// but that it does not know it, such as that the probed transaction does not
+// exist.
+func served(err error) (string, error) {
+	var rpcErr *RPCError
+	if err == nil || errors.As(err, &rpcErr) && !isMethodNotSupported(err) {
+		return "served", nil
+	}
+	return "", err
+}
+
+// probeBatch sends two calls in one JSON-RPC batch request.
+func (parser *EthereumParser) probeBatch(ctx context.Context, endpoint string) (string, error) {
+	body := `[{"jsonrpc":"2.0","method":"eth_blockNumber","params":[],"id":1},{"jsonrpc":"2.0","method":"eth_chainId","params":[],"id":2}]`
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	resp, err := parser.client.Do(request)
+	if err != nil {
+		return "", redactURLError(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	var responses []RPCResponse
+	if err := json.Unmarshal(data, &responses); err != nil {
+		// Nodes without batches answer with a single error
+		var response RPCResponse
+		if json.Unmarshal(data, &response) == nil && response.Error != nil {
+			return "", response.Error
+		}
+		return "", fmt.Errorf("unexpected response: HTTP %d", resp.StatusCode)
+	}
+	for _, response := range responses {
+		if response.Error != nil {
+			return "", response.Error
+		}
+	}
+	if len(responses) != 2 {
+		return "", fmt.Errorf("%d responses to 2 calls", len(responses))
+	}
+	return "2 calls per request", nil
+}
+
+// probeWebSocket opens a WebSocket handshake on the endpoint's URL with the
+// ws or wss scheme. Providers serving WebSockets on another URL are reported
+// as not supporting them.
+func (parser *EthereumParser) probeWebSocket(ctx context.Context, endpoint string) (string, error) {
+	url := "ws" + strings.TrimPrefix(endpoint, "http")
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	key := make([]byte, 16)
+	rand.Read(key)
+	request.Header.Set("Connection", "Upgrade")
+	request.Header.Set("Upgrade", "websocket")
+	request.Header.Set("Sec-WebSocket-Version", "13")
+	request.Header.Set("Sec-WebSocket-Key", base64.StdEncoding.EncodeToString(key))
+
+	resp, err := parser.client.Do(request)
+	if err != nil {
+		return "", redactURLError(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return "", fmt.Errorf("no handshake at %v: HTTP %d", RedactURL(url), resp.StatusCode)
+	}
+	return RedactURL(url), nil
+}
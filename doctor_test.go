@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestDoctor probes a fakeNode serving some of the optional methods.
+func TestDoctor(t *testing.T) {
+	clock := NewMockClock(time.Unix(1_700_000_000, 0))
+	node := newFakeNode(t, testBlock(1), testBlock(2))
+	node.Fail("debug_traceTransaction", &RPCError{Code: -32000, Message: "transaction not found"})
+	node.Fail("eth_getLogs", &RPCError{Code: -32005, Message: "query returned more than 10000 results"})
+	parser := node.NewParser(WithClock(clock), WithChain(Chain{Name: "polygon", ChainID: 137}))
+
+	report := parser.Doctor(context.Background())
+	want := map[string]bool{
+		"chain ID":         true,
+		"latest block":     true,
+		"protocol version": false,
+		"full blocks":      true,
+		"batch requests":   false,
+		"logs range":       false,
+		"log filters":      false,
+		"fee history":      false,
+		"block receipts":   false,
+		"debug traces":     true,
+		"parity traces":    false,
+		"websocket":        false,
+	}
+	if len(report.Probes) != len(want) {
+		t.Errorf("%d probes, want %d", len(report.Probes), len(want))
+	}
+	for _, probe := range report.Probes {
+		if supported, ok := want[probe.Name]; !ok || probe.Supported != supported {
+			t.Errorf("probe %v = %+v, want supported %v", probe.Name, probe, supported)
+		}
+	}
+	for _, warning := range []string{
+		"the node serves chain 1337, not polygon (137)",
+		"log queries, such as contract watchers, fail on ranges of 100 blocks",
+		"contract event subscriptions will fail",
+	} {
+		if !slices.Contains(report.Warnings, warning) {
+			t.Errorf("warnings = %q, want %q", report.Warnings, warning)
+		}
+	}
+
+	capabilities := parser.Capabilities()
+	if capabilities == nil || !capabilities.ProbedAt.Equal(clock.Now()) || !capabilities.Supported["debug traces"] || capabilities.Supported["block receipts"] {
+		t.Errorf("Capabilities = %+v, want the results of the probes", capabilities)
+	}
+}
+
+// TestDoctorBatch probes a node answering batch requests.
+func TestDoctorBatch(t *testing.T) {
+	server := NewFixtureServer(t, nodeFixtures(t))
+	report := NewEthereumParser(server.URL, NewMemoryStorage()).Doctor(context.Background())
+	for _, probe := range report.Probes {
+		if probe.Name == "batch requests" && (!probe.Supported || probe.Detail != "2 calls per request") {
+			t.Errorf("batch probe = %+v, want 2 calls per request", probe)
+		}
+	}
+}
+
+// TestDoctorBasicProbesFail stops after the node fails the basic probes.
+func TestDoctorBasicProbesFail(t *testing.T) {
+	node := newFakeNode(t, testBlock(1))
+	node.Fail("eth_chainId", &RPCError{Code: -32603, Message: "internal error"})
+	node.Fail("eth_blockNumber", &RPCError{Code: -32603, Message: "internal error"})
+	parser := node.NewParser()
+	report := parser.Doctor(context.Background())
+	if len(report.Probes) != 2 || report.Probes[0].Supported || report.Probes[1].Supported {
+		t.Errorf("probes = %+v, want the two failed basic probes", report.Probes)
+	}
+	if !slices.Contains(report.Warnings, "the other capabilities were not probed") {
+		t.Errorf("warnings = %q, want that the other capabilities were not probed", report.Warnings)
+	}
+	if capabilities := parser.Capabilities(); capabilities == nil || capabilities.Supported["latest block"] {
+		t.Errorf("Capabilities = %+v, want the failed probes kept", capabilities)
+	}
+}
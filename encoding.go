@@ -0,0 +1,43 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/gob"
+)
+
+// EncodeTransaction encodes a transaction in the compact gob format.
+func EncodeTransaction(tx Transaction) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(tx); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// DecodeTransaction decodes a transaction produced by EncodeTransaction.
+func DecodeTransaction(data []byte) (*Transaction, error) {
+	var tx Transaction
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&tx); err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// EncodeTransactionBatch encodes a slice of transactions in a single gob stream,
+// so the type information is only written once.
+func EncodeTransactionBatch(txs []Transaction) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(txs); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// DecodeTransactionBatch decodes transactions produced by EncodeTransactionBatch.
+func DecodeTransactionBatch(data []byte) ([]Transaction, error) {
+	var txs []Transaction
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&txs); err != nil {
+		return nil, err
+	}
+	return txs, nil
+}
@@ -0,0 +1,270 @@
+package parser
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultHealthCheckInterval is how often Watch checks the health of the endpoint.
+	DefaultHealthCheckInterval = 30 * time.Second
+	// healthCheckTimeout bounds each health check.
+	healthCheckTimeout = 3 * time.Second
+	// healthCheckHistory is the number of recent checks SuccessRate covers.
+	healthCheckHistory = 20
+	// latencySmoothing is the weight of the latest check in the EWMA of the latency.
+	latencySmoothing = 0.3
+)
+
+// healthCheckRequest is the request of the health checks.
+var healthCheckRequest = []byte(`{"jsonrpc":"2.0","method":"eth_blockNumber","params":[],"id":1}`)
+
+// EndpointStats is the health of an endpoint, as measured by the checks of
+// WithHealthCheckInterval.
+type EndpointStats struct {
+	URL         string    `json:"url"` // Credentials redacted
+	Chain       string    `json:"chain,omitempty"`
+	Healthy     bool      `json:"healthy"`     // Whether the last check succeeded
+	LatencyMs   float64   `json:"latencyMs"`   // EWMA of the latency of the successful checks
+	SuccessRate float64   `json:"successRate"` // Share of the recent checks that succeeded
+	LastChecked time.Time `json:"lastChecked"`
+}
+
+// endpointHealth is the state of the health checks of an endpoint.
+type endpointHealth struct {
+	endpoint    string
+	healthy     bool // Whether the last check succeeded and no request failed since
+	latencyMs   float64
+	results     *RingBuffer[bool] // Whether the recent checks succeeded
+	lastChecked time.Time
+}
+
+// checked reports whether the endpoint was checked at least once.
+func (health *endpointHealth) checked() bool {
+	return health.results.Len() > 0
+}
+
+// EndpointPool holds endpoints serving the same chain, of which Next selects
+// the one to call by the health checks of a running Watch.
+type EndpointPool struct {
+	mu        sync.Mutex
+	endpoints []*endpointHealth
+}
+
+// NewEndpointPool returns a pool of the endpoints, which are presumed healthy
+// until checked.
+func NewEndpointPool(endpoints ...string) *EndpointPool {
+	pool := &EndpointPool{}
+	for _, endpoint := range endpoints {
+		pool.endpoints = append(pool.endpoints, &endpointHealth{endpoint: endpoint, healthy: true, results: NewRingBuffer[bool](healthCheckHistory)})
+	}
+	return pool
+}
+
+// Endpoints returns the endpoints of the pool, in the order they were given.
+func (pool *EndpointPool) Endpoints() []string {
+	endpoints := make([]string, len(pool.endpoints))
+	for i, health := range pool.endpoints {
+		endpoints[i] = health.endpoint
+	}
+	return endpoints
+}
+
+// Next returns the healthy endpoint with the lowest EWMA latency, preferring
+// the checked endpoints to those not yet checked and, among equals, the
+// earlier ones. When no endpoint is healthy it returns the first.
+func (pool *EndpointPool) Next() string {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	var best *endpointHealth
+	for _, health := range pool.endpoints {
+		if !health.healthy {
+			continue
+		}
+		switch {
+		case best == nil:
+			best = health
+		case health.checked() != best.checked():
+			if health.checked() {
+				best = health
+			}
+		case health.latencyMs < best.latencyMs:
+			best = health
+		}
+	}
+	if best == nil {
+		return pool.endpoints[0].endpoint
+	}
+	return best.endpoint
+}
+
+// record records the result of a health check of an endpoint of the pool.
+func (pool *EndpointPool) record(endpoint string, now time.Time, latency time.Duration, err error) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	health := pool.find(endpoint)
+	if health == nil {
+		return
+	}
+	health.results.Push(err == nil)
+	health.healthy = err == nil
+	health.lastChecked = now
+	if err == nil {
+		latencyMs := float64(latency) / float64(time.Millisecond)
+		if health.latencyMs == 0 {
+			health.latencyMs = latencyMs
+		} else {
+			health.latencyMs = latencySmoothing*latencyMs + (1-latencySmoothing)*health.latencyMs
+		}
+	}
+}
+
+// markFailed marks an endpoint whose request failed unhealthy until its next
+// successful check, so that Next selects another.
+func (pool *EndpointPool) markFailed(endpoint string) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	if health := pool.find(endpoint); health != nil {
+		health.healthy = false
+	}
+}
+
+func (pool *EndpointPool) find(endpoint string) *endpointHealth {
+	for _, health := range pool.endpoints {
+		if health.endpoint == endpoint {
+			return health
+		}
+	}
+	return nil
+}
+
+// Stats returns the health of the endpoints, in the order they were given.
+// An endpoint not yet checked is reported unhealthy with no latency.
+func (pool *EndpointPool) Stats() []EndpointStats {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	stats := make([]EndpointStats, 0, len(pool.endpoints))
+	for _, health := range pool.endpoints {
+		endpoint := EndpointStats{URL: RedactURL(health.endpoint)}
+		if health.checked() {
+			results := health.results.Items()
+			succeeded := 0
+			for _, ok := range results {
+				if ok {
+					succeeded++
+				}
+			}
+			endpoint.Healthy = health.healthy
+			endpoint.LatencyMs = health.latencyMs
+			endpoint.SuccessRate = float64(succeeded) / float64(len(results))
+			endpoint.LastChecked = health.lastChecked
+		}
+		stats = append(stats, endpoint)
+	}
+	return stats
+}
+
+// endpointReporter is implemented by parsers that report the health of their endpoints.
+type endpointReporter interface {
+	EndpointStats() []EndpointStats
+}
+
+// WithHealthCheckInterval sets the interval Watch checks the health of the
+// endpoints at, with an eth_blockNumber call of at most 3s to each. 0 disables
+// the checks.
+func WithHealthCheckInterval(interval time.Duration) Option {
+	return func(parser *EthereumParser) {
+		parser.HealthCheckInterval = interval
+	}
+}
+
+// WithEndpointPool makes the parser call the endpoints of the pool, selected
+// by Next for every call and retry, instead of its Endpoint.
+func WithEndpointPool(pool *EndpointPool) Option {
+	return func(parser *EthereumParser) {
+		parser.pool = pool
+	}
+}
+
+// endpoints returns the pool of WithEndpointPool, or else a pool of the
+// Endpoint, replaced when Reconfigure changes it.
+func (parser *EthereumParser) endpoints() *EndpointPool {
+	if parser.pool != nil {
+		return parser.pool
+	}
+	endpoint := parser.settings().Endpoint
+	parser.healthMu.Lock()
+	defer parser.healthMu.Unlock()
+	if parser.health == nil || parser.health.endpoints[0].endpoint != endpoint {
+		parser.health = NewEndpointPool(endpoint)
+	}
+	return parser.health
+}
+
+// checkHealthEvery checks the health of the endpoints every interval until
+// ctx is cancelled.
+func (parser *EthereumParser) checkHealthEvery(ctx context.Context, interval time.Duration) {
+	for {
+		for _, endpoint := range parser.endpoints().Endpoints() {
+			parser.checkHealth(ctx, endpoint)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-parser.clock.After(interval):
+		}
+	}
+}
+
+// checkHealth makes one eth_blockNumber call to an endpoint, without retries,
+// and records whether it succeeded and how long it took.
+func (parser *EthereumParser) checkHealth(ctx context.Context, endpoint string) {
+	checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	start := parser.clock.Now()
+	var result string
+	_, err := parser.sendRPCRequest(checkCtx, endpoint, healthCheckRequest, &result)
+	if err == nil {
+		_, err = ParseHexUint64(result)
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	now := parser.clock.Now()
+	if err != nil {
+		parser.logger.WarnContext(ctx, "endpoint health check failed", "endpoint", RedactURL(endpoint), "error", err)
+	}
+	parser.endpoints().record(endpoint, now, now.Sub(start), err)
+}
+
+// EndpointStats returns the health of the endpoints, unknown until the first
+// check of a running Watch.
+func (parser *EthereumParser) EndpointStats() []EndpointStats {
+	stats := parser.endpoints().Stats()
+	for i := range stats {
+		stats[i].Chain = parser.chain.Name
+	}
+	return stats
+}
+
+// EndpointStats returns the health of the endpoints of every chain.
+func (multi *MultiChainParser) EndpointStats() []EndpointStats {
+	var stats []EndpointStats
+	for _, chain := range multi.chains {
+		stats = append(stats, chain.EndpointStats()...)
+	}
+	return stats
+}
+
+func handleEndpoints(parser Parser) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reporter, ok := parser.(endpointReporter)
+		if !ok {
+			writeError(w, http.StatusNotImplemented, "parser does not report endpoint health")
+			return
+		}
+		writeJSON(w, http.StatusOK, reporter.EndpointStats())
+	}
+}
@@ -0,0 +1,85 @@
+package parser
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEndpointPoolNext(t *testing.T) {
+	pool := NewEndpointPool("http://a", "http://b", "http://c")
+	if next := pool.Next(); next != "http://a" {
+		t.Errorf("Next before any check = %v, want the first endpoint", next)
+	}
+
+	now := time.Now()
+	pool.record("http://a", now, 80*time.Millisecond, nil)
+	pool.record("http://b", now, 20*time.Millisecond, nil)
+	if next := pool.Next(); next != "http://b" {
+		t.Errorf("Next = %v, want http://b of the lowest latency", next)
+	}
+
+	pool.markFailed("http://b")
+	if next := pool.Next(); next != "http://a" {
+		t.Errorf("Next after http://b failed = %v, want http://a", next)
+	}
+	pool.record("http://a", now, 0, errors.New("timeout"))
+	if next := pool.Next(); next != "http://c" {
+		t.Errorf("Next with only http://c unchecked = %v, want http://c", next)
+	}
+	pool.markFailed("http://c")
+	if next := pool.Next(); next != "http://a" {
+		t.Errorf("Next with no healthy endpoint = %v, want the first", next)
+	}
+
+	pool.record("http://b", now, 100*time.Millisecond, nil)
+	if next := pool.Next(); next != "http://b" {
+		t.Errorf("Next after http://b recovered = %v, want http://b", next)
+	}
+	stats := pool.Stats()
+	if len(stats) != 3 || !stats[1].Healthy || stats[1].SuccessRate != 1 || stats[1].LatencyMs != 0.3*100+0.7*20 {
+		t.Errorf("Stats of http://b = %+v, want healthy with an EWMA latency of 44ms", stats[1])
+	}
+	if stats[0].Healthy || stats[0].SuccessRate != 0.5 {
+		t.Errorf("Stats of http://a = %+v, want unhealthy with a success rate of 0.5", stats[0])
+	}
+	if stats[2].Healthy || !stats[2].LastChecked.IsZero() {
+		t.Errorf("Stats of the unchecked http://c = %+v, want unhealthy and never checked", stats[2])
+	}
+}
+
+// TestEndpointPoolFallback calls a pool whose first endpoint is down.
+func TestEndpointPoolFallback(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	up := NewFixtureServer(t, nodeFixtures(t))
+
+	pool := NewEndpointPool(down.URL, up.URL)
+	parser := NewEthereumParser(down.URL, NewMemoryStorage(), WithEndpointPool(pool))
+	if block := parser.GetCurrentBlock(); block != fixtureBlock {
+		t.Fatalf("GetCurrentBlock = %d, want %d from the second endpoint", block, fixtureBlock)
+	}
+	if next := pool.Next(); next != up.URL {
+		t.Errorf("Next after the first endpoint failed = %v, want %v", next, up.URL)
+	}
+}
+
+func TestAdminEndpoints(t *testing.T) {
+	parser := NewEthereumParser("http://127.0.0.1:1", NewMemoryStorage(), WithEndpointPool(NewEndpointPool("http://a", "http://b")))
+	recorder := httptest.NewRecorder()
+	NewAdminHandler(parser).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/endpoints", nil))
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), `"url":"http://b"`) {
+		t.Errorf("GET /endpoints = %d %v, want the stats of both endpoints", recorder.Code, recorder.Body)
+	}
+
+	for _, path := range []string{"/admin/endpoints", "/admin/node-info"} {
+		recorder := httptest.NewRecorder()
+		NewServer(parser).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		if recorder.Code != http.StatusNotFound {
+			t.Errorf("GET %v on the public server = %d, want 404", path, recorder.Code)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestFetchTransactionsErrors unwraps the errors of FetchTransactions down to
+// their cause, through the RPCCallError and the context they are wrapped in.
+func TestFetchTransactionsErrors(t *testing.T) {
+	headerNotFound := &RPCError{Code: -32000, Message: "header not found"}
+	isHeaderNotFound := func(err error) bool {
+		var rpcErr *RPCError
+		return errors.As(err, &rpcErr) && rpcErr.Code == headerNotFound.Code
+	}
+	tests := []struct {
+		name    string
+		fail    func(node *fakeNode)
+		timeout time.Duration
+		cause   func(err error) bool
+		context []string // Parts of the message
+	}{
+		{
+			name:    "node error",
+			fail:    func(node *fakeNode) { node.Fail("eth_getBlockByNumber", headerNotFound) },
+			cause:   isHeaderNotFound,
+			context: []string{"failed to get block 2", "eth_getBlockByNumber", "http://fake-node", "header not found"},
+		},
+		{
+			name:    "timeout",
+			fail:    func(node *fakeNode) { node.SetLatency(time.Second) },
+			timeout: 50 * time.Millisecond,
+			cause:   func(err error) bool { return errors.Is(err, context.DeadlineExceeded) },
+			context: []string{"failed to get current block", "eth_blockNumber", "timed out after 50ms"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node := newFakeNode(t, testBlock(1), testBlock(2))
+			parser := node.NewParser()
+			if test.timeout > 0 {
+				parser = node.NewParser(WithRPCTimeouts(test.timeout, nil))
+			}
+			if _, err := parser.SubscribeAddress(checksummedAddress); err != nil {
+				t.Fatal(err)
+			}
+			test.fail(node)
+
+			_, err := parser.FetchTransactions(context.Background(), checksummedAddress)
+			var callErr *RPCCallError
+			if !test.cause(err) || !errors.As(err, &callErr) {
+				t.Fatalf("FetchTransactions error = %v, want an RPCCallError wrapping the %v", err, test.name)
+			}
+			for _, part := range test.context {
+				if !strings.Contains(err.Error(), part) {
+					t.Errorf("FetchTransactions error = %v, want it to mention %v", err, part)
+				}
+			}
+		})
+	}
+}
+
+// TestBlockNotFoundWrapped finds ErrBlockNotFound through the block number
+// and the caller's context it is wrapped in.
+func TestBlockNotFoundWrapped(t *testing.T) {
+	parser := newTestParser(t, []*Block{testBlock(1)})
+	err := parser.StreamTransactionsInRange(context.Background(), checksummedAddress, 1, 3, func(Transaction) error { return nil })
+	wrapped := fmt.Errorf("failed to export transactions: %w", err)
+	if !errors.Is(wrapped, ErrBlockNotFound) || !strings.Contains(wrapped.Error(), "failed to get block 2") {
+		t.Errorf("error = %v, want ErrBlockNotFound of block 2", wrapped)
+	}
+}
+
+// TestSubscriptionErrorWrapped finds every problem of a SubscriptionError
+// through another layer of wrapping.
+func TestSubscriptionErrorWrapped(t *testing.T) {
+	parser := NewEthereumParser(unreachableEndpoint, NewMemoryStorage())
+	if _, err := parser.SubscribeAddress(checksummedAddress); err != nil {
+		t.Fatal(err)
+	}
+	misspelled := "0x5AAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+	err := fmt.Errorf("failed to subscribe %v: %w", misspelled, parser.ValidateSubscription(misspelled))
+	var subscriptionErr *SubscriptionError
+	if !errors.Is(err, ErrChecksumMismatch) || !errors.Is(err, ErrAlreadySubscribed) || !errors.As(err, &subscriptionErr) {
+		t.Errorf("error = %v, want a SubscriptionError wrapping ErrChecksumMismatch and ErrAlreadySubscribed", err)
+	}
+	if errors.Is(err, ErrInvalidAddress) {
+		t.Errorf("error = %v, want it not to wrap ErrInvalidAddress", err)
+	}
+}
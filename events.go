@@ -0,0 +1,276 @@
+package parser
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// eventUninstallTimeout bounds how long a stopped ContractEventSubscription
+// waits for the node to uninstall its filter.
+const eventUninstallTimeout = 5 * time.Second
+
+// EventParam describes a parameter of a contract event.
+type EventParam struct {
+	Name    string
+	Type    string // ABI type: address, bool, uint<N>, int<N>, bytes<N>, bytes or string
+	Indexed bool
+}
+
+// EventSchema describes a contract event, from which its topic is computed and
+// its logs are decoded.
+type EventSchema struct {
+	Name   string
+	Params []EventParam
+}
+
+// Signature returns the event's canonical signature, e.g. Transfer(address,address,uint256).
+func (schema EventSchema) Signature() string {
+	types := make([]string, len(schema.Params))
+	for i, param := range schema.Params {
+		types[i] = param.Type
+	}
+	return schema.Name + "(" + strings.Join(types, ",") + ")"
+}
+
+// Topic returns the hash of the event's signature, the first topic of its logs.
+func (schema EventSchema) Topic() string {
+	return Keccak256Hex(schema.Signature())
+}
+
+// DecodeLog decodes the parameters of a log emitted for the event, keyed by
+// name. Addresses are checksummed strings, integers *big.Int, bools bool,
+// strings string, and byte values 0x-prefixed hex. Indexed strings and bytes
+// are only available as the hash held in their topic.
+func DecodeLog(schema EventSchema, log Log) (map[string]interface{}, error) {
+	if len(log.Topics) == 0 || !strings.EqualFold(log.Topics[0], schema.Topic()) {
+		return nil, fmt.Errorf("log is not a %v event", schema.Name)
+	}
+	data, err := decodeHexData(log.Data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid log data: %w", err)
+	}
+
+	values := make(map[string]interface{}, len(schema.Params))
+	topic, index := 1, 0 // Next topic and data word
+	for _, param := range schema.Params {
+		var value interface{}
+		if param.Indexed {
+			if topic >= len(log.Topics) {
+				return nil, fmt.Errorf("log has no topic for %v", param.Name)
+			}
+			word, err := decodeHexData(log.Topics[topic])
+			if err != nil || len(word) != abiWordSize {
+				return nil, fmt.Errorf("invalid topic for %v: %q", param.Name, log.Topics[topic])
+			}
+			topic++
+			if param.Type == "string" || param.Type == "bytes" {
+				value = log.Topics[topic-1]
+			} else if value, err = decodeStaticValue(param.Type, word); err != nil {
+				return nil, fmt.Errorf("failed to decode %v: %w", param.Name, err)
+			}
+		} else {
+			switch param.Type {
+			case "string", "bytes":
+				bytes, err := abiDynamicBytes(data, index)
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode %v: %w", param.Name, err)
+				}
+				value = "0x" + hex.EncodeToString(bytes)
+				if param.Type == "string" {
+					value = string(bytes)
+				}
+			default:
+				word, err := abiWord(data, index)
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode %v: %w", param.Name, err)
+				}
+				if value, err = decodeStaticValue(param.Type, word); err != nil {
+					return nil, fmt.Errorf("failed to decode %v: %w", param.Name, err)
+				}
+			}
+			index++
+		}
+		values[param.Name] = value
+	}
+	return values, nil
+}
+
+// decodeStaticValue decodes a word holding a value of a static ABI type.
+func decodeStaticValue(abiType string, word []byte) (interface{}, error) {
+	switch {
+	case abiType == "address":
+		return ToChecksumAddress(abiAddress(word)), nil
+	case abiType == "bool":
+		return abiUint(word).Sign() != 0, nil
+	case strings.HasPrefix(abiType, "uint"):
+		return abiUint(word), nil
+	case strings.HasPrefix(abiType, "int"):
+		value := abiUint(word)
+		if word[0]&0x80 != 0 { // Negative in two's complement
+			value.Sub(value, new(big.Int).Lsh(big.NewInt(1), 8*abiWordSize))
+		}
+		return value, nil
+	case strings.HasPrefix(abiType, "bytes"):
+		size, err := strconv.Atoi(strings.TrimPrefix(abiType, "bytes"))
+		if err != nil || size < 1 || size > abiWordSize {
+			return nil, fmt.Errorf("unsupported type %v", abiType)
+		}
+		return "0x" + hex.EncodeToString(word[:size]), nil
+	}
+	return nil, fmt.Errorf("unsupported type %v", abiType)
+}
+
+// ContractEventSubscription delivers the decoded logs of a contract event.
+type ContractEventSubscription struct {
+	Parser          *EthereumParser
+	ContractAddress string
+	EventSchema     EventSchema
+}
+
+// NewContractEventSubscription initializes a subscription to an event of a contract.
+func NewContractEventSubscription(parser *EthereumParser, contract string, schema EventSchema) *ContractEventSubscription {
+	return &ContractEventSubscription{Parser: parser, ContractAddress: contract, EventSchema: schema}
+}
+
+// Subscribe installs a filter for the event from fromBlock on, and sends the
+// decoded parameters of its matching logs to the returned channel: first the
+// logs already in the chain, then new ones, polled every WatchInterval. Logs
+// that fail to decode or were removed by a reorg are skipped. A filter the
+// node expired is reinstalled from the last block seen. The channel is closed
+// and the filter uninstalled once ctx is cancelled.
+func (subscription *ContractEventSubscription) Subscribe(ctx context.Context, fromBlock uint64) (<-chan map[string]interface{}, error) {
+	parser := subscription.Parser
+	filterID, err := subscription.install(ctx, fromBlock)
+	if err != nil {
+		return nil, err
+	}
+	logs, err := parser.GetFilterLogs(ctx, filterID)
+	if err != nil {
+		parser.UninstallLogFilter(ctx, filterID)
+		return nil, fmt.Errorf("failed to get event logs: %w", err)
+	}
+
+	events := make(chan map[string]interface{})
+	go func() {
+		defer close(events)
+		defer func() {
+			uninstallCtx, cancel := context.WithTimeout(context.Background(), eventUninstallTimeout)
+			defer cancel()
+			parser.UninstallLogFilter(uninstallCtx, filterID)
+		}()
+
+		next := fromBlock // Block from which a reinstalled filter starts
+		for {
+			for _, log := range logs {
+				if number, err := ParseHexUint64(log.BlockNumber); err == nil {
+					next = max(next, number)
+				}
+				if log.Removed {
+					continue
+				}
+				values, err := DecodeLog(subscription.EventSchema, log)
+				if err != nil {
+					parser.logger.WarnContext(ctx, "failed to decode event log", "event", subscription.EventSchema.Name, "transaction", log.TransactionHash, "error", err)
+					continue
+				}
+				select {
+				case events <- values:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-parser.clock.After(parser.settings().WatchInterval):
+			}
+
+			logs, err = parser.rpcEthGetFilterChanges(ctx, filterID)
+			if isFilterNotFound(err) {
+				// Logs of the last block seen may be delivered again
+				if filterID, err = subscription.install(ctx, next); err == nil {
+					logs, err = parser.GetFilterLogs(ctx, filterID)
+				}
+			}
+			if err != nil {
+				if ctx.Err() == nil {
+					parser.logger.ErrorContext(ctx, "failed to poll event logs", "event", subscription.EventSchema.Name, "error", err)
+				}
+				logs = nil
+			}
+		}
+	}()
+	return events, nil
+}
+
+// install creates the node filter of the subscription, from fromBlock to the
+// latest block.
+func (subscription *ContractEventSubscription) install(ctx context.Context, fromBlock uint64) (string, error) {
+	params := LogFilter{
+		FromBlock: fromBlock,
+		Address:   subscription.ContractAddress,
+		Topics:    [][]string{{subscription.EventSchema.Topic()}},
+	}.params()
+	params["toBlock"] = "latest"
+
+	filterID, err := subscription.Parser.rpcEthNewFilter(ctx, params)
+	if err != nil {
+		return "", fmt.Errorf("failed to create event filter: %w", err)
+	}
+	return filterID, nil
+}
+
+// ContractWatcher queries the logs of a contract's events, looked up by name.
+type ContractWatcher struct {
+	Parser  *EthereumParser
+	Address string
+	ABI     map[string]EventSchema // Keyed by event name
+}
+
+// NewContractWatcher initializes a ContractWatcher without events, which
+// AddEvent registers.
+func NewContractWatcher(parser *EthereumParser, address string) *ContractWatcher {
+	return &ContractWatcher{Parser: parser, Address: address, ABI: make(map[string]EventSchema)}
+}
+
+// AddEvent registers the schema of an event under name, and returns the
+// watcher so that calls can be chained.
+func (watcher *ContractWatcher) AddEvent(name string, schema EventSchema) *ContractWatcher {
+	watcher.ABI[name] = schema
+	return watcher
+}
+
+// GetLogs returns the decoded parameters of the logs the contract emitted for
+// an event between fromBlock and toBlock, both included.
+func (watcher *ContractWatcher) GetLogs(ctx context.Context, eventName string, fromBlock, toBlock uint64) ([]map[string]interface{}, error) {
+	schema, ok := watcher.ABI[eventName]
+	if !ok {
+		return nil, fmt.Errorf("unknown event %v", eventName)
+	}
+
+	logs, err := watcher.Parser.GetLogs(ctx, LogFilter{
+		FromBlock: fromBlock,
+		ToBlock:   toBlock,
+		Address:   watcher.Address,
+		Topics:    [][]string{{schema.Topic()}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %v logs: %w", eventName, err)
+	}
+
+	events := make([]map[string]interface{}, 0, len(logs))
+	for _, log := range logs {
+		values, err := DecodeLog(schema, log)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %v log of transaction %v: %w", eventName, log.TransactionHash, err)
+		}
+		events = append(events, values)
+	}
+	return events, nil
+}
@@ -0,0 +1,325 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// FakeNode is an http.RoundTripper answering the JSON-RPC requests of a
+// parser from a chain the caller programs, for tests: blocks are added with
+// AddBlock, which advances the head, and replaced with ReplaceBlocks. The
+// pending block holds the pool of SetPending, and the nonces of the addresses
+// are set with SetNonce. Calls can be delayed with SetLatency and made to fail
+// by method with Fail.
+type FakeNode struct {
+	node *simulatedNode
+
+	mu       sync.Mutex
+	latency  time.Duration
+	failures map[string][]error // Errors of the next calls, by method
+	calls    map[string]int
+	pending  []Transaction     // Transaction pool, nil to serve the head as the pending block
+	nonces   map[string]uint64 // Nonces of the next transactions to be mined, by address
+}
+
+// NewFakeNode returns a node whose chain holds the blocks, which must follow
+// each other.
+func NewFakeNode(blocks ...*Block) (*FakeNode, error) {
+	node := &FakeNode{
+		node:     newSimulatedNode(nil, 0, 0, RealClock{}, slog.New(slog.DiscardHandler)),
+		failures: make(map[string][]error),
+		calls:    make(map[string]int),
+		nonces:   make(map[string]uint64),
+	}
+	for _, block := range blocks {
+		if err := node.AddBlock(block); err != nil {
+			return nil, err
+		}
+	}
+	return node, nil
+}
+
+// NewParser returns a parser over a MemoryStorage calling the node, which
+// checks neither the health of the node nor whether it syncs.
+func (node *FakeNode) NewParser(opts ...Option) *EthereumParser {
+	opts = append([]Option{WithHTTPClient(&http.Client{Transport: node}), WithLogger(slog.New(slog.DiscardHandler)), WithHealthCheckInterval(0), WithSyncCheckInterval(0)}, opts...)
+	return NewEthereumParser("http://fake-node", NewMemoryStorage(), opts...)
+}
+
+// AddBlock extends the chain with the block, with the logs served by
+// eth_getLogs and eth_getTransactionReceipt.
+func (node *FakeNode) AddBlock(block *Block, logs ...Log) error {
+	simulated, err := fakeSimulatedBlock(block, logs)
+	if err != nil {
+		return err
+	}
+	node.node.mu.Lock()
+	defer node.node.mu.Unlock()
+	if len(node.node.chain) > 0 {
+		if head := node.node.chain[len(node.node.chain)-1]; simulated.number != head.number+1 {
+			return fmt.Errorf("block %d does not extend the chain at block %d", simulated.number, head.number)
+		}
+	}
+	node.node.chain = append(node.node.chain, simulated)
+	node.node.byHash[simulated.hash] = simulated
+	return nil
+}
+
+// ReplaceBlocks reorganizes the chain: the blocks from the number of the first
+// block on are replaced by the blocks. The replaced blocks are still served by
+// hash.
+func (node *FakeNode) ReplaceBlocks(blocks ...*Block) error {
+	if len(blocks) == 0 {
+		return errors.New("no blocks to replace the chain with")
+	}
+	first, err := ParseHexUint64(blocks[0].Number)
+	if err != nil {
+		return err
+	}
+	node.node.mu.Lock()
+	if len(node.node.chain) == 0 || first <= node.node.chain[0].number || first > node.node.chain[len(node.node.chain)-1].number+1 {
+		node.node.mu.Unlock()
+		return fmt.Errorf("cannot replace the chain from block %d", first)
+	}
+	node.node.chain = node.node.chain[:first-node.node.chain[0].number]
+	node.node.mu.Unlock()
+	for _, block := range blocks {
+		if err := node.AddBlock(block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Head returns the number of the newest block, 0 when the chain is empty.
+func (node *FakeNode) Head() uint64 {
+	node.node.mu.Lock()
+	defer node.node.mu.Unlock()
+	if len(node.node.chain) == 0 {
+		return 0
+	}
+	return node.node.chain[len(node.node.chain)-1].number
+}
+
+// SetLatency delays the answer of every call.
+func (node *FakeNode) SetLatency(latency time.Duration) {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	node.latency = latency
+}
+
+// Fail makes the next calls of the method fail, one with each error: an
+// *RPCError is answered by the node, other errors fail in transit and nil
+// lets the call through.
+func (node *FakeNode) Fail(method string, errs ...error) {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	node.failures[method] = append(node.failures[method], errs...)
+}
+
+// SetPending replaces the transaction pool, which the pending block holds.
+func (node *FakeNode) SetPending(transactions ...Transaction) {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	node.pending = append([]Transaction{}, transactions...)
+}
+
+// SetNonce sets the nonce of the next transaction of the address to be mined,
+// which eth_getTransactionCount returns for the latest block. For the pending
+// block, it counts the transactions of the pool that follow it.
+func (node *FakeNode) SetNonce(address string, nonce uint64) {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	node.nonces[NormalizeAddress(address)] = nonce
+}
+
+// Calls returns the number of calls of the method answered so far.
+func (node *FakeNode) Calls(method string) int {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	return node.calls[method]
+}
+
+func (node *FakeNode) RoundTrip(request *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(request)
+	if err != nil {
+		return nil, err
+	}
+	var call struct {
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+		ID     json.RawMessage   `json:"id"`
+	}
+	json.Unmarshal(body, &call)
+
+	node.mu.Lock()
+	latency := node.latency
+	node.calls[call.Method]++
+	var failure error
+	if failures := node.failures[call.Method]; len(failures) > 0 {
+		failure, node.failures[call.Method] = failures[0], failures[1:]
+	}
+	node.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-request.Context().Done():
+			return nil, request.Context().Err()
+		}
+	}
+	switch failure := failure.(type) {
+	case nil:
+	case *RPCError:
+		return jsonResponse(request, map[string]interface{}{"jsonrpc": "2.0", "id": call.ID, "error": failure})
+	default:
+		return nil, failure
+	}
+
+	var first, second string
+	if len(call.Params) > 1 {
+		json.Unmarshal(call.Params[1], &second)
+	}
+	if len(call.Params) > 0 {
+		json.Unmarshal(call.Params[0], &first)
+	}
+	switch {
+	case call.Method == "eth_getTransactionReceipt" && first != "":
+		return jsonResponse(request, map[string]interface{}{"jsonrpc": "2.0", "id": call.ID, "result": node.receipt(first)})
+	case call.Method == "eth_getTransactionCount":
+		return jsonResponse(request, map[string]interface{}{"jsonrpc": "2.0", "id": call.ID, "result": fmt.Sprintf("0x%x", node.nonce(first, second))})
+	case call.Method == "eth_getBlockByNumber" && first == "pending":
+		if block := node.pendingBlock(); block != nil {
+			return jsonResponse(request, map[string]interface{}{"jsonrpc": "2.0", "id": call.ID, "result": block})
+		}
+	}
+	request.Body = io.NopCloser(bytes.NewReader(body))
+	return node.node.RoundTrip(request)
+}
+
+// nonce returns the nonce of the next transaction of the address in the
+// block tag: that mined next, or for the pending block that after the
+// transactions of the pool following it.
+func (node *FakeNode) nonce(address, tag string) uint64 {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	address = NormalizeAddress(address)
+	nonce := node.nonces[address]
+	for tag == "pending" && slices.ContainsFunc(node.pending, func(transaction Transaction) bool {
+		return NormalizeAddress(transaction.From) == address && transaction.Nonce == fmt.Sprintf("0x%x", nonce)
+	}) {
+		nonce++
+	}
+	return nonce
+}
+
+// pendingBlock returns the block after the head holding the pool, or nil
+// when no pool is set.
+func (node *FakeNode) pendingBlock() *Block {
+	node.mu.Lock()
+	if node.pending == nil {
+		node.mu.Unlock()
+		return nil
+	}
+	pending := append([]Transaction{}, node.pending...)
+	node.mu.Unlock()
+
+	node.node.mu.Lock()
+	var number uint64
+	var parentHash string
+	if len(node.node.chain) > 0 {
+		head := node.node.chain[len(node.node.chain)-1]
+		number, parentHash = head.number+1, head.hash
+	}
+	node.node.mu.Unlock()
+	for i := range pending {
+		pending[i].BlockNumber = fmt.Sprintf("0x%x", number)
+	}
+	return &Block{
+		BlockHeader: BlockHeader{
+			Number:     fmt.Sprintf("0x%x", number),
+			Hash:       Keccak256Hex(fmt.Sprintf("block pending %d", number)),
+			ParentHash: parentHash,
+			Timestamp:  fmt.Sprintf("0x%x", 1_700_000_000+12*number),
+		},
+		Transactions: pending,
+	}
+}
+
+// receipt returns the receipt of a successful transaction of the chain, or
+// nil when it is not in the chain.
+func (node *FakeNode) receipt(hash string) *TransactionReceipt {
+	node.node.mu.Lock()
+	defer node.node.mu.Unlock()
+	for _, block := range node.node.chain {
+		if !blockHolds(block, hash) {
+			continue
+		}
+		receipt := &TransactionReceipt{TransactionHash: hash, Status: "0x1", GasUsed: "0x5208", EffectiveGasPrice: "0x3b9aca00", Logs: []Log{}}
+		for _, log := range block.logs {
+			if log.TransactionHash == hash {
+				receipt.Logs = append(receipt.Logs, log)
+			}
+		}
+		return receipt
+	}
+	return nil
+}
+
+// blockHolds reports whether a simulated block holds the transaction.
+func blockHolds(block *simulatedBlock, hash string) bool {
+	var fields struct {
+		Transactions []string `json:"transactions"`
+	}
+	json.Unmarshal(block.summary, &fields)
+	return slices.Contains(fields.Transactions, hash)
+}
+
+// fakeSimulatedBlock converts a block to the forms a simulatedNode serves.
+func fakeSimulatedBlock(block *Block, logs []Log) (*simulatedBlock, error) {
+	full, err := json.Marshal(block)
+	if err != nil {
+		return nil, err
+	}
+	transactions := make([]json.RawMessage, len(block.Transactions))
+	for i, transaction := range block.Transactions {
+		if transactions[i], err = json.Marshal(transaction); err != nil {
+			return nil, err
+		}
+	}
+	simulated, err := newSimulatedBlock(full, block.Number, block.Hash, transactions)
+	if err != nil {
+		return nil, err
+	}
+	for i := range logs {
+		logs[i].BlockNumber = block.Number
+	}
+	simulated.logs = logs
+	return simulated, nil
+}
+
+// jsonResponse returns a response of the value in JSON.
+func jsonResponse(request *http.Request, value interface{}) (*http.Response, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(data)),
+		Request:    request,
+	}, nil
+}
@@ -0,0 +1,169 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// testWatchInterval is the polling interval of the Watch loops of the tests.
+const testWatchInterval = 10 * time.Millisecond
+
+// receive returns the next transaction of out, failing the test when none is
+// sent within a second.
+func receive(t *testing.T, out <-chan Transaction) Transaction {
+	t.Helper()
+	select {
+	case transaction := <-out:
+		return transaction
+	case <-time.After(time.Second):
+		t.Fatal("no transaction received")
+		return Transaction{}
+	}
+}
+
+// receiveNone fails the test when out sends a transaction within wait.
+func receiveNone(t *testing.T, out <-chan Transaction, wait time.Duration) {
+	t.Helper()
+	select {
+	case transaction := <-out:
+		t.Fatalf("received %v, want none", transaction.Hash)
+	case <-time.After(wait):
+	}
+}
+
+// startWatch runs WatchFromBlock from block from until the test ends.
+func startWatch(t *testing.T, parser *EthereumParser, from uint64) <-chan Transaction {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	out := make(chan Transaction, 16)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		parser.WatchFromBlock(ctx, from, out)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return out
+}
+
+// TestFakeNodeReorg replaces the head block once Watch processed it. The
+// transactions of the replacing block are dispatched once the split resolves.
+func TestFakeNodeReorg(t *testing.T) {
+	orphaned := Transaction{Hash: "0xa", From: checksummedAddress, To: otherAddress}
+	canonical := Transaction{Hash: "0xb", From: checksummedAddress, To: otherAddress}
+	node := newFakeNode(t, testBlock(1), testBlock(2), testBlock(3, orphaned))
+	parser := node.NewParser(WithChain(Chain{PollInterval: testWatchInterval}))
+	if _, err := parser.SubscribeAddress(checksummedAddress); err != nil {
+		t.Fatal(err)
+	}
+	out := startWatch(t, parser, 1)
+
+	if transaction := receive(t, out); transaction.Hash != orphaned.Hash {
+		t.Fatalf("received %v, want %v", transaction.Hash, orphaned.Hash)
+	}
+	replacement := forkBlock(3, "b", canonical)
+	replacement.ParentHash = testBlockHash(2, "")
+	node.ReplaceBlocks(replacement, forkBlock(4, "b"), forkBlock(5, "b"))
+
+	if transaction := receive(t, out); transaction.Hash != canonical.Hash {
+		t.Fatalf("received %v after the reorg, want %v", transaction.Hash, canonical.Hash)
+	}
+	select {
+	case event := <-parser.ChainSplits():
+		if event.BlockNumber != 3 || len(event.BlockHashes) != 2 || event.BlockHashes[1] != replacement.Hash {
+			t.Errorf("ChainSplitEvent = %+v, want block 3 replaced by %v", event, replacement.Hash)
+		}
+	default:
+		t.Error("no ChainSplitEvent")
+	}
+	if reorgs := parser.Metrics().ReorgsHandled; reorgs != 1 {
+		t.Errorf("ReorgsHandled = %d, want 1", reorgs)
+	}
+}
+
+// TestFakeNodeConfirmations watches with 2 confirmations: a block is
+// processed once 2 blocks are on top of it.
+func TestFakeNodeConfirmations(t *testing.T) {
+	third := Transaction{Hash: "0x3", From: otherAddress, To: checksummedAddress}
+	fourth := Transaction{Hash: "0x4", From: otherAddress, To: checksummedAddress}
+	node := newFakeNode(t, testBlock(1), testBlock(2), testBlock(3, third), testBlock(4, fourth), testBlock(5))
+	parser := node.NewParser(WithChain(Chain{PollInterval: testWatchInterval, Confirmations: 2}))
+	if _, err := parser.SubscribeAddress(checksummedAddress); err != nil {
+		t.Fatal(err)
+	}
+	out := startWatch(t, parser, 1)
+
+	if transaction := receive(t, out); transaction.Hash != third.Hash {
+		t.Fatalf("received %v, want %v of the confirmed block 3", transaction.Hash, third.Hash)
+	}
+	receiveNone(t, out, 10*testWatchInterval)
+	node.AddBlock(testBlock(6))
+	if transaction := receive(t, out); transaction.Hash != fourth.Hash {
+		t.Fatalf("received %v, want %v once block 4 is confirmed", transaction.Hash, fourth.Hash)
+	}
+}
+
+// TestFakeNodeRetry fails calls in transit, which are retried, and on the
+// node, which are not.
+func TestFakeNodeRetry(t *testing.T) {
+	node := newFakeNode(t, testBlock(1), testBlock(2))
+	parser := node.NewParser()
+
+	reset := errors.New("connection reset by peer")
+	node.Fail("eth_blockNumber", reset, reset)
+	if block := parser.GetCurrentBlock(); block != 2 {
+		t.Errorf("GetCurrentBlock after two failures in transit = %d, want 2", block)
+	}
+	if calls := node.Calls("eth_blockNumber"); calls != 3 {
+		t.Errorf("eth_blockNumber called %d times, want 3", calls)
+	}
+
+	node.Fail("eth_blockNumber", &RPCError{Code: -32000, Message: "header not found"})
+	if _, err := parser.blockNumber(context.Background()); !errors.As(err, new(*RPCError)) {
+		t.Errorf("blockNumber error = %v, want the RPCError of the node", err)
+	}
+	if calls := node.Calls("eth_blockNumber"); calls != 4 {
+		t.Errorf("eth_blockNumber called %d times, want a single call for the error of the node", calls-3)
+	}
+}
+
+// TestFakeNodeLatency times out calls slower than the RPC timeout.
+func TestFakeNodeLatency(t *testing.T) {
+	node := newFakeNode(t, testBlock(1))
+	node.SetLatency(time.Second)
+	parser := node.NewParser(WithRPCTimeouts(50*time.Millisecond, nil))
+
+	start := time.Now()
+	_, err := parser.blockNumber(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("blockNumber error = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("blockNumber took %v, want it bounded by the 50ms timeout", elapsed)
+	}
+}
+
+func TestFakeNodeReceipt(t *testing.T) {
+	transfer := Transaction{Hash: Keccak256Hex("transfer"), From: checksummedAddress, To: otherAddress}
+	log := Log{Address: otherAddress, Topics: []string{approvalTopic}, TransactionHash: transfer.Hash, LogIndex: "0x0"}
+	node := newFakeNode(t, testBlock(1))
+	node.AddBlock(testBlock(2, transfer), log)
+	parser := node.NewParser()
+
+	receipt, err := parser.GetTransactionReceipt(context.Background(), transfer.Hash)
+	if err != nil || receipt.Status != "0x1" || len(receipt.Logs) != 1 {
+		t.Errorf("GetTransactionReceipt = %+v, %v, want a successful receipt with the log", receipt, err)
+	}
+	receipt, err = parser.GetTransactionReceipt(context.Background(), Keccak256Hex("unknown"))
+	if err == nil {
+		t.Errorf("GetTransactionReceipt of an unknown transaction = %+v, want an error", receipt)
+	}
+	logs, err := parser.GetLogs(context.Background(), LogFilter{FromBlock: 2, ToBlock: 2})
+	if err != nil || len(logs) != 1 || logs[0].BlockNumber != "0x2" {
+		t.Errorf("GetLogs = %v, %v, want the log of block 2", logs, err)
+	}
+}
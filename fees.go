@@ -0,0 +1,220 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// FeeHistory is the base fee and gas usage of a range of blocks, as returned by eth_feeHistory.
+type FeeHistory struct {
+	OldestBlock   string     `json:"oldestBlock"`
+	BaseFeePerGas []string   `json:"baseFeePerGas"` // One per block, followed by the base fee of the next block
+	GasUsedRatio  []float64  `json:"gasUsedRatio"`
+	Reward        [][]string `json:"reward,omitempty"` // Tips at the requested percentiles, one list per block
+}
+
+// feeSuggester is implemented by parsers that can suggest EIP-1559 fees.
+type feeSuggester interface {
+	SuggestEIP1559Fees(ctx context.Context) (baseFee, maxPriorityFee, maxFee *big.Int, err error)
+}
+
+// GetFeeHistory returns the fee history of blockCount blocks up to newestBlock,
+// with the tips paid at each of the reward percentiles.
+func (parser *EthereumParser) GetFeeHistory(ctx context.Context, blockCount uint64, newestBlock string, rewardPercentiles []float64) (*FeeHistory, error) {
+	history, err := parser.rpcEthFeeHistory(ctx, fmt.Sprintf("0x%x", blockCount), newestBlock, rewardPercentiles)
+	if err != nil {
+		return nil, err
+	}
+	return &history, nil
+}
+
+// GetMaxPriorityFeePerGas returns the node's suggested EIP-1559 tip in wei.
+func (parser *EthereumParser) GetMaxPriorityFeePerGas(ctx context.Context) (*big.Int, error) {
+	tipHex, err := parser.rpcEthMaxPriorityFeePerGas(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return parseHexBig(tipHex)
+}
+
+// SuggestEIP1559Fees suggests the fees of an EIP-1559 transaction: the base fee
+// of the next block, the node's suggested tip, and a fee cap of twice the base
+// fee plus the tip, which stays valid through several full blocks.
+func (parser *EthereumParser) SuggestEIP1559Fees(ctx context.Context) (baseFee, maxPriorityFee, maxFee *big.Int, err error) {
+	history, err := parser.GetFeeHistory(ctx, 1, "latest", []float64{})
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get fee history: %w", err)
+	}
+	if len(history.BaseFeePerGas) == 0 {
+		return nil, nil, nil, errors.New("fee history has no base fee")
+	}
+	baseFee, err = parseHexBig(history.BaseFeePerGas[len(history.BaseFeePerGas)-1])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	maxPriorityFee, err = parser.GetMaxPriorityFeePerGas(ctx)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get max priority fee: %w", err)
+	}
+
+	maxFee = new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), maxPriorityFee)
+	return baseFee, maxPriorityFee, maxFee, nil
+}
+
+// feeSuggestion is the result of GET /fees, in wei.
+type feeSuggestion struct {
+	BaseFeePerGas        *big.Int `json:"baseFeePerGas"`
+	MaxPriorityFeePerGas *big.Int `json:"maxPriorityFeePerGas"`
+	MaxFeePerGas         *big.Int `json:"maxFeePerGas"`
+}
+
+const (
+	// feeEstimateBlocks is the number of recent blocks EstimateFees reads the
+	// base fee trend and the tips from.
+	feeEstimateBlocks = 20
+	// baseFeeMaxChange is the factor by which the base fee rises at most from
+	// one block to the next, per EIP-1559.
+	baseFeeMaxChange = 1.125
+)
+
+// feeEstimatePercentiles are the tips read from the fee history for slow,
+// standard and fast inclusion.
+var feeEstimatePercentiles = []float64{10, 50, 90}
+
+// FeeEstimate suggests the fees of a transaction, in wei. Slow, Standard and
+// Fast are maxFeePerGas values that stay valid while the base fee rises for
+// about one, three and six full blocks. On legacy chains without EIP-1559 they
+// are gas prices, and BaseFee and SuggestedPriorityFee are nil.
+type FeeEstimate struct {
+	Legacy               bool     `json:"legacy"`
+	BaseFee              *big.Int `json:"baseFee"` // Of the next block
+	SuggestedPriorityFee *big.Int `json:"suggestedPriorityFee"`
+	Slow                 *big.Int `json:"slow"`
+	Standard             *big.Int `json:"standard"`
+	Fast                 *big.Int `json:"fast"`
+}
+
+// FeeEstimator is implemented by parsers that can estimate fees.
+type FeeEstimator interface {
+	EstimateFees(ctx context.Context) (FeeEstimate, error)
+}
+
+// EstimateFees suggests fees from the base fees and tips of the last blocks.
+// The priority fee is the node's suggestion, or the median tip when the node
+// does not suggest one. Chains without base fees fall back to eth_gasPrice.
+func (parser *EthereumParser) EstimateFees(ctx context.Context) (FeeEstimate, error) {
+	history, err := parser.GetFeeHistory(ctx, feeEstimateBlocks, "latest", feeEstimatePercentiles)
+	if err != nil && !isMethodNotSupported(err) {
+		return FeeEstimate{}, fmt.Errorf("failed to get fee history: %w", err)
+	}
+	if err != nil || len(history.BaseFeePerGas) == 0 {
+		return parser.estimateLegacyFees(ctx)
+	}
+	baseFees := make([]*big.Int, len(history.BaseFeePerGas))
+	for i, baseFeeHex := range history.BaseFeePerGas {
+		if baseFees[i], err = parseHexBig(baseFeeHex); err != nil {
+			return FeeEstimate{}, fmt.Errorf("invalid base fee: %w", err)
+		}
+	}
+	next := baseFees[len(baseFees)-1]
+	if next.Sign() == 0 {
+		return parser.estimateLegacyFees(ctx)
+	}
+
+	tips := make([]*big.Int, len(feeEstimatePercentiles))
+	for i := range tips {
+		if tips[i], err = medianReward(history.Reward, i); err != nil {
+			return FeeEstimate{}, err
+		}
+	}
+	priorityFee, err := parser.GetMaxPriorityFeePerGas(ctx)
+	if err != nil {
+		parser.logger.DebugContext(ctx, "falling back to the fee history tip", "error", err)
+		priorityFee = tips[1]
+	}
+
+	// Slow fees leave no room for the base fee to rise unless it has been rising
+	slowBase := next
+	if next.Cmp(meanBigInt(baseFees)) > 0 {
+		slowBase = scaleBigInt(next, baseFeeMaxChange)
+	}
+	standardBase := scaleBigInt(next, baseFeeMaxChange*baseFeeMaxChange*baseFeeMaxChange)
+	for _, baseFee := range baseFees {
+		if baseFee.Cmp(standardBase) > 0 {
+			standardBase = baseFee // Spikes of the recent blocks may come back
+		}
+	}
+	fastBase := new(big.Int).Mul(next, big.NewInt(2))
+
+	return FeeEstimate{
+		BaseFee:              next,
+		SuggestedPriorityFee: priorityFee,
+		Slow:                 new(big.Int).Add(slowBase, tips[0]),
+		Standard:             new(big.Int).Add(standardBase, priorityFee),
+		Fast:                 new(big.Int).Add(fastBase, maxBigInt(tips[2], priorityFee)),
+	}, nil
+}
+
+// estimateLegacyFees suggests gas prices around the node's eth_gasPrice.
+func (parser *EthereumParser) estimateLegacyFees(ctx context.Context) (FeeEstimate, error) {
+	gasPriceHex, err := parser.rpcEthGasPrice(ctx)
+	if err != nil {
+		return FeeEstimate{}, fmt.Errorf("failed to get gas price: %w", err)
+	}
+	gasPrice, err := parseHexBig(gasPriceHex)
+	if err != nil {
+		return FeeEstimate{}, fmt.Errorf("invalid gas price: %w", err)
+	}
+	return FeeEstimate{
+		Legacy:   true,
+		Slow:     scaleBigInt(gasPrice, 0.9),
+		Standard: gasPrice,
+		Fast:     scaleBigInt(gasPrice, 1.25),
+	}, nil
+}
+
+// medianReward returns the median across blocks of the tips at a percentile
+// of the fee history, zero when it has none.
+func medianReward(rewards [][]string, percentile int) (*big.Int, error) {
+	var tips []*big.Int
+	for _, blockRewards := range rewards {
+		if percentile >= len(blockRewards) {
+			continue
+		}
+		tip, err := parseHexBig(blockRewards[percentile])
+		if err != nil {
+			return nil, fmt.Errorf("invalid reward: %w", err)
+		}
+		tips = append(tips, tip)
+	}
+	if len(tips) == 0 {
+		return new(big.Int), nil
+	}
+	sort.Slice(tips, func(i, j int) bool { return tips[i].Cmp(tips[j]) < 0 })
+	return tips[len(tips)/2], nil
+}
+
+// scaleBigInt returns value multiplied by factor, rounded down.
+func scaleBigInt(value *big.Int, factor float64) *big.Int {
+	scaled, _ := new(big.Float).Mul(new(big.Float).SetInt(value), big.NewFloat(factor)).Int(nil)
+	return scaled
+}
+
+func meanBigInt(values []*big.Int) *big.Int {
+	sum := new(big.Int)
+	for _, value := range values {
+		sum.Add(sum, value)
+	}
+	return sum.Div(sum, big.NewInt(int64(len(values))))
+}
+
+func maxBigInt(a, b *big.Int) *big.Int {
+	if a.Cmp(b) > 0 {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,184 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultFilterKeepAlive is the default interval between the polls that keep
+// managed filters from expiring. Nodes commonly expire filters after 5 minutes.
+const defaultFilterKeepAlive = time.Minute
+
+// ErrUnknownFilter is returned by FilterManager for handles it does not manage.
+var ErrUnknownFilter = errors.New("unknown log filter")
+
+// CreateLogFilter installs a filter on the node and returns its ID.
+func (parser *EthereumParser) CreateLogFilter(ctx context.Context, filter LogFilter) (string, error) {
+	return parser.rpcEthNewFilter(ctx, filter.params())
+}
+
+// GetFilterLogs returns every log matching an installed filter.
+func (parser *EthereumParser) GetFilterLogs(ctx context.Context, filterID string) ([]Log, error) {
+	return parser.rpcEthGetFilterLogs(ctx, filterID)
+}
+
+// UninstallLogFilter removes a filter from the node, reporting whether it was installed.
+func (parser *EthereumParser) UninstallLogFilter(ctx context.Context, filterID string) (bool, error) {
+	return parser.rpcEthUninstallFilter(ctx, filterID)
+}
+
+// isFilterNotFound reports whether the node rejected a filter ID it does not
+// know, usually because the filter expired.
+func isFilterNotFound(err error) bool {
+	var rpcErr *RPCError
+	return errors.As(err, &rpcErr) && strings.Contains(rpcErr.Message, "filter not found")
+}
+
+// FilterManager installs log filters and keeps them alive, reinstalling those
+// the node expired. Filters are referred to by a handle, the ID of the filter
+// when it was first installed, which stays valid across reinstalls.
+type FilterManager struct {
+	parser    *EthereumParser
+	KeepAlive time.Duration // Interval between the polls of each filter in Run
+
+	mu      sync.Mutex
+	filters map[string]*managedFilter // Keyed by handle
+}
+
+// managedFilter is a filter installed by a FilterManager.
+type managedFilter struct {
+	filter LogFilter
+	id     string // ID of the filter currently installed on the node
+}
+
+// NewFilterManager initializes a FilterManager installing filters with the parser.
+func NewFilterManager(parser *EthereumParser) *FilterManager {
+	return &FilterManager{
+		parser:    parser,
+		KeepAlive: defaultFilterKeepAlive,
+		filters:   make(map[string]*managedFilter),
+	}
+}
+
+// Install installs a filter and returns its handle.
+func (manager *FilterManager) Install(ctx context.Context, filter LogFilter) (string, error) {
+	id, err := manager.parser.CreateLogFilter(ctx, filter)
+	if err != nil {
+		return "", fmt.Errorf("failed to create log filter: %w", err)
+	}
+
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	manager.filters[id] = &managedFilter{filter: filter, id: id}
+	return id, nil
+}
+
+// Logs returns every log matching the filter, reinstalling it first if the
+// node expired it.
+func (manager *FilterManager) Logs(ctx context.Context, handle string) ([]Log, error) {
+	id, err := manager.currentID(handle)
+	if err != nil {
+		return nil, err
+	}
+	logs, err := manager.parser.GetFilterLogs(ctx, id)
+	if isFilterNotFound(err) {
+		if id, err = manager.reinstall(ctx, handle); err != nil {
+			return nil, err
+		}
+		logs, err = manager.parser.GetFilterLogs(ctx, id)
+	}
+	return logs, err
+}
+
+// Uninstall removes the filter from the node and stops managing it.
+func (manager *FilterManager) Uninstall(ctx context.Context, handle string) error {
+	manager.mu.Lock()
+	managed, ok := manager.filters[handle]
+	delete(manager.filters, handle)
+	manager.mu.Unlock()
+	if !ok {
+		return ErrUnknownFilter
+	}
+
+	// An expired filter is already gone from the node
+	if _, err := manager.parser.UninstallLogFilter(ctx, managed.id); err != nil && !isFilterNotFound(err) {
+		return fmt.Errorf("failed to uninstall log filter: %w", err)
+	}
+	return nil
+}
+
+// Run polls every managed filter each KeepAlive interval, which resets the
+// node's expiry timer, and reinstalls the filters that expired anyway. The
+// changes returned by the polls are discarded; use Logs to read the logs. It
+// blocks until ctx is cancelled.
+func (manager *FilterManager) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-manager.parser.clock.After(manager.KeepAlive):
+		}
+
+		for _, handle := range manager.handles() {
+			id, err := manager.currentID(handle)
+			if err != nil {
+				continue // Uninstalled meanwhile
+			}
+			if _, err := manager.parser.rpcEthGetFilterChanges(ctx, id); err == nil || ctx.Err() != nil {
+				continue
+			}
+			if _, err := manager.reinstall(ctx, handle); err != nil {
+				manager.parser.logger.ErrorContext(ctx, "failed to reinstall log filter", "filter", handle, "error", err)
+			}
+		}
+	}
+}
+
+// handles returns the handles of the managed filters.
+func (manager *FilterManager) handles() []string {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	handles := make([]string, 0, len(manager.filters))
+	for handle := range manager.filters {
+		handles = append(handles, handle)
+	}
+	return handles
+}
+
+// currentID returns the ID of the filter currently installed for a handle.
+func (manager *FilterManager) currentID(handle string) (string, error) {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	managed, ok := manager.filters[handle]
+	if !ok {
+		return "", ErrUnknownFilter
+	}
+	return managed.id, nil
+}
+
+// reinstall installs the filter of a handle again and returns its new ID.
+func (manager *FilterManager) reinstall(ctx context.Context, handle string) (string, error) {
+	manager.mu.Lock()
+	managed, ok := manager.filters[handle]
+	manager.mu.Unlock()
+	if !ok {
+		return "", ErrUnknownFilter
+	}
+
+	id, err := manager.parser.CreateLogFilter(ctx, managed.filter)
+	if err != nil {
+		return "", fmt.Errorf("failed to reinstall log filter: %w", err)
+	}
+	manager.parser.logger.InfoContext(ctx, "reinstalled log filter", "filter", handle, "id", id)
+
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	managed.id = id
+	return id, nil
+}
@@ -0,0 +1,138 @@
+package parser
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// LoadFixture returns the content of the named file of testdata.
+func LoadFixture(t testing.TB, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to load fixture: %v", err)
+	}
+	return data
+}
+
+// NewFixtureServer returns a node answering each JSON-RPC call with the
+// response of fixtures keyed by its method, with the ID of the call. Other
+// methods are answered with the error of error_method_not_found_response.json.
+// Batch requests are answered call by call.
+func NewFixtureServer(t testing.TB, fixtures map[string][]byte) *httptest.Server {
+	t.Helper()
+	notFound := LoadFixture(t, "error_method_not_found_response.json")
+	respond := func(call json.RawMessage) (json.RawMessage, error) {
+		var request struct {
+			Method string          `json:"method"`
+			ID     json.RawMessage `json:"id"`
+		}
+		if err := json.Unmarshal(call, &request); err != nil {
+			return nil, err
+		}
+		fixture, ok := fixtures[request.Method]
+		if !ok {
+			fixture = notFound
+		}
+		var response map[string]json.RawMessage
+		if err := json.Unmarshal(fixture, &response); err != nil {
+			return nil, err
+		}
+		response["id"] = request.ID
+		return json.Marshal(response)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var response interface{}
+		var batch []json.RawMessage
+		if json.Unmarshal(body, &batch) == nil {
+			responses := make([]json.RawMessage, len(batch))
+			for i, call := range batch {
+				if responses[i], err = respond(call); err != nil {
+					break
+				}
+			}
+			response = responses
+		} else {
+			response, err = respond(body)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// nodeFixtures returns the responses of a node whose latest block is that of
+// eth_getBlockByNumber_full_response.json.
+func nodeFixtures(t testing.TB) map[string][]byte {
+	t.Helper()
+	return map[string][]byte{
+		"eth_blockNumber":           LoadFixture(t, "eth_blockNumber_response.json"),
+		"eth_chainId":               LoadFixture(t, "eth_chainId_response.json"),
+		"eth_getBlockByNumber":      LoadFixture(t, "eth_getBlockByNumber_full_response.json"),
+		"eth_getTransactionByHash":  LoadFixture(t, "eth_getTransactionByHash_response.json"),
+		"eth_getTransactionReceipt": LoadFixture(t, "eth_getTransactionReceipt_response.json"),
+		"eth_getLogs":               LoadFixture(t, "eth_getLogs_response.json"),
+	}
+}
+
+// Fields of the fixtures
+const (
+	fixtureBlock       = 19531250
+	fixtureTransfer    = "0x88df016429689c079f3b2f6ad39fa052532c56795b733da78a91ebe6a713944b" // 1 ether from checksummedAddress to otherAddress
+	fixtureUSDCPayment = "0x3f4a2b1c0d9e8f7a6b5c4d3e2f1a0b9c8d7e6f5a4b3c2d1e0f9a8b7c6d5e4f3a" // USDC transfer to checksummedAddress
+	fixtureUSDC        = "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+)
+
+func TestFixtureServer(t *testing.T) {
+	server := NewFixtureServer(t, nodeFixtures(t))
+	parser := NewEthereumParser(server.URL, NewMemoryStorage())
+	ctx := t.Context()
+
+	if block := parser.GetCurrentBlock(); block != fixtureBlock {
+		t.Errorf("GetCurrentBlock = %d, want %d", block, fixtureBlock)
+	}
+	if chainID, err := parser.ChainID(ctx); err != nil || chainID != 1 {
+		t.Errorf("ChainID = %v, %v, want 1", chainID, err)
+	}
+	if _, err := parser.SubscribeAddress(checksummedAddress); err != nil {
+		t.Fatal(err)
+	}
+	if transactions := parser.GetTransactions(checksummedAddress); len(transactions) != 1 || transactions[0].Hash != fixtureTransfer {
+		t.Errorf("GetTransactions = %v, want %v", transactions, fixtureTransfer)
+	}
+
+	details, err := parser.GetTransactionByHash(ctx, fixtureUSDCPayment)
+	if err != nil || details.To != fixtureUSDC || details.Type != "0x2" {
+		t.Errorf("GetTransactionByHash = %+v, %v, want the USDC payment", details, err)
+	}
+	receipt, err := parser.GetTransactionReceipt(ctx, fixtureUSDCPayment)
+	if err != nil || receipt.Status != "0x1" || len(receipt.Logs) != 1 {
+		t.Errorf("GetTransactionReceipt = %+v, %v, want a successful receipt with a log", receipt, err)
+	}
+	logs, err := parser.GetLogs(ctx, LogFilter{FromBlock: fixtureBlock, ToBlock: fixtureBlock, Address: fixtureUSDC})
+	if err != nil || len(logs) != 1 || logs[0].TransactionHash != fixtureUSDCPayment {
+		t.Errorf("GetLogs = %v, %v, want the log of the USDC payment", logs, err)
+	}
+
+	var rpcErr *RPCError
+	if _, err := parser.GetBalance(ctx, checksummedAddress, "latest"); !errors.As(err, &rpcErr) || rpcErr.Code != -32601 {
+		t.Errorf("call of a method without fixture error = %v, want method not found", err)
+	}
+}
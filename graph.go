@@ -0,0 +1,327 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// TransactionGraph records the flow of transactions between addresses.
+type TransactionGraph struct {
+	outgoing map[string]map[string]int // Map from sender to receivers with transaction counts
+	incoming map[string]map[string]int // Map from receiver to senders with transaction counts
+}
+
+// NewTransactionGraph initializes an empty TransactionGraph.
+func NewTransactionGraph() *TransactionGraph {
+	return &TransactionGraph{
+		outgoing: make(map[string]map[string]int),
+		incoming: make(map[string]map[string]int),
+	}
+}
+
+// AddTransaction adds an edge from the sender to the receiver of a transaction.
+// Contract creations have no receiver and are ignored.
+func (graph *TransactionGraph) AddTransaction(tx Transaction) {
+	if tx.From == "" || tx.To == "" {
+		return
+	}
+	graph.addEdge(tx.From, tx.To, 1)
+}
+
+// AddEdge adds weight transactions from one address to another.
+func (graph *TransactionGraph) AddEdge(from, to string, weight int) {
+	graph.addEdge(from, to, weight)
+}
+
+func (graph *TransactionGraph) addEdge(from, to string, count int) {
+	if graph.outgoing[from] == nil {
+		graph.outgoing[from] = make(map[string]int)
+	}
+	if graph.incoming[to] == nil {
+		graph.incoming[to] = make(map[string]int)
+	}
+	graph.outgoing[from][to] += count
+	graph.incoming[to][from] += count
+}
+
+// InDegree returns the number of addresses that sent to the address.
+func (graph *TransactionGraph) InDegree(address string) int {
+	return len(graph.incoming[address])
+}
+
+// OutDegree returns the number of addresses the address sent to.
+func (graph *TransactionGraph) OutDegree(address string) int {
+	return len(graph.outgoing[address])
+}
+
+// TopCallers returns the n addresses that sent to the most addresses, in
+// descending order of out-degree.
+func (graph *TransactionGraph) TopCallers(n int) []string {
+	return topByDegree(graph.outgoing, n)
+}
+
+// TopCallees returns the n addresses that received from the most addresses,
+// in descending order of in-degree.
+func (graph *TransactionGraph) TopCallees(n int) []string {
+	return topByDegree(graph.incoming, n)
+}
+
+// topByDegree returns the n keys with the most neighbors, ties in ascending order.
+func topByDegree(edges map[string]map[string]int, n int) []string {
+	addresses := sortedKeys(edges)
+	sort.SliceStable(addresses, func(i, j int) bool {
+		return len(edges[addresses[i]]) > len(edges[addresses[j]])
+	})
+	return addresses[:max(min(n, len(addresses)), 0)]
+}
+
+// Neighbors returns all unique addresses that sent to or received from the address.
+func (graph *TransactionGraph) Neighbors(address string) ([]string, error) {
+	if !graph.contains(address) {
+		return nil, fmt.Errorf("address %v is not in the graph", address)
+	}
+
+	seen := make(map[string]bool)
+	for to := range graph.outgoing[address] {
+		seen[to] = true
+	}
+	for from := range graph.incoming[address] {
+		seen[from] = true
+	}
+
+	return sortedKeys(seen), nil
+}
+
+// PathExists reports whether funds can flow from src to dst in at most maxDepth hops.
+func (graph *TransactionGraph) PathExists(src, dst string, maxDepth int) bool {
+	if !graph.contains(src) || !graph.contains(dst) {
+		return false
+	}
+	if src == dst {
+		return true
+	}
+
+	visited := map[string]bool{src: true}
+	frontier := []string{src}
+	for depth := 0; depth < maxDepth && len(frontier) > 0; depth++ {
+		var next []string
+		for _, address := range frontier {
+			for to := range graph.outgoing[address] {
+				if to == dst {
+					return true
+				}
+				if !visited[to] {
+					visited[to] = true
+					next = append(next, to)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	return false
+}
+
+// Subgraph extracts the addresses within depth hops of seed, in either direction,
+// together with all edges between them.
+func (graph *TransactionGraph) Subgraph(seed string, depth int) *TransactionGraph {
+	subgraph := NewTransactionGraph()
+	if !graph.contains(seed) {
+		return subgraph
+	}
+
+	visited := map[string]bool{seed: true}
+	frontier := []string{seed}
+	for level := 0; level < depth && len(frontier) > 0; level++ {
+		var next []string
+		for _, address := range frontier {
+			neighbors, _ := graph.Neighbors(address)
+			for _, neighbor := range neighbors {
+				if !visited[neighbor] {
+					visited[neighbor] = true
+					next = append(next, neighbor)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	for from := range visited {
+		for to, count := range graph.outgoing[from] {
+			if visited[to] {
+				subgraph.addEdge(from, to, count)
+			}
+		}
+	}
+
+	return subgraph
+}
+
+// ExportDOT writes the graph in Graphviz DOT format, labelling each edge with its transaction count.
+func (graph *TransactionGraph) ExportDOT(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, "digraph transactions {"); err != nil {
+		return err
+	}
+	for _, from := range sortedKeys(graph.outgoing) {
+		receivers := graph.outgoing[from]
+		for _, to := range sortedKeys(receivers) {
+			if _, err := fmt.Fprintf(w, "\t%q -> %q [label=\"%d\"];\n", from, to, receivers[to]); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
+
+// graphEdge is an edge of the JSON and GraphML exports.
+type graphEdge struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Weight int    `json:"weight"` // Number of transactions
+}
+
+// edges returns the edges of the graph, ordered by sender and receiver.
+func (graph *TransactionGraph) edges() []graphEdge {
+	edges := []graphEdge{} // Encoded as [] rather than null when empty
+	for _, from := range sortedKeys(graph.outgoing) {
+		receivers := graph.outgoing[from]
+		for _, to := range sortedKeys(receivers) {
+			edges = append(edges, graphEdge{From: from, To: to, Weight: receivers[to]})
+		}
+	}
+	return edges
+}
+
+// nodes returns the addresses of the graph in ascending order.
+func (graph *TransactionGraph) nodes() []string {
+	addresses := make(map[string]bool)
+	for address := range graph.outgoing {
+		addresses[address] = true
+	}
+	for address := range graph.incoming {
+		addresses[address] = true
+	}
+	return sortedKeys(addresses)
+}
+
+// ExportJSON writes the graph as a JSON object holding its nodes and its
+// edges weighted by transaction count.
+func (graph *TransactionGraph) ExportJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(struct {
+		Nodes []string    `json:"nodes"`
+		Edges []graphEdge `json:"edges"`
+	}{graph.nodes(), graph.edges()})
+}
+
+// ExportGraphML writes the graph in GraphML format, with the transaction count
+// of each edge in its weight attribute.
+func (graph *TransactionGraph) ExportGraphML(w io.Writer) error {
+	type data struct {
+		Key   string `xml:"key,attr"`
+		Value int    `xml:",chardata"`
+	}
+	type node struct {
+		ID string `xml:"id,attr"`
+	}
+	type edge struct {
+		Source string `xml:"source,attr"`
+		Target string `xml:"target,attr"`
+		Data   data   `xml:"data"`
+	}
+	type key struct {
+		ID       string `xml:"id,attr"`
+		For      string `xml:"for,attr"`
+		AttrName string `xml:"attr.name,attr"`
+		AttrType string `xml:"attr.type,attr"`
+	}
+	document := struct {
+		XMLName xml.Name `xml:"graphml"`
+		XMLNS   string   `xml:"xmlns,attr"`
+		Key     key      `xml:"key"`
+		Graph   struct {
+			ID          string `xml:"id,attr"`
+			EdgeDefault string `xml:"edgedefault,attr"`
+			Nodes       []node `xml:"node"`
+			Edges       []edge `xml:"edge"`
+		} `xml:"graph"`
+	}{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Key:   key{ID: "weight", For: "edge", AttrName: "weight", AttrType: "int"},
+	}
+	document.Graph.ID = "transactions"
+	document.Graph.EdgeDefault = "directed"
+	for _, address := range graph.nodes() {
+		document.Graph.Nodes = append(document.Graph.Nodes, node{ID: address})
+	}
+	for _, e := range graph.edges() {
+		document.Graph.Edges = append(document.Graph.Edges, edge{Source: e.From, Target: e.To, Data: data{Key: "weight", Value: e.Weight}})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// CallGraph is the graph of the transactions sent between addresses in a
+// block range: an edge A -> B means A sent at least one transaction to B.
+type CallGraph struct {
+	*TransactionGraph
+}
+
+// BuildCallGraph scans the blocks from fromBlock to toBlock, both included,
+// and returns the graph of their transactions. Contract creations have no
+// receiver and are left out.
+func (parser *EthereumParser) BuildCallGraph(ctx context.Context, fromBlock, toBlock uint64) (*CallGraph, error) {
+	if fromBlock > toBlock {
+		return nil, fmt.Errorf("invalid block range: %d > %d", fromBlock, toBlock)
+	}
+
+	graph := &CallGraph{NewTransactionGraph()}
+	for number := fromBlock; ; number++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		block, err := parser.getBlockByNumber(ctx, number)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get block %d: %w", number, err)
+		}
+		for _, transaction := range block.Transactions {
+			graph.AddTransaction(transaction)
+		}
+
+		// Checked here rather than in the loop condition so that toBlock = MaxUint64 terminates
+		if number == toBlock {
+			return graph, nil
+		}
+	}
+}
+
+func (graph *TransactionGraph) contains(address string) bool {
+	_, isSender := graph.outgoing[address]
+	_, isReceiver := graph.incoming[address]
+	return isSender || isReceiver
+}
+
+// sortedKeys returns the keys of a string-keyed map in ascending order.
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,203 @@
+package parser
+
+import (
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+const (
+	// hardhatMnemonic is the mnemonic of the default accounts of Hardhat and
+	// Foundry's anvil. Its keys are public: never use them outside of tests.
+	hardhatMnemonic = "test test test test test test test test test test test junk"
+	// ethDerivationPath is the BIP-44 path of the i-th Ethereum account.
+	ethDerivationPath = "m/44'/60'/0'/0/%d"
+	// hardenedOffset is added to the index of hardened BIP-32 children.
+	hardenedOffset = 1 << 31
+)
+
+// secp256k1 is the curve of Ethereum keys: y² = x³ + 7 over the field of p,
+// with the base point g of order n.
+var secp256k1 = struct {
+	p, n, gx, gy *big.Int
+}{
+	p:  hexBig("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f"),
+	n:  hexBig("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141"),
+	gx: hexBig("79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"),
+	gy: hexBig("483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8"),
+}
+
+func hexBig(text string) *big.Int {
+	value, _ := new(big.Int).SetString(text, 16)
+	return value
+}
+
+// DeriveEthAddress derives the key at a BIP-32 path, e.g. m/44'/60'/0'/0/0,
+// from a BIP-39 mnemonic, and returns its checksummed address and 0x-prefixed
+// private key. The mnemonic's words are not checked against the BIP-39 word
+// list. The arithmetic is not constant-time: it is meant for test accounts,
+// not for keys holding real funds.
+func DeriveEthAddress(mnemonic string, path string) (address, privKey string, err error) {
+	indexes, err := parseDerivationPath(path)
+	if err != nil {
+		return "", "", err
+	}
+	seed, err := mnemonicSeed(mnemonic, "")
+	if err != nil {
+		return "", "", err
+	}
+
+	key, chainCode := masterKey(seed)
+	for _, index := range indexes {
+		if key, chainCode, err = childKey(key, chainCode, index); err != nil {
+			return "", "", err
+		}
+	}
+
+	x, y := scalarBaseMult(key)
+	public := append(x.FillBytes(make([]byte, 32)), y.FillBytes(make([]byte, 32))...)
+	address = ToChecksumAddress(fmt.Sprintf("0x%x", Keccak256(public)[12:]))
+	return address, fmt.Sprintf("0x%x", key.FillBytes(make([]byte, 32))), nil
+}
+
+// DeriveHardhatAccounts returns the addresses of the first n default accounts
+// of Hardhat and anvil, which their development nodes fund.
+func DeriveHardhatAccounts(n int) ([]string, error) {
+	addresses := make([]string, n)
+	for i := range addresses {
+		address, _, err := DeriveEthAddress(hardhatMnemonic, fmt.Sprintf(ethDerivationPath, i))
+		if err != nil {
+			return nil, err
+		}
+		addresses[i] = address
+	}
+	return addresses, nil
+}
+
+// parseDerivationPath parses a BIP-32 path into child indexes. Hardened
+// children are marked with ' or h.
+func parseDerivationPath(path string) ([]uint32, error) {
+	parts := strings.Split(path, "/")
+	if parts[0] != "m" {
+		return nil, fmt.Errorf("invalid derivation path %q, expected it to start with m", path)
+	}
+
+	indexes := make([]uint32, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		offset := uint64(0)
+		if trimmed, ok := strings.CutSuffix(part, "'"); ok {
+			part, offset = trimmed, hardenedOffset
+		} else if trimmed, ok := strings.CutSuffix(part, "h"); ok {
+			part, offset = trimmed, hardenedOffset
+		}
+		index, err := strconv.ParseUint(part, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("invalid derivation path %q: %w", path, err)
+		}
+		indexes = append(indexes, uint32(index+offset))
+	}
+	return indexes, nil
+}
+
+// mnemonicSeed returns the BIP-39 seed of a mnemonic. Words are separated by
+// single spaces, which is the only normalization English mnemonics need.
+func mnemonicSeed(mnemonic, passphrase string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	if len(words) == 0 || len(words)%3 != 0 || len(words) > 24 {
+		return nil, fmt.Errorf("invalid mnemonic: expected 12 to 24 words, got %d", len(words))
+	}
+	return pbkdf2.Key(sha512.New, strings.Join(words, " "), []byte("mnemonic"+passphrase), 2048, 64)
+}
+
+// masterKey returns the BIP-32 master key and chain code of a seed.
+func masterKey(seed []byte) (*big.Int, []byte) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	return new(big.Int).SetBytes(sum[:32]), sum[32:]
+}
+
+// childKey derives the private key and chain code of a child from its parent's.
+func childKey(key *big.Int, chainCode []byte, index uint32) (*big.Int, []byte, error) {
+	var data []byte
+	if index >= hardenedOffset {
+		data = append([]byte{0}, key.FillBytes(make([]byte, 32))...)
+	} else {
+		data = compressedPublicKey(key)
+	}
+	data = binary.BigEndian.AppendUint32(data, index)
+
+	mac := hmac.New(sha512.New, chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	tweak := new(big.Int).SetBytes(sum[:32])
+	child := new(big.Int).Add(tweak, key)
+	child.Mod(child, secp256k1.n)
+	if tweak.Cmp(secp256k1.n) >= 0 || child.Sign() == 0 {
+		return nil, nil, errors.New("invalid child key, use the next index")
+	}
+	return child, sum[32:], nil
+}
+
+// compressedPublicKey returns the SEC1 compressed public key of a private key.
+func compressedPublicKey(key *big.Int) []byte {
+	x, y := scalarBaseMult(key)
+	return append([]byte{byte(2 + y.Bit(0))}, x.FillBytes(make([]byte, 32))...)
+}
+
+// scalarBaseMult returns k·G on secp256k1 by double-and-add in affine
+// coordinates.
+func scalarBaseMult(k *big.Int) (*big.Int, *big.Int) {
+	var x, y *big.Int // Point at infinity while nil
+	px, py := secp256k1.gx, secp256k1.gy
+	for i := 0; i < k.BitLen(); i++ {
+		if k.Bit(i) == 1 {
+			x, y = pointAdd(x, y, px, py)
+		}
+		px, py = pointAdd(px, py, px, py)
+	}
+	return x, y
+}
+
+// pointAdd adds two points of secp256k1, either of which may be the point at
+// infinity, given as nil coordinates.
+func pointAdd(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	p := secp256k1.p
+	if x1 == nil {
+		return x2, y2
+	}
+	if x2 == nil {
+		return x1, y1
+	}
+
+	var slope *big.Int
+	if x1.Cmp(x2) == 0 {
+		if sum := new(big.Int).Add(y1, y2); sum.Mod(sum, p).Sign() == 0 {
+			return nil, nil // P + (-P)
+		}
+		// Tangent: 3x² / 2y
+		numerator := new(big.Int).Mul(x1, x1)
+		numerator.Mul(numerator, big.NewInt(3))
+		denominator := new(big.Int).Lsh(y1, 1)
+		slope = numerator.Mul(numerator, denominator.ModInverse(denominator, p))
+	} else {
+		numerator := new(big.Int).Sub(y2, y1)
+		denominator := new(big.Int).Sub(x2, x1)
+		denominator.Mod(denominator, p)
+		slope = numerator.Mul(numerator, denominator.ModInverse(denominator, p))
+	}
+	slope.Mod(slope, p)
+
+	x := new(big.Int).Mul(slope, slope)
+	x.Sub(x, x1).Sub(x, x2).Mod(x, p)
+	y := new(big.Int).Sub(x1, x)
+	y.Mul(y, slope).Sub(y, y1).Mod(y, p)
+	return x, y
+}
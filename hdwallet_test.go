@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// hardhatAccounts are the first default accounts of Hardhat and anvil, with
+// their published private keys.
+var hardhatAccounts = []struct {
+	address, privKey string
+}{
+	{"0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266", "0xac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"},
+	{"0x70997970C51812dc3A010C7d01b50e0d17dc79C8", "0x59c6995e998f97a5a0044966f0945389dc9e86dae88c7a8412f4603b6b78690d"},
+}
+
+func TestDeriveEthAddress(t *testing.T) {
+	for i, account := range hardhatAccounts {
+		path := fmt.Sprintf(ethDerivationPath, i)
+		address, privKey, err := DeriveEthAddress(hardhatMnemonic, path)
+		if err != nil {
+			t.Fatalf("DeriveEthAddress(%v): %v", path, err)
+		}
+		if address != account.address || privKey != account.privKey {
+			t.Errorf("DeriveEthAddress(%v) = %v, %v, want %v, %v", path, address, privKey, account.address, account.privKey)
+		}
+	}
+	if address, _, err := DeriveEthAddress(hardhatMnemonic, "m/44h/60h/0h/0/0"); err != nil || address != hardhatAccounts[0].address {
+		t.Errorf("DeriveEthAddress with h hardened indexes = %v, %v, want %v", address, err, hardhatAccounts[0].address)
+	}
+
+	for _, path := range []string{"", "44'/60'/0'/0/0", "m/44'/60'/x/0/0", "m/44'/60'/0'/0/-1", "m/2147483648", "m//0"} {
+		if _, _, err := DeriveEthAddress(hardhatMnemonic, path); err == nil {
+			t.Errorf("DeriveEthAddress(%q) succeeded, want an invalid path error", path)
+		}
+	}
+}
+
+func TestDeriveHardhatAccounts(t *testing.T) {
+	addresses, err := DeriveHardhatAccounts(len(hardhatAccounts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []string
+	for _, account := range hardhatAccounts {
+		want = append(want, account.address)
+	}
+	if !slices.Equal(addresses, want) {
+		t.Errorf("DeriveHardhatAccounts(%d) = %v, want %v", len(want), addresses, want)
+	}
+	if addresses, err := DeriveHardhatAccounts(0); err != nil || len(addresses) != 0 {
+		t.Errorf("DeriveHardhatAccounts(0) = %v, %v, want none", addresses, err)
+	}
+}
@@ -0,0 +1,174 @@
+package parser
+
+import (
+	"strings"
+	"sync"
+)
+
+// DefaultIndexCapacity is the default number of transactions the Index keeps per address.
+const DefaultIndexCapacity = 10_000
+
+// Index keeps the transactions of subscribed addresses in the blocks processed
+// by Watch, along with the contiguous range of blocks indexed for each address,
+// so that GetTransactions can answer without refetching those blocks. Only the
+// most recent transactions of each address are kept, up to the capacity.
+type Index struct {
+	mu                     sync.Mutex
+	capacity               int
+	entries                map[string]*indexEntry // Keyed by lowercase address
+	droppedOldTransactions uint64
+}
+
+// indexEntry holds the indexed blocks and transactions of one address.
+type indexEntry struct {
+	from, to     uint64
+	transactions *RingBuffer[Transaction]
+}
+
+// NewIndex initializes an empty Index keeping up to capacity transactions per address.
+func NewIndex(capacity int) *Index {
+	return &Index{capacity: capacity, entries: make(map[string]*indexEntry)}
+}
+
+// WithIndexCapacity sets the number of recent transactions the Index keeps for
+// each address. Older transactions are dropped.
+func WithIndexCapacity(capacity int) Option {
+	return func(parser *EthereumParser) {
+		parser.IndexCapacity = capacity
+	}
+}
+
+// push indexes a transaction of the entry's address. When the oldest one is
+// dropped to make room, the coverage moves past its block, since that block is
+// no longer fully indexed.
+func (index *Index) push(entry *indexEntry, transaction Transaction) {
+	if oldest, ok := entry.transactions.Oldest(); ok && entry.transactions.Len() == entry.transactions.Cap() {
+		index.droppedOldTransactions++
+		if number, err := ParseHexUint64(oldest.BlockNumber); err == nil && number >= entry.from {
+			entry.from = number + 1
+		}
+	}
+	entry.transactions.Push(transaction)
+}
+
+// DroppedOldTransactions returns the number of transactions dropped because
+// their address had reached the capacity.
+func (index *Index) DroppedOldTransactions() uint64 {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	return index.droppedOldTransactions
+}
+
+// Add indexes a block for each of the addresses, keeping the transactions that
+// involve them. Blocks already indexed for an address are ignored, and an
+// address whose coverage does not end right before the block starts over from
+// it, so that the covered range never has gaps.
+func (index *Index) Add(blockNumber uint64, addresses []string, transactions []Transaction) {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	for _, address := range addresses {
+		key := strings.ToLower(address)
+		entry := index.entries[key]
+		switch {
+		case entry != nil && blockNumber >= entry.from && blockNumber <= entry.to:
+			continue
+		case entry != nil && blockNumber == entry.to+1:
+			entry.to = blockNumber
+		default:
+			entry = &indexEntry{from: blockNumber, to: blockNumber, transactions: NewRingBuffer[Transaction](index.capacity)}
+			index.entries[key] = entry
+		}
+
+		for _, transaction := range transactions {
+			if involvesAddress(transaction, address) {
+				index.push(entry, transaction)
+			}
+		}
+	}
+}
+
+// ReplaceBlock replaces the transactions indexed from a block, such as one
+// orphaned by a chain split, with those of another block at the same height,
+// for each of the addresses whose coverage includes it.
+func (index *Index) ReplaceBlock(blockNumber uint64, addresses []string, transactions []Transaction) {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	for _, address := range addresses {
+		entry := index.entries[strings.ToLower(address)]
+		if entry == nil || blockNumber < entry.from || blockNumber > entry.to {
+			continue
+		}
+
+		// Keep the transactions in block order by splicing the new ones in place of the old
+		var kept, after []Transaction
+		for _, transaction := range entry.transactions.Items() {
+			number, _ := ParseHexUint64(transaction.BlockNumber)
+			switch {
+			case number < blockNumber:
+				kept = append(kept, transaction)
+			case number > blockNumber:
+				after = append(after, transaction)
+			}
+		}
+		for _, transaction := range transactions {
+			if involvesAddress(transaction, address) {
+				kept = append(kept, transaction)
+			}
+		}
+		entry.transactions = NewRingBuffer[Transaction](index.capacity)
+		for _, transaction := range append(kept, after...) {
+			index.push(entry, transaction)
+		}
+	}
+}
+
+// Get returns the indexed transactions of the address, oldest first.
+func (index *Index) Get(address string) []Transaction {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	entry := index.entries[strings.ToLower(address)]
+	if entry == nil {
+		return nil
+	}
+	return entry.transactions.Items()
+}
+
+// Coverage returns the range of blocks indexed for the address, or zeros when
+// none is.
+func (index *Index) Coverage(address string) (from, to uint64) {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	entry := index.entries[strings.ToLower(address)]
+	if entry == nil {
+		return 0, 0
+	}
+	return entry.from, entry.to
+}
+
+// Remove drops everything indexed for the address.
+func (index *Index) Remove(address string) {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	delete(index.entries, strings.ToLower(address))
+}
+
+// indexBlock indexes a block processed by Watch for every subscribed address.
+func (parser *EthereumParser) indexBlock(blockNumber uint64, block *Block) {
+	subscribers, err := parser.Subscribers()
+	if err != nil {
+		return
+	}
+
+	// Inactive addresses are indexed from their activation
+	var addresses []string
+	for _, address := range subscribers {
+		if !parser.inactive(address) {
+			addresses = append(addresses, address)
+		}
+	}
+	parser.index.Add(blockNumber, addresses, block.Transactions)
+}
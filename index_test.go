@@ -0,0 +1,51 @@
+package parser
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// TestIndexCapacity indexes more transactions than the capacity, which drops
+// the oldest and moves the coverage past their blocks.
+func TestIndexCapacity(t *testing.T) {
+	index := NewIndex(2)
+	addresses := []string{checksummedAddress}
+	transaction := func(block uint64, n int) Transaction {
+		return Transaction{Hash: fmt.Sprintf("0x%x%02d", block, n), BlockNumber: fmt.Sprintf("0x%x", block), From: checksummedAddress, To: otherAddress}
+	}
+	hashes := func() []string {
+		var hashes []string
+		for _, transaction := range index.Get(checksummedAddress) {
+			hashes = append(hashes, transaction.Hash)
+		}
+		return hashes
+	}
+
+	index.Add(1, addresses, []Transaction{transaction(1, 0)})
+	index.Add(2, addresses, []Transaction{transaction(2, 0)})
+	if from, to := index.Coverage(checksummedAddress); from != 1 || to != 2 {
+		t.Fatalf("Coverage at capacity = %d-%d, want 1-2", from, to)
+	}
+
+	index.Add(3, addresses, []Transaction{transaction(3, 0)})
+	if got := hashes(); !slices.Equal(got, []string{"0x200", "0x300"}) {
+		t.Errorf("Get after block 3 = %v, want [0x200 0x300]", got)
+	}
+	if from, to := index.Coverage(checksummedAddress); from != 2 || to != 3 {
+		t.Errorf("Coverage after block 3 = %d-%d, want 2-3, past the dropped block 1", from, to)
+	}
+
+	// A block without transactions of the address only extends the coverage
+	index.Add(4, addresses, []Transaction{{Hash: "0x999", From: otherAddress, To: otherAddress}})
+	index.Add(5, addresses, []Transaction{transaction(5, 0), transaction(5, 1)})
+	if got := hashes(); !slices.Equal(got, []string{"0x500", "0x501"}) {
+		t.Errorf("Get after block 5 = %v, want [0x500 0x501]", got)
+	}
+	if from, to := index.Coverage(checksummedAddress); from != 4 || to != 5 {
+		t.Errorf("Coverage after block 5 = %d-%d, want 4-5, past the dropped block 3", from, to)
+	}
+	if dropped := index.DroppedOldTransactions(); dropped != 3 {
+		t.Errorf("DroppedOldTransactions = %d, want 3", dropped)
+	}
+}
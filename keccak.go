@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"math/bits"
+)
+
+// keccakRate is the number of bytes absorbed per permutation by Keccak-256.
+const keccakRate = 136
+
+var keccakRoundConstants = [24]uint64{
+	0x0000000000000001, 0x0000000000008082, 0x800000000000808a, 0x8000000080008000,
+	0x000000000000808b, 0x0000000080000001, 0x8000000080008081, 0x8000000000008009,
+	0x000000000000008a, 0x0000000000000088, 0x0000000080008009, 0x000000008000000a,
+	0x000000008000808b, 0x800000000000008b, 0x8000000000008089, 0x8000000000008003,
+	0x8000000000008002, 0x8000000000000080, 0x000000000000800a, 0x800000008000000a,
+	0x8000000080008081, 0x8000000000008080, 0x0000000080000001, 0x8000000080008008,
+}
+
+var keccakRotations = [24]int{1, 3, 6, 10, 15, 21, 28, 36, 45, 55, 2, 14, 27, 41, 56, 8, 25, 43, 62, 18, 39, 61, 20, 44}
+
+var keccakLanes = [24]int{10, 7, 11, 17, 18, 3, 5, 16, 8, 21, 24, 4, 15, 23, 19, 13, 12, 2, 20, 14, 22, 9, 6, 1}
+
+// Keccak256 returns the legacy Keccak-256 hash used by Ethereum, which differs
+// from the standardized SHA3-256 only in its padding.
+func Keccak256(data ...[]byte) []byte {
+	var message []byte
+	for _, part := range data {
+		message = append(message, part...)
+	}
+
+	// Pad with the Keccak domain byte and the final bit to a multiple of the rate
+	padded := make([]byte, (len(message)/keccakRate+1)*keccakRate)
+	copy(padded, message)
+	padded[len(message)] = 0x01
+	padded[len(padded)-1] |= 0x80
+
+	var state [25]uint64
+	for offset := 0; offset < len(padded); offset += keccakRate {
+		for i := 0; i < keccakRate/8; i++ {
+			state[i] ^= binary.LittleEndian.Uint64(padded[offset+i*8:])
+		}
+		keccakF1600(&state)
+	}
+
+	digest := make([]byte, 32)
+	for i := 0; i < 4; i++ {
+		binary.LittleEndian.PutUint64(digest[i*8:], state[i])
+	}
+	return digest
+}
+
+// Keccak256Hex returns the 0x-prefixed hex Keccak-256 hash of a string,
+// e.g. an event or function signature.
+func Keccak256Hex(text string) string {
+	return "0x" + hex.EncodeToString(Keccak256([]byte(text)))
+}
+
+// keccakF1600 applies the Keccak-f[1600] permutation to the state.
+func keccakF1600(state *[25]uint64) {
+	var columns [5]uint64
+	for round := 0; round < 24; round++ {
+		// Theta
+		for i := 0; i < 5; i++ {
+			columns[i] = state[i] ^ state[i+5] ^ state[i+10] ^ state[i+15] ^ state[i+20]
+		}
+		for i := 0; i < 5; i++ {
+			t := columns[(i+4)%5] ^ bits.RotateLeft64(columns[(i+1)%5], 1)
+			for j := 0; j < 25; j += 5 {
+				state[j+i] ^= t
+			}
+		}
+
+		// Rho and pi
+		t := state[1]
+		for i := 0; i < 24; i++ {
+			lane := keccakLanes[i]
+			next := state[lane]
+			state[lane] = bits.RotateLeft64(t, keccakRotations[i])
+			t = next
+		}
+
+		// Chi
+		for j := 0; j < 25; j += 5 {
+			for i := 0; i < 5; i++ {
+				columns[i] = state[j+i]
+			}
+			for i := 0; i < 5; i++ {
+				state[j+i] ^= ^columns[(i+1)%5] & columns[(i+2)%5]
+			}
+		}
+
+		// Iota
+		state[0] ^= keccakRoundConstants[round]
+	}
+}
@@ -0,0 +1,156 @@
+package parser
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// defaultTokenList is the token list of the well-known contracts named out of
+// the box: major tokens, routers and bridges of the chain presets.
+//
+//go:embed known_contracts.json
+var defaultTokenList []byte
+
+// KnownContract is a well-known contract, named in the transactions that
+// involve it. It is an entry of a token list, see https://tokenlists.org;
+// contracts other than tokens, such as routers, have no symbol.
+type KnownContract struct {
+	ChainID  uint64 `json:"chainId"`
+	Address  string `json:"address"`
+	Name     string `json:"name"`
+	Symbol   string `json:"symbol,omitempty"`
+	Decimals int    `json:"decimals,omitempty"`
+}
+
+// tokenList is the standard token list format.
+type tokenList struct {
+	Name   string          `json:"name"`
+	Tokens []KnownContract `json:"tokens"`
+}
+
+// knownContractKey identifies a contract across chains.
+type knownContractKey struct {
+	chainID uint64
+	address string // Lower case
+}
+
+// KnownContractNamer is implemented by parsers that name well-known contracts.
+type KnownContractNamer interface {
+	SetKnownContracts(contracts []KnownContract) error
+}
+
+// defaultKnownContracts returns the embedded well-known contracts.
+var defaultKnownContracts = sync.OnceValue(func() []KnownContract {
+	contracts, err := parseTokenList(defaultTokenList)
+	if err != nil {
+		panic(fmt.Sprintf("invalid embedded token list: %v", err))
+	}
+	return contracts
+})
+
+// parseTokenList decodes and validates a token list.
+func parseTokenList(data []byte) ([]KnownContract, error) {
+	var list tokenList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	for i, contract := range list.Tokens {
+		if !IsValidAddress(contract.Address) {
+			return nil, fmt.Errorf("entry %d: invalid address: %v", i, contract.Address)
+		}
+		if contract.Name == "" {
+			return nil, fmt.Errorf("entry %d: %v has no name", i, contract.Address)
+		}
+		if contract.Decimals < 0 || contract.Decimals > 77 {
+			return nil, fmt.Errorf("entry %d: invalid decimals: %d", i, contract.Decimals)
+		}
+	}
+	return list.Tokens, nil
+}
+
+// LoadKnownContracts returns the embedded well-known contracts, overridden
+// and extended by those of the token list file at path, if any.
+func LoadKnownContracts(path string) ([]KnownContract, error) {
+	if path == "" {
+		return defaultKnownContracts(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token list: %w", err)
+	}
+	contracts, err := parseTokenList(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token list %v: %w", path, err)
+	}
+	return slices.Concat(defaultKnownContracts(), contracts), nil
+}
+
+// WithKnownContracts replaces the embedded well-known contracts. Contracts
+// that are not valid, see SetKnownContracts, are left out.
+func WithKnownContracts(contracts []KnownContract) Option {
+	return func(parser *EthereumParser) {
+		var valid []KnownContract
+		for _, contract := range contracts {
+			if IsValidAddress(contract.Address) {
+				valid = append(valid, contract)
+			}
+		}
+		parser.SetKnownContracts(valid)
+	}
+}
+
+// SetKnownContracts replaces the well-known contracts, of every chain, named
+// in transactions. A later contract overrides an earlier one at the same
+// address of the same chain.
+func (parser *EthereumParser) SetKnownContracts(contracts []KnownContract) error {
+	known := make(map[knownContractKey]KnownContract, len(contracts))
+	for _, contract := range contracts {
+		if !IsValidAddress(contract.Address) {
+			return fmt.Errorf("invalid address: %v", contract.Address)
+		}
+		known[knownContractKey{contract.ChainID, strings.ToLower(contract.Address)}] = contract
+	}
+	parser.knownContracts.Store(&known)
+	return nil
+}
+
+// SetKnownContracts replaces the well-known contracts of every chain.
+func (multi *MultiChainParser) SetKnownContracts(contracts []KnownContract) error {
+	for _, chain := range multi.chains {
+		if err := chain.SetKnownContracts(contracts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// knownContract returns the well-known contract at an address of the
+// parser's chain.
+func (parser *EthereumParser) knownContract(address string) (KnownContract, bool) {
+	known := parser.knownContracts.Load()
+	if known == nil || address == "" {
+		return KnownContract{}, false
+	}
+	contract, ok := (*known)[knownContractKey{parser.chain.ChainID, strings.ToLower(address)}]
+	return contract, ok
+}
+
+// nameKnownContracts sets the name of the well-known contract a transaction
+// calls, or is sent by, and the symbol and name of the token it transfers.
+func (parser *EthereumParser) nameKnownContracts(transaction *Transaction) {
+	if contract, ok := parser.knownContract(transaction.To); ok {
+		transaction.ContractName = contract.Name
+		if _, _, _, transfer := decodeERC20Transfer(*transaction); transfer && contract.Symbol != "" {
+			transaction.TokenSymbol, transaction.TokenName = contract.Symbol, contract.Name
+		}
+		return
+	}
+	if contract, ok := parser.knownContract(transaction.From); ok {
+		transaction.ContractName = contract.Name
+	}
+}
@@ -0,0 +1,78 @@
+package parser
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Rollup stacks of the L2 chains, which have system transactions and fees of
+// their own.
+const (
+	stackArbitrum = "arbitrum"
+	stackOP       = "op"
+)
+
+// RollupStacks lists the values of Chain.Stack, "" being an L1 chain.
+var RollupStacks = map[string]bool{"": true, stackArbitrum: true, stackOP: true}
+
+const (
+	// opDepositorAccount sends the L1 attributes deposit opening every OP
+	// stack block.
+	opDepositorAccount = "0xdeaddeaddeaddeaddeaddeaddeaddeaddead0001"
+	// arbOSAddress sends the internal transactions of Arbitrum blocks, such
+	// as the one starting each block.
+	arbOSAddress = "0x00000000000000000000000000000000000a4b05"
+)
+
+// Transaction types of the rollup stacks, which do not collide with the
+// EIP-2718 types of Ethereum.
+const (
+	opDepositTxType        = 0x7e
+	arbitrumInternalTxType = 0x6a
+)
+
+// systemSenders are the senders of the system transactions of each stack.
+var systemSenders = map[string]string{
+	stackArbitrum: arbOSAddress,
+	stackOP:       opDepositorAccount,
+}
+
+// IsSystemTransaction reports whether a transaction is the chain's own
+// bookkeeping rather than a user's, such as the L1 attributes deposit of OP
+// stack blocks or the internal transactions of Arbitrum. Watch skips them.
+func (chain Chain) IsSystemTransaction(transaction Transaction) bool {
+	sender, ok := systemSenders[chain.Stack]
+	return ok && strings.EqualFold(transaction.From, sender)
+}
+
+// TransactionFee returns the fee paid by a mined transaction in wei: the gas
+// used at the effective gas price, plus the L1 data fee of OP stack chains.
+// Deposits are paid for on L1 and are free on L2. Nodes before the London
+// fork do not report an effective gas price, which is then the gas price.
+func TransactionFee(transaction *TransactionDetails, receipt *TransactionReceipt) (*big.Int, error) {
+	fee := new(big.Int)
+	if txType, err := parseHexBig(transaction.Type); err != nil || txType.Cmp(big.NewInt(opDepositTxType)) != 0 {
+		gasUsed, err := parseHexBig(receipt.GasUsed)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gas used: %w", err)
+		}
+		priceHex := receipt.EffectiveGasPrice
+		if priceHex == "" {
+			priceHex = transaction.GasPrice
+		}
+		price, err := parseHexBig(priceHex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gas price: %w", err)
+		}
+		fee.Mul(gasUsed, price)
+	}
+	if receipt.L1Fee != "" {
+		l1Fee, err := parseHexBig(receipt.L1Fee)
+		if err != nil {
+			return nil, fmt.Errorf("invalid L1 fee: %w", err)
+		}
+		fee.Add(fee, l1Fee)
+	}
+	return fee, nil
+}
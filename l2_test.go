@@ -0,0 +1,167 @@
+package parser
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// loadFixtureResult decodes the result of the named JSON-RPC response of
+// testdata into result.
+func loadFixtureResult(t *testing.T, name string, result interface{}) {
+	t.Helper()
+	var response struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(LoadFixture(t, name), &response); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(response.Result, result); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Transactions of the rollup fixtures
+const (
+	optimismDeposit  = "0xb8e3f2c1a6d94e7b8c5a2f1d0e9b6c3a7f4d1e8b5c2a9f6d3e0b7c4a1f8e5d2b" // L1 attributes deposit
+	optimismTransfer = "0xd41a7e0c3b6f9a2d5e8c1b4f7a0d3e6c9b2f5a8d1e4c7b0a3f6d9e2c5b8a1f4e" // 0.01 ether from checksummedAddress to otherAddress
+	arbitrumInternal = "0x5f9b2e8c1d4a7f0b3e6c9d2a5f8b1e4c7a0d3f6b9e2c5a8d1f4b7e0c3a6d9f2b" // ArbOS start of the block
+	arbitrumTransfer = "0xa3c6f9b2e5d8a1c4f7b0e3d6a9c2f5b8e1d4a7c0f3b6e9d2a5c8f1b4e7d0a3c6" // 0.02 ether to checksummedAddress
+)
+
+func TestIsSystemTransaction(t *testing.T) {
+	deposit := Transaction{From: "0xDeaDDEaDDeAdDeAdDEAdDEaddeAddEAdDEAd0001", To: "0x4200000000000000000000000000000000000015"}
+	internal := Transaction{From: arbOSAddress, To: arbOSAddress}
+	user := Transaction{From: checksummedAddress, To: otherAddress}
+	tests := []struct {
+		chain       string
+		transaction Transaction
+		want        bool
+	}{
+		{"optimism", deposit, true},
+		{"base", deposit, true},
+		{"optimism", internal, false},
+		{"optimism", user, false},
+		{"arbitrum", internal, true},
+		{"arbitrum", deposit, false},
+		{"arbitrum", user, false},
+		{"mainnet", deposit, false},
+		{"mainnet", internal, false},
+	}
+	for _, test := range tests {
+		if got := ChainPresets[test.chain].IsSystemTransaction(test.transaction); got != test.want {
+			t.Errorf("%v IsSystemTransaction(from %v) = %v, want %v", test.chain, test.transaction.From, got, test.want)
+		}
+	}
+}
+
+// TestWatchSkipsSystemTransactions watches the blocks of the rollup fixtures
+// with the senders and recipients of their system transactions subscribed:
+// only the transfer of each block is dispatched.
+func TestWatchSkipsSystemTransactions(t *testing.T) {
+	tests := []struct {
+		chain      string
+		fixture    string
+		subscribed []string
+		want       string
+	}{
+		{"optimism", "optimism_eth_getBlockByNumber_full_response.json", []string{checksummedAddress, "0x4200000000000000000000000000000000000015", opDepositorAccount}, optimismTransfer},
+		{"arbitrum", "arbitrum_eth_getBlockByNumber_full_response.json", []string{checksummedAddress, arbOSAddress}, arbitrumTransfer},
+	}
+	for _, test := range tests {
+		t.Run(test.chain, func(t *testing.T) {
+			var block Block
+			loadFixtureResult(t, test.fixture, &block)
+			number, err := ParseHexUint64(block.Number)
+			if err != nil {
+				t.Fatal(err)
+			}
+			chain := ChainPresets[test.chain]
+			chain.PollInterval, chain.Confirmations = testWatchInterval, 0
+			parser := newFakeNode(t, &block).NewParser(WithChain(chain))
+			for _, address := range test.subscribed {
+				if _, err := parser.SubscribeAddress(address); err != nil {
+					t.Fatal(err)
+				}
+			}
+			out := startWatch(t, parser, number)
+
+			if transaction := receive(t, out); transaction.Hash != test.want || transaction.Chain != test.chain {
+				t.Errorf("received %v on %q, want %v on %v", transaction.Hash, transaction.Chain, test.want, test.chain)
+			}
+			receiveNone(t, out, 5*testWatchInterval)
+			if scanned := parser.Metrics().TransactionsScanned; scanned != 2 {
+				t.Errorf("TransactionsScanned = %d, want the 2 transactions of the block", scanned)
+			}
+		})
+	}
+}
+
+// TestTransactionFeeRollups computes the fees of the transactions of the
+// rollup fixtures from their receipts.
+func TestTransactionFeeRollups(t *testing.T) {
+	transactions := func(fixture string) map[string]*TransactionDetails {
+		var block struct {
+			Transactions []*TransactionDetails `json:"transactions"`
+		}
+		loadFixtureResult(t, fixture, &block)
+		byHash := make(map[string]*TransactionDetails)
+		for _, transaction := range block.Transactions {
+			byHash[transaction.Hash] = transaction
+		}
+		return byHash
+	}
+	receipt := func(fixture string) *TransactionReceipt {
+		var receipt TransactionReceipt
+		loadFixtureResult(t, fixture, &receipt)
+		return &receipt
+	}
+	optimism := transactions("optimism_eth_getBlockByNumber_full_response.json")
+	arbitrum := transactions("arbitrum_eth_getBlockByNumber_full_response.json")
+	preLondon := receipt("arbitrum_eth_getTransactionReceipt_response.json")
+	preLondon.EffectiveGasPrice = ""
+
+	tests := []struct {
+		name        string
+		transaction *TransactionDetails
+		receipt     *TransactionReceipt
+		want        string
+	}{
+		// 21000 gas at 1000080 wei, plus the L1 fee of 100000000000000 wei
+		{"OP transfer", optimism[optimismTransfer], receipt("optimism_eth_getTransactionReceipt_response.json"), "100021001680000"},
+		// Paid for on L1, whatever gas it uses
+		{"OP deposit", optimism[optimismDeposit], receipt("optimism_deposit_eth_getTransactionReceipt_response.json"), "0"},
+		// 129207 gas, L1 gas included, at the effective 10000000 wei rather than the 20000000 of the transaction
+		{"Arbitrum transfer", arbitrum[arbitrumTransfer], receipt("arbitrum_eth_getTransactionReceipt_response.json"), "1292070000000"},
+		{"without effective gas price", arbitrum[arbitrumTransfer], preLondon, "2584140000000"},
+	}
+	for _, test := range tests {
+		fee, err := TransactionFee(test.transaction, test.receipt)
+		if err != nil {
+			t.Errorf("%v: TransactionFee error = %v", test.name, err)
+			continue
+		}
+		if want, _ := new(big.Int).SetString(test.want, 10); fee.Cmp(want) != 0 {
+			t.Errorf("%v: TransactionFee = %v, want %v", test.name, fee, want)
+		}
+	}
+}
+
+// TestRollupPresets checks that the rollup presets poll faster than mainnet,
+// confirm sooner and name their stack.
+func TestRollupPresets(t *testing.T) {
+	mainnet := ChainPresets["mainnet"]
+	for name, stack := range map[string]string{"optimism": stackOP, "base": stackOP, "arbitrum": stackArbitrum} {
+		preset := ChainPresets[name]
+		if preset.Stack != stack || !RollupStacks[preset.Stack] {
+			t.Errorf("%v stack = %q, want %q", name, preset.Stack, stack)
+		}
+		if preset.PollInterval >= mainnet.PollInterval || preset.PollInterval < preset.ExpectedBlockTime || preset.PollInterval > 2*time.Second {
+			t.Errorf("%v polls every %v with blocks every %v, want faster than mainnet and not faster than its blocks", name, preset.PollInterval, preset.ExpectedBlockTime)
+		}
+		if preset.Confirmations >= mainnet.Confirmations {
+			t.Errorf("%v confirmations = %d, want fewer than the %d of mainnet", name, preset.Confirmations, mainnet.Confirmations)
+		}
+	}
+}
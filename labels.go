@@ -0,0 +1,223 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxAddressLabelLength is the length in characters of the longest address label.
+const MaxAddressLabelLength = 64
+
+// labelStore is implemented by stores that keep an address book: names of
+// addresses, subscribed or not, shown next to them in output.
+type labelStore interface {
+	SetAddressLabel(address, name string) error // An empty name removes the label
+	AddressLabel(address string) string
+	AddressLabels() (map[string]string, error) // By lower case address
+}
+
+// AddressBook is implemented by parsers that name addresses in their output.
+type AddressBook interface {
+	SetAddressLabel(address, name string) error
+	AddressLabels() (map[string]string, error)
+}
+
+// transactionLabeler is implemented by parsers that label the transactions
+// they return.
+type transactionLabeler interface {
+	labelTransactions(transactions []Transaction)
+}
+
+func (memory *MemoryStorage) SetAddressLabel(address, name string) error {
+	memory.labelsMu.Lock()
+	defer memory.labelsMu.Unlock()
+	if name == "" {
+		delete(memory.labels, strings.ToLower(address))
+		return nil
+	}
+	if memory.labels == nil {
+		memory.labels = make(map[string]string)
+	}
+	memory.labels[strings.ToLower(address)] = name
+	return nil
+}
+
+func (memory *MemoryStorage) AddressLabel(address string) string {
+	memory.labelsMu.RLock()
+	defer memory.labelsMu.RUnlock()
+	return memory.labels[strings.ToLower(address)]
+}
+
+func (memory *MemoryStorage) AddressLabels() (map[string]string, error) {
+	memory.labelsMu.RLock()
+	defer memory.labelsMu.RUnlock()
+	labels := make(map[string]string, len(memory.labels))
+	for address, name := range memory.labels {
+		labels[address] = name
+	}
+	return labels, nil
+}
+
+// The address book is shared by the chains, since an address names the same
+// account on every chain.
+
+func (store chainStore) SetAddressLabel(address, name string) error {
+	book, ok := store.store.(labelStore)
+	if !ok {
+		return errNoAddressBook
+	}
+	return book.SetAddressLabel(address, name)
+}
+
+func (store chainStore) AddressLabel(address string) string {
+	if book, ok := store.store.(labelStore); ok {
+		return book.AddressLabel(address)
+	}
+	return ""
+}
+
+func (store chainStore) AddressLabels() (map[string]string, error) {
+	book, ok := store.store.(labelStore)
+	if !ok {
+		return nil, errNoAddressBook
+	}
+	return book.AddressLabels()
+}
+
+// The address book of a CompositeStorage is the primary's.
+
+func (composite *CompositeStorage) SetAddressLabel(address, name string) error {
+	book, ok := composite.primary.(labelStore)
+	if !ok {
+		return errNoAddressBook
+	}
+	return book.SetAddressLabel(address, name)
+}
+
+func (composite *CompositeStorage) AddressLabel(address string) string {
+	if book, ok := composite.primary.(labelStore); ok {
+		return book.AddressLabel(address)
+	}
+	return ""
+}
+
+func (composite *CompositeStorage) AddressLabels() (map[string]string, error) {
+	book, ok := composite.primary.(labelStore)
+	if !ok {
+		return nil, errNoAddressBook
+	}
+	return book.AddressLabels()
+}
+
+// errNoAddressBook is returned when the storage does not keep address labels.
+var errNoAddressBook = errors.New("storage does not keep address labels")
+
+// SetAddressLabel names an address, whether or not it is subscribed, in the
+// output of the parser: transaction tables, API responses and the
+// transactions Watch sends. An empty name removes the label.
+func (parser *EthereumParser) SetAddressLabel(address, name string) error {
+	if !IsValidAddress(address) {
+		return fmt.Errorf("invalid address: %v", address)
+	}
+	name = strings.TrimSpace(name)
+	if utf8.RuneCountInString(name) > MaxAddressLabelLength {
+		return fmt.Errorf("label longer than %d characters: %q", MaxAddressLabelLength, name)
+	}
+	book, ok := parser.store.(labelStore)
+	if !ok {
+		return errNoAddressBook
+	}
+	return book.SetAddressLabel(address, name)
+}
+
+// AddressLabels returns the labels of the address book by lower case address.
+func (parser *EthereumParser) AddressLabels() (map[string]string, error) {
+	book, ok := parser.store.(labelStore)
+	if !ok {
+		return nil, errNoAddressBook
+	}
+	return book.AddressLabels()
+}
+
+// labelTransactions sets the labels of the senders and recipients of transactions.
+func (parser *EthereumParser) labelTransactions(transactions []Transaction) {
+	for i := range transactions {
+		parser.labelTransaction(&transactions[i])
+	}
+}
+
+// labelTransaction sets the labels of the sender and recipient of a
+// transaction, and the names of the well-known contracts it involves.
+func (parser *EthereumParser) labelTransaction(transaction *Transaction) {
+	parser.nameKnownContracts(transaction)
+	book, ok := parser.store.(labelStore)
+	if !ok {
+		return
+	}
+	transaction.FromLabel = book.AddressLabel(transaction.From)
+	if transaction.To != "" {
+		transaction.ToLabel = book.AddressLabel(transaction.To)
+	}
+}
+
+// AddressLabel is an entry of the address book.
+type AddressLabel struct {
+	Address string `json:"address"`
+	Name    string `json:"name"`
+}
+
+// AddressLabelList is the address book, ordered by address.
+type AddressLabelList struct {
+	Labels []AddressLabel `json:"labels"`
+}
+
+// ListAddressLabels returns the address book of the parser, ordered by address.
+func ListAddressLabels(parser Parser) (*AddressLabelList, error) {
+	book, ok := parser.(AddressBook)
+	if !ok {
+		return nil, errors.New("parser does not keep address labels")
+	}
+	labels, err := book.AddressLabels()
+	if err != nil {
+		return nil, err
+	}
+	list := &AddressLabelList{Labels: []AddressLabel{}}
+	for address, name := range labels {
+		list.Labels = append(list.Labels, AddressLabel{Address: address, Name: name})
+	}
+	sort.Slice(list.Labels, func(i, j int) bool { return list.Labels[i].Address < list.Labels[j].Address })
+	return list, nil
+}
+
+func (server *Server) handleAddressLabels(w http.ResponseWriter, r *http.Request) {
+	list, err := ListAddressLabels(server.parser)
+	if err != nil {
+		writeError(w, http.StatusNotImplemented, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (server *Server) handleSetAddressLabel(w http.ResponseWriter, r *http.Request) {
+	book, ok := server.parser.(AddressBook)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, "parser does not keep address labels")
+		return
+	}
+	var request struct {
+		Name string `json:"name"`
+	}
+	if !readJSON(w, r, &request) {
+		return
+	}
+	address := r.PathValue("address")
+	if err := book.SetAddressLabel(address, request.Name); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, AddressLabel{Address: address, Name: strings.TrimSpace(request.Name)})
+}
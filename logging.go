@@ -0,0 +1,48 @@
+package parser
+
+import (
+	"io"
+	"log/slog"
+)
+
+// DefaultLogLevel is the log level of the CLI, showing warnings and errors only.
+const DefaultLogLevel = "warn"
+
+// LogLevels maps the accepted log level names to their slog levels.
+var LogLevels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// Log formats, selecting the slog handler of the CLI.
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// LogFormats lists the accepted log formats.
+var LogFormats = map[string]bool{
+	LogFormatText: true,
+	LogFormatJSON: true,
+}
+
+// NewLogger returns a logger writing the records at or above level to w, as
+// text or as one JSON object per line.
+func NewLogger(w io.Writer, level slog.Leveler, format string) *slog.Logger {
+	options := &slog.HandlerOptions{Level: level}
+	if format == LogFormatJSON {
+		return withTraceIDs(slog.New(slog.NewJSONHandler(w, options)))
+	}
+	return withTraceIDs(slog.New(slog.NewTextHandler(w, options)))
+}
+
+// WithLogger sets the logger receiving the parser's diagnostics, slog.Default()
+// by default. Endpoint credentials are redacted from what is logged, and the
+// trace ID of the context is added to the records logged with one.
+func WithLogger(logger *slog.Logger) Option {
+	return func(parser *EthereumParser) {
+		parser.logger = withTraceIDs(logger)
+	}
+}
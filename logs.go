@@ -0,0 +1,60 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+)
+
+// Log represents an event log emitted by a contract.
+type Log struct {
+	Address         string   `json:"address"`
+	Topics          []string `json:"topics"`
+	Data            string   `json:"data"`
+	BlockNumber     string   `json:"blockNumber"`
+	TransactionHash string   `json:"transactionHash"`
+	LogIndex        string   `json:"logIndex"`
+	Removed         bool     `json:"removed"`
+}
+
+// LogFilter selects the logs returned by GetLogs.
+type LogFilter struct {
+	FromBlock uint64
+	ToBlock   uint64
+	BlockHash string     // Block of the logs, instead of FromBlock and ToBlock when set
+	Address   string     // Contract address, any contract when empty
+	Topics    [][]string // Alternatives for each topic position, nil matches any topic
+}
+
+// params converts the filter to the eth_getLogs filter object.
+func (filter LogFilter) params() map[string]interface{} {
+	params := map[string]interface{}{
+		"fromBlock": fmt.Sprintf("0x%x", filter.FromBlock),
+		"toBlock":   fmt.Sprintf("0x%x", filter.ToBlock),
+	}
+	if filter.BlockHash != "" {
+		params = map[string]interface{}{"blockHash": filter.BlockHash}
+	}
+	if filter.Address != "" {
+		params["address"] = filter.Address
+	}
+	if len(filter.Topics) > 0 {
+		topics := make([]interface{}, len(filter.Topics))
+		for i, alternatives := range filter.Topics {
+			if alternatives != nil {
+				topics[i] = alternatives
+			}
+		}
+		params["topics"] = topics
+	}
+	return params
+}
+
+// GetLogs returns the logs matching the filter.
+func (parser *EthereumParser) GetLogs(ctx context.Context, filter LogFilter) ([]Log, error) {
+	logs, err := parser.rpcEthGetLogs(ctx, filter.params())
+	if err != nil {
+		return nil, err
+	}
+
+	return logs, nil
+}
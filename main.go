@@ -1,47 +1,125 @@
-package main
+// Package parser follows the transactions of subscribed Ethereum addresses
+// through a node's JSON-RPC API.
+package parser
 
 import (
-	"bufio"
+	"bytes"
+	"cmp"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"math/big"
 	"net/http"
-	"os"
+	"net/url"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// JSON-RPC request structure
+type RPCRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+	ID      int           `json:"id"`
+}
+
 // JSON-RPC response structure
 type RPCResponse struct {
 	Result json.RawMessage `json:"result"`
-	Error  interface{}     `json:"error"`
+	Error  *RPCError       `json:"error"`
 	ID     int             `json:"id"`
 }
 
+// RPCError is the error object of a JSON-RPC response.
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (err *RPCError) Error() string {
+	if err.Code == 0 {
+		return "JSON-RPC error: " + err.Message
+	}
+	return fmt.Sprintf("JSON-RPC error %d: %v", err.Code, err.Message)
+}
+
+// UnmarshalJSON also accepts the bare message some nodes send instead of an
+// error object.
+func (err *RPCError) UnmarshalJSON(data []byte) error {
+	var message string
+	if json.Unmarshal(data, &message) == nil {
+		*err = RPCError{Message: message}
+		return nil
+	}
+	type rpcError RPCError // Without this method
+	return json.Unmarshal(data, (*rpcError)(err))
+}
+
+// BlockHeader represents the header fields of an Ethereum block.
+type BlockHeader struct {
+	Number        string `json:"number"`
+	Hash          string `json:"hash"`
+	ParentHash    string `json:"parentHash"`
+	Timestamp     string `json:"timestamp"`
+	Miner         string `json:"miner"`
+	GasUsed       string `json:"gasUsed"`
+	GasLimit      string `json:"gasLimit"`
+	BaseFeePerGas string `json:"baseFeePerGas"`
+}
+
 // Block represents a simplified Ethereum block.
 type Block struct {
-	Hash         string        `json:"hash"`
+	BlockHeader
 	Transactions []Transaction `json:"transactions"`
 }
 
 // Transaction represents a simplified Ethereum transaction.
 type Transaction struct {
-	Hash        string `json:"hash"`
-	BlockNumber string `json:"blockNumber"`
-	From        string `json:"from"`
-	To          string `json:"to"`
-	Value       string `json:"value"`
+	Hash         string `json:"hash"`
+	BlockNumber  string `json:"blockNumber"`
+	From         string `json:"from"`
+	To           string `json:"to"`
+	Value        string `json:"value"`
+	Input        string `json:"input"`
+	Nonce        string `json:"nonce,omitempty"`
+	FromLabel    string `json:"fromLabel,omitempty"`    // Name of From in the address book
+	ToLabel      string `json:"toLabel,omitempty"`      // Name of To in the address book
+	ContractName string `json:"contractName,omitempty"` // Name of the well-known contract To, or else From
+	TokenSymbol  string `json:"tokenSymbol,omitempty"`  // Symbol of the well-known token transferred
+	TokenName    string `json:"tokenName,omitempty"`    // Name of the well-known token transferred
+	Chain        string `json:"chain,omitempty"`        // Chain the transaction was dispatched on, set by Watch
 }
 
 // Store defines the interface for interacting with storage.
 type Store interface {
 	GetSubscribers() (map[string]bool, error)
-	SetSubscriber(address string) error
+	SetSubscriber(address string) (existed bool, err error)
+	RemoveSubscriber(address string) error
 	IsSubscriber(address string) bool
+	BeginTransaction() StorageTransaction
 }
 
 // MemoryStorage represents an in-memory data storage.
 type MemoryStorage struct {
-	subscribers map[string]bool // Map from address to subscribers
+	Capacity    int // Maximum number of subscribers, unlimited when zero
+	mu          sync.RWMutex
+	subscribers map[string]bool                 // Map from address to subscribers
+	version     uint64                          // Incremented by every change, so that transactions can detect conflicts
+	snapshot    atomic.Pointer[map[string]bool] // Copy of subscribers shared until the next change, see subscriberSnapshot
+	bloom       atomic.Pointer[bloomFilter]     // Pre-check of the subscriber lookups, see EnableBloomFilter
+
+	mutations      *RingBuffer[subscriberMutation] // Recent changes, see GetSubscribersDiff
+	droppedVersion uint64                          // Version of the last change dropped from mutations
+
+	labelsMu sync.RWMutex
+	labels   map[string]string // Address book by lower case address, see SetAddressLabel
 }
 
 // NewMemoryStorage initializes a new MemoryStorage instance.
@@ -52,19 +130,80 @@ func NewMemoryStorage() *MemoryStorage {
 }
 
 func (memory *MemoryStorage) GetSubscribers() (map[string]bool, error) {
-	return memory.subscribers, nil
+	memory.mu.RLock()
+	defer memory.mu.RUnlock()
+
+	subscribers := make(map[string]bool, len(memory.subscribers))
+	for address, value := range memory.subscribers {
+		subscribers[address] = value
+	}
+	return subscribers, nil
 }
 
-func (memory *MemoryStorage) SetSubscriber(address string) error {
+// SetSubscriber adds a subscriber, reporting whether it already was one, in
+// which case nothing changes.
+func (memory *MemoryStorage) SetSubscriber(address string) (existed bool, err error) {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	if memory.subscribers[address] {
+		return true, nil
+	}
+	if memory.atCapacity() {
+		return false, ErrStoreFull
+	}
+	if filter := memory.bloom.Load(); filter != nil {
+		filter.add(address)
+	}
 	memory.subscribers[address] = true
+	memory.version++
+	memory.recordMutation(subscriberAdded, address)
+	memory.snapshot.Store(nil)
+	return false, nil
+}
+
+// AtCapacity reports whether the storage holds Capacity subscribers.
+func (memory *MemoryStorage) AtCapacity() bool {
+	memory.mu.RLock()
+	defer memory.mu.RUnlock()
+
+	return memory.atCapacity()
+}
+
+func (memory *MemoryStorage) atCapacity() bool {
+	return memory.Capacity > 0 && len(memory.subscribers) >= memory.Capacity
+}
+
+func (memory *MemoryStorage) RemoveSubscriber(address string) error {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	_, exists := memory.subscribers[address]
+	if filter := memory.bloom.Load(); filter != nil && exists {
+		filter.remove(address)
+	}
+	delete(memory.subscribers, address)
+	memory.version++
+	if exists {
+		memory.recordMutation(subscriberRemoved, address)
+	}
+	memory.snapshot.Store(nil)
 	return nil
 }
 
 func (memory *MemoryStorage) IsSubscriber(address string) bool {
-	value, ok := memory.subscribers[address]
-	if !ok {
+	filter := memory.bloom.Load()
+	if filter != nil && !filter.mayContain(address) {
 		return false
 	}
+
+	memory.mu.RLock()
+	defer memory.mu.RUnlock()
+
+	value, ok := memory.subscribers[address]
+	if filter != nil && !ok {
+		filter.falsePositive()
+	}
 	return value
 }
 
@@ -72,222 +211,609 @@ func (memory *MemoryStorage) IsSubscriber(address string) bool {
 type Parser interface {
 	GetCurrentBlock() uint64
 	GetTransactions(address string) []Transaction
-	SubscribeAddress(address string) bool
+	SubscribeAddress(address string) (alreadySubscribed bool, err error)
 }
 
 // EthereumParser implements the Parser interface for Ethereum blockchain.
 type EthereumParser struct {
-	Endpoint string
-	store    Store
+	Endpoint               string
+	WatchInterval          time.Duration // Polling interval used by Watch
+	MinInterval            time.Duration // Lower bound for the AdaptiveWatch polling interval
+	MaxInterval            time.Duration // Upper bound for the AdaptiveWatch polling interval
+	Confirmations          uint64        // Number of blocks Watch stays behind the chain head
+	SplitResolutionTimeout uint64        // Number of blocks Watch waits before resolving a chain split
+	ActivationDelay        time.Duration // Time after subscribing before Watch emits an address's transactions
+	IndexCapacity          int           // Number of recent transactions the Index keeps per address, see WithIndexCapacity
+	SyncCheckInterval      time.Duration // Interval Watch checks whether the node syncs at, 0 not checking
+	HealthCheckInterval    time.Duration // Interval Watch checks the health of the endpoint at, 0 not checking
+	verifyOnChain          bool          // Whether ValidateSubscription checks the address on chain
+	store                  Store
+	client                 *http.Client
+	userAgent              string
+	recordDir              string // Directory WithRecording saves fixtures to
+	logger                 *slog.Logger
+	chain                  Chain // Chain the node is expected to serve
+	clock                  Clock
+	tracer                 Tracer
+	adaptive               *adaptiveInterval
+	started                time.Time
+	index                  *Index // Transactions of subscribed addresses in the blocks Watch processed
+	metrics                *processingMetrics
+	rpcErrors              rpcErrorLog                  // Last failed RPC calls, for DebugDump
+	capabilities           atomic.Pointer[Capabilities] // Results of the last Doctor probe
+	rpcHooks               RPCHooks                     // Called around every attempt of an RPC call, see WithRPCHooks
+	rpcTimeout             time.Duration                // Bound of a call and its retries, see WithRPCTimeouts
+	rpcMethodTimeouts      map[string]time.Duration     // Bounds of the calls of specific methods
+	nodeInfoInterval       time.Duration                // Refresh interval of nodeInfo, see WithNodeInfoInterval
+	nodeInfo               atomic.Pointer[NodeInfo]     // Last NodeInfo, see GetNodeInfo
+	statusCh               chan<- WatchStatus           // Receives the pauses of Watch, see WithWatchStatus
+	syncPaused             atomic.Bool                  // Whether Watch waits for the node to sync
+	pendingScanInterval    time.Duration                // Interval Watch scans the pending block at, see WithPendingScan
+	stuckAfter             time.Duration                // Time a transaction may stay pending before it is stuck
+	alertRules             atomic.Pointer[[]alertRule]  // Rules evaluated against every block, see SetAlertRules
+	alertNotifiers         map[string]chan<- AlertEvent // Notifiers of the alerts by target, see WithAlertNotifier
+	approvalsEnabled       bool                         // Whether Watch matches Approval events, see WithApprovalMonitoring
+	approvalNotifier       chan<- ApprovalEvent
+	knownContracts         atomic.Pointer[map[knownContractKey]KnownContract] // Named in transactions, see SetKnownContracts
+
+	activityMu sync.Mutex
+	activity   map[string]activityCache // Activity scores by lower case address, see ActivityScore
+
+	contractsMu sync.Mutex
+	contracts   map[string]contractStatus // Whether lower case addresses hold code, see PreloadContractStatus
+
+	stuckMu     sync.Mutex
+	minedNonces map[string]uint64              // Nonce of the next transaction of subscribed senders to be mined
+	pendingTxs  map[string]*pendingTransaction // Pending transactions of subscribed senders by hash, see scanPending
+	stuckCh     chan StuckTransaction
+
+	healthMu sync.Mutex
+	health   *EndpointPool // Health checks of the Endpoint, without WithEndpointPool
+	pool     *EndpointPool // Endpoints of WithEndpointPool
+
+	approvalsMu sync.Mutex
+	approvals   map[string]*RingBuffer[ApprovalEvent] // Recent approvals by lower case owner, see Approvals
+
+	decimalsMu sync.Mutex
+	decimals   map[string]int // Decimals of lower case ERC-20 tokens, see tokenDecimals
+
+	// settingsMu guards Endpoint, WatchInterval, Confirmations and
+	// ActivationDelay once the parser runs, see Reconfigure
+	settingsMu sync.RWMutex
+
+	mu          sync.Mutex
+	watermarks  map[string]uint64       // Map from address to the block its subscription started at
+	activations map[string]time.Time    // Map from inactive address to the time it becomes active
+	listeners   map[*watchListener]bool // Listeners receiving the transactions dispatched by Watch
+	throttles   map[string]*addressThrottle
+	throttleCh  chan ThrottledEvent
+	splitCh     chan ChainSplitEvent
+
+	lastProcessed     uint64 // Number of the last block dispatched by Watch
+	lastProcessedHash string
 }
 
+// Option configures an EthereumParser.
+type Option func(*EthereumParser)
+
 // NewEthereumParser initializes a new EthereumParser instance.
-func NewEthereumParser(endpoint string, store Store) *EthereumParser {
-	return &EthereumParser{
-		Endpoint: endpoint,
-		store:    store,
+func NewEthereumParser(endpoint string, store Store, opts ...Option) *EthereumParser {
+	parser := &EthereumParser{
+		Endpoint:               endpoint,
+		WatchInterval:          DefaultWatchInterval,
+		SplitResolutionTimeout: defaultSplitResolutionTimeout,
+		IndexCapacity:          DefaultIndexCapacity,
+		SyncCheckInterval:      DefaultSyncCheckInterval,
+		HealthCheckInterval:    DefaultHealthCheckInterval,
+		MinInterval:            defaultMinInterval,
+		MaxInterval:            defaultMaxInterval,
+		store:                  store,
+		client:                 http.DefaultClient,
+		userAgent:              DefaultUserAgent,
+		rpcTimeout:             DefaultRPCTimeout,
+		stuckAfter:             DefaultStuckAfter,
+		logger:                 withTraceIDs(slog.Default()),
+		adaptive:               &adaptiveInterval{},
+		clock:                  RealClock{},
+		tracer:                 noopTracer{},
+		metrics:                &processingMetrics{},
+		watermarks:             make(map[string]uint64),
+		activations:            make(map[string]time.Time),
+		listeners:              make(map[*watchListener]bool),
+		throttles:              make(map[string]*addressThrottle),
+		throttleCh:             make(chan ThrottledEvent, throttleEventBufferSize),
+		splitCh:                make(chan ChainSplitEvent, splitEventBufferSize),
+		minedNonces:            make(map[string]uint64),
+		pendingTxs:             make(map[string]*pendingTransaction),
+		stuckCh:                make(chan StuckTransaction, stuckEventBufferSize),
+	}
+	for _, opt := range opts {
+		opt(parser)
 	}
+	if parser.knownContracts.Load() == nil {
+		parser.SetKnownContracts(defaultKnownContracts())
+	}
+	parser.started = parser.clock.Now()
+	parser.index = NewIndex(parser.IndexCapacity)
+	if parser.recordDir != "" {
+		parser.client = newRecordingClient(parser.client, parser.recordDir)
+	}
+	parser.client = newRPCClient(parser.client, parser.userAgent)
+	return parser
 }
 
 // GetCurrentBlock gets the current block number from the Ethereum node.
 func (parser *EthereumParser) GetCurrentBlock() uint64 {
-	var blockNumberHex string
-	err := parser.callRPCMethod("eth_blockNumber", nil, &blockNumberHex)
+	return parser.GetCurrentBlockContext(context.Background())
+}
+
+// GetCurrentBlockContext behaves like GetCurrentBlock, making its RPC call
+// with ctx, such as to carry a trace ID.
+func (parser *EthereumParser) GetCurrentBlockContext(ctx context.Context) uint64 {
+	blockNumber, err := parser.blockNumber(ctx)
 	if err != nil {
-		fmt.Printf("error: %v", err)
+		parser.logger.ErrorContext(ctx, "failed to get current block", "error", err)
 		return 0
 	}
 
-	blockNumber, err := ParseHexUint64(blockNumberHex)
+	return blockNumber
+}
+
+// blockNumber fetches the number of the most recent block.
+func (parser *EthereumParser) blockNumber(ctx context.Context) (uint64, error) {
+	blockNumberHex, err := parser.rpcEthBlockNumber(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return ParseHexUint64(blockNumberHex)
+}
+
+// getBlockByNumber fetches a block together with its full transactions.
+func (parser *EthereumParser) getBlockByNumber(ctx context.Context, number uint64) (*Block, error) {
+	var block Block
+	err := parser.rpcEthGetBlockByNumber(ctx, fmt.Sprintf("0x%x", number), true, &block)
 	if err != nil {
-		fmt.Printf("error: %v", err)
-		return 0
+		return nil, err
+	}
+	if block.Hash == "" {
+		return nil, ErrBlockNotFound
 	}
 
-	return blockNumber
+	return &block, nil
 }
 
-// GetTransactions queries transactions for an address.
+// GetTransactions queries transactions for an address. Once Watch has indexed
+// the address since its subscription, it returns the indexed transactions and
+// those of the blocks produced since; otherwise those of the latest block.
 func (parser *EthereumParser) GetTransactions(address string) []Transaction {
-	var transactions []Transaction
-	var block Block
+	return parser.GetTransactionsContext(context.Background(), address)
+}
+
+// GetTransactionsContext behaves like GetTransactions, making its RPC calls
+// with ctx.
+func (parser *EthereumParser) GetTransactionsContext(ctx context.Context, address string) []Transaction {
 	if address == "" {
-		fmt.Printf("You need to define an address\n")
-		return transactions
+		parser.logger.WarnContext(ctx, "no address given")
+		return nil
+	}
+	transactions, err := parser.FetchTransactions(ctx, address)
+	switch {
+	case errors.Is(err, ErrNotSubscribed):
+		parser.logger.WarnContext(ctx, "address is not subscribed", "address", NormalizeAddress(address))
+	case err != nil:
+		parser.logger.ErrorContext(ctx, "failed to get transactions", "address", NormalizeAddress(address), "error", err)
 	}
+	return transactions
+}
+
+// ErrNotSubscribed is returned by FetchTransactions for an address that is not
+// subscribed.
+var ErrNotSubscribed = errors.New("address is not subscribed")
+
+// FetchTransactions behaves like GetTransactionsContext, but returns the error
+// of a failed RPC call, together with the transactions of the indexed blocks
+// and of the blocks fetched before it.
+func (parser *EthereumParser) FetchTransactions(ctx context.Context, address string) ([]Transaction, error) {
+	if address == "" {
+		return nil, errors.New("no address given")
+	}
+	address = NormalizeAddress(address)
 	if !parser.store.IsSubscriber(address) {
-		fmt.Printf("Address: %v is not subscribed\n", address)
-		return transactions
+		return nil, fmt.Errorf("%w: %v", ErrNotSubscribed, address)
+	}
+	blockNumber, err := parser.blockNumber(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current block: %w", err)
 	}
-	blockNumber := parser.GetCurrentBlock()
-	if blockNumber == 0 {
-		fmt.Printf("blockNumber is %v\n", 0)
-		return transactions
+
+	// Read through the index when it covers the subscription, fetching only the
+	// blocks produced since it was last updated
+	var transactions []Transaction
+	if from, to := parser.index.Coverage(address); to != 0 {
+		watermark, ok := parser.SubscriptionWatermark(address)
+		if !ok || from <= watermark {
+			transactions = parser.index.Get(address)
+			for number := to + 1; number <= blockNumber; number++ {
+				block, err := parser.getBlockByNumber(ctx, number)
+				if err != nil {
+					return transactions, fmt.Errorf("failed to get block %d: %w", number, err)
+				}
+				parser.index.Add(number, []string{address}, block.Transactions)
+				for _, transaction := range block.Transactions {
+					if involvesAddress(transaction, address) {
+						transactions = append(transactions, transaction)
+					}
+				}
+			}
+			return transactions, nil
+		}
 	}
-	err := parser.callRPCMethod("eth_getBlockByNumber", ParseToAnySlice(fmt.Sprintf("0x%x", blockNumber), true), &block)
+
+	block, err := parser.getBlockByNumber(ctx, blockNumber)
 	if err != nil {
-		fmt.Printf("error: %v", err)
-		return transactions
+		return nil, fmt.Errorf("failed to get block %d: %w", blockNumber, err)
 	}
 
 	for _, transaction := range block.Transactions {
-		if transaction.From == address || transaction.To == address {
+		if involvesAddress(transaction, address) {
 			transactions = append(transactions, transaction)
 		}
 	}
 
-	return transactions
+	return transactions, nil
 }
 
-// SubscribeAddress subscribes to an Ethereum address.
-func (parser *EthereumParser) SubscribeAddress(address string) bool {
+// SubscribeAddress subscribes to an Ethereum address once ValidateSubscription
+// accepts it. Subscribing to an address again, in any spelling, is not an
+// error: it reports that the address was already subscribed, and changes
+// nothing. The address is stored in the form of NormalizeAddress.
+func (parser *EthereumParser) SubscribeAddress(address string) (alreadySubscribed bool, err error) {
 	if address == "" {
-		fmt.Printf("You need to define an address\n")
-		return false
+		return false, errors.New("no address given")
 	}
-	if err := parser.store.SetSubscriber(address); err != nil {
+	if err := parser.validateSubscription(address, true); err != nil {
+		return false, err
+	}
+	key := NormalizeAddress(address)
+	existed, err := parser.store.SetSubscriber(key)
+	if err != nil {
+		return false, &SubscriptionError{Address: address, Problems: []error{err}}
+	}
+	if !existed {
+		parser.delayActivation(key)
+	}
+	return existed, nil
+}
+
+// UnsubscribeAddress removes the subscription to an Ethereum address.
+func (parser *EthereumParser) UnsubscribeAddress(address string) bool {
+	address = NormalizeAddress(address)
+	if err := parser.store.RemoveSubscriber(address); err != nil {
 		return false
 	}
+
+	parser.mu.Lock()
+	delete(parser.watermarks, address)
+	delete(parser.activations, address)
+	parser.mu.Unlock()
+	parser.index.Remove(address)
 	return true
 }
 
-// callRPCMethod sends a JSON-RPC request to the Ethereum node.
-func (parser *EthereumParser) callRPCMethod(method string, params []interface{}, result interface{}) error {
+// SubscriptionWatermark returns the block from which the address's subscription
+// is watched, if one was recorded.
+func (parser *EthereumParser) SubscriptionWatermark(address string) (uint64, bool) {
+	parser.mu.Lock()
+	defer parser.mu.Unlock()
+
+	watermark, ok := parser.watermarks[NormalizeAddress(address)]
+	return watermark, ok
+}
+
+// Subscribers returns the subscribed addresses in ascending order.
+func (parser *EthereumParser) Subscribers() ([]string, error) {
+	subscribers, err := parser.store.GetSubscribers()
+	if err != nil {
+		return nil, err
+	}
+
+	var addresses []string
+	for address, subscribed := range subscribers {
+		if subscribed {
+			addresses = append(addresses, address)
+		}
+	}
+	sort.Strings(addresses)
+	return addresses, nil
+}
+
+// CallCustomMethod calls a JSON-RPC method that has no typed wrapper, such
+// as a network's proprietary method, and decodes its result into result. It is
+// an escape hatch: prefer adding the method to rpc_methods.yaml.
+func (parser *EthereumParser) CallCustomMethod(ctx context.Context, method string, params []interface{}, result interface{}) error {
+	return parser.callRPCMethod(ctx, method, params, result)
+}
+
+//go:generate go run cmd/gen-rpc/main.go -in rpc_methods.yaml -out rpc_generated.go
+
+// callRPCMethod sends a JSON-RPC request to the Ethereum node. It is called
+// through the typed wrappers generated from rpc_methods.yaml. Calls of the
+// idempotent methods that fail in transit are retried within the retry
+// budget, see retryWithin; the others are sent once. The method's RPC timeout
+// bounds the call and its retries. Each attempt goes to the endpoint the
+// EndpointPool selects, which avoids an endpoint that failed in transit.
+func (parser *EthereumParser) callRPCMethod(ctx context.Context, method string, params []interface{}, result interface{}) (err error) {
+	start := parser.clock.Now()
+	pool := parser.endpoints()
+	rawEndpoint := pool.Next()
+	endpoint := RedactURL(rawEndpoint)
+	attempts := 0
+	ctx, span := parser.tracer.Start(ctx, "rpc "+method, slog.String("rpc.method", method), slog.String("rpc.endpoint", endpoint))
+	defer func() {
+		duration := parser.clock.Now().Sub(start)
+		attrs := []any{"method", method, "duration", duration, "endpoint", endpoint, "attempts", attempts}
+		if err != nil {
+			attrs = append(attrs, "error", err)
+		}
+		parser.logger.DebugContext(ctx, "RPC call", attrs...)
+		parser.metrics.recordRPC(method, duration, err)
+		if err != nil {
+			parser.rpcErrors.record(RPCErrorRecord{Time: start, Method: method, Error: err.Error()})
+		}
+		span.RecordError(err)
+		span.End()
+		if err != nil {
+			err = &RPCCallError{Method: method, Endpoint: endpoint, Attempts: attempts, Err: err}
+		}
+	}()
+
+	if params == nil {
+		params = []interface{}{} // Sent as [] rather than null
+	}
+	requestBody, err := json.Marshal(RPCRequest{JSONRPC: "2.0", Method: method, Params: params, ID: 1})
+	if err != nil {
+		return fmt.Errorf("failed to encode params: %w", err)
+	}
+
+	if timeout := parser.rpcTimeoutOf(method); timeout > 0 {
+		parent := ctx
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+		defer func() {
+			if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) && parent.Err() == nil {
+				err = fmt.Errorf("timed out after %v: %w", timeout, err)
+			}
+		}()
+	}
+
+	for delay := rpcRetryDelay; ; delay *= 2 {
+		attempts++
+		var transient bool
+		if parser.rpcHooks.OnRequest != nil {
+			parser.rpcHooks.OnRequest(ctx, RPCRequestInfo{TraceID: TraceID(ctx), Method: method, Attempt: attempts})
+		}
+		sent := parser.clock.Now()
+		transient, err = parser.sendRPCRequest(ctx, rawEndpoint, requestBody, result)
+		if transient {
+			pool.markFailed(rawEndpoint)
+		}
+		if parser.rpcHooks.OnResponse != nil {
+			parser.rpcHooks.OnResponse(ctx, RPCResponseInfo{TraceID: TraceID(ctx), Method: method, Attempt: attempts, Duration: parser.clock.Now().Sub(sent), Err: err})
+		}
+		if err == nil || !transient || !idempotentRPCMethods[method] || attempts == rpcMaxAttempts || !parser.retryWithin(ctx, start, delay) {
+			return err
+		}
+		parser.logger.DebugContext(ctx, "retrying RPC call", "method", method, "attempt", attempts, "delay", delay, "error", err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-parser.clock.After(delay):
+		}
+		rawEndpoint = pool.Next()
+		endpoint = RedactURL(rawEndpoint)
+	}
+}
+
+// sendRPCRequest makes one attempt of a JSON-RPC call to endpoint. It reports whether
+// the failure happened in transit, in which case the node may or may not have
+// processed the request.
+func (parser *EthereumParser) sendRPCRequest(ctx context.Context, endpoint string, requestBody []byte, result interface{}) (transient bool, err error) {
 	var response RPCResponse
-	requestBody := fmt.Sprintf(`{
-		"jsonrpc": "2.0",
-		"method": "%s",
-		"params": %s,
-		"id": 1
-	}`, method, toJSON(params))
-
-	resp, err := http.Post(parser.Endpoint, "application/json", strings.NewReader(requestBody))
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(requestBody))
+	if err != nil {
+		return false, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	resp, err := parser.client.Do(request)
 	if err != nil {
-		return err
+		return ctx.Err() == nil, redactURLError(err)
 	}
 	defer resp.Body.Close()
 
 	err = json.NewDecoder(resp.Body).Decode(&response)
 	if err != nil {
-		fmt.Printf("Failed to decode JSON-RPC response: %v\n", err)
-		return err
+		// Also the error pages of proxies and rate limiters, which are not JSON
+		return ctx.Err() == nil, fmt.Errorf("failed to decode JSON-RPC response: %w", err)
 	}
 
 	// Check for errors in response
 	if response.Error != nil {
-		return fmt.Errorf("JSON-RPC error: %v", response.Error)
+		return false, response.Error
 	}
 
 	// parse to result
 	err = json.Unmarshal(response.Result, &result)
 	if err != nil {
-		return fmt.Errorf("failed to parse response details: %v", err)
+		return false, fmt.Errorf("failed to decode result: %w", err)
 	}
 
-	return nil
+	return false, nil
 }
 
-// toJSON converts parameters to JSON string.
-func toJSON(params []interface{}) string {
-	if len(params) == 0 {
-		return "[]"
+// RedactURL hides the credentials an endpoint URL may hold in its user
+// information, path or query, keeping the scheme and host.
+func RedactURL(endpoint string) string {
+	if endpoint == "" {
+		return endpoint
 	}
-
-	var builder strings.Builder
-	builder.WriteByte('[')
-	for i, param := range params {
-		jsonParam, _ := json.Marshal(param)
-		builder.Write(jsonParam)
-		if i < len(params)-1 {
-			builder.WriteByte(',')
-		}
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return "[redacted]"
 	}
-	builder.WriteByte(']')
-	return builder.String()
+	if parsed.User == nil && parsed.RawQuery == "" && (parsed.Path == "" || parsed.Path == "/") {
+		return endpoint
+	}
+	return parsed.Scheme + "://" + parsed.Host + "/[redacted]"
 }
 
-// ParseHexUint64 parses a hex-encoded string into a uint64.
-func ParseHexUint64(hexStr string) (uint64, error) {
-	return strconv.ParseUint(hexStr[2:], 16, 64)
+// redactURLError keeps credentials in the endpoint out of the errors of HTTP
+// requests, which are logged and printed.
+func redactURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = RedactURL(urlErr.URL)
+	}
+	return err
 }
 
-// ParseToAnySlice parses any argument string into an interface{}.
-func ParseToAnySlice(params ...interface{}) []interface{} {
-	var allParams []interface{}
+// Maximum number of significant digits of the hex quantities.
+const (
+	maxHexUint64Digits = 16
+	maxHexBigDigits    = 64 // uint256
+)
 
-	// Convert each string element to interface and append to allParams
-	for _, param := range params {
-		allParams = append(allParams, param)
+// ParseHexUint64 parses a 0x-prefixed hex quantity, such as "0x0" or "0x1A",
+// into a uint64. It fails on an empty or unprefixed value and on one that
+// overflows uint64, which ParseHexBig parses.
+func ParseHexUint64(hexStr string) (uint64, error) {
+	digits, err := hexQuantityDigits(hexStr, maxHexUint64Digits)
+	if err != nil {
+		return 0, err
 	}
-
-	return allParams
+	return strconv.ParseUint(digits, 16, 64)
 }
 
-func processCommands(cmdCh <-chan string) {
-	// Ethereum node JSON-RPC endpoint
-	endpoint := "https://cloudflare-eth.com"
+// ParseHexBig parses a 0x-prefixed hex quantity of up to 256 bits.
+func ParseHexBig(hexStr string) (*big.Int, error) {
+	digits, err := hexQuantityDigits(hexStr, maxHexBigDigits)
+	if err != nil {
+		return nil, err
+	}
+	value, _ := new(big.Int).SetString(digits, 16)
+	return value, nil
+}
 
-	memoryDB := NewMemoryStorage()
+// CmpHex compares two 0x-prefixed hex quantities of up to 256 bits, such as
+// block numbers, returning -1, 0 or +1 as a is less than, equal to or greater
+// than b. The digits are compared in place, without parsing the values.
+func CmpHex(a, b string) (int, error) {
+	digitsA, err := hexQuantityDigits(a, maxHexBigDigits)
+	if err != nil {
+		return 0, err
+	}
+	digitsB, err := hexQuantityDigits(b, maxHexBigDigits)
+	if err != nil {
+		return 0, err
+	}
+	// Without leading zeros, the longer quantity is the greater
+	digitsA, digitsB = strings.TrimLeft(digitsA, "0"), strings.TrimLeft(digitsB, "0")
+	if len(digitsA) != len(digitsB) {
+		return cmp.Compare(len(digitsA), len(digitsB)), nil
+	}
+	for i := 0; i < len(digitsA); i++ {
+		if c := cmp.Compare(lowerHexDigit(digitsA[i]), lowerHexDigit(digitsB[i])); c != 0 {
+			return c, nil
+		}
+	}
+	return 0, nil
+}
 
-	// Create EthereumParser instance
-	parser := NewEthereumParser(endpoint, memoryDB)
+// lowerHexDigit returns the lower case form of a hex digit.
+func lowerHexDigit(digit byte) byte {
+	if 'A' <= digit && digit <= 'F' {
+		return digit + 'a' - 'A'
+	}
+	return digit
+}
 
-	var args []string
-	for {
-		select {
-		case cmd := <-cmdCh:
-			args = strings.Fields(cmd)
-			if len(args) < 1 {
-				fmt.Println("\nYou need to define an action (getCurrentBlock, getTransaction, SubscribeAddress)")
-				continue
-			}
-			action := args[0]
+// MaxHex returns the greatest of hex quantities, as given, see CmpHex.
+func MaxHex(values ...string) (string, error) {
+	return extremeHex(values, 1)
+}
 
-			address := ""
-			if len(args) > 1 {
-				address = args[1]
-			}
+// MinHex returns the least of hex quantities, as given, see CmpHex.
+func MinHex(values ...string) (string, error) {
+	return extremeHex(values, -1)
+}
 
-			// Example usage
-			switch action {
-			case "getCurrentBlock":
-				fmt.Println(parser.GetCurrentBlock())
-				continue
-			case "getTransaction":
-				fmt.Println(parser.GetTransactions(address))
-				continue
-			case "subscribeAddress":
-				fmt.Println(parser.SubscribeAddress(address))
-				continue
-			default:
-				fmt.Printf("Invalid action: %v. please pick valid action (getCurrentBlock, getTransaction, subscribeAddress)", action)
-				continue
-			}
+// extremeHex returns the first of values that no other compares to as sign.
+func extremeHex(values []string, sign int) (string, error) {
+	if len(values) == 0 {
+		return "", errors.New("no hex quantities")
+	}
+	extreme := values[0]
+	if _, err := CmpHex(extreme, extreme); err != nil {
+		return "", err
+	}
+	for _, value := range values[1:] {
+		c, err := CmpHex(value, extreme)
+		if err != nil {
+			return "", err
+		}
+		if c == sign {
+			extreme = value
 		}
 	}
-
+	return extreme, nil
 }
 
-func main() {
-	// Create a channel to receive commands
-	cmdCh := make(chan string)
+// hexQuantityDigits returns the digits of a 0x-prefixed hex quantity, checking
+// that there are some, that they are hex and that at most maxDigits of them
+// are significant.
+func hexQuantityDigits(hexStr string, maxDigits int) (string, error) {
+	digits, ok := strings.CutPrefix(hexStr, "0x")
+	if !ok {
+		digits, ok = strings.CutPrefix(hexStr, "0X")
+	}
+	if !ok {
+		return "", fmt.Errorf("invalid hex quantity %q: missing 0x prefix", hexStr)
+	}
+	if digits == "" {
+		return "", fmt.Errorf("invalid hex quantity %q: no digits", hexStr)
+	}
+	for _, char := range digits {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", char) {
+			return "", fmt.Errorf("invalid hex quantity %q: %q is not a hex digit", hexStr, char)
+		}
+	}
+	if len(strings.TrimLeft(digits, "0")) > maxDigits {
+		return "", fmt.Errorf("invalid hex quantity %q: more than %d bits", hexStr, 4*maxDigits)
+	}
+	return digits, nil
+}
 
-	// Start a goroutine to continuously process commands
-	go processCommands(cmdCh)
+// IsValidAddress reports whether address is a 0x-prefixed, 20-byte hex string.
+func IsValidAddress(address string) bool {
+	return isHexOfLength(address, 20)
+}
 
-	// Main loop to read user input and send commands to the channel
-	scanner := bufio.NewScanner(os.Stdin)
-	for {
-		fmt.Print("Enter command (e.g: getCurrentBlock): ")
-		if !scanner.Scan() {
-			break
-		}
+// IsValidHash reports whether hash is a 0x-prefixed, 32-byte hex string.
+func IsValidHash(hash string) bool {
+	return isHexOfLength(hash, 32)
+}
 
-		command := scanner.Text()
-		cmdCh <- command // Send the command to the channel
+// isHexOfLength reports whether value is a 0x-prefixed hex string of size bytes.
+func isHexOfLength(value string, size int) bool {
+	if len(value) != 2+2*size || !strings.HasPrefix(value, "0x") {
+		return false
 	}
-
-	if err := scanner.Err(); err != nil {
-		fmt.Printf("Error reading standard input: %v\n", err)
+	for _, char := range value[2:] {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", char) {
+			return false
+		}
 	}
+	return true
 }
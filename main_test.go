@@ -0,0 +1,115 @@
+package parser
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseHexUint64(t *testing.T) {
+	tests := []struct {
+		hex     string
+		want    uint64
+		wantErr string // Part of the error, "" when none is expected
+	}{
+		{"0x0", 0, ""},
+		{"0x1a", 26, ""},
+		{"0x1A", 26, ""},
+		{"0X1A", 26, ""},
+		{"0xffffffffffffffff", 1<<64 - 1, ""},
+		{"0x00000000000000000001", 1, ""},
+		{"", 0, "missing 0x prefix"},
+		{"0x", 0, "no digits"},
+		{"0X", 0, "no digits"},
+		{"1a", 0, "missing 0x prefix"},
+		{"26", 0, "missing 0x prefix"},
+		{"0x1g", 0, "is not a hex digit"},
+		{"0x-1", 0, "is not a hex digit"},
+		{"0x10000000000000000", 0, "more than 64 bits"},
+		{"0xffffffffffffffffff", 0, "more than 64 bits"},
+	}
+	for _, test := range tests {
+		got, err := ParseHexUint64(test.hex)
+		if test.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("ParseHexUint64(%q) = %d, %v, want an error containing %q", test.hex, got, err, test.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("ParseHexUint64(%q) = %d, %v, want %d", test.hex, got, err, test.want)
+		}
+	}
+}
+
+// FuzzParseHexUint64 checks that ParseHexUint64 never panics, and agrees with
+// ParseHexBig on the quantities it accepts.
+func FuzzParseHexUint64(f *testing.F) {
+	for _, seed := range []string{"", "0x", "0x0", "0X1A", "1a", "0xffffffffffffffff", "0x10000000000000000", "0x0000000000000000000001", "0x1g"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, hex string) {
+		got, err := ParseHexUint64(hex)
+		value, bigErr := ParseHexBig(hex)
+		if err != nil {
+			if bigErr == nil && value.IsUint64() {
+				t.Errorf("ParseHexUint64(%q) error = %v, but ParseHexBig = %v", hex, err, value)
+			}
+			return
+		}
+		if bigErr != nil || !value.IsUint64() || value.Uint64() != got {
+			t.Errorf("ParseHexUint64(%q) = %d, but ParseHexBig = %v, %v", hex, got, value, bigErr)
+		}
+		if again, err := ParseHexUint64(fmt.Sprintf("0x%x", got)); err != nil || again != got {
+			t.Errorf("ParseHexUint64(%#x) = %d, %v, want %d", got, again, err, got)
+		}
+	})
+}
+
+func TestParseHexBig(t *testing.T) {
+	maxUint256 := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	tests := []struct {
+		hex     string
+		want    *big.Int
+		wantErr bool
+	}{
+		{"0x0", big.NewInt(0), false},
+		{"0xde0b6b3a7640000", big.NewInt(1e18), false},
+		{"0x" + "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", maxUint256, false},
+		{"0x1" + "0000000000000000000000000000000000000000000000000000000000000000", nil, true},
+		{"0x", nil, true},
+		{"de0b6b3a7640000", nil, true},
+	}
+	for _, test := range tests {
+		got, err := ParseHexBig(test.hex)
+		if (err != nil) != test.wantErr {
+			t.Errorf("ParseHexBig(%q) error = %v, want error %v", test.hex, err, test.wantErr)
+			continue
+		}
+		if err == nil && got.Cmp(test.want) != 0 {
+			t.Errorf("ParseHexBig(%q) = %v, want %v", test.hex, got, test.want)
+		}
+	}
+}
+
+// TestGetTransactionsAddressCase reads transactions of the latest block whose
+// addresses are spelled otherwise than the subscription.
+func TestGetTransactionsAddressCase(t *testing.T) {
+	sent := Transaction{Hash: "0x1", From: strings.ToLower(checksummedAddress), To: otherAddress}
+	received := Transaction{Hash: "0x2", From: otherAddress, To: "0x" + strings.ToUpper(checksummedAddress[2:])}
+	other := Transaction{Hash: "0x3", From: otherAddress, To: otherAddress}
+	parser := newTestParser(t, []*Block{testBlock(1, sent, received, other)})
+	if _, err := parser.SubscribeAddress(checksummedAddress); err != nil {
+		t.Fatal(err)
+	}
+
+	var hashes []string
+	for _, transaction := range parser.GetTransactions(checksummedAddress) {
+		hashes = append(hashes, transaction.Hash)
+	}
+	if want := []string{"0x1", "0x2"}; !slices.Equal(hashes, want) {
+		t.Errorf("GetTransactions = %v, want %v", hashes, want)
+	}
+}
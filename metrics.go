@@ -0,0 +1,234 @@
+package parser
+
+import (
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Match types counted by the processing metrics, the keys of Metrics.Matches.
+const (
+	MatchSent             = "sent"
+	MatchReceived         = "received"
+	MatchInternal         = "internal" // Between two subscribed addresses, or an address and itself
+	MatchContractCreation = "contract_creation"
+)
+
+// latencyBuckets are the upper bounds of the RPC latency histogram buckets. A
+// last, unbounded bucket holds the slower calls.
+var latencyBuckets = [...]time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// Metrics is a snapshot of the parser's processing counters.
+type Metrics struct {
+	BlocksProcessed      uint64                `json:"blocksProcessed"`
+	BlocksRetried        uint64                `json:"blocksRetried"` // Blocks that failed to be fetched and were retried on the next poll
+	TransactionsScanned  uint64                `json:"transactionsScanned"`
+	Matches              map[string]uint64     `json:"matches"` // Transactions of subscribed addresses by match type
+	ReorgsHandled        uint64                `json:"reorgsHandled"`
+	NotificationsSent    uint64                `json:"notificationsSent"`
+	NotificationsFailed  uint64                `json:"notificationsFailed"`   // Transactions not delivered because the receiver went away
+	NotificationsDropped uint64                `json:"notificationsDropped"`  // Transactions not delivered because a listener's buffer was full
+	DroppedTransactions  uint64                `json:"droppedTransactions"`   // Old transactions dropped from the Index at capacity
+	RPC                  map[string]RPCMetrics `json:"rpc"`                   // Keyed by method
+	BloomFilter          *BloomFilterStats     `json:"bloomFilter,omitempty"` // Nil when the storage has no bloom filter
+	rpcLatencyHistograms map[string]latencyHistogram
+}
+
+// RPCMetrics summarizes the calls of an RPC method. The latency percentiles
+// are the upper bounds of the histogram buckets they fall in.
+type RPCMetrics struct {
+	Calls  uint64        `json:"calls"`
+	Errors uint64        `json:"errors"`
+	P50    time.Duration `json:"p50"`
+	P90    time.Duration `json:"p90"`
+	P99    time.Duration `json:"p99"`
+}
+
+// MetricsReporter is implemented by parsers that count their processing.
+type MetricsReporter interface {
+	Metrics() Metrics
+}
+
+// Metrics returns a snapshot of the processing counters.
+func (parser *EthereumParser) Metrics() Metrics {
+	metrics := snapshotMetrics(parser.metrics)
+	metrics.DroppedTransactions = parser.index.DroppedOldTransactions()
+	if reporter, ok := parser.store.(bloomReporter); ok {
+		metrics.BloomFilter = reporter.BloomFilterStats()
+	}
+	return metrics
+}
+
+// processingMetrics holds the counters updated by Watch and the RPC calls.
+// They are updated atomically, so reading them never blocks processing.
+type processingMetrics struct {
+	blocksProcessed      atomic.Uint64
+	blocksRetried        atomic.Uint64
+	transactionsScanned  atomic.Uint64
+	reorgsHandled        atomic.Uint64
+	notificationsSent    atomic.Uint64
+	notificationsFailed  atomic.Uint64
+	notificationsDropped atomic.Uint64
+	matches              sync.Map // Map from match type to *atomic.Uint64
+	rpc                  sync.Map // Map from method to *rpcMethodMetrics
+}
+
+// rpcMethodMetrics counts the calls of an RPC method.
+type rpcMethodMetrics struct {
+	calls   atomic.Uint64
+	errors  atomic.Uint64
+	total   atomic.Int64                           // Sum of the latencies in nanoseconds
+	buckets [len(latencyBuckets) + 1]atomic.Uint64 // Calls per latencyBuckets bucket
+}
+
+// countMatch counts a transaction dispatched for subscribed addresses.
+func (metrics *processingMetrics) countMatch(matchType string) {
+	counter, _ := metrics.matches.LoadOrStore(matchType, new(atomic.Uint64))
+	counter.(*atomic.Uint64).Add(1)
+}
+
+// recordRPC counts an RPC call and its latency.
+func (metrics *processingMetrics) recordRPC(method string, latency time.Duration, err error) {
+	value, _ := metrics.rpc.LoadOrStore(method, new(rpcMethodMetrics))
+	counters := value.(*rpcMethodMetrics)
+	counters.calls.Add(1)
+	if err != nil {
+		counters.errors.Add(1)
+	}
+	counters.total.Add(int64(latency))
+	bucket, _ := slices.BinarySearch(latencyBuckets[:], latency)
+	counters.buckets[bucket].Add(1)
+}
+
+// matchType classifies a transaction dispatched because it was sent and/or
+// received by subscribed addresses.
+func matchType(transaction Transaction, sent, received bool) string {
+	switch {
+	case IsContractCreation(transaction):
+		return MatchContractCreation
+	case sent && received:
+		return MatchInternal
+	case sent:
+		return MatchSent
+	default:
+		return MatchReceived
+	}
+}
+
+// latencyHistogram is a snapshot of an RPC method's latency histogram.
+type latencyHistogram struct {
+	buckets []uint64 // Calls per latencyBuckets bucket, not cumulative
+	total   time.Duration
+}
+
+// percentile returns the upper bound of the bucket holding the q-th quantile
+// of the latencies, or the largest bound when it falls in the unbounded bucket.
+func (histogram latencyHistogram) percentile(q float64) time.Duration {
+	var count uint64
+	for _, calls := range histogram.buckets {
+		count += calls
+	}
+	if count == 0 {
+		return 0
+	}
+
+	rank := uint64(q*float64(count) + 0.5)
+	var cumulative uint64
+	for i, calls := range histogram.buckets[:len(latencyBuckets)] {
+		cumulative += calls
+		if cumulative >= max(rank, 1) {
+			return latencyBuckets[i]
+		}
+	}
+	return latencyBuckets[len(latencyBuckets)-1]
+}
+
+// snapshotMetrics reads the counters of one or more parsers into one snapshot.
+func snapshotMetrics(sources ...*processingMetrics) Metrics {
+	snapshot := Metrics{
+		Matches:              make(map[string]uint64),
+		RPC:                  make(map[string]RPCMetrics),
+		rpcLatencyHistograms: make(map[string]latencyHistogram),
+	}
+	for _, metrics := range sources {
+		snapshot.BlocksProcessed += metrics.blocksProcessed.Load()
+		snapshot.BlocksRetried += metrics.blocksRetried.Load()
+		snapshot.TransactionsScanned += metrics.transactionsScanned.Load()
+		snapshot.ReorgsHandled += metrics.reorgsHandled.Load()
+		snapshot.NotificationsSent += metrics.notificationsSent.Load()
+		snapshot.NotificationsFailed += metrics.notificationsFailed.Load()
+		snapshot.NotificationsDropped += metrics.notificationsDropped.Load()
+		metrics.matches.Range(func(key, value any) bool {
+			snapshot.Matches[key.(string)] += value.(*atomic.Uint64).Load()
+			return true
+		})
+		metrics.rpc.Range(func(key, value any) bool {
+			method, counters := key.(string), value.(*rpcMethodMetrics)
+			rpc := snapshot.RPC[method]
+			rpc.Calls += counters.calls.Load()
+			rpc.Errors += counters.errors.Load()
+			snapshot.RPC[method] = rpc
+
+			histogram := snapshot.rpcLatencyHistograms[method]
+			if histogram.buckets == nil {
+				histogram.buckets = make([]uint64, len(counters.buckets))
+			}
+			for i := range counters.buckets {
+				histogram.buckets[i] += counters.buckets[i].Load()
+			}
+			histogram.total += time.Duration(counters.total.Load())
+			snapshot.rpcLatencyHistograms[method] = histogram
+			return true
+		})
+	}
+
+	for method, histogram := range snapshot.rpcLatencyHistograms {
+		rpc := snapshot.RPC[method]
+		rpc.P50 = histogram.percentile(0.5)
+		rpc.P90 = histogram.percentile(0.9)
+		rpc.P99 = histogram.percentile(0.99)
+		snapshot.RPC[method] = rpc
+	}
+	return snapshot
+}
+
+// RPCMethods returns the methods with RPC metrics in alphabetical order.
+func (metrics Metrics) RPCMethods() []string {
+	methods := make([]string, 0, len(metrics.RPC))
+	for method := range metrics.RPC {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// Metrics returns a snapshot of the processing counters of every chain.
+func (multi *MultiChainParser) Metrics() Metrics {
+	sources := make([]*processingMetrics, len(multi.chains))
+	for i, parser := range multi.chains {
+		sources[i] = parser.metrics
+	}
+	metrics := snapshotMetrics(sources...)
+	for _, parser := range multi.chains {
+		metrics.DroppedTransactions += parser.index.DroppedOldTransactions()
+	}
+	if reporter, ok := multi.store.(bloomReporter); ok {
+		metrics.BloomFilter = reporter.BloomFilterStats()
+	}
+	return metrics
+}
@@ -0,0 +1,283 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// MultiChainParser runs a parser per chain over one storage. Subscriptions and
+// watches span every chain, while other requests go to the first chain unless
+// a chain is selected with ChainParser.
+type MultiChainParser struct {
+	*EthereumParser                   // First chain
+	chains          []*EthereumParser // Every chain, in configuration order
+	store           Store             // Storage shared by the chains
+}
+
+// ChainSelector is implemented by parsers that serve several chains.
+type ChainSelector interface {
+	ChainParser(name string) (Parser, bool)
+	ChainNames() []string
+}
+
+// NewMultiChainParser initializes a parser for each chain, named by the chain
+// and connected to its endpoint. The chains share store, in which their
+// subscriptions are kept apart. opts are applied to every chain.
+func NewMultiChainParser(chains []Chain, endpoints []string, store Store, opts ...Option) (*MultiChainParser, error) {
+	if len(chains) == 0 || len(chains) != len(endpoints) {
+		return nil, errors.New("you need to define an endpoint for each chain")
+	}
+
+	multi := &MultiChainParser{store: store}
+	for i, chain := range chains {
+		if _, ok := multi.ChainParser(chain.Name); ok {
+			return nil, fmt.Errorf("duplicate chain: %v", chain.Name)
+		}
+		chainOpts := append(append([]Option{}, opts...), WithChain(chain))
+		multi.chains = append(multi.chains, NewEthereumParser(endpoints[i], chainStore{store: store, chain: chain.Name}, chainOpts...))
+	}
+	multi.EthereumParser = multi.chains[0]
+	return multi, nil
+}
+
+// ChainParser returns the parser of the named chain.
+func (multi *MultiChainParser) ChainParser(name string) (Parser, bool) {
+	for _, parser := range multi.chains {
+		if parser.chain.Name == name {
+			return parser, true
+		}
+	}
+	return nil, false
+}
+
+// Chains returns the parsers of the chains in configuration order.
+func (multi *MultiChainParser) Chains() []*EthereumParser {
+	return multi.chains
+}
+
+// ChainNames returns the names of the chains in configuration order.
+func (multi *MultiChainParser) ChainNames() []string {
+	names := make([]string, len(multi.chains))
+	for i, parser := range multi.chains {
+		names[i] = parser.chain.Name
+	}
+	return names
+}
+
+// SelectChain returns the parser of the named chain, which is the parser
+// itself when it serves only that chain.
+func SelectChain(parser Parser, name string) (Parser, bool) {
+	if selector, ok := parser.(ChainSelector); ok {
+		return selector.ChainParser(name)
+	}
+	if ChainOf(parser).Name == name {
+		return parser, true
+	}
+	return nil, false
+}
+
+// SubscribeAddress subscribes to the address on every chain it is not
+// subscribed on yet, reporting whether it already was on every chain. It
+// fails with the first chain's error when no chain accepted the address.
+func (multi *MultiChainParser) SubscribeAddress(address string) (alreadySubscribed bool, err error) {
+	var first error
+	subscribed := false
+	alreadySubscribed = true
+	for _, parser := range multi.chains {
+		existed, err := parser.SubscribeAddress(address)
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			continue
+		}
+		subscribed = true
+		alreadySubscribed = alreadySubscribed && existed
+	}
+	if !subscribed {
+		return false, first
+	}
+	return alreadySubscribed, nil
+}
+
+// UnsubscribeAddress removes the subscription to the address on every chain.
+func (multi *MultiChainParser) UnsubscribeAddress(address string) bool {
+	unsubscribed := true
+	for _, parser := range multi.chains {
+		if !parser.UnsubscribeAddress(address) {
+			unsubscribed = false
+		}
+	}
+	return unsubscribed
+}
+
+// ValidateSubscription reports the problems with subscribing to the address,
+// which is valid as long as one chain accepts it.
+func (multi *MultiChainParser) ValidateSubscription(address string) error {
+	var first error
+	for _, parser := range multi.chains {
+		err := parser.ValidateSubscription(address)
+		if err == nil {
+			return nil
+		}
+		if first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// SubscribeGroup subscribes every address on every chain, or none of them.
+func (multi *MultiChainParser) SubscribeGroup(addresses []string) error {
+	for _, parser := range multi.chains {
+		for _, address := range addresses {
+			if err := parser.ValidateSubscription(address); err != nil {
+				return err
+			}
+		}
+	}
+
+	tx := multi.store.BeginTransaction()
+	for _, parser := range multi.chains {
+		for _, address := range addresses {
+			if err := tx.SetSubscriber(chainKey(parser.chain.Name, NormalizeAddress(address))); err != nil {
+				tx.Rollback()
+				return &SubscriptionError{Address: address, Problems: []error{err}}
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for _, parser := range multi.chains {
+		for _, address := range addresses {
+			parser.delayActivation(NormalizeAddress(address))
+		}
+	}
+	return nil
+}
+
+// CheckChainID verifies that every endpoint serves its chain.
+func (multi *MultiChainParser) CheckChainID(ctx context.Context) error {
+	var errs []error
+	for _, parser := range multi.chains {
+		if err := parser.CheckChainID(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%v: %w", parser.chain.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Watch polls every chain, sending the transactions of subscribed addresses to
+// out with their chain set. It blocks until ctx is cancelled and every poller
+// has stopped.
+func (multi *MultiChainParser) Watch(ctx context.Context, out chan<- Transaction) error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(multi.chains))
+	for i, parser := range multi.chains {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = parser.Watch(ctx, out)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// WatchAddress forwards the transactions the running pollers dispatch for the
+// address on every chain to out. The returned function stops forwarding.
+func (multi *MultiChainParser) WatchAddress(ctx context.Context, address string, out chan<- Transaction) (func(), error) {
+	var stops []func()
+	stopAll := func() {
+		for _, stop := range stops {
+			stop()
+		}
+	}
+	for _, parser := range multi.chains {
+		stop, err := parser.WatchAddress(ctx, address, out)
+		if err != nil {
+			stopAll()
+			return nil, fmt.Errorf("%v: %w", parser.chain.Name, err)
+		}
+		stops = append(stops, stop)
+	}
+	return stopAll, nil
+}
+
+// chainStore is the view of a shared Store holding one chain's subscriptions,
+// which are stored under addresses prefixed with the chain name.
+type chainStore struct {
+	store Store
+	chain string
+}
+
+// chainKey returns the key of an address's subscription on a chain.
+func chainKey(chain, address string) string {
+	return chain + ":" + address
+}
+
+func (store chainStore) GetSubscribers() (map[string]bool, error) {
+	all, err := store.store.GetSubscribers()
+	if err != nil {
+		return nil, err
+	}
+
+	subscribers := make(map[string]bool)
+	for key, value := range all {
+		if address, ok := strings.CutPrefix(key, chainKey(store.chain, "")); ok {
+			subscribers[address] = value
+		}
+	}
+	return subscribers, nil
+}
+
+func (store chainStore) SetSubscriber(address string) (existed bool, err error) {
+	return store.store.SetSubscriber(chainKey(store.chain, address))
+}
+
+func (store chainStore) RemoveSubscriber(address string) error {
+	return store.store.RemoveSubscriber(chainKey(store.chain, address))
+}
+
+func (store chainStore) IsSubscriber(address string) bool {
+	return store.store.IsSubscriber(chainKey(store.chain, address))
+}
+
+func (store chainStore) AtCapacity() bool {
+	limiter, ok := store.store.(capacityLimiter)
+	return ok && limiter.AtCapacity()
+}
+
+func (store chainStore) BeginTransaction() StorageTransaction {
+	return chainTransaction{tx: store.store.BeginTransaction(), chain: store.chain}
+}
+
+// chainTransaction is a transaction on a chainStore.
+type chainTransaction struct {
+	tx    StorageTransaction
+	chain string
+}
+
+func (tx chainTransaction) SetSubscriber(address string) error {
+	return tx.tx.SetSubscriber(chainKey(tx.chain, address))
+}
+
+func (tx chainTransaction) RemoveSubscriber(address string) error {
+	return tx.tx.RemoveSubscriber(chainKey(tx.chain, address))
+}
+
+func (tx chainTransaction) IsSubscriber(address string) bool {
+	return tx.tx.IsSubscriber(chainKey(tx.chain, address))
+}
+
+func (tx chainTransaction) Commit() error {
+	return tx.tx.Commit()
+}
+
+func (tx chainTransaction) Rollback() error {
+	return tx.tx.Rollback()
+}
@@ -0,0 +1,149 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// NodeInfo describes the node behind the endpoint, for diagnostics.
+type NodeInfo struct {
+	ClientVersion   string    `json:"clientVersion"`
+	ProtocolVersion uint64    `json:"protocolVersion,omitempty"` // Zero when the node does not report it
+	PeerCount       uint      `json:"peerCount"`
+	Listening       bool      `json:"listening"` // Whether the node accepts peer connections
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+// minProtocolVersion is the oldest Ethereum wire protocol version that is not
+// deprecated (eth/65).
+const minProtocolVersion = 65
+
+// nodeInfoProvider is implemented by parsers that describe their node.
+type nodeInfoProvider interface {
+	GetNodeInfo(ctx context.Context) (*NodeInfo, error)
+}
+
+// WithNodeInfoInterval makes the parser refresh its NodeInfo every interval
+// while Watch runs, so that GetNodeInfo answers from the cache.
+func WithNodeInfoInterval(interval time.Duration) Option {
+	return func(parser *EthereumParser) {
+		parser.nodeInfoInterval = interval
+	}
+}
+
+// GetPeerCount returns the number of peers connected to the node.
+func (parser *EthereumParser) GetPeerCount(ctx context.Context) (uint, error) {
+	countHex, err := parser.rpcNetPeerCount(ctx)
+	if err != nil {
+		return 0, err
+	}
+	count, err := ParseHexUint64(countHex)
+	if err != nil {
+		return 0, fmt.Errorf("invalid peer count: %w", err)
+	}
+	return uint(count), nil
+}
+
+// IsNodeListening reports whether the node accepts peer connections.
+func (parser *EthereumParser) IsNodeListening(ctx context.Context) (bool, error) {
+	return parser.rpcNetListening(ctx)
+}
+
+// GetClientVersion returns the name and version of the node's client.
+func (parser *EthereumParser) GetClientVersion(ctx context.Context) (string, error) {
+	return parser.rpcWeb3ClientVersion(ctx)
+}
+
+// GetProtocolVersion returns the Ethereum wire protocol version of the node.
+func (parser *EthereumParser) GetProtocolVersion(ctx context.Context) (uint64, error) {
+	versionHex, err := parser.rpcEthProtocolVersion(ctx)
+	if isMethodNotSupported(err) {
+		return 0, fmt.Errorf("%w: %w", ErrMethodNotSupported, err)
+	}
+	if err != nil {
+		return 0, err
+	}
+	version, err := ParseHexUint64(versionHex)
+	if err != nil {
+		return 0, fmt.Errorf("invalid protocol version: %w", err)
+	}
+	return version, nil
+}
+
+// IsDeprecatedProtocol reports whether the node speaks a deprecated wire
+// protocol version, older than eth/65.
+func (parser *EthereumParser) IsDeprecatedProtocol(ctx context.Context) (bool, error) {
+	version, err := parser.GetProtocolVersion(ctx)
+	if err != nil {
+		return false, err
+	}
+	return version < minProtocolVersion, nil
+}
+
+// GetNodeInfo returns the client version and peers of the node. With
+// WithNodeInfoInterval, the info cached within the interval is returned.
+func (parser *EthereumParser) GetNodeInfo(ctx context.Context) (*NodeInfo, error) {
+	if info := parser.nodeInfo.Load(); info != nil && parser.clock.Now().Sub(info.UpdatedAt) < parser.nodeInfoInterval {
+		return info, nil
+	}
+	return parser.refreshNodeInfo(ctx)
+}
+
+// refreshNodeInfo queries the node and caches the result.
+func (parser *EthereumParser) refreshNodeInfo(ctx context.Context) (*NodeInfo, error) {
+	version, err := parser.GetClientVersion(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client version: %w", err)
+	}
+	// Recent clients dropped eth_protocolVersion
+	protocol, err := parser.GetProtocolVersion(ctx)
+	if err != nil && !errors.Is(err, ErrMethodNotSupported) {
+		return nil, fmt.Errorf("failed to get protocol version: %w", err)
+	}
+	peers, err := parser.GetPeerCount(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get peer count: %w", err)
+	}
+	listening, err := parser.IsNodeListening(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get listening status: %w", err)
+	}
+	info := &NodeInfo{ClientVersion: version, ProtocolVersion: protocol, PeerCount: peers, Listening: listening, UpdatedAt: parser.clock.Now()}
+	parser.nodeInfo.Store(info)
+	return info, nil
+}
+
+// refreshNodeInfoEvery refreshes the cached NodeInfo every interval until ctx
+// is cancelled.
+func (parser *EthereumParser) refreshNodeInfoEvery(ctx context.Context, interval time.Duration) {
+	for {
+		refreshCtx := ContextWithTraceID(ctx, NewTraceID())
+		if _, err := parser.refreshNodeInfo(refreshCtx); err != nil && ctx.Err() == nil {
+			parser.logger.WarnContext(refreshCtx, "failed to refresh node info", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-parser.clock.After(interval):
+		}
+	}
+}
+
+func handleNodeInfo(parser Parser) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		provider, ok := parser.(nodeInfoProvider)
+		if !ok {
+			writeError(w, http.StatusNotImplemented, "parser does not report node info")
+			return
+		}
+		info, err := provider.GetNodeInfo(r.Context())
+		if err != nil {
+			writeError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, info)
+	}
+}
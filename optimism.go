@@ -0,0 +1,129 @@
+package parser
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+)
+
+const (
+	// optimismChainID is the chain ID of OP Mainnet.
+	optimismChainID = 10
+	// l2CrossDomainMessenger is the OP Mainnet predeploy that relays messages to L1.
+	l2CrossDomainMessenger = "0x4200000000000000000000000000000000000007"
+)
+
+var (
+	sentMessageTopic           = Keccak256Hex("SentMessage(address,address,bytes,uint256,uint256)")
+	sentMessageExtension1Topic = Keccak256Hex("SentMessageExtension1(address,uint256)")
+)
+
+// SentL1Message is a message sent from L2 to L1 through the cross-domain messenger.
+type SentL1Message struct {
+	Target          string
+	Sender          string
+	Message         string   // Hex-encoded calldata executed on the target
+	Value           *big.Int // ETH value sent with the message in wei
+	MinGasLimit     uint64
+	ExtraData       string // Reserved for messenger versions that emit extra data, empty for Bedrock
+	TransactionHash string
+}
+
+// OptimismParser extends EthereumParser with OP Mainnet specific queries.
+type OptimismParser struct {
+	*EthereumParser
+	ChainID uint64
+}
+
+// NewOptimismParser initializes a new OptimismParser instance.
+func NewOptimismParser(endpoint string, store Store, opts ...Option) *OptimismParser {
+	return &OptimismParser{
+		EthereumParser: NewEthereumParser(endpoint, store, opts...),
+		ChainID:        optimismChainID,
+	}
+}
+
+// GetSentMessages returns the L2 to L1 messages sent in the block range.
+func (parser *OptimismParser) GetSentMessages(ctx context.Context, fromBlock, toBlock uint64) ([]SentL1Message, error) {
+	logs, err := parser.GetLogs(ctx, LogFilter{
+		FromBlock: fromBlock,
+		ToBlock:   toBlock,
+		Address:   l2CrossDomainMessenger,
+		Topics:    [][]string{{sentMessageTopic, sentMessageExtension1Topic}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []SentL1Message
+	for _, log := range logs {
+		if len(log.Topics) < 2 || log.Removed {
+			continue
+		}
+		switch log.Topics[0] {
+		case sentMessageTopic:
+			message, err := decodeSentMessage(log)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode SentMessage in %v: %w", log.TransactionHash, err)
+			}
+			messages = append(messages, message)
+		case sentMessageExtension1Topic:
+			// The extension carries the value of the SentMessage emitted just before it
+			last := len(messages) - 1
+			if last < 0 || messages[last].TransactionHash != log.TransactionHash {
+				continue
+			}
+			data, err := decodeHexData(log.Data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode SentMessageExtension1 in %v: %w", log.TransactionHash, err)
+			}
+			word, err := abiWord(data, 0)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode SentMessageExtension1 in %v: %w", log.TransactionHash, err)
+			}
+			messages[last].Value = abiUint(word)
+		}
+	}
+
+	return messages, nil
+}
+
+// decodeSentMessage decodes SentMessage(address indexed target, address sender,
+// bytes message, uint256 messageNonce, uint256 gasLimit).
+func decodeSentMessage(log Log) (SentL1Message, error) {
+	target, err := topicAddress(log.Topics[1])
+	if err != nil {
+		return SentL1Message{}, err
+	}
+	data, err := decodeHexData(log.Data)
+	if err != nil {
+		return SentL1Message{}, err
+	}
+
+	senderWord, err := abiWord(data, 0)
+	if err != nil {
+		return SentL1Message{}, err
+	}
+	message, err := abiDynamicBytes(data, 1)
+	if err != nil {
+		return SentL1Message{}, err
+	}
+	gasLimitWord, err := abiWord(data, 3)
+	if err != nil {
+		return SentL1Message{}, err
+	}
+	gasLimit := abiUint(gasLimitWord)
+	if !gasLimit.IsUint64() {
+		return SentL1Message{}, fmt.Errorf("gas limit %v overflows uint64", gasLimit)
+	}
+
+	return SentL1Message{
+		Target:          target,
+		Sender:          abiAddress(senderWord),
+		Message:         "0x" + hex.EncodeToString(message),
+		Value:           new(big.Int),
+		MinGasLimit:     gasLimit.Uint64(),
+		TransactionHash: log.TransactionHash,
+	}, nil
+}
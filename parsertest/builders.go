@@ -0,0 +1,157 @@
+package parsertest
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/GeorgeIwu/go-parser"
+)
+
+// Addresses of the fixtures, in checksummed form so that an EthereumParser
+// subscribes them.
+const (
+	Alice = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+	Bob   = "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359"
+	Carol = "0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB"
+)
+
+// TransactionBuilder builds a parser.Transaction. Its defaults are a
+// transfer of 1 wei from Alice to Bob in block 1, with a hash unique to the
+// nonce.
+type TransactionBuilder struct {
+	transaction parser.Transaction
+	nonce       uint64
+}
+
+// NewTransaction returns a TransactionBuilder with the default fields.
+func NewTransaction() *TransactionBuilder {
+	return &TransactionBuilder{transaction: parser.Transaction{
+		BlockNumber: "0x1",
+		From:        Alice,
+		To:          Bob,
+		Value:       "0x1",
+		Input:       "0x",
+	}}
+}
+
+func (builder *TransactionBuilder) From(address string) *TransactionBuilder {
+	builder.transaction.From = address
+	return builder
+}
+
+// To sets the recipient, "" making the transaction a contract creation.
+func (builder *TransactionBuilder) To(address string) *TransactionBuilder {
+	builder.transaction.To = address
+	return builder
+}
+
+// Value sets the value in wei.
+func (builder *TransactionBuilder) Value(wei *big.Int) *TransactionBuilder {
+	builder.transaction.Value = fmt.Sprintf("0x%x", wei)
+	return builder
+}
+
+func (builder *TransactionBuilder) Block(number uint64) *TransactionBuilder {
+	builder.transaction.BlockNumber = fmt.Sprintf("0x%x", number)
+	return builder
+}
+
+// Nonce sets the nonce, from which the default hash is derived.
+func (builder *TransactionBuilder) Nonce(nonce uint64) *TransactionBuilder {
+	builder.nonce = nonce
+	builder.transaction.Nonce = fmt.Sprintf("0x%x", nonce)
+	return builder
+}
+
+func (builder *TransactionBuilder) Hash(hash string) *TransactionBuilder {
+	builder.transaction.Hash = hash
+	return builder
+}
+
+func (builder *TransactionBuilder) Input(input string) *TransactionBuilder {
+	builder.transaction.Input = input
+	return builder
+}
+
+// Build returns the transaction. Its hash, unless set, is derived from its
+// sender, nonce and block.
+func (builder *TransactionBuilder) Build() parser.Transaction {
+	transaction := builder.transaction
+	if transaction.Hash == "" {
+		transaction.Hash = parser.Keccak256Hex(fmt.Sprintf("transaction %v %d %v", transaction.From, builder.nonce, transaction.BlockNumber))
+	}
+	return transaction
+}
+
+// BlockBuilder builds a parser.Block. Blocks built with the same fork link to
+// each other: the parent hash of block n is the hash of block n-1, unless set
+// with Parent.
+type BlockBuilder struct {
+	number       uint64
+	fork         string
+	parentHash   string
+	transactions []parser.Transaction
+	timestamp    uint64
+}
+
+// NewBlock returns a BlockBuilder of the block number without transactions,
+// made 12 seconds after its parent.
+func NewBlock(number uint64) *BlockBuilder {
+	return &BlockBuilder{number: number, timestamp: 1_700_000_000 + 12*number}
+}
+
+// Fork sets the name of the chain the block belongs to, giving it and its
+// descendants hashes other than those of the canonical chain, "".
+func (builder *BlockBuilder) Fork(name string) *BlockBuilder {
+	builder.fork = name
+	return builder
+}
+
+// Transactions appends transactions, setting their block number.
+func (builder *BlockBuilder) Transactions(transactions ...parser.Transaction) *BlockBuilder {
+	for _, transaction := range transactions {
+		transaction.BlockNumber = fmt.Sprintf("0x%x", builder.number)
+		builder.transactions = append(builder.transactions, transaction)
+	}
+	return builder
+}
+
+// Parent sets the parent hash, such as to link the first block of a fork to
+// the canonical chain.
+func (builder *BlockBuilder) Parent(hash string) *BlockBuilder {
+	builder.parentHash = hash
+	return builder
+}
+
+func (builder *BlockBuilder) Timestamp(unix uint64) *BlockBuilder {
+	builder.timestamp = unix
+	return builder
+}
+
+// Build returns the block.
+func (builder *BlockBuilder) Build() *parser.Block {
+	transactions := append([]parser.Transaction{}, builder.transactions...)
+	parentHash := builder.parentHash
+	if parentHash == "" {
+		parentHash = BlockHash(builder.number-1, builder.fork)
+	}
+	return &parser.Block{
+		BlockHeader: parser.BlockHeader{
+			Number:        fmt.Sprintf("0x%x", builder.number),
+			Hash:          BlockHash(builder.number, builder.fork),
+			ParentHash:    parentHash,
+			Timestamp:     fmt.Sprintf("0x%x", builder.timestamp),
+			Miner:         Carol,
+			GasUsed:       fmt.Sprintf("0x%x", 21000*len(transactions)),
+			GasLimit:      "0x1c9c380",
+			BaseFeePerGas: "0x3b9aca00",
+		},
+		Transactions: transactions,
+	}
+}
+
+// BlockHash returns the hash of the numbered block of a fork, "" being the
+// canonical chain.
+func BlockHash(number uint64, fork string) string {
+	return parser.Keccak256Hex(fmt.Sprintf("block %v %d", fork, number))
+}
@@ -0,0 +1,43 @@
+package parsertest
+
+import (
+	"testing"
+
+	"github.com/GeorgeIwu/go-parser"
+)
+
+// FakeNode is a parser.FakeNode failing the test when its chain cannot be
+// programmed, such as with a block not extending it.
+type FakeNode struct {
+	*parser.FakeNode
+	t testing.TB
+}
+
+// NewFakeNode returns a node whose chain holds the blocks, built for example
+// with NewBlock.
+func NewFakeNode(t testing.TB, blocks ...*parser.Block) *FakeNode {
+	t.Helper()
+	node, err := parser.NewFakeNode(blocks...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &FakeNode{FakeNode: node, t: t}
+}
+
+// AddBlock extends the chain with the block, with the logs served by
+// eth_getLogs and eth_getTransactionReceipt.
+func (node *FakeNode) AddBlock(block *parser.Block, logs ...parser.Log) {
+	node.t.Helper()
+	if err := node.FakeNode.AddBlock(block, logs...); err != nil {
+		node.t.Fatal(err)
+	}
+}
+
+// ReplaceBlocks reorganizes the chain: the blocks from the number of the first
+// block on are replaced by the blocks.
+func (node *FakeNode) ReplaceBlocks(blocks ...*parser.Block) {
+	node.t.Helper()
+	if err := node.FakeNode.ReplaceBlocks(blocks...); err != nil {
+		node.t.Fatal(err)
+	}
+}
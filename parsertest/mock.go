@@ -0,0 +1,158 @@
+package parsertest
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/GeorgeIwu/go-parser"
+)
+
+// Call is a call of a MockParser method.
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// MockParser is a parser.Parser whose results can be scripted, and which
+// records its calls. Unless scripted it behaves like an EthereumParser over
+// Store, without a node: SubscribeAddress rejects the addresses an
+// EthereumParser rejects with the same *parser.SubscriptionError and stores
+// the others in the form of parser.NormalizeAddress, and GetTransactions
+// returns the transactions added with AddTransactions for subscribed
+// addresses only.
+type MockParser struct {
+	Store        parser.Store // Subscriptions, a FakeStorage by default
+	CurrentBlock uint64       // Returned by GetCurrentBlock
+
+	// Replace the behavior of the methods when set
+	GetCurrentBlockFunc  func() uint64
+	GetTransactionsFunc  func(address string) []parser.Transaction
+	SubscribeAddressFunc func(address string) (alreadySubscribed bool, err error)
+
+	mu           sync.Mutex
+	transactions []parser.Transaction
+	calls        []Call
+}
+
+// NewMockParser returns a MockParser over an empty FakeStorage.
+func NewMockParser() *MockParser {
+	return &MockParser{Store: NewFakeStorage()}
+}
+
+// AddTransactions adds transactions returned by GetTransactions for their
+// sender and recipient.
+func (mock *MockParser) AddTransactions(transactions ...parser.Transaction) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	mock.transactions = append(mock.transactions, transactions...)
+}
+
+// Calls returns the calls of the named method, or of every method when name
+// is empty, in the order they were made.
+func (mock *MockParser) Calls(method string) []Call {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	var calls []Call
+	for _, call := range mock.calls {
+		if method == "" || call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+func (mock *MockParser) record(method string, args ...interface{}) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	mock.calls = append(mock.calls, Call{Method: method, Args: args})
+}
+
+func (mock *MockParser) GetCurrentBlock() uint64 {
+	mock.record("GetCurrentBlock")
+	if mock.GetCurrentBlockFunc != nil {
+		return mock.GetCurrentBlockFunc()
+	}
+	return mock.CurrentBlock
+}
+
+func (mock *MockParser) GetTransactions(address string) []parser.Transaction {
+	mock.record("GetTransactions", address)
+	if mock.GetTransactionsFunc != nil {
+		return mock.GetTransactionsFunc(address)
+	}
+	var transactions []parser.Transaction
+	if address == "" || !mock.Store.IsSubscriber(parser.NormalizeAddress(address)) {
+		return transactions
+	}
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	for _, transaction := range mock.transactions {
+		if strings.EqualFold(transaction.From, address) || strings.EqualFold(transaction.To, address) {
+			transactions = append(transactions, transaction)
+		}
+	}
+	return transactions
+}
+
+func (mock *MockParser) SubscribeAddress(address string) (alreadySubscribed bool, err error) {
+	mock.record("SubscribeAddress", address)
+	if mock.SubscribeAddressFunc != nil {
+		return mock.SubscribeAddressFunc(address)
+	}
+	if address == "" {
+		return false, errors.New("no address given")
+	}
+	key := parser.NormalizeAddress(address)
+	subscribed := mock.Store.IsSubscriber(key)
+	if subscribed && parser.IsChecksumAddress(address) {
+		return true, nil
+	}
+
+	var problems []error
+	switch {
+	case !parser.IsValidAddress(address):
+		problems = append(problems, parser.ErrInvalidAddress)
+	case !parser.IsChecksumAddress(address):
+		problems = append(problems, parser.ErrChecksumMismatch)
+	}
+	if subscribed {
+		problems = append(problems, parser.ErrAlreadySubscribed)
+	} else if limiter, ok := mock.Store.(interface{ AtCapacity() bool }); ok && limiter.AtCapacity() {
+		problems = append(problems, parser.ErrStoreFull)
+	}
+	if len(problems) > 0 {
+		return false, &parser.SubscriptionError{Address: address, Problems: problems}
+	}
+
+	existed, err := mock.Store.SetSubscriber(key)
+	if err != nil {
+		return false, &parser.SubscriptionError{Address: address, Problems: []error{err}}
+	}
+	return existed, nil
+}
+
+// UnsubscribeAddress removes the subscription to an address, reporting
+// whether the storage removed it.
+func (mock *MockParser) UnsubscribeAddress(address string) bool {
+	mock.record("UnsubscribeAddress", address)
+	return mock.Store.RemoveSubscriber(parser.NormalizeAddress(address)) == nil
+}
+
+// Subscribers returns the subscribed addresses in ascending order.
+func (mock *MockParser) Subscribers() ([]string, error) {
+	mock.record("Subscribers")
+	subscribers, err := mock.Store.GetSubscribers()
+	if err != nil {
+		return nil, err
+	}
+	var addresses []string
+	for address, subscribed := range subscribers {
+		if subscribed {
+			addresses = append(addresses, address)
+		}
+	}
+	sort.Strings(addresses)
+	return addresses, nil
+}
@@ -0,0 +1,250 @@
+package parsertest_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/GeorgeIwu/go-parser"
+	"github.com/GeorgeIwu/go-parser/parsertest"
+)
+
+// unreachableEndpoint fails every call, so that the EthereumParser compared
+// with MockParser answers without a node.
+const unreachableEndpoint = "http://127.0.0.1:1"
+
+// TestFakeStorageMatchesMemoryStorage runs the same operations on both stores.
+func TestFakeStorageMatchesMemoryStorage(t *testing.T) {
+	memory := parser.NewMemoryStorage()
+	memory.Capacity = 2
+	fake := parsertest.NewFakeStorage()
+	fake.Capacity = 2
+
+	for name, store := range map[string]parser.Store{"memory": memory, "fake": fake} {
+		t.Run(name, func(t *testing.T) {
+			if existed, err := store.SetSubscriber(parsertest.Alice); existed || err != nil {
+				t.Fatalf("SetSubscriber = %v, %v, want false, nil", existed, err)
+			}
+			if existed, err := store.SetSubscriber(parsertest.Alice); !existed || err != nil {
+				t.Fatalf("SetSubscriber again = %v, %v, want true, nil", existed, err)
+			}
+			store.SetSubscriber(parsertest.Bob)
+			if _, err := store.SetSubscriber(parsertest.Carol); !errors.Is(err, parser.ErrStoreFull) {
+				t.Fatalf("SetSubscriber at capacity error = %v, want ErrStoreFull", err)
+			}
+			if store.IsSubscriber(parser.NormalizeAddress(parsertest.Alice)) {
+				t.Error("IsSubscriber matched another spelling of the address")
+			}
+
+			tx := store.BeginTransaction()
+			tx.RemoveSubscriber(parsertest.Bob)
+			tx.SetSubscriber(parsertest.Carol)
+			if store.IsSubscriber(parsertest.Carol) {
+				t.Error("transaction changed the store before Commit")
+			}
+			if err := tx.Commit(); err != nil {
+				t.Fatalf("Commit: %v", err)
+			}
+			if err := tx.Commit(); !errors.Is(err, parser.ErrTransactionDone) {
+				t.Errorf("second Commit error = %v, want ErrTransactionDone", err)
+			}
+
+			stale := store.BeginTransaction()
+			store.RemoveSubscriber(parsertest.Carol)
+			stale.SetSubscriber(parsertest.Bob)
+			if err := stale.Commit(); !errors.Is(err, parser.ErrTransactionConflict) {
+				t.Errorf("Commit after a change error = %v, want ErrTransactionConflict", err)
+			}
+
+			subscribers, err := store.GetSubscribers()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := map[string]bool{parsertest.Alice: true}; !reflect.DeepEqual(subscribers, want) {
+				t.Errorf("GetSubscribers = %v, want %v", subscribers, want)
+			}
+		})
+	}
+}
+
+func TestFakeStorageFail(t *testing.T) {
+	store := parsertest.NewFakeStorage()
+	failure := errors.New("disk full")
+	store.Fail("SetSubscriber", failure)
+	if _, err := store.SetSubscriber(parsertest.Alice); !errors.Is(err, failure) {
+		t.Fatalf("SetSubscriber error = %v, want %v", err, failure)
+	}
+	store.Fail("SetSubscriber", nil)
+	if _, err := store.SetSubscriber(parsertest.Alice); err != nil {
+		t.Fatalf("SetSubscriber after Fail(nil): %v", err)
+	}
+}
+
+// TestMockParserMatchesEthereumParser subscribes the same addresses with both
+// parsers and compares the results.
+func TestMockParserMatchesEthereumParser(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+	}{
+		{"checksummed", parsertest.Alice},
+		{"lowercase", "0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359"},
+		{"bad checksum", "0x5AAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"},
+		{"invalid", "0x1234"},
+		{"empty", ""},
+		{"at capacity", parsertest.Carol},
+	}
+	newParsers := func() map[string]parser.Parser {
+		store := parsertest.NewFakeStorage()
+		store.Capacity = 2
+		mock := parsertest.NewMockParser()
+		mock.Store = parsertest.NewFakeStorage()
+		mock.Store.(*parsertest.FakeStorage).Capacity = 2
+		return map[string]parser.Parser{
+			"ethereum": parser.NewEthereumParser(unreachableEndpoint, store),
+			"mock":     mock,
+		}
+	}
+
+	results := make(map[string][]string)
+	for name, p := range newParsers() {
+		for _, test := range tests {
+			alreadySubscribed, err := p.SubscribeAddress(test.address)
+			results[name] = append(results[name], describeSubscription(alreadySubscribed, err))
+		}
+		alreadySubscribed, err := p.SubscribeAddress(parsertest.Alice)
+		results[name] = append(results[name], describeSubscription(alreadySubscribed, err))
+	}
+	if !reflect.DeepEqual(results["mock"], results["ethereum"]) {
+		t.Errorf("MockParser subscriptions = %q, EthereumParser = %q", results["mock"], results["ethereum"])
+	}
+}
+
+// describeSubscription describes a SubscribeAddress result with the problems
+// of its error.
+func describeSubscription(alreadySubscribed bool, err error) string {
+	var subscriptionErr *parser.SubscriptionError
+	switch {
+	case errors.As(err, &subscriptionErr):
+		return "rejected: " + subscriptionErr.Reason()
+	case err != nil:
+		return "failed: " + err.Error()
+	case alreadySubscribed:
+		return "already subscribed"
+	}
+	return "subscribed"
+}
+
+func TestMockParserGetTransactions(t *testing.T) {
+	mock := parsertest.NewMockParser()
+	sent := parsertest.NewTransaction().Nonce(1).Build()
+	received := parsertest.NewTransaction().From(parsertest.Bob).To(parsertest.Alice).Build()
+	other := parsertest.NewTransaction().From(parsertest.Bob).To(parsertest.Carol).Build()
+	mock.AddTransactions(sent, received, other)
+
+	// Like EthereumParser, nothing is returned for unsubscribed addresses
+	if transactions := mock.GetTransactions(parsertest.Alice); transactions != nil {
+		t.Fatalf("GetTransactions of an unsubscribed address = %v, want nil", transactions)
+	}
+	if transactions := parser.NewEthereumParser(unreachableEndpoint, parsertest.NewFakeStorage()).GetTransactions(parsertest.Alice); transactions != nil {
+		t.Fatalf("EthereumParser.GetTransactions of an unsubscribed address = %v, want nil", transactions)
+	}
+
+	mock.SubscribeAddress(parsertest.Alice)
+	if got, want := mock.GetTransactions(parsertest.Alice), []parser.Transaction{sent, received}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetTransactions = %v, want %v", got, want)
+	}
+
+	calls := mock.Calls("GetTransactions")
+	if len(calls) != 2 || calls[1].Args[0] != parsertest.Alice {
+		t.Errorf("GetTransactions calls = %v, want 2 with %v", calls, parsertest.Alice)
+	}
+	if len(mock.Calls("")) != 3 {
+		t.Errorf("recorded %d calls, want 3", len(mock.Calls("")))
+	}
+}
+
+func TestMockParserScripted(t *testing.T) {
+	mock := parsertest.NewMockParser()
+	mock.CurrentBlock = 100
+	if block := mock.GetCurrentBlock(); block != 100 {
+		t.Errorf("GetCurrentBlock = %d, want 100", block)
+	}
+	failure := errors.New("node down")
+	mock.SubscribeAddressFunc = func(address string) (bool, error) { return false, failure }
+	if _, err := mock.SubscribeAddress(parsertest.Alice); !errors.Is(err, failure) {
+		t.Errorf("SubscribeAddress error = %v, want %v", err, failure)
+	}
+}
+
+func TestBuilders(t *testing.T) {
+	transaction := parsertest.NewTransaction().Value(big.NewInt(1e18)).Build()
+	if !parser.IsValidHash(transaction.Hash) {
+		t.Errorf("default hash %q is not a valid hash", transaction.Hash)
+	}
+	if transaction.Value != "0xde0b6b3a7640000" {
+		t.Errorf("Value = %v, want 0xde0b6b3a7640000", transaction.Value)
+	}
+	if other := parsertest.NewTransaction().Nonce(1).Build(); other.Hash == transaction.Hash {
+		t.Error("transactions of different nonces share a hash")
+	}
+
+	parent := parsertest.NewBlock(9).Build()
+	block := parsertest.NewBlock(10).Transactions(transaction).Build()
+	if block.ParentHash != parent.Hash {
+		t.Errorf("ParentHash = %v, want the hash of block 9 %v", block.ParentHash, parent.Hash)
+	}
+	if number, _ := parser.ParseHexUint64(block.Transactions[0].BlockNumber); number != 10 {
+		t.Errorf("transaction block number = %d, want 10", number)
+	}
+
+	fork := parsertest.NewBlock(10).Fork("b").Parent(parent.Hash).Build()
+	if fork.Hash == block.Hash || fork.ParentHash != parent.Hash {
+		t.Errorf("fork block = %v with parent %v, want a new hash with parent %v", fork.Hash, fork.ParentHash, parent.Hash)
+	}
+}
+
+// TestFakeNode programs the chain of a FakeNode from outside the parser
+// package and reads it back through an EthereumParser.
+func TestFakeNode(t *testing.T) {
+	transfer := parsertest.NewTransaction().Build()
+	node := parsertest.NewFakeNode(t, parsertest.NewBlock(1).Transactions(transfer).Build(), parsertest.NewBlock(2).Build())
+	p := node.NewParser()
+	ctx := context.Background()
+
+	if head := p.GetCurrentBlock(); head != 2 {
+		t.Errorf("GetCurrentBlock = %d, want 2", head)
+	}
+	node.AddBlock(parsertest.NewBlock(3).Build())
+	if head := p.GetCurrentBlock(); head != 3 {
+		t.Errorf("GetCurrentBlock after AddBlock = %d, want 3", head)
+	}
+
+	node.ReplaceBlocks(parsertest.NewBlock(2).Fork("b").Parent(parsertest.BlockHash(1, "")).Build(), parsertest.NewBlock(3).Fork("b").Build())
+	block, err := p.GetBlock(ctx, "0x3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block.Hash != parsertest.BlockHash(3, "b") {
+		t.Errorf("block 3 after ReplaceBlocks = %v, want that of the fork", block.Hash)
+	}
+
+	node.SetNonce(parsertest.Alice, 5)
+	node.SetPending(parsertest.NewTransaction().Nonce(5).Build(), parsertest.NewTransaction().Nonce(6).Build())
+	if nonce, err := p.GetTransactionCount(ctx, parsertest.Alice, "latest"); err != nil || nonce != 5 {
+		t.Errorf("latest nonce = %d, %v, want 5", nonce, err)
+	}
+	if nonce, err := p.GetTransactionCount(ctx, parsertest.Alice, "pending"); err != nil || nonce != 7 {
+		t.Errorf("pending nonce = %d, %v, want 7 after the pool", nonce, err)
+	}
+
+	node.Fail("eth_blockNumber", &parser.RPCError{Code: -32603, Message: "internal error"})
+	if head := p.GetCurrentBlock(); head != 0 {
+		t.Errorf("GetCurrentBlock with a failing node = %d, want 0", head)
+	}
+	if calls := node.Calls("eth_blockNumber"); calls != 3 {
+		t.Errorf("eth_blockNumber calls = %d, want 3", calls)
+	}
+}
@@ -0,0 +1,176 @@
+// Package parsertest provides test doubles of the parser package: a scriptable
+// MockParser, a FakeStorage, and builders of Transaction and Block fixtures.
+// They mirror the semantics of EthereumParser and MemoryStorage, including
+// their errors, and depend on nothing but the standard library.
+package parsertest
+
+import (
+	"sync"
+
+	"github.com/GeorgeIwu/go-parser"
+)
+
+// FakeStorage is a parser.Store holding subscribers in memory, like
+// parser.MemoryStorage, whose operations can be made to fail, see Fail.
+type FakeStorage struct {
+	Capacity int // Maximum number of subscribers, unlimited when zero
+
+	mu          sync.Mutex
+	subscribers map[string]bool
+	version     uint64           // Incremented by every change, so that transactions can detect conflicts
+	failures    map[string]error // Errors returned by the methods, by name
+}
+
+// NewFakeStorage returns a FakeStorage subscribing addresses.
+func NewFakeStorage(addresses ...string) *FakeStorage {
+	storage := &FakeStorage{subscribers: make(map[string]bool), failures: make(map[string]error)}
+	for _, address := range addresses {
+		storage.subscribers[address] = true
+	}
+	return storage
+}
+
+// Fail makes the named method, such as "SetSubscriber" or "Commit", return
+// err until Fail is called with a nil err.
+func (storage *FakeStorage) Fail(method string, err error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	if err == nil {
+		delete(storage.failures, method)
+		return
+	}
+	storage.failures[method] = err
+}
+
+func (storage *FakeStorage) GetSubscribers() (map[string]bool, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	if err := storage.failures["GetSubscribers"]; err != nil {
+		return nil, err
+	}
+	subscribers := make(map[string]bool, len(storage.subscribers))
+	for address, value := range storage.subscribers {
+		subscribers[address] = value
+	}
+	return subscribers, nil
+}
+
+// SetSubscriber adds a subscriber, reporting whether it already was one. It
+// returns parser.ErrStoreFull at capacity.
+func (storage *FakeStorage) SetSubscriber(address string) (existed bool, err error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	if err := storage.failures["SetSubscriber"]; err != nil {
+		return false, err
+	}
+	if storage.subscribers[address] {
+		return true, nil
+	}
+	if storage.atCapacity() {
+		return false, parser.ErrStoreFull
+	}
+	storage.subscribers[address] = true
+	storage.version++
+	return false, nil
+}
+
+func (storage *FakeStorage) RemoveSubscriber(address string) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	if err := storage.failures["RemoveSubscriber"]; err != nil {
+		return err
+	}
+	delete(storage.subscribers, address)
+	storage.version++
+	return nil
+}
+
+func (storage *FakeStorage) IsSubscriber(address string) bool {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	return storage.subscribers[address]
+}
+
+// AtCapacity reports whether the storage holds Capacity subscribers.
+func (storage *FakeStorage) AtCapacity() bool {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	return storage.atCapacity()
+}
+
+func (storage *FakeStorage) atCapacity() bool {
+	return storage.Capacity > 0 && len(storage.subscribers) >= storage.Capacity
+}
+
+// BeginTransaction starts a transaction on a copy of the current subscribers.
+// Its Commit returns parser.ErrTransactionConflict when the storage changed
+// since, and its methods parser.ErrTransactionDone once it ended.
+func (storage *FakeStorage) BeginTransaction() parser.StorageTransaction {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	subscribers := make(map[string]bool, len(storage.subscribers))
+	for address, value := range storage.subscribers {
+		subscribers[address] = value
+	}
+	return &fakeTransaction{storage: storage, version: storage.version, subscribers: subscribers}
+}
+
+// fakeTransaction stages changes on a copy of a FakeStorage's subscribers,
+// which Commit swaps in.
+type fakeTransaction struct {
+	storage     *FakeStorage
+	version     uint64
+	subscribers map[string]bool
+	done        bool
+}
+
+func (tx *fakeTransaction) SetSubscriber(address string) error {
+	if tx.done {
+		return parser.ErrTransactionDone
+	}
+	if _, ok := tx.subscribers[address]; !ok && tx.storage.Capacity > 0 && len(tx.subscribers) >= tx.storage.Capacity {
+		return parser.ErrStoreFull
+	}
+	tx.subscribers[address] = true
+	return nil
+}
+
+func (tx *fakeTransaction) RemoveSubscriber(address string) error {
+	if tx.done {
+		return parser.ErrTransactionDone
+	}
+	delete(tx.subscribers, address)
+	return nil
+}
+
+func (tx *fakeTransaction) IsSubscriber(address string) bool {
+	return tx.subscribers[address]
+}
+
+func (tx *fakeTransaction) Commit() error {
+	if tx.done {
+		return parser.ErrTransactionDone
+	}
+	tx.done = true
+
+	tx.storage.mu.Lock()
+	defer tx.storage.mu.Unlock()
+	if err := tx.storage.failures["Commit"]; err != nil {
+		return err
+	}
+	if tx.storage.version != tx.version {
+		return parser.ErrTransactionConflict
+	}
+	tx.storage.subscribers = tx.subscribers
+	tx.storage.version++
+	return nil
+}
+
+func (tx *fakeTransaction) Rollback() error {
+	if tx.done {
+		return parser.ErrTransactionDone
+	}
+	tx.done = true
+	tx.subscribers = nil
+	return nil
+}
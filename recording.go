@@ -0,0 +1,209 @@
+package parser
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// sensitiveHeaders are left out of recorded fixtures.
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// rpcFixture is a recorded JSON-RPC request and the node's response.
+type rpcFixture struct {
+	Method   string          `json:"method"`
+	Params   json.RawMessage `json:"params"`
+	Status   int             `json:"status"`
+	Header   http.Header     `json:"header"` // Response header
+	Response json.RawMessage `json:"response"`
+}
+
+// WithRecording records every JSON-RPC request and the node's response as a
+// fixture file in dir, which NewReplayClient serves back. Credentials in the
+// headers and the endpoint are not recorded.
+func WithRecording(dir string) Option {
+	return func(parser *EthereumParser) {
+		parser.recordDir = dir
+	}
+}
+
+// NewReplayClient returns a client that answers JSON-RPC requests with the
+// fixtures recorded in dir, for use with WithHTTPClient. A request without a
+// fixture fails, naming the file it expected.
+func NewReplayClient(dir string) *http.Client {
+	return &http.Client{Transport: &replayTransport{dir: dir}}
+}
+
+// newRecordingClient returns a copy of client whose transport records fixtures in dir.
+func newRecordingClient(client *http.Client, dir string) *http.Client {
+	inner := client.Transport
+	if inner == nil {
+		inner = http.DefaultTransport
+	}
+
+	wrapped := *client
+	wrapped.Transport = &recordingTransport{inner: inner, dir: dir}
+	return &wrapped
+}
+
+// recordingTransport saves the requests it sends and their responses as fixtures.
+type recordingTransport struct {
+	inner http.RoundTripper
+	dir   string
+}
+
+func (transport *recordingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(request)
+	if err != nil {
+		return nil, err
+	}
+	response, err := transport.inner.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+
+	fixture, err := newRPCFixture(body)
+	if err != nil {
+		return response, nil // Not a JSON-RPC request, which has nothing to replay
+	}
+	if err := fixture.setResponse(response); err != nil {
+		return nil, err
+	}
+	if err := fixture.save(transport.dir); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// replayTransport answers requests with recorded fixtures.
+type replayTransport struct {
+	dir string
+}
+
+func (transport *replayTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(request)
+	if err != nil {
+		return nil, err
+	}
+	key, err := newRPCFixture(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay request: %w", err)
+	}
+
+	path := key.path(transport.dir)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("unrecorded request %v %s, expected fixture %v", key.Method, key.Params, path)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %w", err)
+	}
+	var fixture rpcFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("failed to decode fixture %v: %w", path, err)
+	}
+	return fixture.httpResponse(request), nil
+}
+
+// readRequestBody reads the body of a request, leaving it readable again.
+func readRequestBody(request *http.Request) ([]byte, error) {
+	if request.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(request.Body)
+	request.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	request.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// newRPCFixture returns a fixture keyed by the method and params of a
+// JSON-RPC request body.
+func newRPCFixture(body []byte) (rpcFixture, error) {
+	var request struct {
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil || request.Method == "" {
+		return rpcFixture{}, fmt.Errorf("not a JSON-RPC request: %s", body)
+	}
+
+	if len(request.Params) == 0 {
+		request.Params = json.RawMessage("[]")
+	}
+	var params bytes.Buffer
+	if err := json.Compact(&params, request.Params); err != nil {
+		return rpcFixture{}, err
+	}
+	return rpcFixture{Method: request.Method, Params: params.Bytes()}, nil
+}
+
+// path returns the file of the fixture in dir, named by the method and a
+// hash of the params.
+func (fixture rpcFixture) path(dir string) string {
+	sum := sha256.Sum256(fixture.Params)
+	return filepath.Join(dir, fmt.Sprintf("%v-%x.json", fixture.Method, sum[:8]))
+}
+
+// setResponse records the response, which is left readable again, without
+// its sensitive headers. A body that is not JSON is recorded as a string.
+func (fixture *rpcFixture) setResponse(response *http.Response) error {
+	body, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return err
+	}
+	response.Body = io.NopCloser(bytes.NewReader(body))
+
+	fixture.Status = response.StatusCode
+	fixture.Header = response.Header.Clone()
+	for _, name := range sensitiveHeaders {
+		fixture.Header.Del(name)
+	}
+	fixture.Response = body
+	if !json.Valid(body) {
+		fixture.Response, _ = json.Marshal(string(body))
+	}
+	return nil
+}
+
+// httpResponse returns the recorded response to the request.
+func (fixture rpcFixture) httpResponse(request *http.Request) *http.Response {
+	body := []byte(fixture.Response)
+	var text string
+	if json.Unmarshal(fixture.Response, &text) == nil {
+		body = []byte(text) // Recorded as a string because it was not JSON
+	}
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %v", fixture.Status, http.StatusText(fixture.Status)),
+		StatusCode: fixture.Status,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     fixture.Header,
+		Body:       io.NopCloser(bytes.NewReader(body)),
+		Request:    request,
+	}
+}
+
+func (fixture rpcFixture) save(dir string) error {
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode fixture: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to record fixture: %w", err)
+	}
+	if err := os.WriteFile(fixture.path(dir), data, 0o644); err != nil {
+		return fmt.Errorf("failed to record fixture: %w", err)
+	}
+	return nil
+}
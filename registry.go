@@ -0,0 +1,159 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// poller is implemented by parsers that can watch their chain.
+type poller interface {
+	Watch(ctx context.Context, out chan<- Transaction) error
+}
+
+// Registry keeps the parsers of several chains by name, for programs that
+// embed the parser rather than run the CLI. Unlike MultiChainParser, the
+// parsers are built and configured independently, each over its own storage.
+// StartAll runs the poller of every chain, and the transactions they dispatch
+// are sent to the channels added with Notify, with their chain set to the
+// name it was registered under.
+type Registry struct {
+	mu        sync.RWMutex
+	names     []string // In registration order
+	parsers   map[string]Parser
+	notifiers []chan<- Transaction
+	cancel    context.CancelFunc // Stops the pollers, nil when they are not running
+	wg        sync.WaitGroup
+	errs      []error // Errors the pollers stopped with
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{parsers: make(map[string]Parser)}
+}
+
+// Register adds the parser of a chain. Names are unique, and parsers cannot be
+// added while the pollers run.
+func (registry *Registry) Register(name string, parser Parser) error {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	switch {
+	case name == "":
+		return errors.New("chain name is empty")
+	case parser == nil:
+		return fmt.Errorf("parser of chain %v is nil", name)
+	case registry.parsers[name] != nil:
+		return fmt.Errorf("duplicate chain: %v", name)
+	case registry.cancel != nil:
+		return errors.New("cannot register a chain while the pollers run")
+	}
+	registry.names = append(registry.names, name)
+	registry.parsers[name] = parser
+	return nil
+}
+
+// Get returns the parser registered under name.
+func (registry *Registry) Get(name string) (Parser, bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	parser, ok := registry.parsers[name]
+	return parser, ok
+}
+
+// ForEach calls fn with every chain in registration order, stopping at the
+// first error, which it returns.
+func (registry *Registry) ForEach(fn func(name string, parser Parser) error) error {
+	registry.mu.RLock()
+	names := append([]string(nil), registry.names...)
+	registry.mu.RUnlock()
+	for _, name := range names {
+		parser, _ := registry.Get(name)
+		if err := fn(name, parser); err != nil {
+			return fmt.Errorf("%v: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Notify adds a channel receiving the transactions dispatched on every chain.
+// Sends block, so that a slow receiver slows the pollers down rather than
+// missing transactions.
+func (registry *Registry) Notify(out chan<- Transaction) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.notifiers = append(registry.notifiers, out)
+}
+
+// StartAll starts the poller of every chain. They run until StopAll is called
+// or ctx is cancelled. Every registered parser must be able to watch its chain.
+func (registry *Registry) StartAll(ctx context.Context) error {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if registry.cancel != nil {
+		return errors.New("the pollers already run")
+	}
+	for _, name := range registry.names {
+		if _, ok := registry.parsers[name].(poller); !ok {
+			return fmt.Errorf("parser of chain %v cannot watch", name)
+		}
+	}
+
+	ctx, registry.cancel = context.WithCancel(ctx)
+	registry.errs = nil
+	for _, name := range registry.names {
+		parser := registry.parsers[name].(poller)
+		transactions := make(chan Transaction, listenerBufferSize)
+		registry.wg.Add(2)
+		go func() {
+			defer registry.wg.Done()
+			defer close(transactions)
+			err := parser.Watch(ctx, transactions)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				registry.mu.Lock()
+				registry.errs = append(registry.errs, fmt.Errorf("%v: %w", name, err))
+				registry.mu.Unlock()
+			}
+		}()
+		go func() {
+			defer registry.wg.Done()
+			registry.forward(ctx, name, transactions)
+		}()
+	}
+	return nil
+}
+
+// forward sends the transactions of a chain to the notifiers, with their
+// chain set, until the chain's poller stops.
+func (registry *Registry) forward(ctx context.Context, name string, transactions <-chan Transaction) {
+	for transaction := range transactions {
+		transaction.Chain = name
+		registry.mu.RLock()
+		notifiers := registry.notifiers
+		registry.mu.RUnlock()
+		for _, out := range notifiers {
+			select {
+			case out <- transaction:
+			case <-ctx.Done():
+			}
+		}
+	}
+}
+
+// StopAll stops the pollers and waits for them to return. It returns the
+// errors they failed with, other than being stopped.
+func (registry *Registry) StopAll() error {
+	registry.mu.Lock()
+	cancel := registry.cancel
+	registry.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+	registry.wg.Wait()
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.cancel = nil
+	return errors.Join(registry.errs...)
+}
@@ -0,0 +1,107 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestRegistry runs the pollers of two chains, each of a fakeNode, and
+// receives the transactions of both on two channels.
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	nodes := map[string]*fakeNode{}
+	for _, name := range []string{"ethereum", "polygon"} {
+		nodes[name] = newFakeNode(t, testBlock(1))
+		parser := nodes[name].NewParser(WithChain(Chain{Name: name, PollInterval: testWatchInterval}))
+		if _, err := parser.SubscribeAddress(checksummedAddress); err != nil {
+			t.Fatal(err)
+		}
+		if err := registry.Register(name, parser); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := registry.Register("ethereum", NewEthereumParser(unreachableEndpoint, NewMemoryStorage())); err == nil {
+		t.Error("Register of a duplicate chain succeeded")
+	}
+	if parser, ok := registry.Get("polygon"); !ok || ChainOf(parser).Name != "polygon" {
+		t.Errorf("Get(polygon) = %v, %v", parser, ok)
+	}
+
+	first, second := make(chan Transaction, 4), make(chan Transaction, 4)
+	registry.Notify(first)
+	registry.Notify(second)
+	if err := registry.StartAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.StartAll(context.Background()); err == nil {
+		t.Error("StartAll of running pollers succeeded")
+	}
+	if err := registry.Register("base", NewEthereumParser(unreachableEndpoint, NewMemoryStorage())); err == nil {
+		t.Error("Register while the pollers run succeeded")
+	}
+
+	// The pollers start at the head, so the transactions are in the next block
+	want := map[string]string{}
+	for name, node := range nodes {
+		waitForCall(t, node, "eth_blockNumber")
+		transaction := Transaction{Hash: Keccak256Hex("transaction on " + name), From: checksummedAddress, To: otherAddress, Value: "0x1", Nonce: "0x0"}
+		node.AddBlock(testBlock(2, transaction))
+		want[transaction.Hash] = name
+	}
+	for _, out := range []chan Transaction{first, second} {
+		for range want {
+			transaction := receive(t, out)
+			if chain, ok := want[transaction.Hash]; !ok || transaction.Chain != chain {
+				t.Errorf("received %v of chain %q, want a transaction of chain %q", transaction.Hash, transaction.Chain, chain)
+			}
+		}
+	}
+
+	if err := registry.StopAll(); err != nil {
+		t.Fatalf("StopAll = %v", err)
+	}
+	for name, node := range nodes {
+		node.AddBlock(testBlock(3, Transaction{Hash: Keccak256Hex("late transaction on " + name), From: checksummedAddress, To: otherAddress, Value: "0x1", Nonce: "0x1"}))
+	}
+	receiveNone(t, first, 5*testWatchInterval)
+	if err := registry.StartAll(context.Background()); err != nil {
+		t.Errorf("StartAll after StopAll = %v", err)
+	}
+	registry.StopAll()
+}
+
+// TestRegistryForEach visits the chains in registration order.
+func TestRegistryForEach(t *testing.T) {
+	registry := NewRegistry()
+	for _, name := range []string{"polygon", "ethereum", "base"} {
+		if err := registry.Register(name, NewEthereumParser(unreachableEndpoint, NewMemoryStorage())); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var names []string
+	err := registry.ForEach(func(name string, parser Parser) error {
+		names = append(names, name)
+		if name == "ethereum" {
+			return errors.New("stop")
+		}
+		return nil
+	})
+	if fmt.Sprint(names) != "[polygon ethereum]" || err == nil || err.Error() != "ethereum: stop" {
+		t.Errorf("ForEach visited %v and returned %v, want [polygon ethereum] and ethereum: stop", names, err)
+	}
+}
+
+// waitForCall waits until the node answered a call of the method.
+func waitForCall(t *testing.T, node *fakeNode, method string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for node.Calls(method) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("%v not called", method)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,35 @@
+package parser
+
+import "time"
+
+// RuntimeSettings are the settings of an EthereumParser that can change while
+// it runs, see Reconfigure.
+type RuntimeSettings struct {
+	Endpoint        string
+	WatchInterval   time.Duration
+	Confirmations   uint64
+	ActivationDelay time.Duration
+}
+
+// settings returns the runtime settings in effect.
+func (parser *EthereumParser) settings() RuntimeSettings {
+	parser.settingsMu.RLock()
+	defer parser.settingsMu.RUnlock()
+	return RuntimeSettings{
+		Endpoint:        parser.Endpoint,
+		WatchInterval:   parser.WatchInterval,
+		Confirmations:   parser.Confirmations,
+		ActivationDelay: parser.ActivationDelay,
+	}
+}
+
+// Reconfigure replaces the runtime settings. Polls and RPC calls in progress
+// finish with the previous ones.
+func (parser *EthereumParser) Reconfigure(settings RuntimeSettings) {
+	parser.settingsMu.Lock()
+	defer parser.settingsMu.Unlock()
+	parser.Endpoint = settings.Endpoint
+	parser.WatchInterval = settings.WatchInterval
+	parser.Confirmations = settings.Confirmations
+	parser.ActivationDelay = settings.ActivationDelay
+}
@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// rpcMaxAttempts is the number of times callRPCMethod sends a call of an idempotent method.
+	rpcMaxAttempts = 3
+	// rpcRetryDelay is the wait before the first retry, doubled before each next one.
+	rpcRetryDelay = 250 * time.Millisecond
+	// rpcRetryBudget bounds the time spent on a call and its retries when
+	// neither the caller's context nor the RPC timeouts set a deadline.
+	rpcRetryBudget = 10 * time.Second
+	// DefaultRPCTimeout bounds a call and its retries, unless the method has
+	// its own timeout, see WithRPCTimeouts.
+	DefaultRPCTimeout = 30 * time.Second
+)
+
+// RPCCallError is a failed JSON-RPC call. It names the method and the
+// endpoint, and counts the attempts made.
+type RPCCallError struct {
+	Method   string
+	Endpoint string // Credentials redacted
+	Attempts int
+	Err      error
+}
+
+func (err *RPCCallError) Error() string {
+	if err.Attempts > 1 {
+		return fmt.Sprintf("%v at %v failed after %d attempts: %v", err.Method, err.Endpoint, err.Attempts, err.Err)
+	}
+	return fmt.Sprintf("%v at %v: %v", err.Method, err.Endpoint, err.Err)
+}
+
+func (err *RPCCallError) Unwrap() error {
+	return err.Err
+}
+
+// retryWithin reports whether a call started at start can be retried after
+// waiting delay: before the deadline of ctx, which includes the method's RPC
+// timeout, or without one, within rpcRetryBudget of start.
+func (parser *EthereumParser) retryWithin(ctx context.Context, start time.Time, delay time.Duration) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		return time.Until(deadline) > delay
+	}
+	return parser.clock.Now().Sub(start)+delay < rpcRetryBudget
+}
+
+// WithRPCTimeouts sets the time a JSON-RPC call and its retries may take:
+// methods lists the timeouts of the methods, such as a long one for
+// eth_getLogs, and the others use defaultTimeout. A timeout of 0 leaves the
+// calls bounded by their context only.
+func WithRPCTimeouts(defaultTimeout time.Duration, methods map[string]time.Duration) Option {
+	return func(parser *EthereumParser) {
+		parser.rpcTimeout = defaultTimeout
+		parser.rpcMethodTimeouts = methods
+	}
+}
+
+// rpcTimeoutOf returns the timeout of the calls of method.
+func (parser *EthereumParser) rpcTimeoutOf(method string) time.Duration {
+	if timeout, ok := parser.rpcMethodTimeouts[method]; ok {
+		return timeout
+	}
+	return parser.rpcTimeout
+}
+
+// ParseRPCTimeouts parses method=duration pairs, such as eth_getLogs=1m.
+func ParseRPCTimeouts(items []string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration, len(items))
+	for _, item := range items {
+		method, text, ok := strings.Cut(item, "=")
+		if !ok || method == "" {
+			return nil, fmt.Errorf("invalid RPC timeout %q, expected method=duration", item)
+		}
+		if _, exists := timeouts[method]; exists {
+			return nil, fmt.Errorf("duplicate RPC timeout of %v", method)
+		}
+		timeout, err := time.ParseDuration(text)
+		if err != nil || timeout < 0 {
+			return nil, fmt.Errorf("invalid RPC timeout of %v: %q", method, text)
+		}
+		timeouts[method] = timeout
+	}
+	return timeouts, nil
+}
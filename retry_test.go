@@ -0,0 +1,138 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"testing"
+	"time"
+)
+
+func TestRetryWithin(t *testing.T) {
+	clock := NewMockClock(time.Unix(1_700_000_000, 0))
+	parser := NewEthereumParser(unreachableEndpoint, NewMemoryStorage(), WithClock(clock))
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	soon, cancelSoon := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancelSoon()
+	later, cancelLater := context.WithTimeout(context.Background(), time.Hour)
+	defer cancelLater()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		elapsed time.Duration
+		want    bool
+	}{
+		{"within the budget", context.Background(), time.Second, true},
+		{"past the budget", context.Background(), rpcRetryBudget - rpcRetryDelay/2, false},
+		{"cancelled", cancelled, 0, false},
+		{"deadline before the retry", soon, 0, false},
+		{"deadline after the retry", later, 0, true},
+		{"deadline after the retry, past the budget", later, rpcRetryBudget, true},
+	}
+	for _, test := range tests {
+		start := clock.Now()
+		clock.Advance(test.elapsed)
+		if got := parser.retryWithin(test.ctx, start, rpcRetryDelay); got != test.want {
+			t.Errorf("%v: retryWithin = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+// TestCallRPCMethodRetries fails the calls of a fakeNode, which callRPCMethod
+// retries only for idempotent methods failing in transit.
+func TestCallRPCMethodRetries(t *testing.T) {
+	reset := errors.New("connection reset by peer")
+	tests := []struct {
+		name     string
+		method   string
+		failures []error
+		timeout  time.Duration // Of the caller's context, none when 0
+		want     int           // Attempts
+	}{
+		{"idempotent method failing in transit", "eth_blockNumber", []error{reset, reset, reset}, 0, rpcMaxAttempts},
+		{"idempotent method failing on the node", "eth_blockNumber", []error{&RPCError{Code: -32000, Message: "header not found"}}, 0, 1},
+		{"transaction sent", "eth_sendRawTransaction", []error{reset}, 0, 1},
+		{"filter installed", "eth_newFilter", []error{reset}, 0, 1},
+		{"deadline allowing one retry", "eth_blockNumber", []error{reset, reset, reset}, 2*rpcRetryDelay + rpcRetryDelay/2, 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node := newFakeNode(t, testBlock(1))
+			node.Fail(test.method, test.failures...)
+			parser := node.NewParser()
+			ctx := context.Background()
+			if test.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, test.timeout)
+				defer cancel()
+			}
+
+			var result interface{}
+			err := parser.callRPCMethod(ctx, test.method, nil, &result)
+			var callErr *RPCCallError
+			if !errors.As(err, &callErr) || callErr.Attempts != test.want {
+				t.Fatalf("callRPCMethod error = %v, want an RPCCallError after %d attempts", err, test.want)
+			}
+			if calls := node.Calls(test.method); calls != test.want {
+				t.Errorf("%v called %d times, want %d", test.method, calls, test.want)
+			}
+		})
+	}
+}
+
+// TestRPCTimeoutsPerMethod calls a slow node: eth_getLogs, given a long
+// timeout, survives while eth_blockNumber times out with the short default.
+func TestRPCTimeoutsPerMethod(t *testing.T) {
+	node := newFakeNode(t, testBlock(1), testBlock(2))
+	node.SetLatency(200 * time.Millisecond)
+	parser := node.NewParser(WithRPCTimeouts(50*time.Millisecond, map[string]time.Duration{"eth_getLogs": 5 * time.Second}))
+
+	start := time.Now()
+	if _, err := parser.blockNumber(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("blockNumber error = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("blockNumber took %v, want its retries bounded by the 50ms default", elapsed)
+	}
+	if _, err := parser.GetLogs(context.Background(), LogFilter{FromBlock: 1, ToBlock: 2}); err != nil {
+		t.Errorf("GetLogs slower than the default timeout error = %v, want its own timeout", err)
+	}
+}
+
+// TestRPCTimeoutRetryBudget bounds the retries of a method by its own
+// timeout rather than the default.
+func TestRPCTimeoutRetryBudget(t *testing.T) {
+	reset := errors.New("connection reset by peer")
+	node := newFakeNode(t, testBlock(1))
+	node.Fail("eth_blockNumber", reset, reset, reset)
+	parser := node.NewParser(WithRPCTimeouts(time.Minute, map[string]time.Duration{"eth_blockNumber": 2*rpcRetryDelay + rpcRetryDelay/2}))
+
+	_, err := parser.blockNumber(context.Background())
+	var callErr *RPCCallError
+	if !errors.As(err, &callErr) || callErr.Attempts != 2 {
+		t.Errorf("blockNumber error = %v, want an RPCCallError after the 2 attempts its timeout allows", err)
+	}
+}
+
+func TestParseRPCTimeouts(t *testing.T) {
+	timeouts, err := ParseRPCTimeouts([]string{"eth_getLogs=1m", "debug_traceBlockByNumber=90s", "eth_blockNumber=0"})
+	want := map[string]time.Duration{"eth_getLogs": time.Minute, "debug_traceBlockByNumber": 90 * time.Second, "eth_blockNumber": 0}
+	if err != nil || !maps.Equal(timeouts, want) {
+		t.Errorf("ParseRPCTimeouts = %v, %v, want %v", timeouts, err, want)
+	}
+
+	for _, items := range [][]string{
+		{"eth_getLogs"},
+		{"=1m"},
+		{"eth_getLogs=soon"},
+		{"eth_getLogs=-1s"},
+		{"eth_getLogs=1m", "eth_getLogs=2m"},
+	} {
+		if timeouts, err := ParseRPCTimeouts(items); err == nil {
+			t.Errorf("ParseRPCTimeouts(%q) = %v, want an error", items, timeouts)
+		}
+	}
+}
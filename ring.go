@@ -0,0 +1,55 @@
+package parser
+
+// RingBuffer keeps the last items pushed to it, up to its capacity, dropping
+// the oldest item when a new one is pushed while it is full. It is not safe
+// for concurrent use.
+type RingBuffer[T any] struct {
+	items []T
+	start int // Index of the oldest item
+	count int
+}
+
+// NewRingBuffer initializes an empty RingBuffer holding up to capacity items,
+// at least one.
+func NewRingBuffer[T any](capacity int) *RingBuffer[T] {
+	return &RingBuffer[T]{items: make([]T, max(capacity, 1))}
+}
+
+// Push appends an item, dropping the oldest one when the buffer is full.
+func (ring *RingBuffer[T]) Push(item T) {
+	end := (ring.start + ring.count) % len(ring.items)
+	ring.items[end] = item
+	if ring.count == len(ring.items) {
+		ring.start = (ring.start + 1) % len(ring.items)
+	} else {
+		ring.count++
+	}
+}
+
+// Oldest returns the oldest item, which the next Push drops when the buffer is full.
+func (ring *RingBuffer[T]) Oldest() (T, bool) {
+	if ring.count == 0 {
+		var zero T
+		return zero, false
+	}
+	return ring.items[ring.start], true
+}
+
+// Items returns a copy of the items, oldest first.
+func (ring *RingBuffer[T]) Items() []T {
+	items := make([]T, 0, ring.count)
+	for i := 0; i < ring.count; i++ {
+		items = append(items, ring.items[(ring.start+i)%len(ring.items)])
+	}
+	return items
+}
+
+// Len returns the number of items in the buffer.
+func (ring *RingBuffer[T]) Len() int {
+	return ring.count
+}
+
+// Cap returns the number of items the buffer holds before dropping the oldest.
+func (ring *RingBuffer[T]) Cap() int {
+	return len(ring.items)
+}
@@ -0,0 +1,33 @@
+package parser
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRingBufferWraps(t *testing.T) {
+	ring := NewRingBuffer[int](3)
+	if _, ok := ring.Oldest(); ok || ring.Len() != 0 || ring.Cap() != 3 {
+		t.Fatalf("new ring: Len = %d, Cap = %d, Oldest ok = %v", ring.Len(), ring.Cap(), ok)
+	}
+	for i := 1; i <= 7; i++ {
+		ring.Push(i)
+	}
+	if items := ring.Items(); !slices.Equal(items, []int{5, 6, 7}) {
+		t.Errorf("Items after 7 pushes = %v, want [5 6 7]", items)
+	}
+	if oldest, ok := ring.Oldest(); !ok || oldest != 5 {
+		t.Errorf("Oldest = %v, %v, want 5, true", oldest, ok)
+	}
+	if ring.Len() != 3 {
+		t.Errorf("Len = %d, want 3", ring.Len())
+	}
+
+	// A capacity below one holds one item
+	single := NewRingBuffer[int](0)
+	single.Push(1)
+	single.Push(2)
+	if items := single.Items(); !slices.Equal(items, []int{2}) {
+		t.Errorf("Items of a ring of capacity 0 = %v, want [2]", items)
+	}
+}
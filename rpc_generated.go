@@ -0,0 +1,220 @@
+// Code generated by gen-rpc from rpc_methods.yaml. DO NOT EDIT.
+
+package parser
+
+import "context"
+
+// idempotentRPCMethods are the methods callRPCMethod retries.
+var idempotentRPCMethods = map[string]bool{
+	"eth_blockNumber":           true,
+	"eth_chainId":               true,
+	"eth_getBlockByNumber":      true,
+	"eth_getBlockByHash":        true,
+	"eth_getBalance":            true,
+	"eth_getCode":               true,
+	"eth_getTransactionCount":   true,
+	"eth_call":                  true,
+	"eth_getLogs":               true,
+	"eth_getFilterLogs":         true,
+	"eth_getTransactionByHash":  true,
+	"eth_getTransactionReceipt": true,
+	"eth_feeHistory":            true,
+	"eth_maxPriorityFeePerGas":  true,
+	"eth_gasPrice":              true,
+	"eth_accounts":              true,
+	"eth_coinbase":              true,
+	"eth_getBlockReceipts":      true,
+	"debug_traceTransaction":    true,
+	"trace_transaction":         true,
+	"net_peerCount":             true,
+	"net_listening":             true,
+	"web3_clientVersion":        true,
+	"eth_protocolVersion":       true,
+	"eth_syncing":               true,
+}
+
+// rpcEthBlockNumber calls eth_blockNumber.
+func (parser *EthereumParser) rpcEthBlockNumber(ctx context.Context) (string, error) {
+	var result string
+	err := parser.callRPCMethod(ctx, "eth_blockNumber", nil, &result)
+	return result, err
+}
+
+// rpcEthChainId calls eth_chainId.
+func (parser *EthereumParser) rpcEthChainId(ctx context.Context) (string, error) {
+	var result string
+	err := parser.callRPCMethod(ctx, "eth_chainId", nil, &result)
+	return result, err
+}
+
+// rpcEthGetBlockByNumber calls eth_getBlockByNumber, decoding its result into result.
+func (parser *EthereumParser) rpcEthGetBlockByNumber(ctx context.Context, block string, full bool, result interface{}) error {
+	return parser.callRPCMethod(ctx, "eth_getBlockByNumber", []interface{}{block, full}, result)
+}
+
+// rpcEthGetBlockByHash calls eth_getBlockByHash, decoding its result into result.
+func (parser *EthereumParser) rpcEthGetBlockByHash(ctx context.Context, hash string, full bool, result interface{}) error {
+	return parser.callRPCMethod(ctx, "eth_getBlockByHash", []interface{}{hash, full}, result)
+}
+
+// rpcEthGetBalance calls eth_getBalance.
+func (parser *EthereumParser) rpcEthGetBalance(ctx context.Context, address string, block string) (string, error) {
+	var result string
+	err := parser.callRPCMethod(ctx, "eth_getBalance", []interface{}{address, block}, &result)
+	return result, err
+}
+
+// rpcEthGetCode calls eth_getCode.
+func (parser *EthereumParser) rpcEthGetCode(ctx context.Context, address string, block string) (string, error) {
+	var result string
+	err := parser.callRPCMethod(ctx, "eth_getCode", []interface{}{address, block}, &result)
+	return result, err
+}
+
+// rpcEthGetTransactionCount calls eth_getTransactionCount.
+func (parser *EthereumParser) rpcEthGetTransactionCount(ctx context.Context, address string, block string) (string, error) {
+	var result string
+	err := parser.callRPCMethod(ctx, "eth_getTransactionCount", []interface{}{address, block}, &result)
+	return result, err
+}
+
+// rpcEthCall calls eth_call.
+func (parser *EthereumParser) rpcEthCall(ctx context.Context, call map[string]interface{}, block string) (string, error) {
+	var result string
+	err := parser.callRPCMethod(ctx, "eth_call", []interface{}{call, block}, &result)
+	return result, err
+}
+
+// rpcEthGetLogs calls eth_getLogs.
+func (parser *EthereumParser) rpcEthGetLogs(ctx context.Context, filter map[string]interface{}) ([]Log, error) {
+	var result []Log
+	err := parser.callRPCMethod(ctx, "eth_getLogs", []interface{}{filter}, &result)
+	return result, err
+}
+
+// rpcEthNewFilter calls eth_newFilter.
+func (parser *EthereumParser) rpcEthNewFilter(ctx context.Context, filter map[string]interface{}) (string, error) {
+	var result string
+	err := parser.callRPCMethod(ctx, "eth_newFilter", []interface{}{filter}, &result)
+	return result, err
+}
+
+// rpcEthGetFilterLogs calls eth_getFilterLogs.
+func (parser *EthereumParser) rpcEthGetFilterLogs(ctx context.Context, id string) ([]Log, error) {
+	var result []Log
+	err := parser.callRPCMethod(ctx, "eth_getFilterLogs", []interface{}{id}, &result)
+	return result, err
+}
+
+// rpcEthGetFilterChanges calls eth_getFilterChanges.
+func (parser *EthereumParser) rpcEthGetFilterChanges(ctx context.Context, id string) ([]Log, error) {
+	var result []Log
+	err := parser.callRPCMethod(ctx, "eth_getFilterChanges", []interface{}{id}, &result)
+	return result, err
+}
+
+// rpcEthUninstallFilter calls eth_uninstallFilter.
+func (parser *EthereumParser) rpcEthUninstallFilter(ctx context.Context, id string) (bool, error) {
+	var result bool
+	err := parser.callRPCMethod(ctx, "eth_uninstallFilter", []interface{}{id}, &result)
+	return result, err
+}
+
+// rpcEthGetTransactionByHash calls eth_getTransactionByHash.
+func (parser *EthereumParser) rpcEthGetTransactionByHash(ctx context.Context, hash string) (TransactionDetails, error) {
+	var result TransactionDetails
+	err := parser.callRPCMethod(ctx, "eth_getTransactionByHash", []interface{}{hash}, &result)
+	return result, err
+}
+
+// rpcEthGetTransactionReceipt calls eth_getTransactionReceipt.
+func (parser *EthereumParser) rpcEthGetTransactionReceipt(ctx context.Context, hash string) (TransactionReceipt, error) {
+	var result TransactionReceipt
+	err := parser.callRPCMethod(ctx, "eth_getTransactionReceipt", []interface{}{hash}, &result)
+	return result, err
+}
+
+// rpcEthFeeHistory calls eth_feeHistory.
+func (parser *EthereumParser) rpcEthFeeHistory(ctx context.Context, blockCount string, newestBlock string, rewardPercentiles []float64) (FeeHistory, error) {
+	var result FeeHistory
+	err := parser.callRPCMethod(ctx, "eth_feeHistory", []interface{}{blockCount, newestBlock, rewardPercentiles}, &result)
+	return result, err
+}
+
+// rpcEthMaxPriorityFeePerGas calls eth_maxPriorityFeePerGas.
+func (parser *EthereumParser) rpcEthMaxPriorityFeePerGas(ctx context.Context) (string, error) {
+	var result string
+	err := parser.callRPCMethod(ctx, "eth_maxPriorityFeePerGas", nil, &result)
+	return result, err
+}
+
+// rpcEthGasPrice calls eth_gasPrice.
+func (parser *EthereumParser) rpcEthGasPrice(ctx context.Context) (string, error) {
+	var result string
+	err := parser.callRPCMethod(ctx, "eth_gasPrice", nil, &result)
+	return result, err
+}
+
+// rpcEthAccounts calls eth_accounts.
+func (parser *EthereumParser) rpcEthAccounts(ctx context.Context) ([]string, error) {
+	var result []string
+	err := parser.callRPCMethod(ctx, "eth_accounts", nil, &result)
+	return result, err
+}
+
+// rpcEthCoinbase calls eth_coinbase.
+func (parser *EthereumParser) rpcEthCoinbase(ctx context.Context) (string, error) {
+	var result string
+	err := parser.callRPCMethod(ctx, "eth_coinbase", nil, &result)
+	return result, err
+}
+
+// rpcEthGetBlockReceipts calls eth_getBlockReceipts.
+func (parser *EthereumParser) rpcEthGetBlockReceipts(ctx context.Context, block string) ([]TransactionReceipt, error) {
+	var result []TransactionReceipt
+	err := parser.callRPCMethod(ctx, "eth_getBlockReceipts", []interface{}{block}, &result)
+	return result, err
+}
+
+// rpcDebugTraceTransaction calls debug_traceTransaction, decoding its result into result.
+func (parser *EthereumParser) rpcDebugTraceTransaction(ctx context.Context, hash string, result interface{}) error {
+	return parser.callRPCMethod(ctx, "debug_traceTransaction", []interface{}{hash}, result)
+}
+
+// rpcTraceTransaction calls trace_transaction, decoding its result into result.
+func (parser *EthereumParser) rpcTraceTransaction(ctx context.Context, hash string, result interface{}) error {
+	return parser.callRPCMethod(ctx, "trace_transaction", []interface{}{hash}, result)
+}
+
+// rpcNetPeerCount calls net_peerCount.
+func (parser *EthereumParser) rpcNetPeerCount(ctx context.Context) (string, error) {
+	var result string
+	err := parser.callRPCMethod(ctx, "net_peerCount", nil, &result)
+	return result, err
+}
+
+// rpcNetListening calls net_listening.
+func (parser *EthereumParser) rpcNetListening(ctx context.Context) (bool, error) {
+	var result bool
+	err := parser.callRPCMethod(ctx, "net_listening", nil, &result)
+	return result, err
+}
+
+// rpcWeb3ClientVersion calls web3_clientVersion.
+func (parser *EthereumParser) rpcWeb3ClientVersion(ctx context.Context) (string, error) {
+	var result string
+	err := parser.callRPCMethod(ctx, "web3_clientVersion", nil, &result)
+	return result, err
+}
+
+// rpcEthProtocolVersion calls eth_protocolVersion.
+func (parser *EthereumParser) rpcEthProtocolVersion(ctx context.Context) (string, error) {
+	var result string
+	err := parser.callRPCMethod(ctx, "eth_protocolVersion", nil, &result)
+	return result, err
+}
+
+// rpcEthSyncing calls eth_syncing, decoding its result into result.
+func (parser *EthereumParser) rpcEthSyncing(ctx context.Context, result interface{}) error {
+	return parser.callRPCMethod(ctx, "eth_syncing", nil, result)
+}
@@ -0,0 +1,49 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestCallRPCMethodUnencodableParams fails calls whose params JSON cannot
+// encode before anything is sent to the node.
+func TestCallRPCMethodUnencodableParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		params []interface{}
+	}{
+		{"channel", []interface{}{make(chan int)}},
+		{"function", []interface{}{"latest", func() {}}},
+		{"complex number", []interface{}{complex(1, 2)}},
+		{"NaN", []interface{}{math.NaN()}},
+		{"nested channel", []interface{}{map[string]interface{}{"address": make(chan int)}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node := newFakeNode(t, testBlock(1))
+			parser := node.NewParser()
+			var result interface{}
+
+			err := parser.callRPCMethod(context.Background(), "eth_call", test.params, &result)
+			var unsupportedType *json.UnsupportedTypeError
+			var unsupportedValue *json.UnsupportedValueError
+			if err == nil || !strings.Contains(err.Error(), "eth_call") || !strings.Contains(err.Error(), "failed to encode params") ||
+				!errors.As(err, &unsupportedType) && !errors.As(err, &unsupportedValue) {
+				t.Errorf("callRPCMethod error = %v, want the JSON encoding error of eth_call", err)
+			}
+
+			err = parser.callRPCBatch(context.Background(), "eth_call", [][]interface{}{{"0x1"}, test.params}, []interface{}{&result, &result})
+			if err == nil || !strings.Contains(err.Error(), "failed to encode params") {
+				t.Errorf("callRPCBatch error = %v, want the JSON encoding error", err)
+			}
+			// The fakeNode counts batches under no method
+			if calls := node.Calls("eth_call") + node.Calls(""); calls != 0 {
+				t.Errorf("node called %d times, want none", calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package parser
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// maxRequestBodySize limits the size of JSON request bodies accepted by the server.
+const maxRequestBodySize = 1 << 20
+
+// Server exposes the parser over an HTTP JSON API.
+type Server struct {
+	parser Parser
+	mux    *http.ServeMux
+}
+
+// NewServer initializes a new Server for the parser.
+func NewServer(parser Parser) *Server {
+	server := &Server{
+		parser: parser,
+		mux:    http.NewServeMux(),
+	}
+	server.mux.HandleFunc("GET /block", server.handleCurrentBlock)
+	server.mux.HandleFunc("GET /transactions", server.handleTransactions)
+	server.mux.HandleFunc("GET /fees", server.handleFees)
+	server.mux.HandleFunc("GET /subscribers", server.handleSubscribers)
+	server.mux.HandleFunc("POST /subscribers", server.handleSubscribe)
+	server.mux.HandleFunc("POST /subscribers/bulk", server.handleBulkSubscribe)
+	server.mux.HandleFunc("POST /subscribers/validate", server.handleValidateSubscription)
+	server.mux.HandleFunc("GET /debug/dump", server.handleDebugDump)
+	server.mux.HandleFunc("GET /approvals", server.handleApprovals)
+	server.mux.HandleFunc("GET /labels", server.handleAddressLabels)
+	server.mux.HandleFunc("PUT /labels/{address}", server.handleSetAddressLabel)
+	server.mux.HandleFunc("GET /chains", server.handleChains)
+	server.mux.HandleFunc("GET /chains/{chain}/block", server.forChain((*Server).handleCurrentBlock))
+	server.mux.HandleFunc("GET /chains/{chain}/addresses", server.forChain((*Server).handleSubscribers))
+	server.mux.HandleFunc("POST /chains/{chain}/addresses", server.forChain((*Server).handleSubscribe))
+	server.mux.HandleFunc("GET /chains/{chain}/addresses/{address}/transactions", server.forChain((*Server).handleTransactions))
+	return server
+}
+
+func (server *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	traceIDs(traceRequests(tracerOf(server.parser), server.mux)).ServeHTTP(w, r)
+}
+
+// currentBlockResponse is the body of GET /block.
+type currentBlockResponse struct {
+	BlockNumber uint64 `json:"blockNumber"`
+}
+
+func (server *Server) handleCurrentBlock(w http.ResponseWriter, r *http.Request) {
+	blockNumber := CurrentBlockOf(r.Context(), server.parser)
+	if blockNumber == 0 {
+		writeError(w, http.StatusBadGateway, "failed to get current block")
+		return
+	}
+	writeJSON(w, http.StatusOK, currentBlockResponse{BlockNumber: blockNumber})
+}
+
+func (server *Server) handleTransactions(w http.ResponseWriter, r *http.Request) {
+	address := r.PathValue("address")
+	if address == "" {
+		address = r.URL.Query().Get("address")
+	}
+	if address == "" {
+		writeError(w, http.StatusBadRequest, "you need to define an address")
+		return
+	}
+
+	transactions := TransactionsOf(r.Context(), server.parser, address)
+	if transactions == nil {
+		transactions = []Transaction{}
+	}
+	if acceptsYAML(r) {
+		writeYAMLResponse(w, http.StatusOK, NewYAMLTransactions(transactions))
+		return
+	}
+	writeJSON(w, http.StatusOK, transactions)
+}
+
+func (server *Server) handleFees(w http.ResponseWriter, r *http.Request) {
+	suggester, ok := server.parser.(feeSuggester)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, "parser does not support fee suggestions")
+		return
+	}
+
+	baseFee, maxPriorityFee, maxFee, err := suggester.SuggestEIP1559Fees(r.Context())
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, feeSuggestion{BaseFeePerGas: baseFee, MaxPriorityFeePerGas: maxPriorityFee, MaxFeePerGas: maxFee})
+}
+
+func (server *Server) handleSubscribers(w http.ResponseWriter, r *http.Request) {
+	syncer, syncs := server.parser.(subscriberSyncer)
+	if since := r.URL.Query().Get("sinceVersion"); since != "" {
+		sinceVersion, err := strconv.ParseUint(since, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid sinceVersion: "+since)
+			return
+		}
+		if !syncs {
+			writeError(w, http.StatusNotImplemented, "parser does not support subscriber versions")
+			return
+		}
+		diff, err := syncer.SubscribersDiff(sinceVersion)
+		if errors.Is(err, ErrSubscriberVersionExpired) {
+			writeError(w, http.StatusGone, err.Error())
+			return
+		} else if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, diff)
+		return
+	}
+
+	// Read first, so that a diff since the version repeats the changes made
+	// while listing rather than missing them
+	var version uint64
+	if syncs {
+		version = syncer.SubscriberVersion()
+	}
+	list, err := ListSubscribers(server.parser, r.URL.Query().Get("filter"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if syncs {
+		list.Version = &version
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// subscribeResponse is the body of POST /subscribers.
+type subscribeResponse struct {
+	Subscribed        string `json:"subscribed"`
+	AlreadySubscribed bool   `json:"alreadySubscribed,omitempty"`
+}
+
+func (server *Server) handleSubscribe(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Address string `json:"address"`
+	}
+	if !readJSON(w, r, &request) {
+		return
+	}
+
+	result := SubscribeAll(server.parser, []string{request.Address})
+	if len(result.Failed) > 0 {
+		writeError(w, http.StatusBadRequest, result.Failed[0].Reason)
+		return
+	}
+	if len(result.AlreadySubscribed) > 0 {
+		writeJSON(w, http.StatusOK, subscribeResponse{Subscribed: request.Address, AlreadySubscribed: true})
+		return
+	}
+	writeJSON(w, http.StatusOK, subscribeResponse{Subscribed: result.Subscribed[0]})
+}
+
+func (server *Server) handleBulkSubscribe(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Addresses []string `json:"addresses"`
+	}
+	if !readJSON(w, r, &request) {
+		return
+	}
+	if len(request.Addresses) == 0 {
+		writeError(w, http.StatusBadRequest, "you need to define at least one address")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, SubscribeAll(server.parser, request.Addresses))
+}
+
+func (server *Server) handleValidateSubscription(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Address string `json:"address"`
+	}
+	if !readJSON(w, r, &request) {
+		return
+	}
+
+	validator, ok := server.parser.(subscriptionValidator)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, "parser does not support validating subscriptions")
+		return
+	}
+
+	var problems []string
+	var subscriptionErr *SubscriptionError
+	if errors.As(validator.ValidateSubscription(request.Address), &subscriptionErr) {
+		for _, problem := range subscriptionErr.Problems {
+			problems = append(problems, problem.Error())
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"address":  request.Address,
+		"valid":    len(problems) == 0,
+		"problems": append([]string{}, problems...),
+	})
+}
+
+func (server *Server) handleChains(w http.ResponseWriter, r *http.Request) {
+	names := []string{}
+	if selector, ok := server.parser.(ChainSelector); ok {
+		names = selector.ChainNames()
+	} else if name := ChainOf(server.parser).Name; name != "" {
+		names = append(names, name)
+	}
+	writeJSON(w, http.StatusOK, names)
+}
+
+// forChain adapts a handler to serve the chain named in the path, answering
+// 404 for chains the parser does not serve.
+func (server *Server) forChain(handler func(*Server, http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		parser, ok := SelectChain(server.parser, r.PathValue("chain"))
+		if !ok {
+			writeError(w, http.StatusNotFound, "unknown chain: "+r.PathValue("chain"))
+			return
+		}
+		handler(&Server{parser: parser}, w, r)
+	}
+}
+
+// readJSON decodes the request body into v, writing a 400 response when it is invalid.
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodySize))
+	if err := decoder.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// writeJSON writes v as a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// ErrorResponse is the body of error responses, which names the trace ID to
+// quote when reporting the error.
+type ErrorResponse struct {
+	Error   string `json:"error"`
+	TraceID string `json:"traceId,omitempty"`
+}
+
+// writeError writes a JSON error response with the given status code.
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, ErrorResponse{Error: message, TraceID: w.Header().Get(traceIDHeader)})
+}
@@ -0,0 +1,142 @@
+package parser_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/GeorgeIwu/go-parser"
+	"github.com/GeorgeIwu/go-parser/parsertest"
+)
+
+func TestServerTransactions(t *testing.T) {
+	mock := parsertest.NewMockParser()
+	transaction := parsertest.NewTransaction().Build()
+	mock.AddTransactions(transaction)
+	server := parser.NewServer(mock)
+
+	get := func() []parser.Transaction {
+		t.Helper()
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/transactions?address="+parsertest.Alice, nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("GET /transactions = %d %v", recorder.Code, recorder.Body)
+		}
+		var transactions []parser.Transaction
+		if err := json.Unmarshal(recorder.Body.Bytes(), &transactions); err != nil {
+			t.Fatal(err)
+		}
+		return transactions
+	}
+
+	if transactions := get(); len(transactions) != 0 {
+		t.Errorf("transactions of an unsubscribed address = %v, want none", transactions)
+	}
+	mock.SubscribeAddress(parsertest.Alice)
+	if transactions := get(); len(transactions) != 1 || transactions[0].Hash != transaction.Hash {
+		t.Errorf("transactions = %v, want %v", transactions, transaction.Hash)
+	}
+}
+
+func TestServerSubscribe(t *testing.T) {
+	tests := []struct {
+		name       string
+		address    string
+		wantStatus int
+		wantBody   string
+	}{
+		{"new", parsertest.Alice, http.StatusOK, `{"subscribed":"` + parsertest.Alice + `"}`},
+		{"again", parsertest.Alice, http.StatusOK, `{"subscribed":"` + parsertest.Alice + `","alreadySubscribed":true}`},
+		{"invalid", "0x1234", http.StatusBadRequest, `{"error":"invalid address"`},
+		{"bad checksum", "0x5AAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", http.StatusBadRequest, `{"error":"address fails EIP-55 checksum; address already subscribed"`},
+	}
+	mock := parsertest.NewMockParser()
+	server := parser.NewServer(mock)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			body := strings.NewReader(`{"address":"` + test.address + `"}`)
+			server.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/subscribers", body))
+			if recorder.Code != test.wantStatus || !strings.HasPrefix(recorder.Body.String(), test.wantBody) {
+				t.Errorf("POST /subscribers = %d %v, want %d %v", recorder.Code, recorder.Body, test.wantStatus, test.wantBody)
+			}
+		})
+	}
+	if calls := mock.Calls("SubscribeAddress"); len(calls) != 3 {
+		t.Errorf("SubscribeAddress called %d times, want 3 for the well-formed addresses", len(calls))
+	}
+}
+
+func TestServerTransactionsYAML(t *testing.T) {
+	mock := parsertest.NewMockParser()
+	mock.AddTransactions(parsertest.NewTransaction().Hash("0x88df").Block(19531250).Build())
+	mock.SubscribeAddress(parsertest.Alice)
+	server := parser.NewServer(mock)
+
+	request := httptest.NewRequest(http.MethodGet, "/transactions?address="+parsertest.Alice, nil)
+	request.Header.Set("Accept", "application/yaml")
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK || recorder.Header().Get("Content-Type") != "application/yaml" {
+		t.Fatalf("GET /transactions = %d %v", recorder.Code, recorder.Header().Get("Content-Type"))
+	}
+	if body := recorder.Body.String(); !strings.HasPrefix(body, "- hash: \"0x88df\"\n  block_number: 19531250\n") {
+		t.Errorf("GET /transactions YAML = %q", body)
+	}
+}
+
+// TestServerSubscribersSinceVersion syncs a copy of the subscribers with
+// the version of GET /subscribers and the diffs since it.
+func TestServerSubscribersSinceVersion(t *testing.T) {
+	store := parser.NewMemoryStorage()
+	p := parser.NewEthereumParser("http://127.0.0.1:1", store)
+	server := parser.NewServer(p)
+	get := func(target string, want int, body interface{}) {
+		t.Helper()
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+		if recorder.Code != want {
+			t.Fatalf("GET %v = %d %v, want %d", target, recorder.Code, recorder.Body, want)
+		}
+		if body != nil {
+			if err := json.Unmarshal(recorder.Body.Bytes(), body); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	p.SubscribeAddress(parsertest.Alice)
+	p.SubscribeAddress(parsertest.Bob)
+	var list parser.SubscriberList
+	get("/subscribers", http.StatusOK, &list)
+	if list.Total != 2 || list.Version == nil || *list.Version != 2 {
+		t.Fatalf("GET /subscribers = %+v, want 2 subscribers at version 2", list)
+	}
+
+	p.UnsubscribeAddress(parsertest.Alice)
+	p.SubscribeAddress(parsertest.Carol)
+	var diff parser.SubscriberDiff
+	get(fmt.Sprintf("/subscribers?sinceVersion=%d", *list.Version), http.StatusOK, &diff)
+	want := parser.SubscriberDiff{Added: []string{parser.NormalizeAddress(parsertest.Carol)}, Removed: []string{parser.NormalizeAddress(parsertest.Alice)}, Version: 4}
+	if !reflect.DeepEqual(diff, want) {
+		t.Errorf("GET /subscribers?sinceVersion=2 = %+v, want %+v", diff, want)
+	}
+
+	get("/subscribers?sinceVersion=two", http.StatusBadRequest, nil)
+	get("/subscribers?sinceVersion=5", http.StatusBadRequest, nil)
+	for i := range 10000 {
+		store.SetSubscriber(fmt.Sprintf("0x%040x", i))
+	}
+	get("/subscribers?sinceVersion=2", http.StatusGone, nil)
+
+	mockServer := parser.NewServer(parsertest.NewMockParser())
+	recorder := httptest.NewRecorder()
+	mockServer.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/subscribers?sinceVersion=0", nil))
+	if recorder.Code != http.StatusNotImplemented {
+		t.Errorf("GET /subscribers?sinceVersion=0 of a parser without versions = %d, want %d", recorder.Code, http.StatusNotImplemented)
+	}
+}
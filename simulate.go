@@ -0,0 +1,436 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSimulateInterval is the default time between the blocks a
+	// simulation releases.
+	DefaultSimulateInterval = time.Second
+	// simulatedChainID is the chain ID a simulated node reports when no chain
+	// ID is configured, that of local development chains.
+	simulatedChainID = 1337
+)
+
+// simulatedBlock is a block of a simulation fixture, as the node returned it.
+type simulatedBlock struct {
+	number  uint64
+	hash    string
+	full    json.RawMessage // With the transactions
+	summary json.RawMessage // With the hashes of the transactions
+	logs    []Log           // Logs of the transactions, served by eth_getLogs
+}
+
+// simulationStep releases a block, after rolling back the reorg blocks below
+// the head.
+type simulationStep struct {
+	reorg uint64
+	block *simulatedBlock
+}
+
+// loadSimulation reads a simulation fixture: blocks as returned by
+// eth_getBlockByNumber with their transactions, in a JSON array or one per
+// line, such as recordBlocks writes. Blocks may list the logs of their
+// transactions in a "logs" field. Each block extends the chain by one. A
+// {"reorg": N} entry removes the last N blocks, which the blocks after it
+// replace.
+func loadSimulation(r io.Reader) ([]simulationStep, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var entries []json.RawMessage
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &entries); err != nil {
+			return nil, fmt.Errorf("invalid fixture: %w", err)
+		}
+	} else {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		for {
+			var entry json.RawMessage
+			if err := decoder.Decode(&entry); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("invalid fixture: %w", err)
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	var steps []simulationStep
+	var reorg, height uint64 // Blocks to roll back before the next block, and the height of the chain
+	for i, entry := range entries {
+		var fields struct {
+			Reorg        *uint64           `json:"reorg"`
+			Number       string            `json:"number"`
+			Hash         string            `json:"hash"`
+			Transactions []json.RawMessage `json:"transactions"`
+			Logs         []Log             `json:"logs"`
+		}
+		if err := json.Unmarshal(entry, &fields); err != nil {
+			return nil, fmt.Errorf("invalid fixture entry %d: %w", i+1, err)
+		}
+		if fields.Reorg != nil {
+			if *fields.Reorg > height {
+				return nil, fmt.Errorf("fixture entry %d: reorg of %d blocks on a chain of %d", i+1, *fields.Reorg, height)
+			}
+			reorg += *fields.Reorg
+			height -= *fields.Reorg
+			continue
+		}
+
+		block, err := newSimulatedBlock(entry, fields.Number, fields.Hash, fields.Transactions)
+		if err != nil {
+			return nil, fmt.Errorf("fixture entry %d: %w", i+1, err)
+		}
+		for i := range fields.Logs {
+			fields.Logs[i].BlockNumber = cmp.Or(fields.Logs[i].BlockNumber, fields.Number)
+		}
+		block.logs = fields.Logs
+		if len(steps) > 0 && block.number != steps[0].block.number+height {
+			return nil, fmt.Errorf("fixture entry %d: block %d does not extend the chain at block %d", i+1, block.number, steps[0].block.number+height-1)
+		}
+		steps = append(steps, simulationStep{reorg: reorg, block: block})
+		reorg = 0
+		height++
+	}
+	if len(steps) == 0 {
+		return nil, errors.New("fixture holds no blocks")
+	}
+	return steps, nil
+}
+
+// newSimulatedBlock keeps a block of a fixture in the forms the node returns.
+func newSimulatedBlock(full json.RawMessage, numberHex, hash string, transactions []json.RawMessage) (*simulatedBlock, error) {
+	number, err := ParseHexUint64(numberHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid block number %q: %w", numberHex, err)
+	}
+	if hash == "" {
+		return nil, fmt.Errorf("block %d has no hash", number)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(full, &fields); err != nil {
+		return nil, err
+	}
+	hashes := make([]string, len(transactions))
+	for i, transaction := range transactions {
+		var fields struct {
+			Hash string `json:"hash"`
+		}
+		if err := json.Unmarshal(transaction, &fields); err != nil || fields.Hash == "" {
+			return nil, fmt.Errorf("transaction %d of block %d has no hash", i, number)
+		}
+		hashes[i] = fields.Hash
+	}
+	fields["transactions"], _ = json.Marshal(hashes)
+	summary, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	return &simulatedBlock{number: number, hash: hash, full: full, summary: summary}, nil
+}
+
+// simulatedNode is an http.RoundTripper answering the JSON-RPC requests of a
+// parser from a fixture, releasing its blocks one every interval as if they
+// were mined. Blocks removed by a reorg are still served by hash.
+type simulatedNode struct {
+	steps    []simulationStep
+	interval time.Duration // Zero releases every block at once
+	chainID  uint64
+	clock    Clock
+	logger   *slog.Logger
+
+	mu       sync.Mutex
+	started  time.Time
+	released int                        // Steps applied
+	chain    []*simulatedBlock          // Blocks of the chain, by height from the first block
+	byHash   map[string]*simulatedBlock // Every released block
+}
+
+func newSimulatedNode(steps []simulationStep, interval time.Duration, chainID uint64, clock Clock, logger *slog.Logger) *simulatedNode {
+	if chainID == 0 {
+		chainID = simulatedChainID
+	}
+	return &simulatedNode{
+		steps:    steps,
+		interval: interval,
+		chainID:  chainID,
+		clock:    clock,
+		logger:   logger,
+		byHash:   make(map[string]*simulatedBlock),
+	}
+}
+
+// advance applies the steps released since the simulation started, which is
+// on the first request.
+func (node *simulatedNode) advance() {
+	now := node.clock.Now()
+	if node.started.IsZero() {
+		node.started = now
+	}
+	due := len(node.steps)
+	if node.interval > 0 {
+		due = min(due, int(now.Sub(node.started)/node.interval)+1)
+	}
+	for ; node.released < due; node.released++ {
+		step := node.steps[node.released]
+		if step.reorg > 0 {
+			node.logger.Info("simulated reorg", "depth", step.reorg, "block", step.block.number)
+		}
+		node.chain = append(node.chain[:len(node.chain)-int(step.reorg)], step.block)
+		node.byHash[step.block.hash] = step.block
+		if node.released == len(node.steps)-1 {
+			node.logger.Info("simulation finished", "block", step.block.number)
+		}
+	}
+}
+
+func (node *simulatedNode) RoundTrip(request *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(request)
+	if err != nil {
+		return nil, err
+	}
+	var call struct {
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+		ID     json.RawMessage   `json:"id"`
+	}
+	response := map[string]interface{}{"jsonrpc": "2.0"}
+	if err := json.Unmarshal(body, &call); err != nil {
+		response["error"] = RPCError{Code: -32700, Message: "parse error"}
+	} else {
+		response["id"] = call.ID
+		node.mu.Lock()
+		node.advance()
+		result, rpcErr := node.call(call.Method, call.Params)
+		node.mu.Unlock()
+		if rpcErr != nil {
+			response["error"] = rpcErr
+		} else {
+			response["result"] = result
+		}
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(data)),
+		Request:    request,
+	}, nil
+}
+
+// call answers a JSON-RPC call from the released blocks.
+func (node *simulatedNode) call(method string, params []json.RawMessage) (interface{}, *RPCError) {
+	var first string
+	var full bool
+	if len(params) > 0 {
+		json.Unmarshal(params[0], &first)
+	}
+	if len(params) > 1 {
+		json.Unmarshal(params[1], &full)
+	}
+	head := node.chain[len(node.chain)-1]
+
+	switch method {
+	case "eth_chainId":
+		return fmt.Sprintf("0x%x", node.chainID), nil
+	case "eth_blockNumber":
+		return fmt.Sprintf("0x%x", head.number), nil
+	case "eth_syncing":
+		return false, nil
+	case "eth_getBlockByNumber":
+		var number uint64
+		switch first {
+		case "latest", "safe", "finalized", "pending":
+			number = head.number
+		case "earliest":
+			number = node.chain[0].number
+		default:
+			var err error
+			if number, err = ParseHexUint64(first); err != nil {
+				return nil, &RPCError{Code: -32602, Message: "invalid block number: " + first}
+			}
+		}
+		if number < node.chain[0].number || number > head.number {
+			return nil, nil
+		}
+		return node.chain[number-node.chain[0].number].form(full), nil
+	case "eth_getBlockByHash":
+		if block, ok := node.byHash[first]; ok {
+			return block.form(full), nil
+		}
+		return nil, nil
+	case "eth_getTransactionByHash":
+		return node.transaction(first), nil
+	case "eth_getLogs":
+		return node.logs(params)
+	}
+	return nil, &RPCError{Code: rpcMethodNotFound, Message: "the method " + method + " does not exist/is not available in simulation"}
+}
+
+// transaction returns the released transaction with the given hash, or nil.
+func (node *simulatedNode) transaction(hash string) json.RawMessage {
+	for _, block := range node.chain {
+		var fields struct {
+			Transactions []json.RawMessage `json:"transactions"`
+		}
+		json.Unmarshal(block.full, &fields)
+		for _, transaction := range fields.Transactions {
+			var tx struct {
+				Hash string `json:"hash"`
+			}
+			if json.Unmarshal(transaction, &tx) == nil && tx.Hash == hash {
+				return transaction
+			}
+		}
+	}
+	return nil
+}
+
+// logs returns the logs of the released blocks matching the filter of an
+// eth_getLogs call.
+func (node *simulatedNode) logs(params []json.RawMessage) ([]Log, *RPCError) {
+	var filter struct {
+		BlockHash string            `json:"blockHash"`
+		FromBlock string            `json:"fromBlock"`
+		ToBlock   string            `json:"toBlock"`
+		Address   string            `json:"address"`
+		Topics    []json.RawMessage `json:"topics"`
+	}
+	if len(params) == 0 || json.Unmarshal(params[0], &filter) != nil {
+		return nil, &RPCError{Code: -32602, Message: "invalid log filter"}
+	}
+	from, to := node.chain[0].number, node.chain[len(node.chain)-1].number
+	if number, err := ParseHexUint64(filter.FromBlock); err == nil {
+		from = number
+	}
+	if number, err := ParseHexUint64(filter.ToBlock); err == nil {
+		to = number
+	}
+	blocks := node.chain
+	if filter.BlockHash != "" {
+		block, ok := node.byHash[filter.BlockHash]
+		if !ok {
+			return nil, &RPCError{Code: -32000, Message: "unknown block " + filter.BlockHash}
+		}
+		blocks, from, to = []*simulatedBlock{block}, block.number, block.number
+	}
+
+	logs := []Log{}
+	for _, block := range blocks {
+		if block.number < from || block.number > to {
+			continue
+		}
+		for _, log := range block.logs {
+			if (filter.Address == "" || strings.EqualFold(log.Address, filter.Address)) && matchTopics(filter.Topics, log.Topics) {
+				logs = append(logs, log)
+			}
+		}
+	}
+	return logs, nil
+}
+
+// matchTopics reports whether the topics of a log match those of a filter,
+// each null, a topic or an array of alternatives.
+func matchTopics(filter []json.RawMessage, topics []string) bool {
+	for i, position := range filter {
+		var alternatives []string
+		if json.Unmarshal(position, &alternatives) != nil {
+			var topic string
+			if json.Unmarshal(position, &topic) != nil {
+				return false
+			}
+			alternatives = []string{topic}
+		}
+		if alternatives == nil {
+			continue
+		}
+		if i >= len(topics) || !slices.ContainsFunc(alternatives, func(topic string) bool { return strings.EqualFold(topic, topics[i]) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// form returns the block with its transactions when full is set, and with
+// their hashes otherwise.
+func (block *simulatedBlock) form(full bool) json.RawMessage {
+	if full {
+		return block.full
+	}
+	return block.summary
+}
+
+// NewSimulationClient returns a client whose requests are answered by a
+// simulated node replaying the fixture at path.
+func NewSimulationClient(path string, interval time.Duration, chainID uint64, logger *slog.Logger) (*http.Client, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open simulation fixture: %w", err)
+	}
+	defer file.Close()
+	steps, err := loadSimulation(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load simulation fixture %v: %w", path, err)
+	}
+	logger.Info("simulating blocks", "fixture", path, "blocks", len(steps), "interval", interval)
+	return &http.Client{Transport: newSimulatedNode(steps, interval, chainID, RealClock{}, logger)}, nil
+}
+
+// BlockRecorder is implemented by parsers that can capture blocks as a
+// simulation fixture.
+type BlockRecorder interface {
+	RecordBlocks(ctx context.Context, from, to uint64, w io.Writer) (int, error)
+}
+
+// RecordBlocks writes the blocks from and to with their transactions, one
+// per line as the node returned them, in the fixture format of --simulate.
+// It returns the number of blocks written.
+func (parser *EthereumParser) RecordBlocks(ctx context.Context, from, to uint64, w io.Writer) (int, error) {
+	buffered := bufio.NewWriter(w)
+	recorded := 0
+	for number := from; number <= to; number++ {
+		var block json.RawMessage
+		if err := parser.rpcEthGetBlockByNumber(ctx, fmt.Sprintf("0x%x", number), true, &block); err != nil {
+			return recorded, fmt.Errorf("failed to get block %d: %w", number, err)
+		}
+		if len(block) == 0 || string(block) == "null" {
+			return recorded, fmt.Errorf("block %d: %w", number, ErrBlockNotFound)
+		}
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, block); err != nil {
+			return recorded, err
+		}
+		compact.WriteByte('\n')
+		if _, err := buffered.Write(compact.Bytes()); err != nil {
+			return recorded, err
+		}
+		recorded++
+	}
+	return recorded, buffered.Flush()
+}
@@ -0,0 +1,256 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testBlock returns block number of the canonical test chain holding the
+// transactions.
+func testBlock(number uint64, transactions ...Transaction) *Block {
+	return forkBlock(number, "", transactions...)
+}
+
+// forkBlock returns block number of the named fork of the test chain, ""
+// being the canonical chain, holding the transactions.
+func forkBlock(number uint64, fork string, transactions ...Transaction) *Block {
+	for i := range transactions {
+		transactions[i].BlockNumber = fmt.Sprintf("0x%x", number)
+	}
+	return &Block{
+		BlockHeader: BlockHeader{
+			Number:     fmt.Sprintf("0x%x", number),
+			Hash:       testBlockHash(number, fork),
+			ParentHash: testBlockHash(number-1, fork),
+			Timestamp:  fmt.Sprintf("0x%x", 1_700_000_000+12*number),
+		},
+		Transactions: transactions,
+	}
+}
+
+func testBlockHash(number uint64, fork string) string {
+	return Keccak256Hex(fmt.Sprintf("block %v %d", fork, number))
+}
+
+// fakeNode is a FakeNode failing the test when its chain cannot be
+// programmed.
+type fakeNode struct {
+	*FakeNode
+	t testing.TB
+}
+
+// newFakeNode returns a node whose chain holds the blocks, of which there must
+// be at least one.
+func newFakeNode(t testing.TB, blocks ...*Block) *fakeNode {
+	t.Helper()
+	node, err := NewFakeNode(blocks...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &fakeNode{FakeNode: node, t: t}
+}
+
+// AddBlock extends the chain with the block, with the logs served by
+// eth_getLogs and eth_getTransactionReceipt.
+func (node *fakeNode) AddBlock(block *Block, logs ...Log) {
+	node.t.Helper()
+	if err := node.FakeNode.AddBlock(block, logs...); err != nil {
+		node.t.Fatal(err)
+	}
+}
+
+// ReplaceBlocks reorganizes the chain from the number of the first block on.
+func (node *fakeNode) ReplaceBlocks(blocks ...*Block) {
+	node.t.Helper()
+	if err := node.FakeNode.ReplaceBlocks(blocks...); err != nil {
+		node.t.Fatal(err)
+	}
+}
+
+// newTestParser returns a parser of a fakeNode serving the blocks.
+func newTestParser(t *testing.T, blocks []*Block, opts ...Option) *EthereumParser {
+	t.Helper()
+	return newFakeNode(t, blocks...).NewParser(opts...)
+}
+
+// simulationFixture returns the NDJSON fixture of the entries, blocks or
+// reorg markers.
+func simulationFixture(t *testing.T, entries ...interface{}) string {
+	t.Helper()
+	var fixture bytes.Buffer
+	encoder := json.NewEncoder(&fixture)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return fixture.String()
+}
+
+// reorgMarker is the fixture entry rolling back the last depth blocks.
+func reorgMarker(depth uint64) interface{} {
+	return map[string]uint64{"reorg": depth}
+}
+
+func TestLoadSimulation(t *testing.T) {
+	transfer := Transaction{Hash: Keccak256Hex("transfer"), From: checksummedAddress, To: otherAddress}
+	array, err := json.Marshal([]*Block{testBlock(1), testBlock(2, transfer)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps, err := loadSimulation(bytes.NewReader(array))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 2 || steps[1].block.number != 2 || !blockHolds(steps[1].block, transfer.Hash) {
+		t.Errorf("steps of a JSON array = %+v, want blocks 1 and 2 holding the transfer", steps)
+	}
+
+	fixture := simulationFixture(t, testBlock(1), testBlock(2), testBlock(3), reorgMarker(2), forkBlock(2, "b"), forkBlock(3, "b"), forkBlock(4, "b"))
+	steps, err = loadSimulation(strings.NewReader(fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reorgs []uint64
+	for _, step := range steps {
+		reorgs = append(reorgs, step.reorg)
+	}
+	if want := []uint64{0, 0, 0, 2, 0, 0}; !slices.Equal(reorgs, want) {
+		t.Errorf("reorgs of the NDJSON steps = %v, want %v", reorgs, want)
+	}
+	if steps[3].block.hash != testBlockHash(2, "b") {
+		t.Errorf("block after the reorg marker = %v, want block 2 of the fork", steps[3].block.hash)
+	}
+
+	invalid := []struct {
+		name    string
+		fixture string
+	}{
+		{"empty", ""},
+		{"reorgs only", simulationFixture(t, reorgMarker(0))},
+		{"reorg below the first block", simulationFixture(t, testBlock(1), testBlock(2), reorgMarker(3), testBlock(1))},
+		{"gap", simulationFixture(t, testBlock(1), testBlock(3))},
+		{"replacement not extending", simulationFixture(t, testBlock(1), testBlock(2), reorgMarker(1), forkBlock(3, "b"))},
+		{"no hash", `{"number":"0x1","transactions":[]}`},
+		{"invalid number", `{"number":"one","hash":"0x1","transactions":[]}`},
+		{"invalid JSON", `{"number":`},
+	}
+	for _, test := range invalid {
+		if _, err := loadSimulation(strings.NewReader(test.fixture)); err == nil {
+			t.Errorf("loadSimulation of the %v fixture succeeded", test.name)
+		}
+	}
+}
+
+// TestSimulationReorg replays a fixture whose head block is replaced by a
+// reorg marker once Watch processed it, releasing a block every second of a
+// MockClock.
+func TestSimulationReorg(t *testing.T) {
+	orphaned := Transaction{Hash: "0xa", From: checksummedAddress, To: otherAddress}
+	canonical := Transaction{Hash: "0xb", From: checksummedAddress, To: otherAddress}
+	replacement := forkBlock(3, "b", canonical)
+	replacement.ParentHash = testBlockHash(2, "")
+	fixture := simulationFixture(t, testBlock(1), testBlock(2), testBlock(3, orphaned), reorgMarker(1), replacement, forkBlock(4, "b"), forkBlock(5, "b"))
+	steps, err := loadSimulation(strings.NewReader(fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := NewMockClock(time.Unix(1_700_000_000, 0))
+	node := newSimulatedNode(steps, time.Second, 0, clock, slog.New(slog.DiscardHandler))
+	parser := NewEthereumParser("http://simulated-node", NewMemoryStorage(), WithHTTPClient(&http.Client{Transport: node}), WithLogger(slog.New(slog.DiscardHandler)), WithHealthCheckInterval(0), WithSyncCheckInterval(0), WithChain(Chain{PollInterval: testWatchInterval}))
+	if _, err := parser.SubscribeAddress(checksummedAddress); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first request starts the simulation, releasing block 1
+	if block := parser.GetCurrentBlock(); block != 1 {
+		t.Fatalf("GetCurrentBlock at the start of the simulation = %d, want 1", block)
+	}
+	clock.Advance(2 * time.Second)
+	out := startWatch(t, parser, 1)
+	if transaction := receive(t, out); transaction.Hash != orphaned.Hash {
+		t.Fatalf("received %v, want %v", transaction.Hash, orphaned.Hash)
+	}
+
+	// The split resolves 2 blocks after the replacing block
+	clock.Advance(3 * time.Second)
+	if transaction := receive(t, out); transaction.Hash != canonical.Hash {
+		t.Fatalf("received %v after the reorg, want %v", transaction.Hash, canonical.Hash)
+	}
+	if reorgs := parser.Metrics().ReorgsHandled; reorgs != 1 {
+		t.Errorf("ReorgsHandled = %d, want 1", reorgs)
+	}
+	if chainID, err := parser.ChainID(context.Background()); err != nil || chainID != simulatedChainID {
+		t.Errorf("ChainID = %v, %v, want %d", chainID, err, simulatedChainID)
+	}
+}
+
+func TestNewSimulationClient(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocks.ndjson")
+	fixture := simulationFixture(t, testBlock(7), testBlock(8), reorgMarker(1), forkBlock(8, "b"), forkBlock(9, "b"))
+	if err := os.WriteFile(path, []byte(fixture), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewSimulationClient(path, 0, 10, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser := NewEthereumParser("http://simulated-node", NewMemoryStorage(), WithHTTPClient(client), WithHealthCheckInterval(0), WithSyncCheckInterval(0))
+
+	if block := parser.GetCurrentBlock(); block != 9 {
+		t.Errorf("GetCurrentBlock with every block released = %d, want 9", block)
+	}
+	block, err := parser.getBlockByNumber(context.Background(), 8)
+	if err != nil || block.Hash != testBlockHash(8, "b") {
+		t.Errorf("block 8 = %+v, %v, want the block of the fork", block, err)
+	}
+	if chainID, err := parser.ChainID(context.Background()); err != nil || chainID != 10 {
+		t.Errorf("ChainID = %v, %v, want the configured 10", chainID, err)
+	}
+
+	if _, err := NewSimulationClient(filepath.Join(t.TempDir(), "missing.ndjson"), 0, 0, slog.New(slog.DiscardHandler)); err == nil {
+		t.Error("NewSimulationClient of a missing fixture succeeded")
+	}
+}
+
+// TestRecordBlocks records blocks of a node and replays the recording.
+func TestRecordBlocks(t *testing.T) {
+	transfer := Transaction{Hash: Keccak256Hex("transfer"), From: checksummedAddress, To: otherAddress, Value: "0x1"}
+	node := newFakeNode(t, testBlock(1), testBlock(2, transfer), testBlock(3))
+	parser := node.NewParser()
+
+	var recording bytes.Buffer
+	recorded, err := parser.RecordBlocks(context.Background(), 1, 3, &recording)
+	if err != nil || recorded != 3 {
+		t.Fatalf("RecordBlocks = %d, %v, want 3 blocks", recorded, err)
+	}
+	if lines := strings.Count(recording.String(), "\n"); lines != 3 {
+		t.Errorf("recording holds %d lines, want one per block", lines)
+	}
+	steps, err := loadSimulation(&recording)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay := NewEthereumParser("http://simulated-node", NewMemoryStorage(), WithHTTPClient(&http.Client{Transport: newSimulatedNode(steps, 0, 0, RealClock{}, slog.New(slog.DiscardHandler))}), WithHealthCheckInterval(0), WithSyncCheckInterval(0))
+	block, err := replay.getBlockByNumber(context.Background(), 2)
+	if err != nil || block.Hash != testBlockHash(2, "") || len(block.Transactions) != 1 || block.Transactions[0].Hash != transfer.Hash {
+		t.Errorf("replayed block 2 = %+v, %v, want the recorded block with the transfer", block, err)
+	}
+
+	recording.Reset()
+	recorded, err = parser.RecordBlocks(context.Background(), 2, 5, &recording)
+	if !errors.Is(err, ErrBlockNotFound) || recorded != 2 {
+		t.Errorf("RecordBlocks beyond the head = %d, %v, want 2 blocks and ErrBlockNotFound", recorded, err)
+	}
+}
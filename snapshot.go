@@ -0,0 +1,91 @@
+package parser
+
+import "maps"
+
+// subscriberSnapshot is an immutable copy of a store's subscribers, which
+// can be read without locking.
+type subscriberSnapshot struct {
+	subscribers map[string]bool // Shared between snapshots, never modified
+	prefix      string          // Prepended to addresses by views of a shared store, see chainStore
+	bloom       *bloomFilter    // Pre-check of the lookups, nil when disabled
+}
+
+// contains reports whether the address was subscribed when the snapshot was
+// taken. The bloom filter is not part of the snapshot: an address unsubscribed
+// since then may already be reported as not subscribed.
+func (snapshot subscriberSnapshot) contains(address string) bool {
+	key := snapshot.prefix + address
+	if snapshot.bloom != nil && !snapshot.bloom.mayContain(key) {
+		return false
+	}
+	subscribed, ok := snapshot.subscribers[key]
+	if snapshot.bloom != nil && !ok {
+		snapshot.bloom.falsePositive()
+	}
+	return subscribed
+}
+
+// subscriberSnapshotter is implemented by stores that can take a snapshot of
+// their subscribers without copying them every time.
+type subscriberSnapshotter interface {
+	subscriberSnapshot() (subscriberSnapshot, error)
+}
+
+// subscriberSnapshot returns the current subscribers. The copy is taken on the
+// first call after a change and shared by the calls until the next one.
+func (memory *MemoryStorage) subscriberSnapshot() (subscriberSnapshot, error) {
+	if subscribers := memory.snapshot.Load(); subscribers != nil {
+		return subscriberSnapshot{subscribers: *subscribers, bloom: memory.bloom.Load()}, nil
+	}
+
+	memory.mu.RLock()
+	defer memory.mu.RUnlock()
+	if subscribers := memory.snapshot.Load(); subscribers != nil {
+		return subscriberSnapshot{subscribers: *subscribers, bloom: memory.bloom.Load()}, nil
+	}
+	// Stored under the read lock, so that a change cannot clear it first
+	subscribers := maps.Clone(memory.subscribers)
+	memory.snapshot.Store(&subscribers)
+	return subscriberSnapshot{subscribers: subscribers, bloom: memory.bloom.Load()}, nil
+}
+
+func (store chainStore) subscriberSnapshot() (subscriberSnapshot, error) {
+	snapshot, err := takeSubscriberSnapshot(store.store)
+	snapshot.prefix += chainKey(store.chain, "")
+	return snapshot, err
+}
+
+// takeSubscriberSnapshot returns a snapshot of the store's subscribers. Stores
+// without snapshots are copied with GetSubscribers.
+func takeSubscriberSnapshot(store Store) (subscriberSnapshot, error) {
+	if snapshotter, ok := store.(subscriberSnapshotter); ok {
+		return snapshotter.subscriberSnapshot()
+	}
+	subscribers, err := store.GetSubscribers()
+	return subscriberSnapshot{subscribers: subscribers}, err
+}
+
+// emitters returns a function reporting whether Watch emits the transactions
+// of an address, like emitsFor, from snapshots of the subscribers and of the
+// pending activations. dispatch takes them once per block and matches every
+// transaction without locking, so subscriptions and activations changed while
+// a block is dispatched take effect from the next block. When the store
+// cannot be copied, it falls back to emitsFor.
+func (parser *EthereumParser) emitters() func(address string) bool {
+	subscribers, err := takeSubscriberSnapshot(parser.store)
+	if err != nil {
+		parser.logger.Warn("failed to snapshot subscribers", "error", err)
+		return parser.emitsFor
+	}
+	parser.mu.Lock()
+	inactive := make(map[string]bool, len(parser.activations))
+	for address := range parser.activations {
+		inactive[address] = true
+	}
+	parser.mu.Unlock()
+
+	return func(address string) bool {
+		address = NormalizeAddress(address)
+		return subscribers.contains(address) && !inactive[address]
+	}
+}
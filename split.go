@@ -0,0 +1,128 @@
+package parser
+
+import (
+	"context"
+	"slices"
+)
+
+const (
+	// defaultSplitResolutionTimeout is the default number of blocks Watch waits
+	// before resolving a chain split.
+	defaultSplitResolutionTimeout = 2
+	// splitEventBufferSize is the number of ChainSplitEvents buffered for ChainSplits.
+	splitEventBufferSize = 64
+	// splitHistorySize is the number of recent heights whose block hashes Watch remembers.
+	splitHistorySize = 128
+)
+
+// ChainSplitEvent reports competing blocks seen by Watch at the same height.
+type ChainSplitEvent struct {
+	BlockNumber uint64
+	BlockHashes []string // In the order they were seen, starting with the processed block
+}
+
+// ChainSplits returns the channel on which a ChainSplitEvent is sent when Watch
+// sees a second block at a height it processed. Events are dropped when the
+// channel's buffer is full.
+func (parser *EthereumParser) ChainSplits() <-chan ChainSplitEvent {
+	return parser.splitCh
+}
+
+// splitDetector remembers the hashes of the blocks seen by a Watch loop at
+// recent heights, and the heights with competing blocks left to resolve.
+type splitDetector struct {
+	hashes  map[uint64][]string
+	pending map[uint64]bool
+}
+
+func newSplitDetector() *splitDetector {
+	return &splitDetector{hashes: make(map[uint64][]string), pending: make(map[uint64]bool)}
+}
+
+// record adds a block hash seen at a height and reports whether it competes
+// with another block at that height.
+func (detector *splitDetector) record(number uint64, hash string) bool {
+	hashes := detector.hashes[number]
+	if hash == "" || slices.Contains(hashes, hash) {
+		return false
+	}
+	detector.hashes[number] = append(hashes, hash)
+	return len(hashes) > 0
+}
+
+// prune forgets the heights too far below head to be revisited.
+func (detector *splitDetector) prune(head uint64) {
+	for number := range detector.hashes {
+		if number+splitHistorySize < head && !detector.pending[number] {
+			delete(detector.hashes, number)
+		}
+	}
+}
+
+// checkSplits records a block processed by Watch and the parent it references,
+// reporting a split when either competes with a block seen before. Splits
+// that are SplitResolutionTimeout blocks old are resolved.
+func (parser *EthereumParser) checkSplits(ctx context.Context, detector *splitDetector, number uint64, block *Block, out chan<- Transaction) error {
+	if detector.record(number, block.Hash) {
+		parser.reportSplit(ctx, detector, number)
+	}
+	if _, known := detector.hashes[number-1]; known && number > 0 && detector.record(number-1, block.ParentHash) {
+		parser.reportSplit(ctx, detector, number-1)
+	}
+	detector.prune(number)
+
+	for height := range detector.pending {
+		if number < height+max(parser.SplitResolutionTimeout, 1) {
+			continue
+		}
+		if err := parser.resolveSplit(ctx, detector, height, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reportSplit marks a height as split and sends a ChainSplitEvent for it.
+func (parser *EthereumParser) reportSplit(ctx context.Context, detector *splitDetector, number uint64) {
+	detector.pending[number] = true
+	event := ChainSplitEvent{BlockNumber: number, BlockHashes: slices.Clone(detector.hashes[number])}
+	parser.logger.WarnContext(ctx, "chain split", "block", number, "hashes", event.BlockHashes)
+	select {
+	case parser.splitCh <- event:
+	default:
+	}
+}
+
+// resolveSplit settles a split height on the block referenced by the canonical
+// child's parentHash. When the block Watch processed was orphaned, its
+// transactions are replaced in the Index by the canonical block's, which are
+// dispatched again. A split that cannot be resolved is retried after the next block.
+func (parser *EthereumParser) resolveSplit(ctx context.Context, detector *splitDetector, number uint64, out chan<- Transaction) error {
+	child, err := parser.getBlockByNumber(ctx, number+1)
+	if err != nil {
+		parser.logger.ErrorContext(ctx, "failed to resolve chain split", "block", number, "error", err)
+		return nil
+	}
+	canonical := child.ParentHash
+
+	// Watch processed the first block seen at the height
+	if processed := detector.hashes[number][0]; processed != canonical {
+		block, err := parser.GetBlock(ctx, canonical)
+		if err != nil {
+			parser.logger.ErrorContext(ctx, "failed to get canonical block", "block", number, "hash", canonical, "error", err)
+			return nil
+		}
+		if addresses, err := parser.Subscribers(); err == nil {
+			parser.index.ReplaceBlock(number, addresses, block.Transactions)
+		}
+		if err := parser.dispatch(ctx, block, out); err != nil {
+			return err
+		}
+		parser.metrics.reorgsHandled.Add(1)
+	}
+
+	parser.logger.InfoContext(ctx, "chain split resolved", "block", number, "canonical", canonical)
+	delete(detector.pending, number)
+	detector.hashes[number] = []string{canonical}
+	return nil
+}
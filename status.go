@@ -0,0 +1,78 @@
+package parser
+
+import (
+	"context"
+	"time"
+)
+
+// statusMaxLag is the number of blocks Watch may fall behind the confirmed head
+// before status reports it as a problem.
+const statusMaxLag = 10
+
+// Status describes the health of the parser.
+type Status struct {
+	ChainHead          uint64        `json:"chainHead"`
+	ChainHeadError     string        `json:"chainHeadError,omitempty"`
+	Watching           bool          `json:"watching"`
+	Paused             bool          `json:"paused,omitempty"` // Whether Watch waits for the node to sync
+	LastProcessedBlock uint64        `json:"lastProcessedBlock"`
+	Lag                uint64        `json:"lag"` // Blocks between the chain head and the last processed block
+	Confirmations      uint64        `json:"confirmations"`
+	Endpoint           string        `json:"endpoint"`
+	Subscribers        int           `json:"subscribers"`
+	QueueDepth         int           `json:"queueDepth"` // Transactions waiting in SubscribeAndWatch listeners
+	Uptime             time.Duration `json:"uptime"`
+	Fees               *FeeEstimate  `json:"fees,omitempty"` // Network conditions, nil when they could not be estimated
+}
+
+// StatusReporter is implemented by parsers that can report their health.
+type StatusReporter interface {
+	Status(ctx context.Context) Status
+}
+
+// Status reports the chain head, the progress of Watch and the parser's state.
+func (parser *EthereumParser) Status(ctx context.Context) Status {
+	settings := parser.settings()
+	status := Status{
+		Endpoint:      RedactURL(settings.Endpoint),
+		Confirmations: settings.Confirmations,
+		Uptime:        parser.clock.Now().Sub(parser.started).Truncate(time.Second),
+	}
+
+	head, err := parser.blockNumber(ctx)
+	if err != nil {
+		status.ChainHeadError = err.Error()
+	}
+	status.ChainHead = head
+
+	if subscribers, err := parser.store.GetSubscribers(); err == nil {
+		for _, subscribed := range subscribers {
+			if subscribed {
+				status.Subscribers++
+			}
+		}
+	}
+
+	parser.mu.Lock()
+	status.LastProcessedBlock = parser.lastProcessed
+	for listener := range parser.listeners {
+		status.QueueDepth += len(listener.transactions)
+	}
+	parser.mu.Unlock()
+
+	if fees, err := parser.EstimateFees(ctx); err == nil {
+		status.Fees = &fees
+	}
+
+	status.Paused = parser.syncPaused.Load()
+	status.Watching = status.LastProcessedBlock != 0
+	if status.Watching && head > status.LastProcessedBlock {
+		status.Lag = head - status.LastProcessedBlock
+	}
+	return status
+}
+
+// LaggingBehind reports whether Watch has fallen further behind than its confirmations explain.
+func (status Status) LaggingBehind() bool {
+	return status.Watching && status.Lag > status.Confirmations+statusMaxLag
+}
@@ -0,0 +1,140 @@
+package parser
+
+import (
+	"errors"
+)
+
+var (
+	// ErrTransactionDone is returned when a transaction is used after Commit or Rollback.
+	ErrTransactionDone = errors.New("storage transaction already committed or rolled back")
+	// ErrTransactionConflict is returned by Commit when the storage changed after the transaction began.
+	ErrTransactionConflict = errors.New("storage changed since the transaction began")
+)
+
+// StorageTransaction stages changes to the subscribers that Commit applies
+// together and Rollback discards. It is not safe for concurrent use.
+type StorageTransaction interface {
+	SetSubscriber(address string) error
+	RemoveSubscriber(address string) error
+	IsSubscriber(address string) bool
+	Commit() error
+	Rollback() error
+}
+
+// memoryTransaction stages changes on a copy of a MemoryStorage's subscribers,
+// which Commit swaps in.
+type memoryTransaction struct {
+	memory      *MemoryStorage
+	version     uint64          // Version of the storage the copy was taken from
+	subscribers map[string]bool // Copy receiving the changes
+	done        bool
+}
+
+// BeginTransaction starts a transaction on a copy of the current subscribers.
+func (memory *MemoryStorage) BeginTransaction() StorageTransaction {
+	memory.mu.RLock()
+	defer memory.mu.RUnlock()
+
+	subscribers := make(map[string]bool, len(memory.subscribers))
+	for address, value := range memory.subscribers {
+		subscribers[address] = value
+	}
+	return &memoryTransaction{memory: memory, version: memory.version, subscribers: subscribers}
+}
+
+func (tx *memoryTransaction) SetSubscriber(address string) error {
+	if tx.done {
+		return ErrTransactionDone
+	}
+	if _, ok := tx.subscribers[address]; !ok && tx.memory.Capacity > 0 && len(tx.subscribers) >= tx.memory.Capacity {
+		return ErrStoreFull
+	}
+	tx.subscribers[address] = true
+	return nil
+}
+
+func (tx *memoryTransaction) RemoveSubscriber(address string) error {
+	if tx.done {
+		return ErrTransactionDone
+	}
+	delete(tx.subscribers, address)
+	return nil
+}
+
+func (tx *memoryTransaction) IsSubscriber(address string) bool {
+	return tx.subscribers[address]
+}
+
+// Commit replaces the storage's subscribers with the transaction's copy, unless
+// the storage changed since the transaction began.
+func (tx *memoryTransaction) Commit() error {
+	if tx.done {
+		return ErrTransactionDone
+	}
+	tx.done = true
+
+	tx.memory.mu.Lock()
+	defer tx.memory.mu.Unlock()
+	if tx.memory.version != tx.version {
+		return ErrTransactionConflict
+	}
+	if filter := tx.memory.bloom.Load(); filter != nil {
+		filter.update(tx.memory.subscribers, tx.subscribers)
+	}
+	tx.memory.version++
+	for address := range tx.subscribers {
+		if _, ok := tx.memory.subscribers[address]; !ok {
+			tx.memory.recordMutation(subscriberAdded, address)
+		}
+	}
+	for address := range tx.memory.subscribers {
+		if _, ok := tx.subscribers[address]; !ok {
+			tx.memory.recordMutation(subscriberRemoved, address)
+		}
+	}
+	tx.memory.subscribers = tx.subscribers
+	tx.memory.snapshot.Store(nil)
+	return nil
+}
+
+// Rollback discards the transaction's changes.
+func (tx *memoryTransaction) Rollback() error {
+	if tx.done {
+		return ErrTransactionDone
+	}
+	tx.done = true
+	tx.subscribers = nil
+	return nil
+}
+
+// SubscribeGroup subscribes every address or none of them: when an address is
+// rejected by ValidateSubscription, repeated in the group or cannot be stored,
+// nothing is subscribed and the error names that address.
+func (parser *EthereumParser) SubscribeGroup(addresses []string) error {
+	seen := make(map[string]bool)
+	for _, address := range addresses {
+		if err := parser.ValidateSubscription(address); err != nil {
+			return err
+		}
+		key := NormalizeAddress(address)
+		if seen[key] {
+			return &SubscriptionError{Address: address, Problems: []error{ErrAlreadySubscribed}}
+		}
+		seen[key] = true
+	}
+
+	tx := parser.store.BeginTransaction()
+	for _, address := range addresses {
+		if err := tx.SetSubscriber(NormalizeAddress(address)); err != nil {
+			tx.Rollback()
+			return &SubscriptionError{Address: address, Problems: []error{err}}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for _, address := range addresses {
+		parser.delayActivation(NormalizeAddress(address))
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// StreamTransactionsInRange fetches the blocks from fromBlock to toBlock one at a
+// time and calls out for each transaction from or to the address as soon as its
+// block is fetched, so the matches are never held in memory together. It stops
+// at the first error, including one returned by out.
+func (parser *EthereumParser) StreamTransactionsInRange(ctx context.Context, address string, fromBlock, toBlock uint64, out func(Transaction) error) error {
+	if !IsValidAddress(address) {
+		return fmt.Errorf("invalid address: %v", address)
+	}
+	if fromBlock > toBlock {
+		return fmt.Errorf("invalid block range: %d > %d", fromBlock, toBlock)
+	}
+
+	for number := fromBlock; ; number++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		block, err := parser.getBlockByNumber(ctx, number)
+		if err != nil {
+			return fmt.Errorf("failed to get block %d: %w", number, err)
+		}
+		for _, transaction := range block.Transactions {
+			if !involvesAddress(transaction, address) {
+				continue
+			}
+			if err := out(transaction); err != nil {
+				return err
+			}
+		}
+
+		// Checked here rather than in the loop condition so that toBlock = MaxUint64 terminates
+		if number == toBlock {
+			return nil
+		}
+	}
+}
+
+// WriteTransactionsJSONStream writes the transactions from or to the address in
+// the block range to w as a JSON array, encoding each one as it is found.
+func WriteTransactionsJSONStream(ctx context.Context, parser *EthereumParser, address string, fromBlock, toBlock uint64, w io.Writer) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+
+	first := true
+	err := parser.StreamTransactionsInRange(ctx, address, fromBlock, toBlock, func(transaction Transaction) error {
+		data, err := json.Marshal(transaction)
+		if err != nil {
+			return err
+		}
+		if !first {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		first = false
+		_, err = w.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "]\n")
+	return err
+}
@@ -0,0 +1,249 @@
+package parser
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+const (
+	// DefaultStuckAfter is how long a transaction may stay pending before it
+	// is reported as stuck.
+	DefaultStuckAfter = 10 * time.Minute
+	// stuckEventBufferSize is the number of StuckTransactions buffered for
+	// StuckTransactions.
+	stuckEventBufferSize = 64
+)
+
+// Reasons a pending transaction is reported as stuck.
+const (
+	stuckReasonPending  = "pending too long"
+	stuckReasonNonceGap = "nonce gap"
+)
+
+// StuckTransaction reports a pending transaction sent by a subscribed address
+// that does not get mined.
+type StuckTransaction struct {
+	Address      string    `json:"address"`
+	Hash         string    `json:"hash"`
+	Nonce        uint64    `json:"nonce"`
+	MinedNonce   uint64    `json:"minedNonce"` // Nonce of the address's next transaction to be mined
+	Reason       string    `json:"reason"`
+	PendingSince time.Time `json:"pendingSince"`
+}
+
+// pendingTransaction is a pending transaction of a subscribed address seen by
+// the pending scan.
+type pendingTransaction struct {
+	address  string
+	nonce    uint64
+	seen     time.Time
+	reported bool
+}
+
+// WithPendingScan makes Watch scan the pending block every interval for the
+// transactions of subscribed addresses, and report those pending for longer
+// than stuckAfter, or behind a nonce gap, see StuckTransactions.
+func WithPendingScan(interval, stuckAfter time.Duration) Option {
+	return func(parser *EthereumParser) {
+		parser.pendingScanInterval = interval
+		parser.stuckAfter = stuckAfter
+	}
+}
+
+// StuckTransactions returns the channel on which a StuckTransaction is sent
+// when the pending scan finds a transaction that does not get mined. Each
+// transaction is reported once, and forgotten when the nonce of its sender
+// moves past it. Events are dropped when the channel's buffer is full.
+func (parser *EthereumParser) StuckTransactions() <-chan StuckTransaction {
+	return parser.stuckCh
+}
+
+// scanPendingEvery scans the pending block every interval until ctx is
+// cancelled, except while Watch waits for the node to sync.
+func (parser *EthereumParser) scanPendingEvery(ctx context.Context, interval time.Duration) {
+	for {
+		// The pending block of a syncing node is stale, see monitorSync
+		if !parser.syncPaused.Load() {
+			scanCtx := ContextWithTraceID(ctx, NewTraceID())
+			if err := parser.scanPending(scanCtx); err != nil && ctx.Err() == nil {
+				parser.logger.WarnContext(scanCtx, "failed to scan pending transactions", "error", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-parser.clock.After(interval):
+		}
+	}
+}
+
+// scanPending tracks the transactions of subscribed addresses in the pending
+// block, refreshes the mined nonces of their senders, and reports the tracked
+// transactions that are stuck. Transactions are tracked until the nonce of
+// their sender moves past them, even once they left the pending block.
+func (parser *EthereumParser) scanPending(ctx context.Context) error {
+	var block Block
+	if err := parser.getBlock(ctx, "pending", true, &block); err != nil {
+		return fmt.Errorf("failed to get pending block: %w", err)
+	}
+	now := parser.clock.Now()
+	emits := parser.emitters()
+
+	parser.stuckMu.Lock()
+	for _, transaction := range block.Transactions {
+		if parser.chain.IsSystemTransaction(transaction) || !emits(transaction.From) {
+			continue
+		}
+		nonce, err := ParseHexUint64(transaction.Nonce)
+		if err != nil || nonce < parser.minedNonces[transaction.From] {
+			continue
+		}
+		if _, ok := parser.pendingTxs[transaction.Hash]; !ok {
+			parser.pendingTxs[transaction.Hash] = &pendingTransaction{address: transaction.From, nonce: nonce, seen: now}
+		}
+	}
+	senders := make(map[string]bool)
+	for hash, pending := range parser.pendingTxs {
+		if !emits(pending.address) {
+			delete(parser.pendingTxs, hash)
+			continue
+		}
+		senders[pending.address] = true
+	}
+	parser.stuckMu.Unlock()
+
+	for address := range senders {
+		next, err := parser.GetTransactionCount(ctx, address, "latest")
+		if err != nil {
+			return fmt.Errorf("failed to get nonce of %v: %w", address, err)
+		}
+		parser.advanceNonce(address, next)
+	}
+
+	for _, event := range parser.stuckTransactions(now) {
+		parser.logger.WarnContext(ctx, "stuck transaction", "address", event.Address, "hash", event.Hash, "nonce", event.Nonce, "minedNonce", event.MinedNonce, "reason", event.Reason)
+		select {
+		case parser.stuckCh <- event:
+		default:
+		}
+	}
+	return nil
+}
+
+// stuckTransactions marks the tracked transactions that are stuck at now as
+// reported and returns them: those after a nonce that is neither mined nor
+// pending, and those pending for longer than stuckAfter.
+func (parser *EthereumParser) stuckTransactions(now time.Time) []StuckTransaction {
+	parser.stuckMu.Lock()
+	defer parser.stuckMu.Unlock()
+
+	type senderNonce struct {
+		address string
+		nonce   uint64
+	}
+	pendingNonces := make(map[senderNonce]bool, len(parser.pendingTxs))
+	for _, pending := range parser.pendingTxs {
+		pendingNonces[senderNonce{pending.address, pending.nonce}] = true
+	}
+	var events []StuckTransaction
+	for hash, pending := range parser.pendingTxs {
+		if pending.reported {
+			continue
+		}
+		event := StuckTransaction{
+			Address:      pending.address,
+			Hash:         hash,
+			Nonce:        pending.nonce,
+			MinedNonce:   parser.minedNonces[pending.address],
+			PendingSince: pending.seen,
+		}
+		switch {
+		case pending.nonce > event.MinedNonce && !pendingNonces[senderNonce{pending.address, pending.nonce - 1}]:
+			event.Reason = stuckReasonNonceGap
+		case now.Sub(pending.seen) >= parser.stuckAfter:
+			event.Reason = stuckReasonPending
+		default:
+			continue
+		}
+		pending.reported = true
+		events = append(events, event)
+	}
+	slices.SortFunc(events, func(a, b StuckTransaction) int {
+		return cmp.Or(cmp.Compare(a.Address, b.Address), cmp.Compare(a.Nonce, b.Nonce))
+	})
+	return events
+}
+
+// recordMinedNonce advances the mined nonce of the sender of a dispatched
+// transaction past it.
+func (parser *EthereumParser) recordMinedNonce(transaction Transaction) {
+	if nonce, err := ParseHexUint64(transaction.Nonce); err == nil {
+		parser.advanceNonce(transaction.From, nonce+1)
+	}
+}
+
+// advanceNonce raises the mined nonce of an address to next, the nonce of its
+// next transaction, and forgets its pending transactions below it, which were
+// mined or replaced.
+func (parser *EthereumParser) advanceNonce(address string, next uint64) {
+	parser.stuckMu.Lock()
+	defer parser.stuckMu.Unlock()
+	if next <= parser.minedNonces[address] {
+		return
+	}
+	parser.minedNonces[address] = next
+	for hash, pending := range parser.pendingTxs {
+		if pending.address == address && pending.nonce < next {
+			delete(parser.pendingTxs, hash)
+		}
+	}
+}
+
+// DetectNonceGaps returns the nonces of the pending transactions of an
+// address that wait for an earlier one to be mined: those after the next
+// nonce to be mined, up to the last pending one. They are none when at most
+// one transaction is pending.
+func (parser *EthereumParser) DetectNonceGaps(ctx context.Context, address string) ([]uint64, error) {
+	confirmed, err := parser.GetTransactionCount(ctx, address, "latest")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get confirmed nonce: %w", err)
+	}
+	pending, err := parser.GetTransactionCount(ctx, address, "pending")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending nonce: %w", err)
+	}
+
+	var stuck []uint64
+	for nonce := confirmed + 1; nonce < pending; nonce++ {
+		stuck = append(stuck, nonce)
+	}
+	return stuck, nil
+}
+
+// WatchNonceGaps calls DetectNonceGaps every WatchInterval until ctx is
+// cancelled, and sends the nonces to gapCh when there are any. Failures are
+// logged and retried on the next interval.
+func (parser *EthereumParser) WatchNonceGaps(ctx context.Context, address string, gapCh chan<- []uint64) {
+	for {
+		checkCtx := ContextWithTraceID(ctx, NewTraceID())
+		stuck, err := parser.DetectNonceGaps(checkCtx, address)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			parser.logger.WarnContext(checkCtx, "failed to detect nonce gaps", "address", address, "error", err)
+		case len(stuck) > 0:
+			select {
+			case gapCh <- stuck:
+			case <-ctx.Done():
+				return
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-parser.clock.After(parser.settings().WatchInterval):
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pendingFrom returns a pending transaction of checksummedAddress, in the
+// lowercase form nodes return addresses in.
+func pendingFrom(nonce uint64) Transaction {
+	return Transaction{Hash: Keccak256Hex(fmt.Sprint("pending ", nonce)), From: NormalizeAddress(checksummedAddress), To: NormalizeAddress(otherAddress), Value: "0x1", Nonce: fmt.Sprintf("0x%x", nonce)}
+}
+
+// scanStuck scans the pending block and returns the StuckTransactions sent.
+func scanStuck(t *testing.T, parser *EthereumParser) []StuckTransaction {
+	t.Helper()
+	if err := parser.scanPending(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var events []StuckTransaction
+	for {
+		select {
+		case event := <-parser.StuckTransactions():
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+// TestScanPending reports a transaction pending for too long, then one behind
+// a nonce gap, each once, and forgets them once the nonce moves past them.
+func TestScanPending(t *testing.T) {
+	const stuckAfter = 10 * time.Minute
+	clock := NewMockClock(time.Unix(1_700_000_000, 0))
+	node := newFakeNode(t, testBlock(1), testBlock(2))
+	parser := node.NewParser(WithClock(clock), WithPendingScan(time.Minute, stuckAfter))
+	if _, err := parser.SubscribeAddress(checksummedAddress); err != nil {
+		t.Fatal(err)
+	}
+	address := NormalizeAddress(checksummedAddress)
+	node.SetNonce(checksummedAddress, 5)
+	start := clock.Now()
+	node.SetPending(pendingFrom(4), pendingFrom(5), Transaction{Hash: "0xother", From: NormalizeAddress(otherAddress), To: NormalizeAddress(checksummedAddress), Nonce: "0x0"})
+
+	if events := scanStuck(t, parser); len(events) != 0 {
+		t.Errorf("stuck transactions of a fresh pool = %+v, want none", events)
+	}
+	clock.Advance(stuckAfter)
+	want := StuckTransaction{Address: address, Hash: pendingFrom(5).Hash, Nonce: 5, MinedNonce: 5, Reason: stuckReasonPending, PendingSince: start}
+	if events := scanStuck(t, parser); len(events) != 1 || events[0] != want {
+		t.Errorf("stuck transactions after %v = %+v, want %+v", stuckAfter, events, want)
+	}
+	if events := scanStuck(t, parser); len(events) != 0 {
+		t.Errorf("stuck transactions scanned again = %+v, want none reported twice", events)
+	}
+
+	node.SetPending(pendingFrom(5), pendingFrom(7))
+	want = StuckTransaction{Address: address, Hash: pendingFrom(7).Hash, Nonce: 7, MinedNonce: 5, Reason: stuckReasonNonceGap, PendingSince: clock.Now()}
+	if events := scanStuck(t, parser); len(events) != 1 || events[0] != want {
+		t.Errorf("stuck transactions with nonce 6 missing = %+v, want %+v", events, want)
+	}
+
+	node.SetNonce(checksummedAddress, 8)
+	node.SetPending()
+	scanStuck(t, parser)
+	if pending := len(parser.pendingTxs); pending != 0 {
+		t.Errorf("%d transactions tracked once the nonce moved past them, want none", pending)
+	}
+	node.SetPending(pendingFrom(8))
+	scanStuck(t, parser)
+	clock.Advance(stuckAfter)
+	if events := scanStuck(t, parser); len(events) != 1 || events[0].Nonce != 8 || events[0].MinedNonce != 8 {
+		t.Errorf("stuck transactions after the nonce advanced = %+v, want the transaction of nonce 8", events)
+	}
+}
+
+// TestWatchPendingScan reports a stuck transaction while watching, and
+// forgets it once Watch dispatches it mined.
+func TestWatchPendingScan(t *testing.T) {
+	stuck := pendingFrom(3)
+	node := newFakeNode(t, testBlock(1))
+	node.SetNonce(checksummedAddress, 3)
+	node.SetPending(stuck)
+	parser := node.NewParser(WithChain(Chain{PollInterval: testWatchInterval}), WithPendingScan(testWatchInterval, testWatchInterval))
+	if _, err := parser.SubscribeAddress(checksummedAddress); err != nil {
+		t.Fatal(err)
+	}
+	out := startWatch(t, parser, 1)
+
+	select {
+	case event := <-parser.StuckTransactions():
+		if event.Hash != stuck.Hash || event.Reason != stuckReasonPending {
+			t.Errorf("StuckTransaction = %+v, want %v pending too long", event, stuck.Hash)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no StuckTransaction")
+	}
+
+	node.SetPending()
+	node.AddBlock(testBlock(2, stuck))
+	if transaction := receive(t, out); transaction.Hash != stuck.Hash {
+		t.Fatalf("received %v, want the mined %v", transaction.Hash, stuck.Hash)
+	}
+	parser.stuckMu.Lock()
+	mined, tracked := parser.minedNonces[NormalizeAddress(checksummedAddress)], len(parser.pendingTxs)
+	parser.stuckMu.Unlock()
+	if mined != 4 || tracked != 0 {
+		t.Errorf("mined nonce %d with %d transactions tracked, want 4 and none", mined, tracked)
+	}
+}
+
+func TestDetectNonceGaps(t *testing.T) {
+	tests := []struct {
+		name string
+		pool []Transaction
+		want []uint64
+	}{
+		{"nothing pending", nil, nil},
+		{"next nonce pending", []Transaction{pendingFrom(5)}, nil},
+		{"three pending", []Transaction{pendingFrom(5), pendingFrom(6), pendingFrom(7)}, []uint64{6, 7}},
+		{"other sender pending", []Transaction{{Hash: "0xother", From: NormalizeAddress(otherAddress), Nonce: "0x5"}, {Hash: "0xother2", From: NormalizeAddress(otherAddress), Nonce: "0x6"}}, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node := newFakeNode(t, testBlock(1))
+			node.SetNonce(checksummedAddress, 5)
+			node.SetPending(test.pool...)
+			parser := node.NewParser()
+
+			gaps, err := parser.DetectNonceGaps(context.Background(), checksummedAddress)
+			if err != nil || !slices.Equal(gaps, test.want) {
+				t.Errorf("DetectNonceGaps = %v, %v, want %v", gaps, err, test.want)
+			}
+		})
+	}
+}
+
+func TestDetectNonceGapsErrors(t *testing.T) {
+	node := newFakeNode(t, testBlock(1))
+	parser := node.NewParser()
+
+	node.Fail("eth_getTransactionCount", &RPCError{Code: -32000, Message: "header not found"})
+	if _, err := parser.DetectNonceGaps(context.Background(), checksummedAddress); err == nil || !strings.Contains(err.Error(), "confirmed nonce") {
+		t.Errorf("DetectNonceGaps with the latest nonce failing error = %v, want the confirmed nonce error", err)
+	}
+	node.Fail("eth_getTransactionCount", nil, &RPCError{Code: -32000, Message: "header not found"})
+	if _, err := parser.DetectNonceGaps(context.Background(), checksummedAddress); err == nil || !strings.Contains(err.Error(), "pending nonce") {
+		t.Errorf("DetectNonceGaps with the pending nonce failing error = %v, want the pending nonce error", err)
+	}
+	if _, err := parser.DetectNonceGaps(context.Background(), "0x1234"); err == nil {
+		t.Error("DetectNonceGaps of an invalid address succeeded")
+	}
+}
+
+// TestWatchNonceGaps sends the gaps of an address while its transactions
+// wait, and stops sending once they are mined.
+func TestWatchNonceGaps(t *testing.T) {
+	node := newFakeNode(t, testBlock(1))
+	node.SetNonce(checksummedAddress, 5)
+	node.SetPending(pendingFrom(5), pendingFrom(6))
+	parser := node.NewParser(WithChain(Chain{PollInterval: testWatchInterval}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	gapCh := make(chan []uint64)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		parser.WatchNonceGaps(ctx, checksummedAddress, gapCh)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	select {
+	case gaps := <-gapCh:
+		if !slices.Equal(gaps, []uint64{6}) {
+			t.Errorf("gaps = %v, want [6]", gaps)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no nonce gaps sent")
+	}
+
+	node.SetNonce(checksummedAddress, 7)
+	node.SetPending()
+	select {
+	case <-gapCh: // Detected before the node changed
+	case <-time.After(2 * testWatchInterval):
+	}
+	select {
+	case gaps := <-gapCh:
+		t.Errorf("gaps %v sent once the transactions are mined, want none", gaps)
+	case <-time.After(5 * testWatchInterval):
+	}
+}
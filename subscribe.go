@@ -0,0 +1,200 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidAddress     = errors.New("invalid address")
+	ErrChecksumMismatch   = errors.New("address fails EIP-55 checksum")
+	ErrAlreadySubscribed  = errors.New("address already subscribed")
+	ErrStoreFull          = errors.New("subscriber store is at capacity")
+	ErrAddressNotOnChain  = errors.New("address has no code or balance on chain")
+	errOnChainCheckFailed = errors.New("failed to check address on chain")
+)
+
+// SubscriptionError collects every reason an address cannot be subscribed.
+type SubscriptionError struct {
+	Address  string
+	Problems []error
+}
+
+func (err *SubscriptionError) Error() string {
+	return fmt.Sprintf("cannot subscribe %v: %v", err.Address, err.Reason())
+}
+
+// Reason joins the problems found with the address.
+func (err *SubscriptionError) Reason() string {
+	problems := make([]string, len(err.Problems))
+	for i, problem := range err.Problems {
+		problems[i] = problem.Error()
+	}
+	return strings.Join(problems, "; ")
+}
+
+func (err *SubscriptionError) Unwrap() []error {
+	return err.Problems
+}
+
+// capacityLimiter is implemented by stores that hold a limited number of subscribers.
+type capacityLimiter interface {
+	AtCapacity() bool
+}
+
+// subscriptionValidator is implemented by parsers that can explain why an address cannot be subscribed.
+type subscriptionValidator interface {
+	ValidateSubscription(address string) error
+}
+
+// ValidateSubscription checks whether an address can be subscribed without subscribing it.
+// It returns a *SubscriptionError listing every problem found.
+func (parser *EthereumParser) ValidateSubscription(address string) error {
+	return parser.validateSubscription(address, false)
+}
+
+// validateSubscription implements ValidateSubscription. An address already
+// subscribed is accepted when allowSubscribed is set.
+func (parser *EthereumParser) validateSubscription(address string, allowSubscribed bool) error {
+	// A misspelled checksum is rejected even when another spelling is subscribed
+	subscribed := parser.store.IsSubscriber(NormalizeAddress(address))
+	if allowSubscribed && subscribed && IsChecksumAddress(address) {
+		return nil
+	}
+
+	var problems []error
+	valid := IsValidAddress(address)
+	switch {
+	case !valid:
+		problems = append(problems, ErrInvalidAddress)
+	case !IsChecksumAddress(address):
+		problems = append(problems, ErrChecksumMismatch)
+	}
+
+	if subscribed {
+		problems = append(problems, ErrAlreadySubscribed)
+	} else if limiter, ok := parser.store.(capacityLimiter); ok && limiter.AtCapacity() {
+		problems = append(problems, ErrStoreFull)
+	}
+
+	if valid && parser.verifyOnChain {
+		if err := parser.checkOnChain(context.Background(), address); err != nil {
+			problems = append(problems, err)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &SubscriptionError{Address: address, Problems: problems}
+	}
+	return nil
+}
+
+// WithOnChainValidation makes ValidateSubscription reject addresses that have
+// neither code nor a balance on chain.
+func WithOnChainValidation() Option {
+	return func(parser *EthereumParser) {
+		parser.verifyOnChain = true
+	}
+}
+
+// checkOnChain reports ErrAddressNotOnChain for an address with no code and no balance.
+func (parser *EthereumParser) checkOnChain(ctx context.Context, address string) error {
+	code, err := parser.GetCode(ctx, address, "latest")
+	if err != nil {
+		return fmt.Errorf("%w: %w", errOnChainCheckFailed, err)
+	}
+	if hasCode(code) {
+		return nil
+	}
+
+	balance, err := parser.GetBalance(ctx, address, "latest")
+	if err != nil {
+		return fmt.Errorf("%w: %w", errOnChainCheckFailed, err)
+	}
+	if balance.Sign() == 0 {
+		return ErrAddressNotOnChain
+	}
+	return nil
+}
+
+// SubscribeFailureReason explains the error of a failed subscription.
+func SubscribeFailureReason(err error) string {
+	var subscriptionErr *SubscriptionError
+	if errors.As(err, &subscriptionErr) {
+		return subscriptionErr.Reason()
+	}
+	return err.Error()
+}
+
+// SubscribeFailure describes an address that could not be subscribed.
+type SubscribeFailure struct {
+	Address string `json:"address"`
+	Reason  string `json:"reason"`
+}
+
+// BulkSubscribeResult is the result of subscribing a list of addresses.
+type BulkSubscribeResult struct {
+	Subscribed        []string           `json:"subscribed"`
+	AlreadySubscribed []string           `json:"alreadySubscribed"`
+	Failed            []SubscribeFailure `json:"failed"`
+}
+
+// SubscribeAll subscribes each address, collecting the subscribed addresses and the failures.
+func SubscribeAll(parser Parser, addresses []string) *BulkSubscribeResult {
+	result := &BulkSubscribeResult{
+		Subscribed:        []string{},
+		AlreadySubscribed: []string{},
+		Failed:            []SubscribeFailure{},
+	}
+	for _, address := range addresses {
+		if !IsValidAddress(address) {
+			result.Failed = append(result.Failed, SubscribeFailure{Address: address, Reason: "invalid address"})
+			continue
+		}
+		alreadySubscribed, err := parser.SubscribeAddress(address)
+		switch {
+		case err != nil:
+			result.Failed = append(result.Failed, SubscribeFailure{Address: address, Reason: SubscribeFailureReason(err)})
+		case alreadySubscribed:
+			result.AlreadySubscribed = append(result.AlreadySubscribed, address)
+		default:
+			result.Subscribed = append(result.Subscribed, address)
+		}
+	}
+	return result
+}
+
+// SubscriberLister is implemented by parsers that can enumerate their subscribers.
+type SubscriberLister interface {
+	Subscribers() ([]string, error)
+}
+
+// SubscriberList lists the subscribed addresses matching a filter.
+type SubscriberList struct {
+	Subscribers []string `json:"subscribers"`
+	Total       int      `json:"total"`
+	Version     *uint64  `json:"version,omitempty"` // Version of the subscribers for GET /subscribers?sinceVersion=, see SubscribersDiff
+}
+
+// ListSubscribers returns the subscribed addresses containing filter, in ascending order.
+func ListSubscribers(parser Parser, filter string) (*SubscriberList, error) {
+	lister, ok := parser.(SubscriberLister)
+	if !ok {
+		return nil, errors.New("parser does not support listing subscribers")
+	}
+
+	addresses, err := lister.Subscribers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscribers: %w", err)
+	}
+
+	matched := []string{}
+	for _, address := range addresses {
+		if strings.Contains(strings.ToLower(address), strings.ToLower(filter)) {
+			matched = append(matched, address)
+		}
+	}
+	return &SubscriberList{Subscribers: matched, Total: len(matched)}, nil
+}
@@ -0,0 +1,96 @@
+package parser
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// unreachableEndpoint fails every call, for tests that need no node.
+const unreachableEndpoint = "http://127.0.0.1:1"
+
+const (
+	checksummedAddress = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+	otherAddress       = "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359"
+)
+
+func TestSubscribeAddressCase(t *testing.T) {
+	parser := NewEthereumParser(unreachableEndpoint, NewMemoryStorage())
+	if alreadySubscribed, err := parser.SubscribeAddress(checksummedAddress); alreadySubscribed || err != nil {
+		t.Fatalf("SubscribeAddress = %v, %v, want false, nil", alreadySubscribed, err)
+	}
+	if !parser.store.IsSubscriber(NormalizeAddress(checksummedAddress)) {
+		t.Errorf("store does not hold %v", NormalizeAddress(checksummedAddress))
+	}
+	for _, address := range []string{checksummedAddress, strings.ToLower(checksummedAddress), "0x" + strings.ToUpper(checksummedAddress[2:])} {
+		if alreadySubscribed, err := parser.SubscribeAddress(address); !alreadySubscribed || err != nil {
+			t.Errorf("SubscribeAddress(%v) again = %v, %v, want true, nil", address, alreadySubscribed, err)
+		}
+	}
+	if !parser.UnsubscribeAddress(strings.ToLower(checksummedAddress)) {
+		t.Fatal("UnsubscribeAddress of the lowercase address failed")
+	}
+	if parser.store.IsSubscriber(NormalizeAddress(checksummedAddress)) {
+		t.Error("UnsubscribeAddress of the lowercase address kept the subscription")
+	}
+}
+
+// TestDispatchAddressCase dispatches transactions whose addresses are spelled
+// otherwise than when they were subscribed.
+func TestDispatchAddressCase(t *testing.T) {
+	tests := []struct {
+		name       string
+		subscribed string
+		from, to   string
+	}{
+		{"checksummed subscription, lowercase sender", checksummedAddress, strings.ToLower(checksummedAddress), otherAddress},
+		{"lowercase subscription, checksummed sender", strings.ToLower(checksummedAddress), checksummedAddress, otherAddress},
+		{"checksummed subscription, lowercase recipient", checksummedAddress, otherAddress, strings.ToLower(checksummedAddress)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			parser := NewEthereumParser(unreachableEndpoint, NewMemoryStorage())
+			if _, err := parser.SubscribeAddress(test.subscribed); err != nil {
+				t.Fatal(err)
+			}
+			block := &Block{
+				BlockHeader:  BlockHeader{Number: "0x1"},
+				Transactions: []Transaction{{Hash: "0x1", From: test.from, To: test.to, Nonce: "0x0"}},
+			}
+			out := make(chan Transaction, 1)
+			if err := parser.dispatch(context.Background(), block, out); err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case transaction := <-out:
+				if transaction.Hash != "0x1" {
+					t.Errorf("dispatched %v, want 0x1", transaction.Hash)
+				}
+			default:
+				t.Error("transaction not dispatched")
+			}
+		})
+	}
+}
+
+// TestEmittersSnapshot changes the subscriptions while a block is matched,
+// which applies from the next block.
+func TestEmittersSnapshot(t *testing.T) {
+	parser := NewEthereumParser(unreachableEndpoint, NewMemoryStorage())
+	if _, err := parser.SubscribeAddress(checksummedAddress); err != nil {
+		t.Fatal(err)
+	}
+	emits := parser.emitters()
+	if _, err := parser.SubscribeAddress(otherAddress); err != nil {
+		t.Fatal(err)
+	}
+	parser.UnsubscribeAddress(checksummedAddress)
+	if !emits(checksummedAddress) || emits(otherAddress) {
+		t.Errorf("emits = %v, %v during the block, want the subscriptions it started with", emits(checksummedAddress), emits(otherAddress))
+	}
+
+	next := parser.emitters()
+	if next(checksummedAddress) || !next(strings.ToLower(otherAddress)) {
+		t.Errorf("emits = %v, %v on the next block, want the changed subscriptions", next(checksummedAddress), next(otherAddress))
+	}
+}
@@ -0,0 +1,165 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxSubscriberMutations is the number of recent subscriber changes a
+// MemoryStorage keeps for GetSubscribersDiff.
+const maxSubscriberMutations = 10000
+
+// ErrSubscriberVersionExpired is returned by GetSubscribersDiff when changes
+// since the version were dropped, and the subscribers must be listed again.
+var ErrSubscriberVersionExpired = errors.New("changes since the subscriber version are no longer kept")
+
+// subscriberMutation is a change to the subscribers, made at a version of
+// the storage.
+type subscriberMutation struct {
+	action  string // subscriberAdded or subscriberRemoved
+	address string
+	version uint64
+}
+
+const (
+	subscriberAdded   = "add"
+	subscriberRemoved = "remove"
+)
+
+// subscriberDiffer is implemented by stores that report the changes to their
+// subscribers since a version.
+type subscriberDiffer interface {
+	SubscriberVersion() uint64
+	GetSubscribersDiff(sinceVersion uint64) (added, removed []string, currentVersion uint64, err error)
+}
+
+// SubscriberVersion returns the version of the subscribers, incremented by
+// every change.
+func (memory *MemoryStorage) SubscriberVersion() uint64 {
+	memory.mu.RLock()
+	defer memory.mu.RUnlock()
+	return memory.version
+}
+
+// recordMutation keeps a change made at the current version. The caller
+// holds the write lock.
+func (memory *MemoryStorage) recordMutation(action, address string) {
+	if memory.mutations == nil {
+		memory.mutations = NewRingBuffer[subscriberMutation](maxSubscriberMutations)
+	}
+	if oldest, ok := memory.mutations.Oldest(); ok && memory.mutations.Len() == memory.mutations.Cap() {
+		memory.droppedVersion = oldest.version
+	}
+	memory.mutations.Push(subscriberMutation{action: action, address: address, version: memory.version})
+}
+
+// GetSubscribersDiff returns the addresses subscribed and unsubscribed since
+// sinceVersion, in ascending order, and the current version to pass next
+// time. An address subscribed then unsubscribed in between is in neither.
+func (memory *MemoryStorage) GetSubscribersDiff(sinceVersion uint64) (added, removed []string, currentVersion uint64, err error) {
+	memory.mu.RLock()
+	defer memory.mu.RUnlock()
+
+	if sinceVersion > memory.version {
+		return nil, nil, memory.version, fmt.Errorf("unknown subscriber version %d, the current version is %d", sinceVersion, memory.version)
+	}
+	if sinceVersion < memory.droppedVersion {
+		return nil, nil, memory.version, ErrSubscriberVersionExpired
+	}
+
+	// The first change to an address tells whether it was subscribed before,
+	// the last whether it is now
+	first := make(map[string]string)
+	last := make(map[string]string)
+	if memory.mutations != nil {
+		for _, mutation := range memory.mutations.Items() {
+			if mutation.version <= sinceVersion {
+				continue
+			}
+			if _, ok := first[mutation.address]; !ok {
+				first[mutation.address] = mutation.action
+			}
+			last[mutation.address] = mutation.action
+		}
+	}
+	added, removed = []string{}, []string{}
+	for address, action := range last {
+		switch {
+		case action == subscriberAdded && first[address] == subscriberAdded:
+			added = append(added, address)
+		case action == subscriberRemoved && first[address] == subscriberRemoved:
+			removed = append(removed, address)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed, memory.version, nil
+}
+
+// SubscriberVersion returns the version of the shared storage, which the
+// changes of every chain increment.
+func (store chainStore) SubscriberVersion() uint64 {
+	if differ, ok := store.store.(subscriberDiffer); ok {
+		return differ.SubscriberVersion()
+	}
+	return 0
+}
+
+func (store chainStore) GetSubscribersDiff(sinceVersion uint64) (added, removed []string, currentVersion uint64, err error) {
+	differ, ok := store.store.(subscriberDiffer)
+	if !ok {
+		return nil, nil, 0, errors.New("storage does not track subscriber versions")
+	}
+	added, removed, currentVersion, err = differ.GetSubscribersDiff(sinceVersion)
+	return filterChainKeys(added, store.chain), filterChainKeys(removed, store.chain), currentVersion, err
+}
+
+// filterChainKeys returns the addresses of the keys of a chain's subscriptions.
+func filterChainKeys(keys []string, chain string) []string {
+	addresses := []string{}
+	for _, key := range keys {
+		if address, ok := strings.CutPrefix(key, chainKey(chain, "")); ok {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
+
+// SubscriberDiff lists the changes to the subscribers since a version.
+type SubscriberDiff struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Version uint64   `json:"version"` // Version to pass next time
+}
+
+// SubscribersDiff returns the changes to the subscribers since sinceVersion,
+// for callers keeping a copy of them in sync.
+func (parser *EthereumParser) SubscribersDiff(sinceVersion uint64) (*SubscriberDiff, error) {
+	differ, ok := parser.store.(subscriberDiffer)
+	if !ok {
+		return nil, errors.New("storage does not track subscriber versions")
+	}
+	added, removed, version, err := differ.GetSubscribersDiff(sinceVersion)
+	if err != nil {
+		return nil, err
+	}
+	return &SubscriberDiff{Added: added, Removed: removed, Version: version}, nil
+}
+
+// SubscriberVersion returns the version of the subscribers, or 0 when the
+// storage does not track versions.
+func (parser *EthereumParser) SubscriberVersion() uint64 {
+	if differ, ok := parser.store.(subscriberDiffer); ok {
+		return differ.SubscriberVersion()
+	}
+	return 0
+}
+
+// subscriberSyncer is implemented by parsers reporting the changes to their
+// subscribers since a version.
+type subscriberSyncer interface {
+	SubscriberVersion() uint64
+	SubscribersDiff(sinceVersion uint64) (*SubscriberDiff, error)
+}
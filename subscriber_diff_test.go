@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestGetSubscribersDiff(t *testing.T) {
+	const a, b, c, d = "0xa", "0xb", "0xc", "0xd"
+	memory := NewMemoryStorage()
+	memory.SetSubscriber(a)
+	memory.SetSubscriber(b)
+	start := memory.SubscriberVersion()
+	if start != 2 {
+		t.Fatalf("SubscriberVersion after 2 subscriptions = %d, want 2", start)
+	}
+	memory.SetSubscriber(b) // Already subscribed, no change
+	memory.RemoveSubscriber(a)
+	memory.SetSubscriber(c)
+	memory.SetSubscriber(d)
+	memory.RemoveSubscriber(d)
+
+	tests := []struct {
+		since          uint64
+		added, removed []string
+	}{
+		{since: 0, added: []string{b, c}, removed: []string{}},
+		{since: start, added: []string{c}, removed: []string{a}},
+		{since: start + 1, added: []string{c}, removed: []string{}},
+		{since: memory.SubscriberVersion(), added: []string{}, removed: []string{}},
+	}
+	for _, test := range tests {
+		added, removed, version, err := memory.GetSubscribersDiff(test.since)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(added, test.added) || !slices.Equal(removed, test.removed) || version != 6 {
+			t.Errorf("GetSubscribersDiff(%d) = %v, %v, %d, want %v, %v, 6", test.since, added, removed, version, test.added, test.removed)
+		}
+	}
+
+	if _, _, _, err := memory.GetSubscribersDiff(7); err == nil || errors.Is(err, ErrSubscriberVersionExpired) {
+		t.Errorf("GetSubscribersDiff of a future version error = %v, want an unknown version", err)
+	}
+}
+
+// TestGetSubscribersDiffExpired makes more changes than the ring buffer
+// keeps: diffs since the dropped versions expire.
+func TestGetSubscribersDiffExpired(t *testing.T) {
+	memory := NewMemoryStorage()
+	for i := range maxSubscriberMutations + 2 {
+		memory.SetSubscriber(fmt.Sprintf("0x%x", i))
+	}
+
+	for _, since := range []uint64{0, 1} {
+		if _, _, _, err := memory.GetSubscribersDiff(since); !errors.Is(err, ErrSubscriberVersionExpired) {
+			t.Errorf("GetSubscribersDiff(%d) error = %v, want ErrSubscriberVersionExpired", since, err)
+		}
+	}
+	added, _, version, err := memory.GetSubscribersDiff(2)
+	if err != nil || len(added) != maxSubscriberMutations || version != maxSubscriberMutations+2 {
+		t.Errorf("GetSubscribersDiff(2) = %d added, version %d, %v, want the %d kept changes", len(added), version, err, maxSubscriberMutations)
+	}
+}
+
+// TestGetSubscribersDiffTransaction commits changes of a storage transaction
+// as a single version.
+func TestGetSubscribersDiffTransaction(t *testing.T) {
+	memory := NewMemoryStorage()
+	memory.SetSubscriber("0xa")
+	tx := memory.BeginTransaction()
+	tx.RemoveSubscriber("0xa")
+	tx.SetSubscriber("0xb")
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	added, removed, version, err := memory.GetSubscribersDiff(1)
+	if err != nil || !slices.Equal(added, []string{"0xb"}) || !slices.Equal(removed, []string{"0xa"}) || version != 2 {
+		t.Errorf("GetSubscribersDiff(1) = %v, %v, %d, %v, want 0xb added and 0xa removed at version 2", added, removed, version, err)
+	}
+}
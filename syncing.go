@@ -0,0 +1,152 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DefaultSyncCheckInterval is how often Watch checks whether the node syncs.
+const DefaultSyncCheckInterval = time.Minute
+
+// SyncStatus is the sync state of the node, as reported by eth_syncing.
+type SyncStatus struct {
+	IsSyncing     bool   `json:"isSyncing"`
+	StartingBlock uint64 `json:"startingBlock,omitempty"`
+	CurrentBlock  uint64 `json:"currentBlock,omitempty"`
+	HighestBlock  uint64 `json:"highestBlock,omitempty"`
+}
+
+// GetSyncStatus reports whether the node is syncing and how far it got.
+func (parser *EthereumParser) GetSyncStatus(ctx context.Context) (SyncStatus, error) {
+	var result json.RawMessage
+	if err := parser.rpcEthSyncing(ctx, &result); err != nil {
+		return SyncStatus{}, err
+	}
+	var syncing bool
+	if json.Unmarshal(result, &syncing) == nil {
+		return SyncStatus{IsSyncing: syncing}, nil
+	}
+
+	var progress struct {
+		StartingBlock string `json:"startingBlock"`
+		CurrentBlock  string `json:"currentBlock"`
+		HighestBlock  string `json:"highestBlock"`
+	}
+	if err := json.Unmarshal(result, &progress); err != nil {
+		return SyncStatus{}, fmt.Errorf("invalid sync status: %w", err)
+	}
+	status := SyncStatus{IsSyncing: true}
+	var err error
+	if status.StartingBlock, err = parseHexQuantity(progress.StartingBlock); err != nil {
+		return SyncStatus{}, fmt.Errorf("invalid starting block: %w", err)
+	}
+	if status.CurrentBlock, err = parseHexQuantity(progress.CurrentBlock); err != nil {
+		return SyncStatus{}, fmt.Errorf("invalid current block: %w", err)
+	}
+	if status.HighestBlock, err = parseHexQuantity(progress.HighestBlock); err != nil {
+		return SyncStatus{}, fmt.Errorf("invalid highest block: %w", err)
+	}
+	return status, nil
+}
+
+// WatchStatus is a change in the state of Watch: a WatchPausedEvent or a
+// WatchResumedEvent.
+type WatchStatus interface {
+	watchStatus()
+}
+
+// WatchPausedEvent is sent when Watch stops fetching blocks because the node
+// started syncing, and would serve stale blocks.
+type WatchPausedEvent struct {
+	Time time.Time
+	Sync SyncStatus
+}
+
+// WatchResumedEvent is sent when Watch fetches blocks again after the node
+// synced. The blocks between LastProcessedBlock and CurrentBlock are then
+// processed as usual; callers may backfill them otherwise.
+type WatchResumedEvent struct {
+	Time               time.Time
+	LastProcessedBlock uint64
+	CurrentBlock       uint64
+	Paused             time.Duration
+}
+
+func (WatchPausedEvent) watchStatus()  {}
+func (WatchResumedEvent) watchStatus() {}
+
+// WithWatchStatus sets the channel the pauses and resumptions of Watch are
+// sent to.
+func WithWatchStatus(statusCh chan<- WatchStatus) Option {
+	return func(parser *EthereumParser) {
+		parser.statusCh = statusCh
+	}
+}
+
+// WithSyncCheckInterval sets the interval Watch checks whether the node syncs
+// at. 0 never checks.
+func WithSyncCheckInterval(interval time.Duration) Option {
+	return func(parser *EthereumParser) {
+		parser.SyncCheckInterval = interval
+	}
+}
+
+// monitorSync checks whether the node syncs every SyncCheckInterval, pausing
+// the Watch loops while it does. Syncing is checked every WatchInterval while
+// paused, so that Watch resumes promptly. It returns when ctx is cancelled or
+// the node does not report its sync state.
+func (parser *EthereumParser) monitorSync(ctx context.Context, interval time.Duration) {
+	var pausedAt time.Time
+	for {
+		wait := interval
+		checkCtx := ContextWithTraceID(ctx, NewTraceID())
+		status, err := parser.GetSyncStatus(checkCtx)
+		switch {
+		case ctx.Err() != nil:
+			return
+		case isMethodNotSupported(err):
+			parser.logger.DebugContext(checkCtx, "node does not report its sync state, not checking it", "error", err)
+			return
+		case err != nil:
+			parser.logger.WarnContext(checkCtx, "failed to get sync status", "error", err)
+		case status.IsSyncing && pausedAt.IsZero():
+			pausedAt = parser.clock.Now()
+			parser.syncPaused.Store(true)
+			parser.logger.WarnContext(checkCtx, "node is syncing, pausing watch", "current", status.CurrentBlock, "highest", status.HighestBlock)
+			parser.sendWatchStatus(ctx, WatchPausedEvent{Time: pausedAt, Sync: status})
+		case !status.IsSyncing && !pausedAt.IsZero():
+			now := parser.clock.Now()
+			event := WatchResumedEvent{Time: now, Paused: now.Sub(pausedAt)}
+			event.CurrentBlock, _ = parser.blockNumber(checkCtx)
+			parser.mu.Lock()
+			event.LastProcessedBlock = parser.lastProcessed
+			parser.mu.Unlock()
+			pausedAt = time.Time{}
+			parser.syncPaused.Store(false)
+			parser.logger.InfoContext(checkCtx, "node synced, resuming watch", "lastProcessed", event.LastProcessedBlock, "current", event.CurrentBlock, "paused", event.Paused)
+			parser.sendWatchStatus(ctx, event)
+		}
+		if !pausedAt.IsZero() {
+			wait = min(interval, parser.settings().WatchInterval)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-parser.clock.After(wait):
+		}
+	}
+}
+
+// sendWatchStatus sends an event to the channel set with WithWatchStatus, if any.
+func (parser *EthereumParser) sendWatchStatus(ctx context.Context, status WatchStatus) {
+	if parser.statusCh == nil {
+		return
+	}
+	select {
+	case parser.statusCh <- status:
+	case <-ctx.Done():
+	}
+}
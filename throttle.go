@@ -0,0 +1,117 @@
+package parser
+
+import "time"
+
+const (
+	// throttleWindow is the window over which ThrottlePolicy.MaxPerMinute is counted.
+	throttleWindow = time.Minute
+	// throttleEventBufferSize is the number of ThrottledEvents buffered for Throttled.
+	throttleEventBufferSize = 64
+)
+
+// ThrottlePolicy limits how many transactions Watch emits for an address.
+type ThrottlePolicy struct {
+	MaxPerMinute     int           // Transactions emitted per minute before the address is throttled
+	CooldownDuration time.Duration // Time transactions are suppressed for once the limit is reached
+}
+
+// ThrottledEvent reports the transactions suppressed for an address during a cooldown.
+type ThrottledEvent struct {
+	Address    string
+	Suppressed int
+}
+
+// addressThrottle tracks the emissions of a throttled address.
+type addressThrottle struct {
+	policy        ThrottlePolicy
+	emitted       []time.Time // Ring buffer of the last MaxPerMinute emission times
+	next          int
+	count         int
+	cooldownUntil time.Time
+	suppressed    int
+}
+
+// WithAddressThrottle throttles the transactions Watch emits for an address.
+func WithAddressThrottle(address string, policy ThrottlePolicy) Option {
+	return func(parser *EthereumParser) {
+		if policy.MaxPerMinute <= 0 {
+			return
+		}
+		parser.throttles[NormalizeAddress(address)] = &addressThrottle{
+			policy:  policy,
+			emitted: make([]time.Time, policy.MaxPerMinute),
+		}
+	}
+}
+
+// Throttled returns the channel on which a ThrottledEvent is sent when a
+// throttled address resumes after its cooldown. Events are dropped when the
+// channel's buffer is full.
+func (parser *EthereumParser) Throttled() <-chan ThrottledEvent {
+	return parser.throttleCh
+}
+
+// throttle reports whether the transaction must be suppressed because an
+// address it involves is throttled, and records its emission otherwise.
+func (parser *EthereumParser) throttle(transaction Transaction, now time.Time) bool {
+	parser.mu.Lock()
+	defer parser.mu.Unlock()
+
+	var throttles []*addressThrottle
+	for _, address := range []string{NormalizeAddress(transaction.From), NormalizeAddress(transaction.To)} {
+		throttle, ok := parser.throttles[address]
+		if !ok || !parser.store.IsSubscriber(address) {
+			continue
+		}
+		if now.Before(throttle.cooldownUntil) || throttle.full(now) {
+			if throttle.suppressed == 0 {
+				throttle.cooldownUntil = now.Add(throttle.policy.CooldownDuration)
+			}
+			throttle.suppressed++
+			return true
+		}
+		throttles = append(throttles, throttle)
+	}
+
+	for _, throttle := range throttles {
+		throttle.record(now)
+	}
+	return false
+}
+
+// releaseThrottles ends the cooldowns that have expired, reporting the number
+// of transactions each suppressed on the throttle channel.
+func (parser *EthereumParser) releaseThrottles(now time.Time) {
+	parser.mu.Lock()
+	defer parser.mu.Unlock()
+
+	for address, throttle := range parser.throttles {
+		if throttle.suppressed == 0 || now.Before(throttle.cooldownUntil) {
+			continue
+		}
+		select {
+		case parser.throttleCh <- ThrottledEvent{Address: address, Suppressed: throttle.suppressed}:
+		default:
+		}
+		throttle.suppressed = 0
+		throttle.count = 0
+	}
+}
+
+// full reports whether MaxPerMinute transactions were emitted within the last minute.
+func (throttle *addressThrottle) full(now time.Time) bool {
+	if throttle.count < len(throttle.emitted) {
+		return false
+	}
+	// The next slot holds the oldest emission once the ring is full
+	return now.Sub(throttle.emitted[throttle.next]) < throttleWindow
+}
+
+// record adds an emission to the ring buffer, overwriting the oldest one.
+func (throttle *addressThrottle) record(now time.Time) {
+	throttle.emitted[throttle.next] = now
+	throttle.next = (throttle.next + 1) % len(throttle.emitted)
+	if throttle.count < len(throttle.emitted) {
+		throttle.count++
+	}
+}
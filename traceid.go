@@ -0,0 +1,187 @@
+package parser
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// traceIDHeader is the HTTP header carrying the trace ID of an API request,
+// set on every response and accepted from the client.
+const traceIDHeader = "X-Trace-Id"
+
+// maxTraceIDLength bounds the trace IDs accepted from clients.
+const maxTraceIDLength = 64
+
+// traceIDKey is the context key of the trace ID.
+type traceIDKey struct{}
+
+// NewTraceID returns a random trace ID of 16 hex digits.
+func NewTraceID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// ContextWithTraceID returns a context carrying a trace ID, which the log
+// lines, RPC hooks and API responses of the work done with it include.
+func ContextWithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey{}, traceID)
+}
+
+// TraceID returns the trace ID carried by ctx, or "" when there is none.
+func TraceID(ctx context.Context) string {
+	traceID, _ := ctx.Value(traceIDKey{}).(string)
+	return traceID
+}
+
+// ensureTraceID returns ctx when it carries a trace ID, and otherwise a
+// context carrying a new one.
+func ensureTraceID(ctx context.Context) context.Context {
+	if TraceID(ctx) != "" {
+		return ctx
+	}
+	return ContextWithTraceID(ctx, NewTraceID())
+}
+
+// traceIDHandler adds the trace ID of the context to the records it handles.
+type traceIDHandler struct {
+	slog.Handler
+}
+
+// withTraceIDs returns a logger adding trace IDs to its records, unless it
+// already does.
+func withTraceIDs(logger *slog.Logger) *slog.Logger {
+	if _, ok := logger.Handler().(traceIDHandler); ok {
+		return logger
+	}
+	return slog.New(traceIDHandler{logger.Handler()})
+}
+
+func (handler traceIDHandler) Handle(ctx context.Context, record slog.Record) error {
+	if traceID := TraceID(ctx); traceID != "" {
+		record.AddAttrs(slog.String("trace_id", traceID))
+	}
+	return handler.Handler.Handle(ctx, record)
+}
+
+func (handler traceIDHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return traceIDHandler{handler.Handler.WithAttrs(attrs)}
+}
+
+func (handler traceIDHandler) WithGroup(name string) slog.Handler {
+	return traceIDHandler{handler.Handler.WithGroup(name)}
+}
+
+// RPCRequestInfo describes an attempt of a JSON-RPC call, before it is sent.
+type RPCRequestInfo struct {
+	TraceID string
+	Method  string
+	Attempt int // Starting at 1
+}
+
+// RPCResponseInfo describes the outcome of an attempt of a JSON-RPC call.
+type RPCResponseInfo struct {
+	TraceID  string
+	Method   string
+	Attempt  int
+	Duration time.Duration // Of the attempt
+	Err      error         // Nil when the call succeeded
+}
+
+// RPCHooks are called around every attempt of the parser's JSON-RPC calls,
+// such as to log them elsewhere. Either may be nil. They run on the calling
+// goroutine and must return quickly.
+type RPCHooks struct {
+	OnRequest  func(ctx context.Context, request RPCRequestInfo)
+	OnResponse func(ctx context.Context, response RPCResponseInfo)
+}
+
+// WithRPCHooks sets the hooks called around every attempt of a JSON-RPC call.
+func WithRPCHooks(hooks RPCHooks) Option {
+	return func(parser *EthereumParser) {
+		parser.rpcHooks = hooks
+	}
+}
+
+// traceIDs wraps an HTTP handler so that each request carries a trace ID:
+// the client's, when its X-Trace-Id header holds a usable one, or a new one.
+// It is returned in the X-Trace-Id header of the response.
+func traceIDs(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceID := r.Header.Get(traceIDHeader)
+		if !isValidTraceID(traceID) {
+			traceID = NewTraceID()
+		}
+		w.Header().Set(traceIDHeader, traceID)
+		handler.ServeHTTP(w, r.WithContext(ContextWithTraceID(r.Context(), traceID)))
+	})
+}
+
+// isValidTraceID reports whether a client's trace ID can be logged as is:
+// letters, digits, - and _ only.
+func isValidTraceID(traceID string) bool {
+	if traceID == "" || len(traceID) > maxTraceIDLength {
+		return false
+	}
+	for _, char := range traceID {
+		if !(char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z' || char >= '0' && char <= '9' || char == '-' || char == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// contextParser is implemented by parsers with variants of the Parser
+// methods making their RPC calls with a context.
+type contextParser interface {
+	GetCurrentBlockContext(ctx context.Context) uint64
+	GetTransactionsContext(ctx context.Context, address string) []Transaction
+}
+
+// CurrentBlockOf returns the current block of the parser, with ctx when it
+// takes one.
+func CurrentBlockOf(ctx context.Context, parser Parser) uint64 {
+	if parser, ok := parser.(contextParser); ok {
+		return parser.GetCurrentBlockContext(ctx)
+	}
+	return parser.GetCurrentBlock()
+}
+
+// transactionFetcher is implemented by parsers that report why the
+// transactions of an address could not be read, see FetchTransactionsOf.
+type transactionFetcher interface {
+	FetchTransactions(ctx context.Context, address string) ([]Transaction, error)
+}
+
+// FetchTransactionsOf behaves like TransactionsOf, but returns the error of
+// the parsers that report it.
+func FetchTransactionsOf(ctx context.Context, parser Parser, address string) ([]Transaction, error) {
+	fetcher, ok := parser.(transactionFetcher)
+	if !ok {
+		return TransactionsOf(ctx, parser, address), nil
+	}
+	transactions, err := fetcher.FetchTransactions(ctx, address)
+	if book, ok := parser.(transactionLabeler); ok {
+		book.labelTransactions(transactions)
+	}
+	return transactions, err
+}
+
+// TransactionsOf returns the transactions of an address, with ctx when the
+// parser takes one.
+func TransactionsOf(ctx context.Context, parser Parser, address string) []Transaction {
+	var transactions []Transaction
+	if contextParser, ok := parser.(contextParser); ok {
+		transactions = contextParser.GetTransactionsContext(ctx, address)
+	} else {
+		transactions = parser.GetTransactions(address)
+	}
+	if book, ok := parser.(transactionLabeler); ok {
+		book.labelTransactions(transactions)
+	}
+	return transactions
+}
@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+)
+
+// Tracer starts the spans that time RPC calls, block processing and API
+// requests. It mirrors the part of the OpenTelemetry tracing API the parser
+// uses, so that an OpenTelemetry tracer can be plugged in with an adapter.
+type Tracer interface {
+	// Start starts a span as a child of the span in ctx, if any, and returns a
+	// context holding the new span.
+	Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, Span)
+}
+
+// Span is an operation timed by a Tracer.
+type Span interface {
+	SetAttributes(attrs ...slog.Attr)
+	RecordError(err error) // Does nothing when err is nil
+	End()
+}
+
+// noopTracer is the Tracer used when tracing is disabled.
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(attrs ...slog.Attr) {}
+func (noopSpan) RecordError(err error)            {}
+func (noopSpan) End()                             {}
+
+// WithTracer sets the tracer timing the parser's RPC calls and block
+// processing. Tracing is disabled by default.
+func WithTracer(tracer Tracer) Option {
+	return func(parser *EthereumParser) {
+		parser.tracer = tracer
+	}
+}
+
+// Tracer returns the tracer of the parser.
+func (parser *EthereumParser) Tracer() Tracer {
+	return parser.tracer
+}
+
+// tracerOf returns the tracer of parsers that have one, and otherwise a no-op tracer.
+func tracerOf(parser Parser) Tracer {
+	if traced, ok := parser.(interface{ Tracer() Tracer }); ok && traced.Tracer() != nil {
+		return traced.Tracer()
+	}
+	return noopTracer{}
+}
+
+// traceRequests wraps an HTTP handler so that each request runs in a span.
+func traceRequests(tracer Tracer, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), r.Method+" "+r.URL.Path,
+			slog.String("http.method", r.Method), slog.String("http.path", r.URL.Path))
+		defer span.End()
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,170 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// spanRecorder is an in-memory Tracer keeping the spans it started.
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+type recordedSpan struct {
+	recorder *spanRecorder
+	name     string
+	parent   *recordedSpan
+	attrs    map[string]slog.Value
+	err      error
+	ended    bool
+}
+
+type recordedSpanKey struct{}
+
+func (recorder *spanRecorder) Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, Span) {
+	parent, _ := ctx.Value(recordedSpanKey{}).(*recordedSpan)
+	span := &recordedSpan{recorder: recorder, name: name, parent: parent, attrs: make(map[string]slog.Value)}
+	span.SetAttributes(attrs...)
+	recorder.mu.Lock()
+	recorder.spans = append(recorder.spans, span)
+	recorder.mu.Unlock()
+	return context.WithValue(ctx, recordedSpanKey{}, span), span
+}
+
+func (span *recordedSpan) SetAttributes(attrs ...slog.Attr) {
+	span.recorder.mu.Lock()
+	defer span.recorder.mu.Unlock()
+	for _, attr := range attrs {
+		span.attrs[attr.Key] = attr.Value
+	}
+}
+
+func (span *recordedSpan) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	span.recorder.mu.Lock()
+	defer span.recorder.mu.Unlock()
+	span.err = err
+}
+
+func (span *recordedSpan) End() {
+	span.recorder.mu.Lock()
+	defer span.recorder.mu.Unlock()
+	span.ended = true
+}
+
+// tree renders the spans as an indented tree, children in start order.
+func (recorder *spanRecorder) tree() string {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	var lines []string
+	var render func(parent *recordedSpan, depth int)
+	render = func(parent *recordedSpan, depth int) {
+		for _, span := range recorder.spans {
+			if span.parent == parent {
+				lines = append(lines, strings.Repeat("  ", depth)+span.name)
+				render(span, depth+1)
+			}
+		}
+	}
+	render(nil, 0)
+	return strings.Join(lines, "\n")
+}
+
+// find returns the first span with the name.
+func (recorder *spanRecorder) find(name string) *recordedSpan {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	for _, span := range recorder.spans {
+		if span.name == name {
+			return span
+		}
+	}
+	return nil
+}
+
+// TestProcessBlockSpans processes one block and checks the span hierarchy.
+func TestProcessBlockSpans(t *testing.T) {
+	recorder := &spanRecorder{}
+	transfer := Transaction{Hash: "0x1", From: checksummedAddress, To: otherAddress, Value: "0x1", Nonce: "0x0"}
+	parser := newTestParser(t, []*Block{testBlock(1), testBlock(2, transfer)}, WithTracer(recorder))
+	if _, err := parser.SubscribeAddress(checksummedAddress); err != nil {
+		t.Fatal(err)
+	}
+
+	out := make(chan Transaction, 1)
+	if processed, err := parser.processBlock(context.Background(), 2, newSplitDetector(), out, nil); !processed || err != nil {
+		t.Fatalf("processBlock = %v, %v", processed, err)
+	}
+	want := strings.Join([]string{
+		"process block",
+		"  fetch block",
+		"    rpc eth_getBlockByNumber",
+		"  dispatch transactions",
+		"  index block",
+	}, "\n")
+	if got := recorder.tree(); !strings.HasPrefix(got, want) {
+		t.Errorf("spans:\n%v\nwant them to start with:\n%v", got, want)
+	}
+
+	block := recorder.find("process block")
+	if number := block.attrs["block.number"]; number.Uint64() != 2 {
+		t.Errorf("block.number = %v, want 2", number)
+	}
+	if transactions := block.attrs["block.transactions"]; transactions.Int64() != 1 {
+		t.Errorf("block.transactions = %v, want 1", transactions)
+	}
+	rpc := recorder.find("rpc eth_getBlockByNumber")
+	if method, endpoint := rpc.attrs["rpc.method"].String(), rpc.attrs["rpc.endpoint"].String(); method != "eth_getBlockByNumber" || endpoint != "http://fake-node" {
+		t.Errorf("rpc span attributes = %v, %v, want eth_getBlockByNumber, http://fake-node", method, endpoint)
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	for _, span := range recorder.spans {
+		if !span.ended {
+			t.Errorf("span %v not ended", span.name)
+		}
+	}
+}
+
+// TestRPCSpanError records the error of a failed call on its span.
+func TestRPCSpanError(t *testing.T) {
+	recorder := &spanRecorder{}
+	node := newFakeNode(t, testBlock(1))
+	node.Fail("eth_blockNumber", &RPCError{Code: -32000, Message: "header not found"})
+	parser := node.NewParser(WithTracer(recorder))
+	if _, err := parser.blockNumber(context.Background()); err == nil {
+		t.Fatal("blockNumber succeeded")
+	}
+	if span := recorder.find("rpc eth_blockNumber"); span == nil || span.err == nil {
+		t.Errorf("rpc span = %+v, want the error recorded", span)
+	}
+}
+
+// TestTraceRequests runs API requests in spans that the parser's spans are
+// children of.
+func TestTraceRequests(t *testing.T) {
+	recorder := &spanRecorder{}
+	parser := newTestParser(t, []*Block{testBlock(1)}, WithTracer(recorder))
+	handler := traceRequests(recorder, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		number, err := parser.blockNumber(r.Context())
+		fmt.Fprint(w, number, err)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/block", nil))
+
+	want := "GET /block\n  rpc eth_blockNumber"
+	if got := recorder.tree(); got != want {
+		t.Errorf("spans:\n%v\nwant:\n%v", got, want)
+	}
+	if path := recorder.find("GET /block").attrs["http.path"].String(); path != "/block" {
+		t.Errorf("http.path = %v, want /block", path)
+	}
+}
@@ -0,0 +1,176 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// ErrTransactionNotFound is returned when the node does not know a transaction hash.
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+// transactionTypes names the EIP-2718 transaction types.
+var transactionTypes = map[uint64]string{
+	0: "legacy",
+	1: "access list",
+	2: "dynamic fee",
+	3: "blob",
+	4: "set code",
+
+	// Types of the rollup stacks
+	0x64:                   "arbitrum deposit",
+	0x65:                   "arbitrum unsigned",
+	0x66:                   "arbitrum contract",
+	0x68:                   "arbitrum retry",
+	0x69:                   "arbitrum submit retryable",
+	arbitrumInternalTxType: "arbitrum internal",
+	opDepositTxType:        "deposit",
+}
+
+// knownMethods maps 4-byte selectors to the signatures of common contract methods.
+var knownMethods = methodSelectors(
+	"transfer(address,uint256)",
+	"transferFrom(address,address,uint256)",
+	"approve(address,uint256)",
+	"deposit()",
+	"withdraw(uint256)",
+	"safeTransferFrom(address,address,uint256)",
+	"setApprovalForAll(address,bool)",
+	"multicall(bytes[])",
+)
+
+// TransactionDetails is a transaction with all the fields returned by eth_getTransactionByHash.
+type TransactionDetails struct {
+	Transaction
+	BlockHash            string `json:"blockHash"`
+	TransactionIndex     string `json:"transactionIndex"`
+	Type                 string `json:"type"`
+	Nonce                string `json:"nonce"`
+	Gas                  string `json:"gas"`
+	GasPrice             string `json:"gasPrice"`
+	MaxFeePerGas         string `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas string `json:"maxPriorityFeePerGas"`
+}
+
+// TransactionReceipt represents the receipt of a mined transaction.
+type TransactionReceipt struct {
+	TransactionHash   string `json:"transactionHash"`
+	Status            string `json:"status"`
+	GasUsed           string `json:"gasUsed"`
+	EffectiveGasPrice string `json:"effectiveGasPrice"`
+	ContractAddress   string `json:"contractAddress"`
+	Logs              []Log  `json:"logs"`
+
+	L1Fee        string `json:"l1Fee,omitempty"`        // Fee of the L1 data, on OP stack chains
+	GasUsedForL1 string `json:"gasUsedForL1,omitempty"` // Part of the gas used paying for L1 data, on Arbitrum
+}
+
+// TransactionGetter is implemented by parsers that can look up transactions by hash.
+type TransactionGetter interface {
+	GetTransactionByHash(ctx context.Context, hash string) (*TransactionDetails, error)
+	GetTransactionReceipt(ctx context.Context, hash string) (*TransactionReceipt, error)
+}
+
+// GetTransactionByHash returns the transaction with the given hash.
+func (parser *EthereumParser) GetTransactionByHash(ctx context.Context, hash string) (*TransactionDetails, error) {
+	if !IsValidHash(hash) {
+		return nil, fmt.Errorf("invalid transaction hash: %v", hash)
+	}
+
+	transaction, err := parser.rpcEthGetTransactionByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if transaction.Hash == "" {
+		return nil, ErrTransactionNotFound
+	}
+
+	return &transaction, nil
+}
+
+// GetTransactionReceipt returns the receipt of the transaction with the given hash.
+func (parser *EthereumParser) GetTransactionReceipt(ctx context.Context, hash string) (*TransactionReceipt, error) {
+	if !IsValidHash(hash) {
+		return nil, fmt.Errorf("invalid transaction hash: %v", hash)
+	}
+
+	receipt, err := parser.rpcEthGetTransactionReceipt(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if receipt.TransactionHash == "" {
+		return nil, ErrTransactionNotFound
+	}
+
+	return &receipt, nil
+}
+
+// TransactionTypeName names an EIP-2718 transaction type, or returns
+// "unknown".
+func TransactionTypeName(txType uint64) string {
+	if name, ok := transactionTypes[txType]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// methodSelectors builds a map from selector to signature.
+func methodSelectors(signatures ...string) map[string]string {
+	selectors := make(map[string]string, len(signatures))
+	for _, signature := range signatures {
+		selectors[Keccak256Hex(signature)[:10]] = signature
+	}
+	return selectors
+}
+
+// DecodeMethodCall describes transaction input as a known method with its
+// static arguments decoded, falling back to the bare selector.
+func DecodeMethodCall(input string) string {
+	if len(input) < 10 {
+		return ""
+	}
+	selector := strings.ToLower(input[:10])
+	signature, ok := knownMethods[selector]
+	if !ok {
+		return selector
+	}
+
+	data, err := decodeHexData(input[10:])
+	if err != nil {
+		return selector + " " + signature
+	}
+
+	name, params, _ := strings.Cut(strings.TrimSuffix(signature, ")"), "(")
+	var args []string
+	for i, param := range strings.Split(params, ",") {
+		if param == "" {
+			break
+		}
+		word, err := abiWord(data, i)
+		if err != nil {
+			return selector + " " + signature
+		}
+		switch {
+		case param == "address":
+			args = append(args, abiAddress(word))
+		case param == "bool":
+			args = append(args, fmt.Sprint(abiUint(word).Sign() != 0))
+		case strings.HasPrefix(param, "uint"):
+			args = append(args, abiUint(word).String())
+		default:
+			args = append(args, param)
+		}
+	}
+	return fmt.Sprintf("%v %v(%v)", selector, name, strings.Join(args, ", "))
+}
+
+// parseHexBig parses an optional 0x-prefixed hex quantity, treating an empty
+// string or "0x" as zero.
+func parseHexBig(hexStr string) (*big.Int, error) {
+	if hexStr == "" || hexStr == "0x" {
+		return new(big.Int), nil
+	}
+	return ParseHexBig(hexStr)
+}
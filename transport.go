@@ -0,0 +1,46 @@
+package parser
+
+import "net/http"
+
+// DefaultUserAgent is the User-Agent sent to Ethereum nodes unless WithUserAgent overrides it.
+const DefaultUserAgent = "go-parser/1.0 (+https://github.com/GeorgeIwu/go-parser)"
+
+// userAgentTransport sets the User-Agent header on every request it sends.
+type userAgentTransport struct {
+	inner http.RoundTripper
+	ua    string
+}
+
+func (transport *userAgentTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	// A RoundTripper must not modify the request it is given
+	request = request.Clone(request.Context())
+	request.Header.Set("User-Agent", transport.ua)
+	return transport.inner.RoundTrip(request)
+}
+
+// WithUserAgent sets the User-Agent header sent with every request to the node.
+func WithUserAgent(ua string) Option {
+	return func(parser *EthereumParser) {
+		parser.userAgent = ua
+	}
+}
+
+// WithHTTPClient sends requests to the node through client. Its transport is
+// wrapped so that the configured User-Agent is still sent.
+func WithHTTPClient(client *http.Client) Option {
+	return func(parser *EthereumParser) {
+		parser.client = client
+	}
+}
+
+// newRPCClient returns a copy of client whose transport sets the User-Agent header.
+func newRPCClient(client *http.Client, ua string) *http.Client {
+	inner := client.Transport
+	if inner == nil {
+		inner = http.DefaultTransport
+	}
+
+	wrapped := *client
+	wrapped.Transport = &userAgentTransport{inner: inner, ua: ua}
+	return &wrapped
+}
@@ -0,0 +1,49 @@
+package parser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// TestUserAgent checks the User-Agent the node receives by default, set with
+// WithUserAgent, and through a client of WithHTTPClient.
+func TestUserAgent(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received = append(received, r.UserAgent())
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x2a"}`))
+	}))
+	defer node.Close()
+
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"default", nil, DefaultUserAgent},
+		{"WithUserAgent", []Option{WithUserAgent("indexer/2.0")}, "indexer/2.0"},
+		{"WithHTTPClient", []Option{WithHTTPClient(&http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()}), WithUserAgent("indexer/2.0")}, "indexer/2.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mu.Lock()
+			received = nil
+			mu.Unlock()
+			parser := NewEthereumParser(node.URL, NewMemoryStorage(), tt.opts...)
+			if block := parser.GetCurrentBlock(); block != 42 {
+				t.Fatalf("GetCurrentBlock = %d, want 42", block)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if len(received) != 1 || received[0] != tt.want {
+				t.Errorf("node received User-Agent %q, want %q", received, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+var recordVCR = flag.Bool("record-vcr", false, "record the cassettes of testdata/cassettes from the node at $PARSER_TEST_ENDPOINT instead of replaying them")
+
+// A cassette is a YAML file of the JSON-RPC requests a parser made and the
+// node's responses, in the order they were made, as WriteYAML writes a
+// sequence of rpcFixture.
+
+// StartRecording records the JSON-RPC requests the parser makes through its
+// own client, and the node's responses, to the cassette at path. The returned
+// function stops recording and writes the cassette.
+func StartRecording(t *testing.T, parser *EthereumParser, path string) func() {
+	t.Helper()
+	previous := parser.client
+	recorder := &cassetteRecorder{inner: previous.Transport}
+	if recorder.inner == nil {
+		recorder.inner = http.DefaultTransport
+	}
+	client := *previous
+	client.Transport = recorder
+	parser.client = &client
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			parser.client = previous
+			if err := recorder.save(path); err != nil {
+				t.Errorf("failed to record cassette: %v", err)
+			}
+		})
+	}
+}
+
+// cassetteRecorder keeps the JSON-RPC requests it sends and their responses.
+type cassetteRecorder struct {
+	inner http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []rpcFixture
+}
+
+func (recorder *cassetteRecorder) RoundTrip(request *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(request)
+	if err != nil {
+		return nil, err
+	}
+	response, err := recorder.inner.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+	interaction, err := newRPCFixture(body)
+	if err != nil {
+		return response, nil // Not a JSON-RPC request, which has nothing to replay
+	}
+	if err := interaction.setResponse(response); err != nil {
+		return nil, err
+	}
+	recorder.mu.Lock()
+	recorder.interactions = append(recorder.interactions, interaction)
+	recorder.mu.Unlock()
+	return response, nil
+}
+
+// save writes the interactions as the cassette at path.
+func (recorder *cassetteRecorder) save(path string) error {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	var cassette bytes.Buffer
+	if err := WriteYAML(&cassette, recorder.interactions); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, cassette.Bytes(), 0o644)
+}
+
+// LoadCassette returns a node replaying the cassette at path: each request is
+// answered with the next recorded response, so that repeated requests get the
+// responses they got when recorded. A request other than the one recorded
+// next, one past the end of the cassette, or recorded requests left unplayed
+// when the test ends fail the test.
+func LoadCassette(t *testing.T, path string) *httptest.Server {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to load cassette: %v", err)
+	}
+	defer file.Close()
+	var interactions []rpcFixture
+	if err := ReadYAML(file, &interactions); err != nil {
+		t.Fatalf("failed to load cassette %v: %v", path, err)
+	}
+
+	var mu sync.Mutex
+	next := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		call, err := newRPCFixture(body)
+		if err != nil {
+			t.Errorf("failed to replay request: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		i := next
+		next++
+		mu.Unlock()
+		if i >= len(interactions) {
+			t.Errorf("request %d %v %s is past the %d recorded in %v", i+1, call.Method, call.Params, len(interactions), path)
+			http.Error(w, "unrecorded request", http.StatusNotFound)
+			return
+		}
+		if interaction := interactions[i]; !sameRPCCall(call, interaction) {
+			t.Errorf("request %d is %v %s, %v recorded %v %s", i+1, call.Method, call.Params, path, interaction.Method, interaction.Params)
+			http.Error(w, "unrecorded request", http.StatusNotFound)
+			return
+		}
+
+		response := interactions[i].httpResponse(r)
+		for name, values := range response.Header {
+			if name != "Content-Length" {
+				w.Header()[name] = values
+			}
+		}
+		w.WriteHeader(response.StatusCode)
+		io.Copy(w, response.Body)
+	}))
+	t.Cleanup(func() {
+		server.Close()
+		mu.Lock()
+		defer mu.Unlock()
+		if next < len(interactions) {
+			t.Errorf("%d of the %d requests recorded in %v were not replayed", len(interactions)-next, len(interactions), path)
+		}
+	})
+	return server
+}
+
+// sameRPCCall reports whether two fixtures are of the same method and params.
+func sameRPCCall(a, b rpcFixture) bool {
+	var aParams, bParams interface{}
+	json.Unmarshal(a.Params, &aParams)
+	json.Unmarshal(b.Params, &bParams)
+	return a.Method == b.Method && reflect.DeepEqual(aParams, bParams)
+}
+
+// cassetteParser returns a parser of the node of the named cassette: with
+// --record-vcr, the node at $PARSER_TEST_ENDPOINT, recorded until the test
+// ends, and otherwise a replay of the cassette.
+func cassetteParser(t *testing.T, name string) *EthereumParser {
+	t.Helper()
+	path := filepath.Join("testdata", "cassettes", name+".yaml")
+	if !*recordVCR {
+		return NewEthereumParser(LoadCassette(t, path).URL, NewMemoryStorage())
+	}
+	endpoint := os.Getenv("PARSER_TEST_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("recording needs PARSER_TEST_ENDPOINT")
+	}
+	parser := NewEthereumParser(endpoint, NewMemoryStorage())
+	t.Cleanup(StartRecording(t, parser, path))
+	return parser
+}
+
+// TestCassetteLatestBlock reads the latest block and its last transaction.
+func TestCassetteLatestBlock(t *testing.T) {
+	parser := cassetteParser(t, "latest-block")
+	ctx := t.Context()
+	number, err := parser.blockNumber(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := parser.getBlockByNumber(ctx, number)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(block.Transactions) == 0 {
+		t.Fatalf("block %d has no transactions", number)
+	}
+	last := block.Transactions[len(block.Transactions)-1]
+	details, err := parser.GetTransactionByHash(ctx, last.Hash)
+	if err != nil || details.Hash != last.Hash || details.BlockHash != block.Hash {
+		t.Errorf("GetTransactionByHash = %+v, %v, want the last transaction of block %d", details, err, number)
+	}
+}
+
+// TestRecordingReplay records the calls of a parser to a node whose head
+// advances between two identical requests, and replays them in order.
+func TestRecordingReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.yaml")
+	node := newFakeNode(t, testBlock(1))
+	parser := node.NewParser()
+	done := StartRecording(t, parser, path)
+	first := parser.GetCurrentBlock()
+	node.AddBlock(testBlock(2))
+	second := parser.GetCurrentBlock()
+	done()
+	if first != 1 || second != 2 {
+		t.Fatalf("GetCurrentBlock while recording = %d then %d, want 1 then 2", first, second)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var interactions []rpcFixture
+	if err := ReadYAML(file, &interactions); err != nil {
+		t.Fatal(err)
+	}
+	if len(interactions) != 2 || interactions[0].Method != "eth_blockNumber" || interactions[0].Status != http.StatusOK {
+		t.Fatalf("recorded %+v, want two eth_blockNumber calls", interactions)
+	}
+
+	replay := NewEthereumParser(LoadCassette(t, path).URL, NewMemoryStorage())
+	if got := []uint64{replay.GetCurrentBlock(), replay.GetCurrentBlock()}; got[0] != first || got[1] != second {
+		t.Errorf("GetCurrentBlock replayed = %v, want %d then %d", got, first, second)
+	}
+}
+
+// TestWithRecording records the calls of a parser as fixtures, which
+// NewReplayClient serves back by method and params.
+func TestWithRecording(t *testing.T) {
+	dir := t.TempDir()
+	node := newFakeNode(t, testBlock(1))
+	recorded := node.NewParser(WithRecording(dir)).GetCurrentBlock()
+	if fixtures, _ := filepath.Glob(filepath.Join(dir, "eth_blockNumber-*.json")); len(fixtures) != 1 {
+		t.Fatalf("recorded %v, want one eth_blockNumber fixture", fixtures)
+	}
+
+	replay := NewEthereumParser("http://replay", NewMemoryStorage(), WithHTTPClient(NewReplayClient(dir)))
+	if block := replay.GetCurrentBlock(); block != recorded {
+		t.Errorf("GetCurrentBlock replayed = %d, want %d", block, recorded)
+	}
+	if _, err := replay.GetBlock(t.Context(), "0x1"); err == nil {
+		t.Error("GetBlock of an unrecorded request succeeded")
+	}
+}
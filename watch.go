@@ -0,0 +1,492 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultWatchInterval = 12 * time.Second
+	defaultMinInterval   = time.Second
+	defaultMaxInterval   = time.Minute
+
+	// intervalEWMAWeight is the weight given to the newest block time in the moving average.
+	intervalEWMAWeight = 0.3
+	// intervalLeadFactor keeps the polling interval slightly ahead of the block time.
+	intervalLeadFactor = 0.8
+	// intervalHistorySize is the number of past intervals kept for observability.
+	intervalHistorySize = 64
+	// listenerBufferSize is the number of transactions buffered for each Watch listener.
+	listenerBufferSize = 64
+)
+
+// Watch polls the node every WatchInterval and sends each transaction from or to
+// a subscribed address to out, once its block has Confirmations blocks on top of it.
+// It blocks until ctx is cancelled.
+func (parser *EthereumParser) Watch(ctx context.Context, out chan<- Transaction) error {
+	return parser.watch(ctx, 0, newSplitDetector(), out, nil, func() time.Duration {
+		return parser.settings().WatchInterval
+	})
+}
+
+// WatchFromBlock behaves like Watch, but starts at startBlock instead of the
+// chain head: the historical blocks are processed first, in order, and the
+// loop then polls for new blocks, so that every block is dispatched exactly
+// once. Block 0 holds no transactions, so a startBlock of 0 starts at block 1.
+func (parser *EthereumParser) WatchFromBlock(ctx context.Context, startBlock uint64, out chan<- Transaction) error {
+	return parser.watch(ctx, max(startBlock, 1), newSplitDetector(), out, nil, func() time.Duration {
+		return parser.settings().WatchInterval
+	})
+}
+
+// AdaptiveWatch behaves like Watch, but derives the polling interval from the
+// observed block production time, clamped to [MinInterval, MaxInterval].
+func (parser *EthereumParser) AdaptiveWatch(ctx context.Context, out chan<- Transaction) {
+	parser.watch(ctx, 0, newSplitDetector(), out, func(block *Block) error {
+		parser.adaptive.observe(block, parser.MinInterval, parser.MaxInterval)
+		return nil
+	}, parser.CurrentWatchInterval)
+}
+
+// WatchWithSummaries behaves like Watch, and also sends the BlockStats of every
+// processed block to summaryOut, whether or not the block had matching
+// transactions. Either channel may be nil to opt out of its stream. Nothing is
+// sent on either channel once ctx is cancelled, and summaryOut is closed on
+// return, so that its reader can range over it.
+func (parser *EthereumParser) WatchWithSummaries(ctx context.Context, txOut chan<- Transaction, summaryOut chan<- BlockStats) error {
+	var onBlock func(*Block) error
+	if summaryOut != nil {
+		defer close(summaryOut)
+		onBlock = func(block *Block) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			stats := newBlockStats(block)
+			stats.ExpectedBlockTime, stats.FinalityDepth = parser.chain.ExpectedBlockTime, parser.chain.FinalityDepth
+			select {
+			case summaryOut <- stats:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+	return parser.watch(ctx, 0, newSplitDetector(), txOut, onBlock, func() time.Duration {
+		return parser.settings().WatchInterval
+	})
+}
+
+// CurrentWatchInterval returns the polling interval currently used by AdaptiveWatch.
+// It is WatchInterval until a block time has been measured.
+func (parser *EthereumParser) CurrentWatchInterval() time.Duration {
+	if interval := parser.adaptive.current(); interval > 0 {
+		return interval
+	}
+	return parser.settings().WatchInterval
+}
+
+// WatchIntervalHistory returns the most recent AdaptiveWatch intervals, oldest first.
+func (parser *EthereumParser) WatchIntervalHistory() []time.Duration {
+	return parser.adaptive.recent()
+}
+
+// watch runs the polling loop shared by the Watch variants, starting at block
+// next, or at the confirmed head when next is zero. onBlock is called for every
+// processed block, stopping the loop if it fails, and interval is consulted
+// before each wait.
+func (parser *EthereumParser) watch(ctx context.Context, next uint64, splits *splitDetector, out chan<- Transaction, onBlock func(*Block) error, interval func() time.Duration) error {
+	wait := parser.clock.After(0)
+	if parser.nodeInfoInterval > 0 {
+		refreshCtx, stopRefresh := context.WithCancel(ctx)
+		defer stopRefresh()
+		go parser.refreshNodeInfoEvery(refreshCtx, parser.nodeInfoInterval)
+	}
+	if parser.SyncCheckInterval > 0 {
+		monitorCtx, stopMonitor := context.WithCancel(ctx)
+		defer stopMonitor()
+		go parser.monitorSync(monitorCtx, parser.SyncCheckInterval)
+	}
+	if parser.HealthCheckInterval > 0 {
+		checkCtx, stopChecks := context.WithCancel(ctx)
+		defer stopChecks()
+		go parser.checkHealthEvery(checkCtx, parser.HealthCheckInterval)
+	}
+	if parser.pendingScanInterval > 0 {
+		scanCtx, stopScan := context.WithCancel(ctx)
+		defer stopScan()
+		go parser.scanPendingEvery(scanCtx, parser.pendingScanInterval)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-wait:
+		}
+		if parser.syncPaused.Load() {
+			// The node serves stale blocks while it syncs, see monitorSync
+			wait = parser.clock.After(interval())
+			continue
+		}
+
+		// Each poll has a trace ID of its own
+		pollCtx := ContextWithTraceID(ctx, NewTraceID())
+		head, err := parser.confirmedHead(pollCtx)
+		if err != nil {
+			parser.logger.ErrorContext(pollCtx, "failed to get chain head", "error", err)
+		}
+		if next == 0 {
+			next = head
+		}
+		for ; err == nil && next != 0 && next <= head && !parser.syncPaused.Load(); next++ {
+			processed, err := parser.processBlock(pollCtx, next, splits, out, onBlock)
+			if err != nil {
+				return err
+			}
+			if !processed {
+				break
+			}
+		}
+
+		wait = parser.clock.After(interval())
+	}
+}
+
+// processBlock fetches a block, dispatches and indexes its transactions and
+// checks it for chain splits, in a span with a child span for each step. It
+// reports false when the block could not be fetched, so that it is retried
+// on the next poll.
+func (parser *EthereumParser) processBlock(ctx context.Context, number uint64, splits *splitDetector, out chan<- Transaction, onBlock func(*Block) error) (bool, error) {
+	ctx, span := parser.tracer.Start(ctx, "process block", slog.Uint64("block.number", number))
+	defer span.End()
+
+	fetchCtx, fetchSpan := parser.tracer.Start(ctx, "fetch block")
+	block, err := parser.getBlockByNumber(fetchCtx, number)
+	fetchSpan.RecordError(err)
+	fetchSpan.End()
+	if err != nil {
+		parser.logger.ErrorContext(ctx, "failed to get block", "block", number, "error", err)
+		parser.metrics.blocksRetried.Add(1)
+		span.RecordError(err)
+		return false, nil
+	}
+	span.SetAttributes(slog.Int("block.transactions", len(block.Transactions)))
+
+	dispatchCtx, dispatchSpan := parser.tracer.Start(ctx, "dispatch transactions")
+	err = parser.dispatch(dispatchCtx, block, out)
+	dispatchSpan.End()
+	if err != nil {
+		return false, err
+	}
+
+	_, indexSpan := parser.tracer.Start(ctx, "index block")
+	parser.indexBlock(number, block)
+	indexSpan.End()
+
+	if err := parser.checkSplits(ctx, splits, number, block, out); err != nil {
+		return false, err
+	}
+	parser.mu.Lock()
+	parser.lastProcessed = number
+	parser.lastProcessedHash = block.Hash
+	parser.mu.Unlock()
+	parser.metrics.blocksProcessed.Add(1)
+	if onBlock != nil {
+		if err := onBlock(block); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// confirmedHead returns the newest block that has at least Confirmations blocks on top of it.
+func (parser *EthereumParser) confirmedHead(ctx context.Context) (uint64, error) {
+	head, err := parser.blockNumber(ctx)
+	if err != nil {
+		return 0, err
+	}
+	confirmations := parser.settings().Confirmations
+	if head < confirmations {
+		return 0, nil
+	}
+	return head - confirmations, nil
+}
+
+// dispatch sends the block's transactions that involve a subscribed address to out,
+// unless out is nil, and to the listeners. Transactions of throttled addresses are
+// suppressed while over their limit, and system transactions of L2 chains are
+// skipped. The alert rules and, with WithApprovalMonitoring, the approvals are
+// evaluated after the transactions are dispatched.
+// Subscriptions changed during the dispatch apply from the next block.
+func (parser *EthereumParser) dispatch(ctx context.Context, block *Block, out chan<- Transaction) error {
+	now := parser.clock.Now()
+	parser.releaseThrottles(now)
+	if number, err := ParseHexUint64(block.Number); err == nil {
+		parser.activateDue(now, number)
+	}
+
+	parser.metrics.transactionsScanned.Add(uint64(len(block.Transactions)))
+	emits := parser.emitters()
+	for _, transaction := range block.Transactions {
+		if parser.chain.IsSystemTransaction(transaction) {
+			continue
+		}
+		sent, received := emits(transaction.From), emits(transaction.To)
+		if !sent && !received {
+			continue
+		}
+		parser.metrics.countMatch(matchType(transaction, sent, received))
+		if sent {
+			parser.recordMinedNonce(transaction)
+		}
+		transaction.Chain = parser.chain.Name
+		if parser.throttle(transaction, now) {
+			continue
+		}
+		parser.labelTransaction(&transaction)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if out != nil {
+			select {
+			case out <- transaction:
+				parser.metrics.notificationsSent.Add(1)
+			case <-ctx.Done():
+				parser.metrics.notificationsFailed.Add(1)
+				return ctx.Err()
+			}
+		}
+		parser.notifyListeners(transaction)
+	}
+	if err := parser.raiseAlerts(ctx, block, emits); err != nil {
+		return err
+	}
+	return parser.dispatchApprovals(ctx, block, emits)
+}
+
+// watchListener receives the transactions dispatched by Watch.
+type watchListener struct {
+	transactions chan Transaction
+	done         chan struct{} // Closed to ask the listener to stop
+	stopped      chan struct{} // Closed once the listener no longer receives
+}
+
+// notifyListeners passes a dispatched transaction to every registered listener
+// without blocking: a listener whose buffer of listenerBufferSize transactions
+// is full misses the transaction, which is counted in
+// Metrics.NotificationsDropped, so that a slow listener never stalls Watch.
+func (parser *EthereumParser) notifyListeners(transaction Transaction) {
+	parser.mu.Lock()
+	listeners := make([]*watchListener, 0, len(parser.listeners))
+	for listener := range parser.listeners {
+		listeners = append(listeners, listener)
+	}
+	parser.mu.Unlock()
+
+	for _, listener := range listeners {
+		select {
+		case <-listener.stopped:
+			parser.metrics.notificationsFailed.Add(1)
+			continue
+		default:
+		}
+		select {
+		case listener.transactions <- transaction:
+			parser.metrics.notificationsSent.Add(1)
+		default:
+			parser.metrics.notificationsDropped.Add(1)
+		}
+	}
+}
+
+// SubscribeAndWatch subscribes the address, starting after the last block
+// Watch processed, and forwards the transactions a running Watch dispatches
+// for it to out. The returned function stops forwarding and unsubscribes the
+// address, unless it was already subscribed, in which case its subscription
+// and watermark are kept; it must be called once the caller is done, even if
+// ctx was cancelled. Transactions are dropped, see notifyListeners, while out
+// is not read.
+func (parser *EthereumParser) SubscribeAndWatch(ctx context.Context, address string, out chan<- Transaction) (func(), error) {
+	parser.mu.Lock()
+	watermark := parser.lastProcessed + 1
+	parser.mu.Unlock()
+
+	// Listen before subscribing so that no dispatched transaction is missed
+	stop := parser.listen(ctx, address, watermark, out)
+	alreadySubscribed, err := parser.SubscribeAddress(address)
+	if err != nil {
+		stop()
+		return nil, err
+	}
+	if alreadySubscribed {
+		return stop, nil
+	}
+	parser.mu.Lock()
+	parser.watermarks[NormalizeAddress(address)] = watermark
+	parser.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			stop()
+			parser.UnsubscribeAddress(address)
+		})
+	}, nil
+}
+
+// WatchAddress forwards the transactions a running Watch dispatches for an
+// address that is already subscribed to out, without changing the
+// subscription. The returned function stops forwarding; it must be called once
+// the caller is done, even if ctx was cancelled.
+func (parser *EthereumParser) WatchAddress(ctx context.Context, address string, out chan<- Transaction) (func(), error) {
+	if !parser.store.IsSubscriber(NormalizeAddress(address)) {
+		return nil, fmt.Errorf("address is not subscribed: %v", address)
+	}
+	return parser.listen(ctx, address, 0, out), nil
+}
+
+// listen forwards the dispatched transactions that involve the address, from
+// the watermark block on, to out until ctx is cancelled or the returned
+// function is called. The returned function waits for forwarding to stop.
+func (parser *EthereumParser) listen(ctx context.Context, address string, watermark uint64, out chan<- Transaction) func() {
+	listener := &watchListener{
+		transactions: make(chan Transaction, listenerBufferSize),
+		done:         make(chan struct{}),
+		stopped:      make(chan struct{}),
+	}
+	parser.mu.Lock()
+	parser.listeners[listener] = true
+	parser.mu.Unlock()
+
+	go func() {
+		defer func() {
+			parser.mu.Lock()
+			delete(parser.listeners, listener)
+			parser.mu.Unlock()
+			close(listener.stopped)
+		}()
+		for {
+			select {
+			case transaction := <-listener.transactions:
+				if !involvesAddress(transaction, address) {
+					continue
+				}
+				if number := blockNumberOf(transaction); number != 0 && number < watermark {
+					continue
+				}
+				select {
+				case out <- transaction:
+				case <-listener.done:
+					return
+				case <-ctx.Done():
+					return
+				}
+			case <-listener.done:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(listener.done)
+			<-listener.stopped
+		})
+	}
+}
+
+// involvesAddress reports whether the address, in any spelling, sent or received
+// the transaction.
+func involvesAddress(transaction Transaction, address string) bool {
+	address = NormalizeAddress(address)
+	return NormalizeAddress(transaction.From) == address || NormalizeAddress(transaction.To) == address
+}
+
+// blockNumberOf returns the block number of a transaction, or zero if it is unknown.
+func blockNumberOf(transaction Transaction) uint64 {
+	if transaction.BlockNumber == "" {
+		return 0
+	}
+	number, err := ParseHexUint64(transaction.BlockNumber)
+	if err != nil {
+		return 0
+	}
+	return number
+}
+
+// adaptiveInterval tracks the moving average of block production time.
+type adaptiveInterval struct {
+	mu            sync.Mutex
+	interval      time.Duration
+	average       float64 // Moving average of the block time in seconds
+	lastTimestamp uint64
+	history       [intervalHistorySize]time.Duration // Ring buffer of past intervals
+	historyNext   int
+	historyCount  int
+}
+
+// observe updates the interval using the time elapsed since the previous block.
+func (adaptive *adaptiveInterval) observe(block *Block, minInterval, maxInterval time.Duration) {
+	if block.Timestamp == "" {
+		return
+	}
+	timestamp, err := ParseHexUint64(block.Timestamp)
+	if err != nil {
+		return
+	}
+
+	adaptive.mu.Lock()
+	defer adaptive.mu.Unlock()
+
+	previous := adaptive.lastTimestamp
+	adaptive.lastTimestamp = timestamp
+	if previous == 0 || timestamp <= previous {
+		return
+	}
+
+	blockTime := float64(timestamp - previous)
+	if adaptive.average == 0 {
+		adaptive.average = blockTime
+	} else {
+		adaptive.average = intervalEWMAWeight*blockTime + (1-intervalEWMAWeight)*adaptive.average
+	}
+
+	interval := time.Duration(adaptive.average * intervalLeadFactor * float64(time.Second))
+	if interval < minInterval {
+		interval = minInterval
+	}
+	if interval > maxInterval {
+		interval = maxInterval
+	}
+
+	adaptive.interval = interval
+	adaptive.history[adaptive.historyNext] = interval
+	adaptive.historyNext = (adaptive.historyNext + 1) % intervalHistorySize
+	if adaptive.historyCount < intervalHistorySize {
+		adaptive.historyCount++
+	}
+}
+
+func (adaptive *adaptiveInterval) current() time.Duration {
+	adaptive.mu.Lock()
+	defer adaptive.mu.Unlock()
+	return adaptive.interval
+}
+
+func (adaptive *adaptiveInterval) recent() []time.Duration {
+	adaptive.mu.Lock()
+	defer adaptive.mu.Unlock()
+
+	intervals := make([]time.Duration, 0, adaptive.historyCount)
+	start := (adaptive.historyNext - adaptive.historyCount + intervalHistorySize) % intervalHistorySize
+	for i := 0; i < adaptive.historyCount; i++ {
+		intervals = append(intervals, adaptive.history[(start+i)%intervalHistorySize])
+	}
+	return intervals
+}
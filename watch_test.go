@@ -0,0 +1,175 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestSubscribeAndWatch(t *testing.T) {
+	parser := newTestParser(t, []*Block{testBlock(1)})
+	parser.lastProcessed = 7
+
+	stop, err := parser.SubscribeAndWatch(context.Background(), checksummedAddress, make(chan Transaction))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if watermark, ok := parser.SubscriptionWatermark(checksummedAddress); !ok || watermark != 8 {
+		t.Errorf("SubscriptionWatermark = %v, %v, want 8, true", watermark, ok)
+	}
+	stop()
+	if parser.store.IsSubscriber(NormalizeAddress(checksummedAddress)) {
+		t.Error("stop kept the subscription SubscribeAndWatch made")
+	}
+
+	if _, err := parser.SubscribeAndWatch(context.Background(), "0x1234", make(chan Transaction)); err == nil {
+		t.Error("SubscribeAndWatch of an invalid address succeeded")
+	}
+	if len(parser.listeners) != 0 {
+		t.Errorf("%d listeners left after a failed SubscribeAndWatch", len(parser.listeners))
+	}
+}
+
+// TestSubscribeAndWatchSubscribed watches an address subscribed beforehand,
+// whose subscription must outlive the watch.
+func TestSubscribeAndWatchSubscribed(t *testing.T) {
+	parser := newTestParser(t, []*Block{testBlock(1)})
+	if _, err := parser.SubscribeAddress(checksummedAddress); err != nil {
+		t.Fatal(err)
+	}
+	parser.watermarks[NormalizeAddress(checksummedAddress)] = 3
+	parser.lastProcessed = 7
+
+	stop, err := parser.SubscribeAndWatch(context.Background(), checksummedAddress, make(chan Transaction))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop()
+	if !parser.store.IsSubscriber(NormalizeAddress(checksummedAddress)) {
+		t.Error("stop removed a subscription SubscribeAndWatch did not make")
+	}
+	if watermark, ok := parser.SubscriptionWatermark(checksummedAddress); !ok || watermark != 3 {
+		t.Errorf("SubscriptionWatermark = %v, %v, want the former 3, true", watermark, ok)
+	}
+}
+
+// TestNotifyListenersDrops dispatches more transactions than a listener that
+// is not read buffers.
+func TestNotifyListenersDrops(t *testing.T) {
+	const count = 2 * listenerBufferSize
+	transactions := make([]Transaction, count)
+	for i := range transactions {
+		transactions[i] = Transaction{Hash: fmt.Sprintf("0x%x", i), From: checksummedAddress, To: otherAddress}
+	}
+	parser := newTestParser(t, []*Block{testBlock(1)})
+	stop, err := parser.SubscribeAndWatch(context.Background(), checksummedAddress, make(chan Transaction))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	dispatched := make(chan error, 1)
+	go func() { dispatched <- parser.dispatch(context.Background(), testBlock(1, transactions...), nil) }()
+	select {
+	case err := <-dispatched:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("dispatch blocked on a listener that is not read")
+	}
+
+	metrics := parser.Metrics()
+	if metrics.NotificationsDropped == 0 || metrics.NotificationsSent+metrics.NotificationsDropped != count {
+		t.Errorf("notifications sent %d and dropped %d, want some dropped of %d", metrics.NotificationsSent, metrics.NotificationsDropped, count)
+	}
+}
+
+// TestWatchFromBlock catches up with 5 historical blocks, then follows 3 new
+// ones, dispatching the transaction of each block exactly once.
+func TestWatchFromBlock(t *testing.T) {
+	transaction := func(number uint64) Transaction {
+		return Transaction{Hash: Keccak256Hex(fmt.Sprint("transaction ", number)), From: checksummedAddress, To: otherAddress, Value: "0x1", Nonce: fmt.Sprintf("0x%x", number)}
+	}
+	var history []*Block
+	for number := uint64(1); number <= 5; number++ {
+		history = append(history, testBlock(number, transaction(number)))
+	}
+	node := newFakeNode(t, history...)
+	parser := node.NewParser(WithChain(Chain{PollInterval: testWatchInterval}))
+	if _, err := parser.SubscribeAddress(checksummedAddress); err != nil {
+		t.Fatal(err)
+	}
+	out := startWatch(t, parser, 1)
+
+	for number := uint64(1); number <= 8; number++ {
+		if number > 5 {
+			node.AddBlock(testBlock(number, transaction(number)))
+		}
+		if received := receive(t, out); received.Hash != transaction(number).Hash {
+			t.Fatalf("received %v of block %v, want the transaction of block %d", received.Hash, received.BlockNumber, number)
+		}
+	}
+	receiveNone(t, out, 5*testWatchInterval)
+}
+
+// TestWatchWithSummaries summarizes every block, with or without a matching
+// transaction. Cancelling the context stops both streams, closes the summary
+// channel and leaves no goroutine running.
+func TestWatchWithSummaries(t *testing.T) {
+	transfer := Transaction{Hash: Keccak256Hex("transfer"), From: checksummedAddress, To: otherAddress, Value: "0x1"}
+	node := newFakeNode(t, testBlock(1), testBlock(2))
+	parser := node.NewParser(WithChain(Chain{PollInterval: testWatchInterval, ExpectedBlockTime: 12 * time.Second}))
+	if _, err := parser.SubscribeAddress(checksummedAddress); err != nil {
+		t.Fatal(err)
+	}
+	goroutines := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	txOut, summaries := make(chan Transaction, 16), make(chan BlockStats)
+	watchErr := make(chan error, 1)
+	go func() { watchErr <- parser.WatchWithSummaries(ctx, txOut, summaries) }()
+	summary := func() BlockStats {
+		t.Helper()
+		select {
+		case stats := <-summaries:
+			return stats
+		case <-time.After(time.Second):
+			t.Fatal("no summary received")
+			return BlockStats{}
+		}
+	}
+
+	if stats := summary(); stats.Number != 2 || stats.Hash != testBlockHash(2, "") || stats.TransactionCount != 0 || stats.ExpectedBlockTime != 12*time.Second {
+		t.Errorf("summary of the head = %+v, want block 2 without transactions", stats)
+	}
+	node.AddBlock(testBlock(3, transfer))
+	if stats := summary(); stats.Number != 3 || stats.TransactionCount != 1 {
+		t.Errorf("summary = %+v, want block 3 with a transaction", stats)
+	}
+	if received := receive(t, txOut); received.Hash != transfer.Hash {
+		t.Errorf("received %v, want the transfer", received.Hash)
+	}
+
+	cancel()
+	for stats := range summaries {
+		t.Errorf("summary of block %d sent after cancellation", stats.Number)
+	}
+	if err := <-watchErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("WatchWithSummaries error = %v, want context.Canceled", err)
+	}
+	node.AddBlock(testBlock(4, transfer))
+	receiveNone(t, txOut, 5*testWatchInterval)
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > goroutines && time.Now().Before(deadline) {
+		time.Sleep(testWatchInterval)
+	}
+	if running := runtime.NumGoroutine(); running > goroutines {
+		t.Errorf("%d goroutines running after cancellation, want %d", running, goroutines)
+	}
+}
@@ -0,0 +1,482 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// YAMLTransaction is the YAML form of a Transaction, with snake_case keys and
+// decoded quantities.
+type YAMLTransaction struct {
+	Hash        string   `json:"hash"`
+	BlockNumber uint64   `json:"block_number"`
+	From        string   `json:"from_address"`
+	FromLabel   string   `json:"from_label,omitempty"`
+	To          string   `json:"to_address"`
+	ToLabel     string   `json:"to_label,omitempty"`
+	Contract    string   `json:"contract_name,omitempty"`
+	TokenSymbol string   `json:"token_symbol,omitempty"`
+	TokenName   string   `json:"token_name,omitempty"`
+	ValueWei    *big.Int `json:"value_wei"`
+	ValueEther  string   `json:"value_ether"`
+	Input       string   `json:"input"`
+	Chain       string   `json:"chain,omitempty"`
+}
+
+// NewYAMLTransaction returns the YAML form of a transaction.
+func NewYAMLTransaction(transaction Transaction) YAMLTransaction {
+	blockNumber, _ := ParseHexUint64(transaction.BlockNumber)
+	value, err := parseHexBig(transaction.Value)
+	if err != nil {
+		value = new(big.Int)
+	}
+	return YAMLTransaction{
+		Hash:        transaction.Hash,
+		BlockNumber: blockNumber,
+		From:        transaction.From,
+		FromLabel:   transaction.FromLabel,
+		To:          transaction.To,
+		ToLabel:     transaction.ToLabel,
+		Contract:    transaction.ContractName,
+		TokenSymbol: transaction.TokenSymbol,
+		TokenName:   transaction.TokenName,
+		ValueWei:    value,
+		ValueEther:  FormatEther(value),
+		Input:       transaction.Input,
+		Chain:       transaction.Chain,
+	}
+}
+
+// NewYAMLTransactions returns the YAML form of transactions.
+func NewYAMLTransactions(transactions []Transaction) []YAMLTransaction {
+	items := make([]YAMLTransaction, len(transactions))
+	for i, transaction := range transactions {
+		items[i] = NewYAMLTransaction(transaction)
+	}
+	return items
+}
+
+// YAMLValuer is implemented by values with a YAML form other than their JSON
+// encoding.
+type YAMLValuer interface {
+	YAMLValue() interface{}
+}
+
+// WriteYAML writes the value as a YAML document. It is encoded like JSON,
+// keeping the keys and their order, then rewritten in YAML's block style.
+func WriteYAML(w io.Writer, value interface{}) error {
+	if valuer, ok := value.(YAMLValuer); ok {
+		value = valuer.YAMLValue()
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	node, err := readYAMLNode(decoder)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, strings.Join(node.lines(""), "\n")+"\n")
+	return err
+}
+
+// yamlNode is a value being rewritten in YAML: a scalar, or a mapping or
+// sequence when it has children.
+type yamlNode struct {
+	kind   byte        // yamlScalar, yamlMapping or yamlSequence
+	scalar string      // YAML text of a scalar
+	keys   []string    // YAML text of the keys of a mapping
+	values []*yamlNode // Values of a mapping, or items of a sequence
+}
+
+const (
+	yamlScalar   = 's'
+	yamlMapping  = 'm'
+	yamlSequence = 'l'
+)
+
+// readYAMLNode reads the next JSON value from decoder.
+func readYAMLNode(decoder *json.Decoder) (*yamlNode, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token := token.(type) {
+	case json.Delim:
+		node := &yamlNode{kind: yamlSequence}
+		if token == '{' {
+			node.kind = yamlMapping
+		}
+		for decoder.More() {
+			if node.kind == yamlMapping {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				node.keys = append(node.keys, yamlString(key.(string)))
+			}
+			value, err := readYAMLNode(decoder)
+			if err != nil {
+				return nil, err
+			}
+			node.values = append(node.values, value)
+		}
+		_, err := decoder.Token() // Closing delimiter
+		return node, err
+	case string:
+		return &yamlNode{kind: yamlScalar, scalar: yamlString(token)}, nil
+	case json.Number:
+		return &yamlNode{kind: yamlScalar, scalar: token.String()}, nil
+	case bool:
+		return &yamlNode{kind: yamlScalar, scalar: strconv.FormatBool(token)}, nil
+	case nil:
+		return &yamlNode{kind: yamlScalar, scalar: "null"}, nil
+	}
+	return nil, fmt.Errorf("unexpected JSON token %v", token)
+}
+
+// inline returns the text of a node written on its key's or item's line: a
+// scalar or an empty collection. ok is false for the other nodes.
+func (node *yamlNode) inline() (text string, ok bool) {
+	switch {
+	case node.kind == yamlScalar:
+		return node.scalar, true
+	case len(node.values) > 0:
+		return "", false
+	case node.kind == yamlMapping:
+		return "{}", true
+	default:
+		return "[]", true
+	}
+}
+
+// lines returns the lines of the node in block style, indented by indent.
+func (node *yamlNode) lines(indent string) []string {
+	if text, ok := node.inline(); ok {
+		return []string{indent + text}
+	}
+
+	var lines []string
+	for i, value := range node.values {
+		text, inline := value.inline()
+		switch {
+		case node.kind == yamlMapping && inline:
+			lines = append(lines, indent+node.keys[i]+": "+text)
+		case node.kind == yamlMapping:
+			lines = append(lines, indent+node.keys[i]+":")
+			lines = append(lines, value.lines(indent+"  ")...)
+		case inline:
+			lines = append(lines, indent+"- "+text)
+		default:
+			// The item's first line starts on the dash's line
+			item := value.lines(indent + "  ")
+			item[0] = indent + "- " + strings.TrimPrefix(item[0], indent+"  ")
+			lines = append(lines, item...)
+		}
+	}
+	return lines
+}
+
+// yamlString returns a string as a YAML scalar: plain when it cannot be read
+// as anything else, otherwise double-quoted, escaping the characters YAML
+// does not allow in documents.
+func yamlString(value string) string {
+	if isPlainYAML(value) {
+		return value
+	}
+	var quoted strings.Builder
+	quoted.WriteByte('"')
+	for _, char := range value { // Invalid UTF-8 is read as U+FFFD
+		switch {
+		case char == '"' || char == '\\':
+			quoted.WriteByte('\\')
+			quoted.WriteRune(char)
+		case char == '\n':
+			quoted.WriteString(`\n`)
+		case char == '\t':
+			quoted.WriteString(`\t`)
+		case isPrintableYAML(char):
+			quoted.WriteRune(char)
+		case char > 0xffff:
+			fmt.Fprintf(&quoted, `\U%08x`, char)
+		default:
+			fmt.Fprintf(&quoted, `\u%04x`, char)
+		}
+	}
+	quoted.WriteByte('"')
+	return quoted.String()
+}
+
+// isPrintableYAML reports whether a character may appear unescaped in a
+// quoted YAML scalar: a printable character other than a line break, which
+// would be folded into a space.
+func isPrintableYAML(char rune) bool {
+	return char >= 0x20 && char <= 0x7e ||
+		char >= 0xa0 && char <= 0xd7ff && char != 0x2028 && char != 0x2029 ||
+		char >= 0xe000 && char <= 0xfffd && char != 0xfeff ||
+		char >= 0x10000 && char <= 0x10ffff
+}
+
+// yamlReserved are the plain scalars YAML reads as booleans or null.
+var yamlReserved = map[string]bool{
+	"true": true, "false": true, "yes": true, "no": true, "on": true, "off": true,
+	"y": true, "n": true, "null": true, "~": true,
+}
+
+// isPlainYAML reports whether a string can be written unquoted: it starts with
+// a letter, holds no indicator such as : or #, and is not read as a boolean
+// or null. Numbers, including hex quantities, are always quoted.
+func isPlainYAML(value string) bool {
+	if value == "" || yamlReserved[strings.ToLower(value)] || strings.HasSuffix(value, " ") {
+		return false
+	}
+	for i, char := range value {
+		letter := char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z'
+		if i == 0 && !letter {
+			return false
+		}
+		if !letter && !(char >= '0' && char <= '9') && !strings.ContainsRune(" _.-/", char) {
+			return false
+		}
+	}
+	return true
+}
+
+// ReadYAML reads a YAML document written by WriteYAML into value, as
+// json.Unmarshal reads its JSON encoding. Only the block style WriteYAML writes
+// is read: no flow collections other than {} and [], comments, anchors or
+// multi-line scalars.
+func ReadYAML(r io.Reader, value interface{}) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	var reader yamlReader
+	for i, text := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		trimmed := strings.TrimLeft(text, " ")
+		reader.lines = append(reader.lines, yamlLine{number: i + 1, indent: len(text) - len(trimmed), text: trimmed})
+	}
+	if len(reader.lines) == 0 {
+		return errors.New("empty YAML document")
+	}
+
+	var document bytes.Buffer
+	if err := reader.node(&document, 0); err != nil {
+		return err
+	}
+	if reader.next < len(reader.lines) {
+		return reader.lines[reader.next].errorf("unexpected line")
+	}
+	return json.Unmarshal(document.Bytes(), value)
+}
+
+// yamlLine is a line of a YAML document, without its indentation.
+type yamlLine struct {
+	number int
+	indent int
+	text   string
+}
+
+func (line yamlLine) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("YAML line %d: %v", line.number, fmt.Sprintf(format, args...))
+}
+
+// yamlReader rewrites the lines of a YAML document as JSON.
+type yamlReader struct {
+	lines []yamlLine
+	next  int // Index of the line to read next
+}
+
+// node writes the JSON of the value starting at the next line, which must be
+// indented by indent.
+func (reader *yamlReader) node(out *bytes.Buffer, indent int) error {
+	if reader.next == len(reader.lines) {
+		return errors.New("unexpected end of YAML document")
+	}
+	line := reader.lines[reader.next]
+	if line.indent != indent {
+		return line.errorf("expected an indentation of %d", indent)
+	}
+	if strings.HasPrefix(line.text, "- ") {
+		return reader.sequence(out, indent)
+	}
+	if _, _, ok, err := cutYAMLKey(line.text); err != nil {
+		return line.errorf("%v", err)
+	} else if ok {
+		return reader.mapping(out, indent)
+	}
+	reader.next++
+	if err := writeYAMLScalar(out, line.text); err != nil {
+		return line.errorf("%v", err)
+	}
+	return nil
+}
+
+// sequence writes the JSON of the sequence whose items start at the next line.
+func (reader *yamlReader) sequence(out *bytes.Buffer, indent int) error {
+	out.WriteByte('[')
+	for i := 0; reader.next < len(reader.lines); i++ {
+		line := &reader.lines[reader.next]
+		if line.indent < indent {
+			break
+		}
+		rest, ok := strings.CutPrefix(line.text, "- ")
+		if line.indent > indent || !ok {
+			return line.errorf("expected a sequence item")
+		}
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		// The item's first line starts after the dash, as if indented past it
+		line.indent, line.text = indent+2, rest
+		if err := reader.node(out, indent+2); err != nil {
+			return err
+		}
+	}
+	out.WriteByte(']')
+	return nil
+}
+
+// mapping writes the JSON of the mapping whose entries start at the next line.
+func (reader *yamlReader) mapping(out *bytes.Buffer, indent int) error {
+	out.WriteByte('{')
+	for i := 0; reader.next < len(reader.lines); i++ {
+		line := reader.lines[reader.next]
+		if line.indent < indent {
+			break
+		}
+		key, rest, ok, err := cutYAMLKey(line.text)
+		if err != nil {
+			return line.errorf("%v", err)
+		}
+		if line.indent > indent || !ok {
+			return line.errorf("expected a mapping entry")
+		}
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		out.Write(name)
+		out.WriteByte(':')
+		reader.next++
+		if rest != "" {
+			if err := writeYAMLScalar(out, rest); err != nil {
+				return line.errorf("%v", err)
+			}
+			continue
+		}
+		if err := reader.node(out, indent+2); err != nil {
+			return err
+		}
+	}
+	out.WriteByte('}')
+	return nil
+}
+
+// cutYAMLKey splits a mapping entry into its key and the text of its value,
+// empty when the value is on the following lines. ok is false when the text
+// is not a mapping entry.
+func cutYAMLKey(text string) (key, rest string, ok bool, err error) {
+	if !strings.HasPrefix(text, `"`) {
+		if key, rest, ok := strings.Cut(text, ": "); ok {
+			return key, rest, true, nil
+		}
+		if key, ok := strings.CutSuffix(text, ":"); ok {
+			return key, "", true, nil
+		}
+		return "", "", false, nil
+	}
+
+	end := quotedYAMLEnd(text)
+	if end < 0 {
+		return "", "", false, fmt.Errorf("unterminated quoted scalar %v", text)
+	}
+	after := text[end:]
+	if after == "" {
+		return "", "", false, nil // A quoted scalar
+	}
+	if after != ":" && !strings.HasPrefix(after, ": ") {
+		return "", "", false, fmt.Errorf("unexpected %q after a quoted key", after)
+	}
+	key, err = strconv.Unquote(text[:end])
+	if err != nil {
+		return "", "", false, fmt.Errorf("invalid quoted key %v: %w", text[:end], err)
+	}
+	return key, strings.TrimPrefix(after[1:], " "), true, nil
+}
+
+// quotedYAMLEnd returns the index after the closing quote of the quoted scalar
+// text starts with, or -1 when it is not closed.
+func quotedYAMLEnd(text string) int {
+	for i := 1; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// writeYAMLScalar writes the JSON of a scalar written by WriteYAML: quoted and
+// plain strings, numbers, booleans, null and empty collections.
+func writeYAMLScalar(out *bytes.Buffer, text string) error {
+	switch {
+	case strings.HasPrefix(text, `"`):
+		if quotedYAMLEnd(text) != len(text) {
+			return fmt.Errorf("invalid quoted scalar %v", text)
+		}
+		value, err := strconv.Unquote(text)
+		if err != nil {
+			return fmt.Errorf("invalid quoted scalar %v: %w", text, err)
+		}
+		data, _ := json.Marshal(value)
+		out.Write(data)
+	case text == "null" || text == "true" || text == "false" || text == "{}" || text == "[]":
+		out.WriteString(text)
+	case text[0] == '-' || text[0] >= '0' && text[0] <= '9':
+		if !json.Valid([]byte(text)) {
+			return fmt.Errorf("invalid number %v", text)
+		}
+		out.WriteString(text)
+	default:
+		data, _ := json.Marshal(text)
+		out.Write(data)
+	}
+	return nil
+}
+
+// acceptsYAML reports whether the request prefers YAML to JSON: the first of
+// the media types it accepts that either could serve is a YAML one.
+func acceptsYAML(r *http.Request) bool {
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, _ := strings.Cut(accepted, ";")
+		switch strings.TrimSpace(mediaType) {
+		case "application/yaml", "application/x-yaml", "text/yaml":
+			return true
+		case "application/json", "application/*", "*/*":
+			return false
+		}
+	}
+	return false
+}
+
+// writeYAMLResponse writes a YAML response with the given status code.
+func writeYAMLResponse(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/yaml")
+	w.WriteHeader(status)
+	WriteYAML(w, v)
+}
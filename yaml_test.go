@@ -0,0 +1,141 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// readYAMLMappings reads a YAML sequence of flat mappings in the block style
+// WriteYAML writes, unquoting the scalars. It fails on any other YAML.
+func readYAMLMappings(text string) ([]map[string]string, error) {
+	var items []map[string]string
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		if rest, ok := strings.CutPrefix(line, "- "); ok {
+			items = append(items, map[string]string{})
+			line = "  " + rest
+		}
+		entry, ok := strings.CutPrefix(line, "  ")
+		if !ok || len(items) == 0 {
+			return nil, fmt.Errorf("unexpected line %q", line)
+		}
+		key, value, ok := strings.Cut(entry, ": ")
+		if !ok || !isPlainYAML(key) {
+			return nil, fmt.Errorf("invalid mapping entry %q", line)
+		}
+		if strings.HasPrefix(value, `"`) {
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted scalar %v: %w", value, err)
+			}
+			value = unquoted
+		} else if strings.ContainsAny(value, `"#:`) || strings.HasSuffix(value, " ") {
+			return nil, fmt.Errorf("invalid plain scalar %q", value)
+		}
+		items[len(items)-1][key] = value
+	}
+	return items, nil
+}
+
+// FuzzWriteYAML writes transactions of arbitrary fields as YAML, which must
+// read back to their fields.
+func FuzzWriteYAML(f *testing.F) {
+	f.Add("0x88df", uint64(19531250), checksummedAddress, "Alice", otherAddress, "", "0xde0b6b3a7640000", "0x", "ethereum")
+	f.Add("true", uint64(0), "", "a: b", "-", "# not a comment", "0x0", "line\nbreak\ttab", "")
+	f.Add(`"quoted"`, uint64(1<<64-1), "null", "\x00\u2028\ufeff", "\xff", "trailing ", "zz", "\\", "~")
+	f.Fuzz(func(t *testing.T, hash string, block uint64, from, fromLabel, to, toLabel, value, input, chain string) {
+		transaction := Transaction{Hash: hash, BlockNumber: fmt.Sprintf("0x%x", block), From: from, FromLabel: fromLabel, To: to, ToLabel: toLabel, Value: value, Input: input, Chain: chain}
+		var out bytes.Buffer
+		if err := WriteYAML(&out, NewYAMLTransactions([]Transaction{transaction})); err != nil {
+			t.Fatal(err)
+		}
+		items, err := readYAMLMappings(out.String())
+		if err != nil || len(items) != 1 {
+			t.Fatalf("WriteYAML wrote invalid YAML (%v):\n%v", err, out.String())
+		}
+
+		// Compared with the JSON encoding, which replaces invalid UTF-8 as WriteYAML does
+		var want map[string]interface{}
+		data, _ := json.Marshal(NewYAMLTransaction(transaction))
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&want); err != nil {
+			t.Fatal(err)
+		}
+		if len(items[0]) != len(want) {
+			t.Errorf("read %d fields, want %d:\n%v", len(items[0]), len(want), out.String())
+		}
+		for key, value := range want {
+			if got := items[0][key]; got != fmt.Sprint(value) {
+				t.Errorf("%v = %q, want %q", key, got, value)
+			}
+		}
+	})
+}
+
+func TestAcceptsYAML(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{"", false},
+		{"application/yaml", true},
+		{"text/yaml; charset=utf-8", true},
+		{"application/x-yaml, application/json", true},
+		{"application/json, application/yaml", false},
+		{"*/*", false},
+		{"text/html, application/yaml;q=0.9", true},
+	}
+	for _, test := range tests {
+		request := httptest.NewRequest("GET", "/transactions", nil)
+		request.Header.Set("Accept", test.accept)
+		if got := acceptsYAML(request); got != test.want {
+			t.Errorf("acceptsYAML(%q) = %v, want %v", test.accept, got, test.want)
+		}
+	}
+}
+
+// TestReadYAML reads back the YAML WriteYAML writes of nested collections and
+// scalars YAML would read otherwise unquoted.
+func TestReadYAML(t *testing.T) {
+	value := map[string]interface{}{
+		"strings":  []interface{}{"plain text", "true", "0x1", "a: b", "# comment", "- item", "", "line\nbreak", "\x00 \U0001f600"},
+		"numbers":  []interface{}{json.Number("0"), json.Number("-12"), json.Number("1.5e+10")},
+		"literals": []interface{}{true, false, nil},
+		"empty":    map[string]interface{}{"mapping": map[string]interface{}{}, "sequence": []interface{}{}},
+		"nested":   []interface{}{[]interface{}{"a", "b"}, map[string]interface{}{"key: quoted": "value", "list": []interface{}{json.Number("1")}}},
+	}
+	var out bytes.Buffer
+	if err := WriteYAML(&out, value); err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := ReadYAML(&out, &got); err != nil {
+		t.Fatalf("ReadYAML: %v", err)
+	}
+	data, _ := json.Marshal(value)
+	var want map[string]interface{}
+	json.Unmarshal(data, &want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadYAML = %v, want %v", got, want)
+	}
+
+	for _, invalid := range []string{
+		"",
+		"key: value\n  indented: too far",
+		"- item\nkey: value",
+		"key:",
+		`"unterminated`,
+		`"key" value`,
+		"number: 0x1",
+	} {
+		if err := ReadYAML(strings.NewReader(invalid), &got); err == nil {
+			t.Errorf("ReadYAML(%q) succeeded", invalid)
+		}
+	}
+}